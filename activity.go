@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// activityEvent es el evento emitido con cada nueva entrada del registro de actividad
+const activityEvent = "activity:new"
+
+// maxActivityEntries es el tamaño del registro de actividad; las entradas más antiguas se descartan
+const maxActivityEntries = 500
+
+// Tipos de entrada del registro de actividad
+const (
+	ActivityScan      = "scan"
+	ActivityBackup    = "backup"
+	ActivityRestore   = "restore"
+	ActivityConfig    = "config"
+	ActivityIntegrity = "integrity"
+)
+
+// Resultados de una entrada del registro de actividad
+const (
+	ActivitySuccess = "success"
+	ActivityFailure = "failure"
+)
+
+// ActivityEntry es una operación del registro de actividad que se muestra al usuario.
+// MessageKey y Params permiten al frontend traducir el resumen con su catálogo; Summary es el texto por defecto.
+type ActivityEntry struct {
+	Type       string            `json:"type"`
+	Time       time.Time         `json:"time"`
+	GameID     string            `json:"game_id,omitempty"`
+	GameName   string            `json:"game_name,omitempty"`
+	MessageKey string            `json:"message_key"` // p. ej. "activity.backup.success"
+	Params     map[string]string `json:"params,omitempty"`
+	Summary    string            `json:"summary"`
+	Outcome    string            `json:"outcome"`
+	Error      string            `json:"error,omitempty"`
+
+	OperationID string `json:"operation_id,omitempty"` // Operación de la cola o de la API que originó la entrada
+}
+
+// GetActivity devuelve las entradas más recientes primero, filtradas por tipo si se indica (limit <= 0 = todas)
+func (bm *BackupManager) GetActivity(limit int, types []string) ([]ActivityEntry, error) {
+	bm.activityMu.Lock()
+	entries, err := bm.loadActivity()
+	bm.activityMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	result := []ActivityEntry{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if len(wanted) > 0 && !wanted[entries[i].Type] {
+			continue
+		}
+		result = append(result, entries[i])
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// recordActivity agrega una entrada al registro de actividad y la emite al frontend.
+// Un fallo al guardar el registro no afecta a la operación registrada.
+func (bm *BackupManager) recordActivity(entry ActivityEntry, err error) {
+	entry.Time = time.Now()
+	entry.Outcome = ActivitySuccess
+	if err != nil {
+		entry.Outcome = ActivityFailure
+		entry.Error = err.Error()
+	}
+	entry.MessageKey = fmt.Sprintf("activity.%s.%s", entry.Type, entry.Outcome)
+
+	bm.activityMu.Lock()
+	entries, loadErr := bm.loadActivity()
+	if loadErr != nil {
+		log.Printf("Registro de actividad ilegible, se empieza uno nuevo: %v", loadErr)
+		entries = nil
+	}
+	entries = append(entries, entry)
+	if len(entries) > maxActivityEntries {
+		entries = entries[len(entries)-maxActivityEntries:]
+	}
+	saveErr := bm.saveActivity(entries)
+	bm.activityMu.Unlock()
+
+	if saveErr != nil {
+		log.Printf("Error guardando el registro de actividad: %v", saveErr)
+	}
+	bm.emit(activityEvent, entry)
+}
+
+// activityPath devuelve el archivo del registro de actividad, junto a la base de datos
+func (bm *BackupManager) activityPath() string {
+	return filepath.Join(filepath.Dir(bm.DatabasePath), "activity.json")
+}
+
+// loadActivity lee el registro de actividad, de la entrada más antigua a la más reciente
+func (bm *BackupManager) loadActivity() ([]ActivityEntry, error) {
+	data, err := os.ReadFile(bm.activityPath())
+	if os.IsNotExist(err) {
+		return []ActivityEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ActivityEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %v", bm.activityPath(), err)
+	}
+	return entries, nil
+}
+
+// saveActivity escribe el registro de actividad
+func (bm *BackupManager) saveActivity(entries []ActivityEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(bm.activityPath(), data, 0644)
+}
+
+// recordGameActivity registra una operación sobre un juego con su resumen en español
+func (bm *BackupManager) recordGameActivity(activityType, gameID string, params map[string]string, summary string, err error) {
+	bm.recordActivity(bm.gameActivityEntry(activityType, gameID, params, summary), err)
+}
+
+// gameActivityEntry prepara la entrada de actividad de una operación sobre un juego
+func (bm *BackupManager) gameActivityEntry(activityType, gameID string, params map[string]string, summary string) ActivityEntry {
+	entry := ActivityEntry{Type: activityType, GameID: gameID, Params: params, Summary: summary}
+	if game, exists := bm.getGame(gameID); exists {
+		entry.GameName = game.Name
+	}
+	if entry.Params == nil {
+		entry.Params = make(map[string]string)
+	}
+	entry.Params["game"] = entry.GameName
+	return entry
+}
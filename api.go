@@ -0,0 +1,251 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultAPIPort es el puerto de la API local cuando APIPort no está configurado
+const defaultAPIPort = 8765
+
+// apiTokenHeader es la cabecera alternativa a "Authorization: Bearer <token>"
+const apiTokenHeader = "X-WineSave-Token"
+
+// StartAPI inicia la API HTTP local (solo en 127.0.0.1) si APIEnabled está activo.
+// Si no hay token configurado se genera uno y se guarda en la configuración.
+func (bm *BackupManager) StartAPI() error {
+	if !bm.config().APIEnabled {
+		return nil
+	}
+
+	bm.apiM.Lock()
+	defer bm.apiM.Unlock()
+	if bm.apiServer != nil {
+		return nil
+	}
+
+	if bm.config().APIToken == "" {
+		token, err := generateAPIToken()
+		if err != nil {
+			return fmt.Errorf("error generando token de la API: %v", err)
+		}
+		bm.setConfig(func(config *BackupConfig) { config.APIToken = token })
+		log.Printf("Token de la API generado; consúltalo en la configuración")
+	}
+
+	port := apiPort(bm.config())
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return fmt.Errorf("error iniciando la API en el puerto %d: %v", port, err)
+	}
+
+	server := &http.Server{
+		Handler:           bm.requireToken(bm.apiRoutes()),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	bm.apiServer = server
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Error en la API local: %v", err)
+		}
+	}()
+
+	log.Printf("API local escuchando en %s", listener.Addr())
+	return nil
+}
+
+// StopAPI detiene la API local esperando a que terminen las peticiones en curso
+func (bm *BackupManager) StopAPI(timeout time.Duration) {
+	bm.apiM.Lock()
+	server := bm.apiServer
+	bm.apiServer = nil
+	bm.apiM.Unlock()
+
+	if server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Error deteniendo la API local: %v", err)
+	}
+}
+
+// updateAPI aplica a la API local la configuración actual: la detiene si se desactivó o cambió de puerto y la
+// vuelve a iniciar si está activa. previous es la configuración con la que se inició.
+func (bm *BackupManager) updateAPI(previous BackupConfig) error {
+	current := bm.config()
+	if !current.APIEnabled || apiPort(current) != apiPort(previous) {
+		bm.StopAPI(5 * time.Second)
+	}
+	return bm.StartAPI()
+}
+
+// apiPort devuelve el puerto en el que escucha la API con una configuración
+func apiPort(config BackupConfig) int {
+	return cmp.Or(config.APIPort, defaultAPIPort)
+}
+
+// generateAPIToken genera un token aleatorio de 32 bytes en hexadecimal
+func generateAPIToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// requireToken rechaza las peticiones sin el token de la API
+func (bm *BackupManager) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(apiTokenHeader)
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		expected := bm.config().APIToken
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			writeAPIError(w, http.StatusUnauthorized, errors.New("token inválido"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiRoutes define los endpoints de la API local
+func (bm *BackupManager) apiRoutes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /api/scan", func(w http.ResponseWriter, r *http.Request) {
+		result, err := bm.ScanForGames()
+		writeAPIResult(w, result, err)
+	})
+
+	if bm.config().APIMetrics {
+		mux.HandleFunc("GET /metrics", bm.serveMetrics)
+	}
+
+	mux.HandleFunc("GET /api/games", func(w http.ResponseWriter, r *http.Request) {
+		// Copias: los juegos de la biblioteca cambian mientras se serializan
+		writeAPIResult(w, bm.humanGames(bm.GetGamesByTag(r.URL.Query().Get("tag"))), nil)
+	})
+
+	mux.HandleFunc("GET /api/games/{id}/history", bm.withGame(func(w http.ResponseWriter, r *http.Request, gameID string) {
+		history, err := bm.GetBackupHistory(gameID)
+		writeAPIResult(w, history, err)
+	}))
+
+	mux.HandleFunc("POST /api/games/{id}/backup", bm.withGame(func(w http.ResponseWriter, r *http.Request, gameID string) {
+		info, err := bm.RunBackup(gameID, BackupOptions{Trigger: "api"})
+		writeAPIResult(w, info, err)
+	}))
+
+	mux.HandleFunc("GET /api/operations/{id}", func(w http.ResponseWriter, r *http.Request) {
+		job, err := bm.GetOperationStatus(r.PathValue("id"))
+		if err != nil {
+			writeAPIError(w, http.StatusNotFound, err)
+			return
+		}
+		writeAPIResult(w, job, nil)
+	})
+
+	mux.HandleFunc("POST /api/operations/{id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		if err := bm.CancelOperation(r.PathValue("id")); err != nil {
+			writeAPIError(w, http.StatusConflict, err)
+			return
+		}
+		writeAPIResult(w, map[string]bool{"cancelled": true}, nil)
+	})
+
+	mux.HandleFunc("POST /api/games/{id}/restore", bm.withGame(func(w http.ResponseWriter, r *http.Request, gameID string) {
+		var req struct {
+			Backup           string `json:"backup"`
+			Force            bool   `json:"force"`
+			Mode             string `json:"mode"`
+			TargetPrefix     string `json:"target_prefix"`
+			OverwriteProfile bool   `json:"overwrite_profile"`
+
+			SteamAccount        string `json:"steam_account"`
+			ConfirmSteamAccount bool   `json:"confirm_steam_account"`
+			TouchCloudCache     bool   `json:"touch_cloud_cache"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("cuerpo inválido: %v", err))
+			return
+		}
+		if req.Backup == "" {
+			writeAPIError(w, http.StatusBadRequest, errors.New("falta el campo backup"))
+			return
+		}
+
+		record, err := bm.RestoreBackup(gameID, req.Backup, RestoreOptions{
+			Force:            req.Force,
+			Mode:             req.Mode,
+			TargetPrefix:     req.TargetPrefix,
+			OverwriteProfile: req.OverwriteProfile,
+
+			SteamAccount:        req.SteamAccount,
+			ConfirmSteamAccount: req.ConfirmSteamAccount,
+			TouchCloudCache:     req.TouchCloudCache,
+		})
+		if errors.Is(err, ErrUnsavedChanges) || errors.Is(err, ErrWholeProfileRestore) || errors.Is(err, ErrOtherSteamAccount) {
+			writeAPIError(w, http.StatusConflict, err)
+			return
+		}
+		if errors.Is(err, ErrInsufficientSpace) {
+			writeAPIError(w, http.StatusInsufficientStorage, err)
+			return
+		}
+		writeAPIResult(w, record, err)
+	}))
+
+	return mux
+}
+
+// withGame comprueba que el juego de la ruta existe antes de llamar al handler
+func (bm *BackupManager) withGame(handler func(w http.ResponseWriter, r *http.Request, gameID string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID := r.PathValue("id")
+		if _, exists := bm.getGame(gameID); !exists {
+			writeAPIError(w, http.StatusNotFound, errGameNotFound(gameID))
+			return
+		}
+		handler(w, r, gameID)
+	}
+}
+
+// writeAPIResult responde con el resultado en JSON o con el error
+func writeAPIResult(w http.ResponseWriter, data interface{}, err error) {
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, data)
+}
+
+// writeAPIError responde con {"error": "...", "code": "..."} (el código de AppError)
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, map[string]string{"error": err.Error(), "code": toAppError(err).Code})
+}
+
+// writeAPIJSON serializa una respuesta JSON
+func writeAPIJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("Error escribiendo respuesta de la API: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Códigos de AppError: no cambian entre versiones ni con el idioma, para que el frontend decida qué mostrar
+// sin analizar el texto del error
+const (
+	ErrCodeGameNotFound      = "GAME_NOT_FOUND"
+	ErrCodePathMissing       = "PATH_MISSING"     // Ninguna ruta de guardado del juego existe
+	ErrCodeNoFilesMatched    = "NO_FILES_MATCHED" // Las rutas existen pero ningún archivo coincide con los patrones
+	ErrCodePathUnmounted     = "PATH_UNMOUNTED"
+	ErrCodeBackupTooLarge    = "BACKUP_TOO_LARGE"
+	ErrCodeInsufficientSpace = "INSUFFICIENT_SPACE"
+	ErrCodeRestoreConflict   = "RESTORE_CONFLICT" // La restauración necesita confirmación (Force, OverwriteProfile...)
+	ErrCodeCopyMismatch      = "COPY_MISMATCH"
+	ErrCodePCGWUnreachable   = "PCGW_UNREACHABLE"
+	ErrCodeInternal          = "INTERNAL" // Cualquier otro error
+)
+
+// AppError es el error que reciben el frontend y la API: un código estable, la clave del mensaje en el catálogo
+// del frontend y el texto por defecto, como MessageKey y Summary en el registro de actividad
+type AppError struct {
+	Code       string            `json:"code"`
+	MessageKey string            `json:"message_key"` // p. ej. "errors.game_not_found"
+	Message    string            `json:"message"`
+	Details    map[string]string `json:"details,omitempty"` // Parámetros del mensaje (game_id, path...)
+
+	cause error
+}
+
+func (e *AppError) Error() string { return e.Message }
+
+func (e *AppError) Unwrap() error { return e.cause }
+
+// newAppError crea un AppError; la clave del mensaje sale del código
+func newAppError(code, message string, details map[string]string, cause error) *AppError {
+	return &AppError{
+		Code:       code,
+		MessageKey: "errors." + strings.ToLower(code),
+		Message:    message,
+		Details:    details,
+		cause:      cause,
+	}
+}
+
+// errGameNotFound es el error de las operaciones sobre un juego que no está en la base de datos
+func errGameNotFound(gameID string) error {
+	return newAppError(ErrCodeGameNotFound, fmt.Sprintf("juego con ID %s no encontrado", gameID),
+		map[string]string{"game_id": gameID}, nil)
+}
+
+// errPCGWUnreachable envuelve un error de conexión con PCGamingWiki
+func errPCGWUnreachable(message string, err error) error {
+	if err != nil {
+		message = fmt.Sprintf("%s: %v", message, err)
+	}
+	return newAppError(ErrCodePCGWUnreachable, message, nil, err)
+}
+
+// sentinelCodes son los códigos de los errores centinela, que llegan envueltos con %w desde más abajo
+var sentinelCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrPathUnmounted, ErrCodePathUnmounted},
+	{ErrBackupTooLarge, ErrCodeBackupTooLarge},
+	{ErrInsufficientSpace, ErrCodeInsufficientSpace},
+	{ErrUnsavedChanges, ErrCodeRestoreConflict},
+	{ErrWholeProfileRestore, ErrCodeRestoreConflict},
+	{ErrOtherSteamAccount, ErrCodeRestoreConflict},
+	{ErrCopyMismatch, ErrCodeCopyMismatch},
+}
+
+// toAppError convierte cualquier error en un AppError. Si err envuelve un AppError con más contexto,
+// se conserva su código con el texto completo.
+func toAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		if appErr.Error() == err.Error() {
+			return appErr
+		}
+		return newAppError(appErr.Code, err.Error(), appErr.Details, err)
+	}
+	for _, sentinel := range sentinelCodes {
+		if errors.Is(err, sentinel.err) {
+			return newAppError(sentinel.code, err.Error(), nil, err)
+		}
+	}
+	return newAppError(ErrCodeInternal, err.Error(), nil, err)
+}
+
+// formatAppError es el ErrorFormatter de Wails: los métodos de App siguen devolviendo error y el frontend
+// recibe siempre {code, message_key, message, details}
+func formatAppError(err error) any {
+	return toAppError(err)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToAppError(t *testing.T) {
+	notFound := errGameNotFound("abc")
+	tests := []struct {
+		name    string
+		err     error
+		code    string
+		message string
+		details map[string]string
+	}{
+		{"game not found", notFound, ErrCodeGameNotFound, "juego con ID abc no encontrado", map[string]string{"game_id": "abc"}},
+		{"wrapped app error", fmt.Errorf("error restaurando: %w", notFound), ErrCodeGameNotFound,
+			"error restaurando: juego con ID abc no encontrado", map[string]string{"game_id": "abc"}},
+		{"double wrapped", fmt.Errorf("lote: %w", fmt.Errorf("juego 2: %w", notFound)), ErrCodeGameNotFound,
+			"lote: juego 2: juego con ID abc no encontrado", map[string]string{"game_id": "abc"}},
+		{"no files", newAppError(ErrCodeNoFilesMatched, "nada coincide", nil, nil), ErrCodeNoFilesMatched, "nada coincide", nil},
+		{"insufficient space", fmt.Errorf("%w: /home: faltan 2 GiB", ErrInsufficientSpace), ErrCodeInsufficientSpace,
+			"no hay espacio libre suficiente para restaurar el backup: /home: faltan 2 GiB", nil},
+		{"unsaved changes", fmt.Errorf("%w: 1 nuevos", ErrUnsavedChanges), ErrCodeRestoreConflict,
+			"los archivos de guardado tienen cambios sin respaldar: 1 nuevos", nil},
+		{"whole profile", fmt.Errorf("%w: /pfx", ErrWholeProfileRestore), ErrCodeRestoreConflict, "", nil},
+		{"other steam account", fmt.Errorf("%w: 123", ErrOtherSteamAccount), ErrCodeRestoreConflict, "", nil},
+		{"unmounted", fmt.Errorf("%w: /mnt/usb", ErrPathUnmounted), ErrCodePathUnmounted, "", nil},
+		{"too large", fmt.Errorf("%w: /saves", ErrBackupTooLarge), ErrCodeBackupTooLarge, "", nil},
+		{"copy mismatch", fmt.Errorf("copiando: %w", ErrCopyMismatch), ErrCodeCopyMismatch, "", nil},
+		{"pcgw", errPCGWUnreachable("error consultando PCGamingWiki", errors.New("timeout")), ErrCodePCGWUnreachable,
+			"error consultando PCGamingWiki: timeout", nil},
+		{"other", errors.New("algo falló"), ErrCodeInternal, "algo falló", nil},
+	}
+
+	for _, test := range tests {
+		appErr := toAppError(test.err)
+		if appErr.Code != test.code {
+			t.Errorf("%s: código %s, se esperaba %s", test.name, appErr.Code, test.code)
+		}
+		if test.message == "" {
+			test.message = test.err.Error()
+		}
+		if appErr.Message != test.message || appErr.Error() != test.message {
+			t.Errorf("%s: mensaje %q, se esperaba %q", test.name, appErr.Message, test.message)
+		}
+		if appErr.MessageKey != "errors."+strings.ToLower(test.code) {
+			t.Errorf("%s: clave %q", test.name, appErr.MessageKey)
+		}
+		if test.details != nil && fmt.Sprint(appErr.Details) != fmt.Sprint(test.details) {
+			t.Errorf("%s: detalles %v, se esperaban %v", test.name, appErr.Details, test.details)
+		}
+		// El error original sigue disponible para errors.Is
+		if !errors.Is(appErr, test.err) && appErr != test.err {
+			t.Errorf("%s: el AppError no envuelve el error original", test.name)
+		}
+	}
+}
+
+func TestFormatAppErrorJSON(t *testing.T) {
+	data, err := json.Marshal(formatAppError(fmt.Errorf("borrando: %w", errGameNotFound("abc"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"code":        "GAME_NOT_FOUND",
+		"message_key": "errors.game_not_found",
+		"message":     "borrando: juego con ID abc no encontrado",
+		"details":     map[string]any{"game_id": "abc"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("JSON %s, se esperaba %v", data, want)
+	}
+}
+
+// Los códigos que devuelven las operaciones reales, no solo los errores construidos a mano
+func TestAppErrorCodesFromOperations(t *testing.T) {
+	bm, dir := newTestManager(t)
+	code := func(err error) string {
+		if err == nil {
+			return ""
+		}
+		return toAppError(err).Code
+	}
+
+	if got := code(bm.CreateBackup("no-existe")); got != ErrCodeGameNotFound {
+		t.Errorf("CreateBackup de un juego que no existe: %s", got)
+	}
+
+	game := bm.DetectedGames["g"]
+	game.Patterns = []string{"*.nada"}
+	if got := code(bm.CreateBackup("g")); got != ErrCodeNoFilesMatched {
+		t.Errorf("CreateBackup sin archivos que coincidan: %s", got)
+	}
+	savePaths := game.SavePaths
+	game.SavePaths = []string{filepath.Join(dir, "no-existe")}
+	if got := code(bm.CreateBackup("g")); got != ErrCodePathMissing {
+		t.Errorf("CreateBackup sin rutas: %s", got)
+	}
+
+	game.SavePaths, game.Patterns = savePaths, []string{"*"}
+	info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFiles(t, savePaths[0], map[string]string{"nuevo.sav": "sin respaldar"})
+	_, err = bm.RestoreBackup("g", info.Name, RestoreOptions{})
+	if got := code(err); got != ErrCodeRestoreConflict {
+		t.Errorf("RestoreBackup con cambios sin respaldar: %s (%v)", got, err)
+	}
+}
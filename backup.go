@@ -2,22 +2,31 @@ package main
 
 import (
 	"archive/zip"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // Estructuras principales
 type GameInfo struct {
-	ID          string            `json:"id"`
+	ID          string            `json:"id"`   // UUID estable: no cambia al renombrar el juego ni al mover sus rutas
+	Slug        string            `json:"slug"` // Nombre legible y carpeta de sus backups; se fija al crear el juego
 	Name        string            `json:"name"`
 	SavePaths   []string          `json:"save_paths"`
 	Patterns    []string          `json:"patterns"`
@@ -27,23 +36,154 @@ type GameInfo struct {
 	FileCount   int               `json:"file_count"`
 	CustomPaths []string          `json:"custom_paths"`
 	Metadata    map[string]string `json:"metadata"`
+
+	Schedule       string    `json:"schedule"`         // "1h", "every 6h", "daily", "weekly", "off"; vacío = DefaultSchedule
+	LastAutoBackup time.Time `json:"last_auto_backup"` // Última ejecución del programador
+
+	MaxAutoBackupsPerDay int               `json:"max_auto_backups_per_day"` // 0 = usar el límite global
+	AutoBackupCounter    AutoBackupCounter `json:"auto_backup_counter"`
+
+	BackupRegistry bool     `json:"backup_registry"` // Incluir RegistryKeys del prefijo de Wine en los backups
+	RegistryKeys   []string `json:"registry_keys"`   // p. ej. HKEY_CURRENT_USER\Software\Vendor\Game
+
+	ExtraBackupDirs []string `json:"extra_backup_dirs"` // Destinos donde se copia cada backup además de BackupDir
+
+	SavePathFromConfig *SavePathConfig `json:"save_path_from_config,omitempty"` // Lee la carpeta real de partidas de la configuración del juego
+
+	BackupWholeProfile bool `json:"backup_whole_profile"` // Respaldar todo drive_c/users/<usuario> del prefijo, sin Patterns
+
+	Status string `json:"status"` // Problema detectado (p. ej. "prefix_missing"); vacío = sin problemas
+
+	CloudSyncStatus string `json:"cloud_sync_status"` // "active" si Steam Cloud sincroniza sus partidas; vacío = sin Steam Cloud
+
+	LastPlayed time.Time `json:"last_played"` // Según Steam o, si es posterior, la última modificación de sus partidas
+
+	PathWarnings []string `json:"path_warnings,omitempty"` // Rutas de guardado compartidas con otros juegos
+
+	UnmatchedExtensions map[string]int `json:"unmatched_extensions,omitempty"` // Extensiones fuera de Patterns vistas en el último backup manual, con su número de archivos
+
+	Tags []string `json:"tags,omitempty"` // Etiquetas del usuario para agrupar juegos, normalizadas por SetTags
+
+	InstallDir string `json:"install_dir,omitempty"` // Carpeta de instalación del juego; sustituye a %GAME_DIR% en sus rutas
+
+	KeepDeletedFiles bool `json:"keep_deleted_files"` // Guardar en deleted/ las partidas que el juego borra (ver GetDeletedFiles)
+
+	// Installed indica si el juego sigue instalado según su tienda o su carpeta de instalación; sin eso
+	// (InstallCheck vacío) no se sabe, y un false no significa que solo queden las partidas
+	Installed    bool   `json:"installed"`
+	InstallCheck string `json:"install_check,omitempty"` // "steam", "epic" o "install_dir"
+
+	HumanSize string `json:"human_size,omitempty"` // TotalSize para mostrar; solo en las copias que se devuelven al frontend
 }
 
 type BackupConfig struct {
-	BackupDir          string        `json:"backup_dir"`
-	MaxBackups         int           `json:"max_backups"`
-	CompressionEnabled bool          `json:"compression_enabled"`
-	ScanInterval       time.Duration `json:"scan_interval"`
-	ExcludePatterns    []string      `json:"exclude_patterns"`
-	AutoBackup         bool          `json:"auto_backup"`
+	BackupDir            string        `json:"backup_dir"`
+	MaxBackups           int           `json:"max_backups"`
+	CompressionEnabled   bool          `json:"compression_enabled"`
+	ScanInterval         time.Duration `json:"scan_interval"`
+	ExcludePatterns      []string      `json:"exclude_patterns"`
+	AutoBackup           bool          `json:"auto_backup"`
+	MaxBackupFiles       int           `json:"max_backup_files"`         // 0 = sin límite
+	MaxBackupBytes       int64         `json:"max_backup_bytes"`         // 0 = sin límite
+	TempDir              string        `json:"temp_dir"`                 // Vacío = directorio temporal del sistema
+	DefaultSchedule      string        `json:"default_schedule"`         // Programación de los juegos sin una propia
+	MaxTotalBackupSize   int64         `json:"max_total_backup_size"`    // Bytes; 0 = sin cuota
+	MaxAutoBackupsPerDay int           `json:"max_auto_backups_per_day"` // Por juego; 0 = sin límite
+	APIEnabled           bool          `json:"api_enabled"`              // API HTTP local para automatización
+	APIPort              int           `json:"api_port"`                 // 0 = puerto por defecto (8765)
+	APIToken             string        `json:"api_token"`                // Se genera al iniciar la API si está vacío
+	APIMetrics           bool          `json:"api_metrics"`              // Exponer /metrics (formato Prometheus) en la API local
+	CheckArchiveAfter    bool          `json:"check_archive_after"`      // Leer cada entrada del backup recién creado antes de guardarlo
+	CompressionWorkers   int           `json:"compression_workers"`      // Hilos de compresión de los ZIP; 0 = según los núcleos (máx. 8), 1 = sin paralelismo
+	StoreExtensions      []string      `json:"store_extensions"`         // Archivos ya comprimidos que se guardan en el ZIP sin comprimir
+	StoreEntropyCheck    bool          `json:"store_entropy_check"`      // Guardar sin comprimir los archivos cuyo contenido parece ya comprimido
+
+	BackgroundIOThrottleMBps int `json:"background_io_throttle_mbps"` // Límite de lectura de los backups automáticos (MB/s); 0 = sin límite
+
+	// CurrentMirror mantiene en <juego>/current una copia sin comprimir de la última partida, actualizada en cada
+	// backup; los backups comprimidos del historial solo se guardan cada SnapshotSchedule (vacío = en cada backup)
+	CurrentMirror    bool   `json:"current_mirror"`
+	SnapshotSchedule string `json:"snapshot_schedule"`
+
+	AutoBackupPlayedWithinDays int `json:"auto_backup_played_within_days"` // Solo backups automáticos de juegos jugados en N días; 0 = todos
+	StaleBackupDays            int `json:"stale_backup_days"`              // Antigüedad de un backup desactualizado en el informe de cobertura; 0 = 7
+	FileLockRetries            int `json:"file_lock_retries"`              // Reintentos al abrir una partida bloqueada por el juego; 0 = sin reintentos
+
+	AutoBackupPausedUntil time.Time `json:"auto_backup_paused_until"` // Backups automáticos y comprobaciones en pausa hasta esta fecha
+
+	IntegrityCheckSchedule string `json:"integrity_check_schedule"` // Cada cuánto se verifica cada backup ("monthly"...); vacío u "off" = nunca
+	ScrubSchedule          string `json:"scrub_schedule"`           // Cada cuánto se revisan y reparan todos los backups (ScrubAllBackups); vacío u "off" = nunca
+
+	PCGWBaseURL string `json:"pcgw_base_url"` // api.php de PCGamingWiki o de un espejo de MediaWiki; vacío = la pública
+
+	DefaultRestoreMode string `json:"default_restore_mode"` // Modo de las restauraciones que no indican uno; vacío = "merge"
+
+	ContentIndex bool `json:"content_index"` // Escribir junto a cada backup un <backup>.index.txt con sus archivos, para buscarlos sin la aplicación
+
+	DatabaseBackend string `json:"database_backend"` // Formato de la base de datos de juegos: "json" (vacío) o "sqlite"
+
+	DeletedFilesMaxCount int   `json:"deleted_files_max_count"` // Partidas borradas que se guardan por juego; 0 = 200
+	DeletedFilesMaxBytes int64 `json:"deleted_files_max_bytes"` // Tamaño de las partidas borradas guardadas por juego; 0 = 512 MiB
+
+	Locale string `json:"locale"` // Idioma de los tamaños y duraciones que se envían formateados ("es", "en"...); vacío = español
+
+	// MinBackupAgeBeforeDelete protege de la rotación por MaxBackups a los backups más recientes que esto,
+	// aunque sobren: un MaxBackups mal puesto no borra enseguida los backups buenos. 0 = sin protección.
+	MinBackupAgeBeforeDelete time.Duration `json:"min_backup_age_before_delete"`
+
+	DiagnosticsRedact []string `json:"diagnostics_redact"` // Textos que ExportDiagnostics sustituye por <REDACTED> además de la carpeta personal y los usuarios
+
+	// SkipCopyReread no vuelve a leer cada archivo copiado (backups en carpeta, restauraciones, exportaciones y
+	// migraciones) para compararlo con lo leído: más rápido, pero solo se comprueba el hash calculado al copiar
+	SkipCopyReread bool `json:"skip_copy_reread"`
 }
 
+// ErrBackupTooLarge indica que una ruta de guardado supera los límites de seguridad del backup
+var ErrBackupTooLarge = errors.New("el backup excede los límites configurados")
+
 // BackupManager estructura principal con cliente PCGamingWiki
 type BackupManager struct {
 	Config        BackupConfig         `json:"config"`
 	DetectedGames map[string]*GameInfo `json:"detected_games"`
 	DatabasePath  string               `json:"database_path"`
 	PCGWClient    *PCGWClient          `json:"-"` // No serializar el cliente
+
+	// emitEvent envía eventos al frontend (nil cuando no hay interfaz)
+	emitEvent func(event string, data interface{})
+
+	mu     sync.RWMutex // Protege DetectedGames frente a la cola de backups en segundo plano
+	dbMu   sync.Mutex   // Serializa las escrituras de la base de datos
+	store  Store        // Base de datos de juegos; se abre al primer uso con Config.DatabaseBackend
+	storeM sync.Mutex
+	queue  *backupQueue
+	queueM sync.Mutex
+
+	configM sync.RWMutex // Protege Config: UpdateConfig la cambia mientras la leen la cola, el programador y la API
+
+	stopScheduler func()
+	schedulerM    sync.Mutex
+
+	scan  *scanReporter // Progreso del escaneo en curso
+	scanM sync.Mutex
+
+	apiServer *http.Server
+	apiM      sync.Mutex
+
+	activityMu sync.Mutex // Serializa las escrituras del registro de actividad
+
+	integrityM        sync.Mutex
+	integrityRunning  bool      // Hay una comprobación de integridad en segundo plano en curso
+	integrityLastPass time.Time // Última búsqueda de backups pendientes de verificar
+
+	scrubM       sync.Mutex
+	scrubRunning bool // Hay una revisión completa de los backups (ScrubAllBackups) en curso
+
+	metrics backupMetrics
+
+	firstRun bool // No existía config.json al arrancar
+
+	startupIssues []StartupIssue // Problemas al arrancar, p. ej. base de datos dañada
+	startupM      sync.Mutex
 }
 
 // UserGameSelection representa la selección de un usuario
@@ -52,6 +192,7 @@ type UserGameSelection struct {
 	SelectedGame *GameSearchResult `json:"selected_game"`
 	CustomPath   string            `json:"custom_path"`
 	BackupPath   string            `json:"backup_path"`
+	InstallDir   string            `json:"install_dir"` // Carpeta del juego para sus rutas de PCGamingWiki con %GAME_DIR%
 }
 
 type ScanResult struct {
@@ -60,6 +201,15 @@ type ScanResult struct {
 	Updated    []*GameInfo   `json:"updated"`
 	Errors     []string      `json:"errors"`
 	ScanTime   time.Duration `json:"scan_time"`
+	DryRun     bool          `json:"dry_run"` // Nada se guardó: resultado de PreviewScan
+
+	HumanDuration string `json:"human_duration,omitempty"` // ScanTime para mostrar
+
+	// Juegos conocidos cuyos archivos aparecieron en otra carpeta; se aplican con RelocateGame
+	Relocated []GameRelocation `json:"relocated"`
+
+	// Prefijos de Wine de juegos registrados que faltan o están dañados; se corrigen con RebindGamePrefix
+	PrefixProblems []PrefixHealth `json:"prefix_problems"`
 }
 
 // Definición de ubicaciones comunes de guardado para diferentes juegos
@@ -69,8 +219,7 @@ var CommonSavePaths = map[string][]string{
 		"%APPDATA%",
 		"%LOCALAPPDATA%",
 		"%USERPROFILE%/Saved Games",
-		"C:/Program Files (x86)/Steam/userdata",
-		"C:/Program Files/Steam/userdata",
+		// userdata lo recorre steamUserdataDetector, con un juego por cuenta
 	},
 	"epic": {
 		"%LOCALAPPDATA%/EpicGamesLauncher/Saved",
@@ -198,21 +347,23 @@ var KnownGames = map[string]*GameInfo{
 
 // NewBackupManager crea una nueva instancia del manager de backups
 func NewBackupManager(configPath string) (*BackupManager, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
-	}
-
-	defaultBackupDir := filepath.Join(homeDir, "WineSaveBackups")
-
 	bm := &BackupManager{
 		Config: BackupConfig{
-			BackupDir:          defaultBackupDir,
+			BackupDir:          defaultBackupDir(),
 			MaxBackups:         10,
 			CompressionEnabled: true,
 			ScanInterval:       time.Hour * 24,
 			ExcludePatterns:    []string{"*.tmp", "*.log", "*.cache", "*.lock"},
 			AutoBackup:         false,
+			MaxBackupFiles:     50000,
+			MaxBackupBytes:     20 << 30, // 20 GiB
+			DefaultSchedule:    "daily",
+			FileLockRetries:    defaultFileLockRetries,
+			StoreExtensions:    defaultStoreExtensions,
+			StoreEntropyCheck:  true,
+
+			IntegrityCheckSchedule: "monthly",
+			PCGWBaseURL:            defaultPCGWBaseURL,
 		},
 		DetectedGames: make(map[string]*GameInfo),
 		DatabasePath:  "game_saves.json",
@@ -224,6 +375,13 @@ func NewBackupManager(configPath string) (*BackupManager, error) {
 		if err := bm.LoadConfig(configPath); err != nil {
 			log.Printf("Error cargando configuración: %v", err)
 		}
+		if err := validatePCGWBaseURL(bm.config().PCGWBaseURL); err != nil {
+			log.Printf("%v; se usa la API pública", err)
+			bm.setConfig(func(config *BackupConfig) { config.PCGWBaseURL = defaultPCGWBaseURL })
+		}
+		bm.PCGWClient.SetBaseURL(bm.config().PCGWBaseURL)
+	} else {
+		bm.firstRun = true
 	}
 
 	// Cargar base de datos de juegos detectados
@@ -234,6 +392,57 @@ func NewBackupManager(configPath string) (*BackupManager, error) {
 	return bm, nil
 }
 
+// SetEventEmitter configura la función usada para enviar eventos al frontend
+func (bm *BackupManager) SetEventEmitter(emit func(event string, data interface{})) {
+	bm.emitEvent = emit
+}
+
+// emit envía un evento al frontend si hay un emisor configurado
+func (bm *BackupManager) emit(event string, data interface{}) {
+	if bm.emitEvent != nil {
+		bm.emitEvent(event, data)
+	}
+}
+
+// getGame devuelve un juego detectado por su ID
+func (bm *BackupManager) getGame(gameID string) (*GameInfo, bool) {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	game, exists := bm.DetectedGames[gameID]
+	return game, exists
+}
+
+// setGame agrega o reemplaza un juego detectado
+func (bm *BackupManager) setGame(game *GameInfo) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.DetectedGames[game.ID] = game
+}
+
+// deleteGame elimina un juego detectado
+func (bm *BackupManager) deleteGame(gameID string) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	delete(bm.DetectedGames, gameID)
+}
+
+// updateGame cambia campos de un juego con bm.mu bloqueado: la cola de backups y el programador los cambian
+// en segundo plano mientras el frontend, la API y SaveDatabase los leen
+func (bm *BackupManager) updateGame(game *GameInfo, update func(game *GameInfo)) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	update(game)
+}
+
+// gameSnapshot devuelve una copia de un juego hecha con bm.mu bloqueado, para leer sus campos mientras otro
+// hilo puede estar cambiándolos. Las listas y mapas se comparten con el original.
+func (bm *BackupManager) gameSnapshot(game *GameInfo) *GameInfo {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	snapshot := *game
+	return &snapshot
+}
+
 // ExpandPath expande variables de entorno en rutas de Windows/Linux/macOS
 func ExpandPath(path string) string {
 	// Variables de Windows
@@ -242,6 +451,7 @@ func ExpandPath(path string) string {
 	expanded = strings.ReplaceAll(expanded, "%LOCALAPPDATA%", os.Getenv("LOCALAPPDATA"))
 	expanded = strings.ReplaceAll(expanded, "%PROGRAMFILES%", os.Getenv("PROGRAMFILES"))
 	expanded = strings.ReplaceAll(expanded, "%PROGRAMFILES(X86)%", os.Getenv("PROGRAMFILES(X86)"))
+	expanded = strings.ReplaceAll(expanded, "%PROGRAMDATA%", os.Getenv("PROGRAMDATA"))
 
 	// Variables de Unix (Linux/macOS)
 	if home := os.Getenv("HOME"); home != "" {
@@ -261,49 +471,49 @@ func ExpandPath(path string) string {
 	return expanded
 }
 
+// ResolvePath expande una ruta y devuelve el resultado junto con si existe en el sistema
+func ResolvePath(path string) (string, bool) {
+	expanded := ExpandPath(path)
+	if expanded == "" {
+		return "", false
+	}
+	_, err := os.Stat(expanded)
+	return expanded, err == nil
+}
+
 // ScanForGames busca automáticamente juegos y sus archivos de guardado
 func (bm *BackupManager) ScanForGames() (*ScanResult, error) {
+	return bm.scanGames(true)
+}
+
+// PreviewScan ejecuta un escaneo sin modificar la lista de juegos ni escribir la base de datos
+func (bm *BackupManager) PreviewScan() (*ScanResult, error) {
+	return bm.scanGames(false)
+}
+
+// scanGames ejecuta los detectores; con persist=false solo informa de lo que encontraría
+func (bm *BackupManager) scanGames(persist bool) (*ScanResult, error) {
 	startTime := time.Now()
 	result := &ScanResult{
-		NewGames: []*GameInfo{},
-		Updated:  []*GameInfo{},
-		Errors:   []string{},
+		NewGames:       []*GameInfo{},
+		Updated:        []*GameInfo{},
+		Errors:         []string{},
+		DryRun:         !persist,
+		Relocated:      []GameRelocation{},
+		PrefixProblems: []PrefixHealth{},
 	}
 
 	log.Println("Iniciando escaneo de juegos...")
 
-	// Primero, agregar juegos conocidos a la base de datos
-	for id, game := range KnownGames {
-		if _, exists := bm.DetectedGames[id]; !exists {
-			// Verificar si el juego realmente existe
-			if bm.gameExists(game) {
-				newGame := *game // Copiar estructura
-				// Inicializar mapas si son nil
-				if newGame.Metadata == nil {
-					newGame.Metadata = make(map[string]string)
-				}
-				if newGame.CustomPaths == nil {
-					newGame.CustomPaths = []string{}
-				}
-				bm.DetectedGames[id] = &newGame
-				result.NewGames = append(result.NewGames, &newGame)
-				log.Printf("Juego conocido detectado: %s", game.Name)
-			}
-		}
-	}
+	// Ejecutar los detectores registrados (juegos conocidos, plataformas, Wine...)
+	bm.runDetectors(result, persist)
 
-	// Escanear ubicaciones comunes para detectar nuevos juegos
-	for platform, paths := range CommonSavePaths {
-		for _, basePath := range paths {
-			expandedPath := ExpandPath(basePath)
-			if err := bm.scanDirectory(expandedPath, platform, result); err != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("Error escaneando %s: %v", expandedPath, err))
-			}
+	// Actualizar información de juegos existentes (sobre copias en modo previsualización)
+	for _, game := range bm.GetGameList() {
+		if !persist {
+			copied := *game
+			game = &copied
 		}
-	}
-
-	// Actualizar información de juegos existentes
-	for _, game := range bm.DetectedGames {
 		if err := bm.updateGameInfo(game); err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("Error actualizando %s: %v", game.Name, err))
 		} else {
@@ -311,64 +521,52 @@ func (bm *BackupManager) ScanForGames() (*ScanResult, error) {
 		}
 	}
 
-	result.TotalGames = len(bm.DetectedGames)
+	// Prefijos borrados (p. ej. compatdata eliminado por Steam) o dañados
+	prefixes, _ := bm.checkPrefixes(persist)
+	for _, health := range prefixes {
+		if !health.Healthy {
+			result.PrefixProblems = append(result.PrefixProblems, health)
+			log.Printf("Prefijo con problemas: %s (%s)", health.Path, health.Problem)
+		}
+	}
+
+	result.TotalGames = len(bm.GetGameList())
+	if !persist {
+		result.TotalGames += len(result.NewGames)
+	}
 	result.ScanTime = time.Since(startTime)
 
 	log.Printf("Escaneo completado: %d juegos detectados, %d nuevos, %d actualizados",
 		result.TotalGames, len(result.NewGames), len(result.Updated))
 
-	return result, bm.SaveDatabase()
+	if !persist {
+		return result, nil
+	}
+
+	bm.checkPathOverlaps()
+	err := bm.SaveDatabase()
+	bm.recordActivity(ActivityEntry{
+		Type: ActivityScan,
+		Params: map[string]string{
+			"total":   fmt.Sprint(result.TotalGames),
+			"new":     fmt.Sprint(len(result.NewGames)),
+			"updated": fmt.Sprint(len(result.Updated)),
+		},
+		Summary: fmt.Sprintf("Escaneo: %d juegos, %d nuevos", result.TotalGames, len(result.NewGames)),
+	}, err)
+	return result, err
 }
 
 // gameExists verifica si un juego realmente existe verificando sus rutas de guardado
 func (bm *BackupManager) gameExists(game *GameInfo) bool {
-	for _, path := range game.SavePaths {
-		expandedPath := ExpandPath(path)
-		if _, err := os.Stat(expandedPath); err == nil {
+	for _, root := range bm.saveRoots(game) {
+		if _, err := os.Stat(root.Path); err == nil {
 			return true
 		}
 	}
 	return false
 }
 
-// scanDirectory escanea un directorio en busca de posibles archivos de guardado
-func (bm *BackupManager) scanDirectory(path, platform string, result *ScanResult) error {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return nil // Directorio no existe, continuar
-	}
-
-	return filepath.WalkDir(path, func(currentPath string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil // Continuar con otros directorios
-		}
-
-		if d.IsDir() {
-			// Verificar si este directorio parece contener archivos de guardado
-			if bm.looksLikeSaveDirectory(currentPath) {
-				gameID := bm.generateGameID(currentPath)
-				if _, exists := bm.DetectedGames[gameID]; !exists {
-					// Crear nueva entrada de juego
-					game := &GameInfo{
-						ID:          gameID,
-						Name:        bm.inferGameName(currentPath),
-						Platform:    platform,
-						SavePaths:   []string{currentPath},
-						Patterns:    SaveFilePatterns,
-						CustomPaths: []string{},
-						Metadata:    make(map[string]string),
-					}
-
-					bm.DetectedGames[gameID] = game
-					result.NewGames = append(result.NewGames, game)
-					log.Printf("Nuevo juego detectado: %s en %s", game.Name, currentPath)
-				}
-			}
-		}
-
-		return nil
-	})
-}
-
 // looksLikeSaveDirectory determina si un directorio parece contener archivos de guardado
 func (bm *BackupManager) looksLikeSaveDirectory(path string) bool {
 	// Buscar archivos que coincidan con patrones de guardado
@@ -412,16 +610,20 @@ func (bm *BackupManager) looksLikeSaveDirectory(path string) bool {
 	return saveFileCount >= 1 || (totalFiles > 0 && totalFiles < 20 && saveFileCount > 0)
 }
 
-// generateGameID genera un ID único para un juego basado en su ruta
-func (bm *BackupManager) generateGameID(path string) string {
+// generateSlug genera el slug de un juego a partir de su ruta de guardado
+func (bm *BackupManager) generateSlug(path string) string {
 	// Extraer el nombre del directorio del juego
 	parts := strings.Split(filepath.Clean(path), string(os.PathSeparator))
 	if len(parts) > 0 {
 		gameName := parts[len(parts)-1]
-		// Limpiar el nombre para usarlo como ID
+		// Un archivo de partida suele tener un nombre genérico (profile.bin, save.dat): se antepone su carpeta
+		if len(parts) > 1 && isRegularFile(ExpandPath(path)) {
+			gameName = parts[len(parts)-2] + "-" + strings.TrimSuffix(gameName, filepath.Ext(gameName))
+		}
+		// Limpiar el nombre para usarlo como slug
 		re := regexp.MustCompile(`[^a-zA-Z0-9\-_]`)
 		id := re.ReplaceAllString(strings.ToLower(gameName), "-")
-		return strings.Trim(id, "-")
+		return sanitizePathComponent(strings.Trim(id, "-"))
 	}
 	return fmt.Sprintf("unknown-game-%d", time.Now().Unix())
 }
@@ -450,22 +652,65 @@ func (bm *BackupManager) inferGameName(path string) string {
 func (bm *BackupManager) updateGameInfo(game *GameInfo) error {
 	var totalSize int64
 	var fileCount int
+	var lastSaved time.Time
+
+	err := bm.walkSaveFiles(game, func(path, name string, d fs.DirEntry) error {
+		if info, err := d.Info(); err == nil {
+			totalSize += info.Size()
+			fileCount++
+			if info.ModTime().After(lastSaved) {
+				lastSaved = info.ModTime()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-	for _, savePath := range game.SavePaths {
-		expandedPath := ExpandPath(savePath)
+	cloudStatus := cloudSyncStatus(game)
+	installed, installCheck := installState(game)
+	// Guardar partida implica haber jugado: sirve también para los juegos que no son de Steam
+	lastPlayed := steamLastPlayed(game)
+	if lastSaved.After(lastPlayed) {
+		lastPlayed = lastSaved
+	}
+
+	bm.updateGame(game, func(game *GameInfo) {
+		game.TotalSize = totalSize
+		game.FileCount = fileCount
+		game.CloudSyncStatus = cloudStatus
+		game.Installed, game.InstallCheck = installed, installCheck
+		game.LastPlayed = lastPlayed
+	})
+	return nil
+}
 
-		err := filepath.WalkDir(expandedPath, func(path string, d fs.DirEntry, err error) error {
+// walkSaveFiles recorre los archivos de guardado de un juego que coinciden con sus patrones y no están excluidos.
+// name es el nombre (con barras /) que tendrá el archivo dentro del backup.
+func (bm *BackupManager) walkSaveFiles(game *GameInfo, fn func(path, name string, d fs.DirEntry) error) error {
+	// Las raíces pueden solaparse (p. ej. saves/**/profile con un profile dentro de otro): cada archivo, una vez
+	seen := make(map[string]bool)
+	for _, root := range bm.saveRoots(game) {
+		err := filepath.WalkDir(root.Path, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return nil
 			}
 
-			if !d.IsDir() {
-				if bm.matchesPatterns(d.Name(), game.Patterns) && !bm.isExcluded(d.Name()) {
-					if info, err := d.Info(); err == nil {
-						totalSize += info.Size()
-						fileCount++
-					}
+			if skip, err := bm.skipEntry(game, root.Path, path, d); skip || err != nil {
+				return err
+			}
+
+			if !d.IsDir() && (root.File || bm.includesFile(game, d.Name())) {
+				if seen[path] {
+					return nil
 				}
+				seen[path] = true
+				name, err := root.entryName(path)
+				if err != nil {
+					return err
+				}
+				return fn(path, name, d)
 			}
 			return nil
 		})
@@ -475,12 +720,14 @@ func (bm *BackupManager) updateGameInfo(game *GameInfo) error {
 		}
 	}
 
-	game.TotalSize = totalSize
-	game.FileCount = fileCount
-
 	return nil
 }
 
+// includesFile indica si un archivo de las rutas del juego entra en el backup: en modo perfil completo, todos
+func (bm *BackupManager) includesFile(game *GameInfo, filename string) bool {
+	return game.BackupWholeProfile || bm.matchesPatterns(filename, game.Patterns)
+}
+
 // matchesPatterns verifica si un archivo coincide con los patrones del juego
 func (bm *BackupManager) matchesPatterns(filename string, patterns []string) bool {
 	filename = strings.ToLower(filename)
@@ -492,10 +739,13 @@ func (bm *BackupManager) matchesPatterns(filename string, patterns []string) boo
 	return false
 }
 
-// isExcluded verifica si un archivo debe ser excluido del backup
+// isExcluded verifica si un archivo debe ser excluido del backup por su nombre
 func (bm *BackupManager) isExcluded(filename string) bool {
 	filename = strings.ToLower(filename)
-	for _, pattern := range bm.Config.ExcludePatterns {
+	for _, pattern := range bm.config().ExcludePatterns {
+		if strings.Contains(pattern, "/") {
+			continue // Patrón de ruta: lo evalúa isExcludedPath
+		}
 		if matched, _ := filepath.Match(strings.ToLower(pattern), filename); matched {
 			return true
 		}
@@ -503,53 +753,325 @@ func (bm *BackupManager) isExcluded(filename string) bool {
 	return false
 }
 
+// isExcludedPath verifica si una ruta relativa a la raíz de guardado coincide con un patrón de exclusión de rutas
+// (los que contienen "/", p. ej. "logs/**", "**/crashes" o "cache/"). Un "/" final limita el patrón a directorios.
+func (bm *BackupManager) isExcludedPath(rel string, isDir bool) bool {
+	parts := strings.Split(strings.ToLower(rel), "/")
+	for _, pattern := range bm.config().ExcludePatterns {
+		if !strings.Contains(pattern, "/") {
+			continue
+		}
+
+		dirOnly := strings.HasSuffix(pattern, "/")
+		if dirOnly && !isDir {
+			continue
+		}
+		pattern = strings.Trim(strings.ToLower(pattern), "/")
+		if matchPathGlob(strings.Split(pattern, "/"), parts) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipEntry decide si el recorrido de una raíz de guardado omite una entrada.
+// Los directorios excluidos devuelven fs.SkipDir para no recorrer su contenido.
+func (bm *BackupManager) skipEntry(game *GameInfo, rootPath, path string, d fs.DirEntry) (bool, error) {
+	rel, err := filepath.Rel(rootPath, path)
+	if err != nil || rel == "." {
+		return false, nil
+	}
+	rel = filepath.ToSlash(rel)
+
+	// En el perfil completo se omiten las carpetas temporales y los enlaces de Wine a las carpetas del
+	// usuario de Linux (Documents, Desktop...), que pueden apuntar a todo el directorio personal
+	if game.BackupWholeProfile {
+		if d.Type()&fs.ModeSymlink != 0 {
+			return true, nil
+		}
+		if d.IsDir() && isProfilePruned(rel) {
+			return true, fs.SkipDir
+		}
+	}
+
+	if d.IsDir() {
+		if bm.isExcludedPath(rel, true) {
+			return true, fs.SkipDir
+		}
+		return false, nil
+	}
+	return bm.isExcluded(d.Name()) || bm.isExcludedPath(rel, false), nil
+}
+
+// BackupOptions controla cómo se ejecuta un backup
+type BackupOptions struct {
+	Trigger string `json:"trigger"` // "manual", "auto", ...
+
+	// OperationID identifica el backup en los eventos, el registro, el resultado y la actividad.
+	// La cola usa el ID del trabajo; vacío fuera de la cola = se genera uno.
+	OperationID string `json:"operation_id"`
+
+	// Progress recibe el número de archivos copiados y el total (opcional)
+	Progress func(done, total int) `json:"-"`
+}
+
 // CreateBackup crea un backup de un juego específico
 func (bm *BackupManager) CreateBackup(gameID string) error {
-	game, exists := bm.DetectedGames[gameID]
+	_, err := bm.CreateBackupWithOptions(gameID, BackupOptions{Trigger: "manual"})
+	return err
+}
+
+// CreateBackupWithOptions crea un backup de un juego y devuelve la información del backup creado
+func (bm *BackupManager) CreateBackupWithOptions(gameID string, opts BackupOptions) (*BackupInfo, error) {
+	if opts.OperationID == "" {
+		opts.OperationID = uuid.NewString()
+	}
+	info, err := bm.createBackup(gameID, opts)
+
+	params := map[string]string{"trigger": opts.Trigger}
+	summary := ""
+	if err != nil {
+		summary = fmt.Sprintf("Error creando backup: %v", err)
+	} else {
+		info.OperationID = opts.OperationID
+		params["backup"] = info.Name
+		params["size"] = strconv.FormatInt(info.Size, 10)
+		summary = fmt.Sprintf("Backup creado: %s", info.Name)
+	}
+	entry := bm.gameActivityEntry(ActivityBackup, gameID, params, summary)
+	entry.OperationID = opts.OperationID
+	bm.recordActivity(entry, err)
+	bm.recordBackupMetrics(info, err)
+	if game, exists := bm.getGame(gameID); exists && err == nil {
+		bm.writeGameSidecar(game)
+		if opts.Trigger == "manual" {
+			bm.learnExtensions(game)
+		}
+	}
+	return info, err
+}
+
+// createBackup crea el backup de CreateBackupWithOptions
+func (bm *BackupManager) createBackup(gameID string, opts BackupOptions) (*BackupInfo, error) {
+	game, exists := bm.getGame(gameID)
 	if !exists {
-		return fmt.Errorf("juego con ID %s no encontrado", gameID)
+		return nil, errGameNotFound(gameID)
 	}
 
-	log.Printf("Creando backup para: %s", game.Name)
+	// Una unidad desmontada no es una pérdida de datos: no crear un backup vacío o parcial
+	if err := bm.checkSaveMounts(game); err != nil {
+		return nil, err
+	}
 
-	// Crear directorio de backup si no existe
-	backupDir := filepath.Join(bm.Config.BackupDir, game.ID)
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
-		return fmt.Errorf("error creando directorio de backup: %v", err)
+	log.Printf("Creando backup para: %s (operación %s)", game.Name, opts.OperationID)
+
+	// Los backups del programador no deben saturar el disco mientras se juega; los manuales van a toda velocidad
+	var limit *ioLimiter
+	if isBackgroundTrigger(opts.Trigger) {
+		limit = newIOLimiter(bm.config().BackgroundIOThrottleMBps)
+	}
+
+	// Contar archivos y comprobar los límites antes de escribir nada en disco
+	start := time.Now()
+	totalFiles, totalBytes, err := bm.countBackupFiles(game)
+	if err != nil {
+		return nil, err
+	}
+	// Un backup vacío no sirve para restaurar y la rotación descartaría por él uno bueno
+	if totalFiles == 0 && !(game.BackupRegistry && len(game.RegistryKeys) > 0) {
+		return nil, bm.noFilesError(game)
+	}
+
+	// Contexto de la partida (nivel, zona...) para el historial; nunca hace fallar el backup
+	saveInfo := bm.extractSaveMetadata(game)
+
+	// Partidas que el juego borró desde el último backup: se apartan antes de que la rotación se las lleve
+	if game.KeepDeletedFiles {
+		if err := bm.keepDeletedFiles(game); err != nil {
+			log.Printf("Error guardando las partidas borradas de %s: %v", game.Name, err)
+		}
+	}
+
+	filesDone := 0
+	onFile := func() {
+		filesDone++
+		if opts.Progress != nil {
+			opts.Progress(filesDone, totalFiles)
+		}
 	}
 
 	// Generar nombre de archivo de backup con timestamp
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	var backupPath string
+	now := time.Now()
+	timestamp := now.Format(backupTimestampLayout)
+	backupName := fmt.Sprintf("%s_%s", bm.backupFolder(game.ID), timestamp)
+	compressed := bm.config().CompressionEnabled
+
+	// Con copia actual, el backup la actualiza y el historial solo recibe instantáneas comprimidas cuando toca
+	if bm.config().CurrentMirror {
+		mirrorPath, err := bm.updateCurrentMirror(game, limit, onFile)
+		if err != nil {
+			return nil, err
+		}
+		if !bm.snapshotDue(game.ID, now) {
+			bm.updateGame(game, func(game *GameInfo) { game.LastBackup = now })
+			return &BackupInfo{
+				Name:      currentMirrorName,
+				Path:      mirrorPath,
+				Size:      backupSize(mirrorPath),
+				Created:   now,
+				SaveInfo:  saveInfo,
+				Throttled: limit != nil,
+			}, bm.SaveDatabase()
+		}
+		compressed = true
+		filesDone = 0
+	}
+	if compressed {
+		backupName += ".zip"
+	}
 
-	if bm.Config.CompressionEnabled {
-		backupPath = filepath.Join(backupDir, fmt.Sprintf("%s_%s.zip", game.ID, timestamp))
-		if err := bm.createZipBackup(game, backupPath); err != nil {
-			return err
+	// Construir el backup en el directorio temporal y moverlo al destino solo si todo fue bien
+	workDir, err := os.MkdirTemp(bm.tempDir(), "winesave-")
+	if err != nil {
+		return nil, fmt.Errorf("error creando directorio temporal: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	workPath := filepath.Join(workDir, backupName)
+	if compressed {
+		if err := bm.createZipBackup(game, workPath, limit, onFile); err != nil {
+			return nil, err
 		}
 	} else {
-		backupPath = filepath.Join(backupDir, fmt.Sprintf("%s_%s", game.ID, timestamp))
-		if err := os.MkdirAll(backupPath, 0755); err != nil {
-			return err
+		if err := os.MkdirAll(workPath, 0755); err != nil {
+			return nil, err
 		}
-		if err := bm.createFolderBackup(game, backupPath); err != nil {
-			return err
+		if err := bm.createFolderBackup(game, workPath, limit, onFile); err != nil {
+			return nil, err
+		}
+	}
+
+	// Un backup que no se puede leer entero no llega al directorio de backups
+	if bm.config().CheckArchiveAfter {
+		if err := checkArchive(workPath); err != nil {
+			return nil, fmt.Errorf("el backup creado está dañado: %v", err)
 		}
 	}
 
-	game.LastBackup = time.Now()
+	// Checksums de cada archivo, calculados antes de guardarlo
+	manifest, err := buildManifest(game.ID, workPath)
+	if err != nil {
+		log.Printf("Error calculando el manifiesto de %s: %v", backupName, err)
+		manifest = &BackupManifest{GameID: game.ID, Backup: backupName} // Sin archivos: se guarda sin manifiesto
+	}
+	manifest.CreatedAt = now
+
+	source := &localBackup{path: workPath}
+	backupPath, err := bm.backupStore().Put(game.ID, manifest, source)
+	source.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	bm.updateGame(game, func(game *GameInfo) { game.LastBackup = now })
 	log.Printf("Backup creado exitosamente: %s", backupPath)
 
+	// Registrar historial
+	if len(manifest.Files) == 0 {
+		log.Printf("Backup %s sin manifiesto: no se registra en el historial hasta verificarlo", backupName)
+	} else if _, err := bm.recordBackup(game.ID, backupPath, "backup", now, manifest); err != nil {
+		log.Printf("Error registrando backup en el historial: %v", err)
+	} else {
+		// Duración y tamaño de origen para estimar los próximos backups
+		duration := time.Since(start)
+		err := bm.updateBackupRecord(game.ID, backupName, func(record *BackupRecord) {
+			record.SaveInfo = saveInfo
+			record.Duration = duration
+			record.SourceBytes = totalBytes
+			record.WholeProfile = game.BackupWholeProfile
+			record.Throttled = limit != nil
+		})
+		if err != nil {
+			log.Printf("Error guardando datos del backup en el historial: %v", err)
+		}
+	}
+
 	// Limpiar backups antiguos
 	if err := bm.cleanOldBackups(game.ID); err != nil {
 		log.Printf("Error limpiando backups antiguos: %v", err)
 	}
 
-	return bm.SaveDatabase()
+	// Respetar la cuota total del directorio de backups
+	if _, err := bm.enforceBackupQuota(); err != nil {
+		log.Printf("Error aplicando la cuota de backups: %v", err)
+	}
+
+	info := &BackupInfo{
+		Name:       backupName,
+		Path:       backupPath,
+		Size:       backupSize(backupPath),
+		Created:    now,
+		Compressed: compressed,
+		SaveInfo:   saveInfo,
+
+		WholeProfile: game.BackupWholeProfile,
+		Throttled:    limit != nil,
+	}
+	if compressed {
+		info.CompressionRatio = compressionRatio(info.Size, totalBytes)
+	}
+
+	// Copias en los destinos adicionales del juego, cada una con su propia retención
+	if len(game.ExtraBackupDirs) > 0 {
+		info.Destinations = bm.copyToExtraDestinations(game, backupPath)
+	}
+	return info, bm.SaveDatabase()
+}
+
+// checkBackupLimits comprueba que las rutas del juego no superan los límites de seguridad del backup
+func (bm *BackupManager) checkBackupLimits(game *GameInfo) error {
+	_, _, err := bm.countBackupFiles(game)
+	return err
+}
+
+// noFilesError explica por qué un juego no tiene archivos que respaldar: sus rutas no existen o nada coincide
+// con sus patrones
+func (bm *BackupManager) noFilesError(game *GameInfo) error {
+	details := map[string]string{"game_id": game.ID}
+	for _, root := range bm.saveRoots(game) {
+		if _, err := os.Stat(root.Path); err == nil {
+			return newAppError(ErrCodeNoFilesMatched,
+				fmt.Sprintf("ningún archivo de las rutas de guardado de %s coincide con sus patrones", game.Name), details, nil)
+		}
+	}
+	return newAppError(ErrCodePathMissing, fmt.Sprintf("ninguna ruta de guardado de %s existe", game.Name), details, nil)
+}
+
+// countBackupFiles recorre las rutas del juego contando archivos y bytes, y aborta si se superan los límites
+func (bm *BackupManager) countBackupFiles(game *GameInfo) (int, int64, error) {
+	maxFiles := bm.config().MaxBackupFiles
+	maxBytes := bm.config().MaxBackupBytes
+
+	var totalFiles int
+	var totalBytes int64
+
+	// Con walkSaveFiles se cuenta lo mismo que se copia: cada archivo una vez aunque las raíces se solapen
+	err := bm.walkSaveFiles(game, func(path, name string, d fs.DirEntry) error {
+		totalFiles++
+		if info, err := d.Info(); err == nil {
+			totalBytes += info.Size()
+		}
+
+		if (maxFiles > 0 && totalFiles > maxFiles) || (maxBytes > 0 && totalBytes > maxBytes) {
+			return fmt.Errorf("%w: %s (más de %d archivos o %d bytes)", ErrBackupTooLarge, filepath.Dir(path), maxFiles, maxBytes)
+		}
+		return nil
+	})
+	return totalFiles, totalBytes, err
 }
 
 // createZipBackup crea un backup comprimido en ZIP
-func (bm *BackupManager) createZipBackup(game *GameInfo, zipPath string) error {
+func (bm *BackupManager) createZipBackup(game *GameInfo, zipPath string, limit *ioLimiter, onFile func()) error {
 	zipFile, err := os.Create(zipPath)
 	if err != nil {
 		return err
@@ -557,75 +1079,137 @@ func (bm *BackupManager) createZipBackup(game *GameInfo, zipPath string) error {
 	defer zipFile.Close()
 
 	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
-
-	for _, savePath := range game.SavePaths {
-		expandedPath := ExpandPath(savePath)
 
-		err := filepath.WalkDir(expandedPath, func(path string, d fs.DirEntry, err error) error {
+	if workers := bm.compressionWorkers(); workers > 1 {
+		err = bm.writeZipEntriesParallel(game, zipWriter, workers, limit, onFile)
+	} else {
+		err = bm.writeZipEntries(game, zipWriter, limit, onFile)
+	}
+	if err == nil {
+		err = bm.writeRegistryEntry(game, func(data []byte) error {
+			zipEntry, err := zipWriter.Create(registryEntryName)
 			if err != nil {
-				return nil
+				return err
 			}
-
-			if !d.IsDir() && bm.matchesPatterns(d.Name(), game.Patterns) && !bm.isExcluded(d.Name()) {
-				relPath, _ := filepath.Rel(expandedPath, path)
-
-				zipEntry, err := zipWriter.Create(relPath)
-				if err != nil {
-					return err
-				}
-
-				file, err := os.Open(path)
-				if err != nil {
-					return err
-				}
-				defer file.Close()
-
-				_, err = io.Copy(zipEntry, file)
+			_, err = zipEntry.Write(data)
+			return err
+		})
+	}
+	if err == nil {
+		err = writeBackupMeta(game, func(data []byte) error {
+			zipEntry, err := zipWriter.Create(backupMetaEntryName)
+			if err != nil {
 				return err
 			}
-			return nil
+			_, err = zipEntry.Write(data)
+			return err
 		})
+	}
+	if err != nil {
+		zipWriter.Close()
+		return err
+	}
+
+	// Cerrar explícitamente para detectar errores al escribir el directorio central
+	return zipWriter.Close()
+}
 
+// writeZipEntries comprime los archivos de partida uno a uno en el ZIP
+func (bm *BackupManager) writeZipEntries(game *GameInfo, zipWriter *zip.Writer, limit *ioLimiter, onFile func()) error {
+	return bm.walkSaveFiles(game, func(path, name string, d fs.DirEntry) error {
+		// Guardar la fecha de modificación permite restaurar solo los archivos más recientes que los actuales
+		header := &zip.FileHeader{Name: name, Method: bm.zipMethod(path)}
+		if info, err := d.Info(); err == nil {
+			header.Modified = info.ModTime()
+		}
+		zipEntry, err := zipWriter.CreateHeader(header)
 		if err != nil {
 			return err
 		}
-	}
 
-	return nil
+		file, err := bm.openSaveFile(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if _, err := io.Copy(zipEntry, limit.reader(file)); err != nil {
+			return err
+		}
+		onFile()
+		return nil
+	})
 }
 
 // createFolderBackup crea un backup en carpeta sin comprimir
-func (bm *BackupManager) createFolderBackup(game *GameInfo, backupPath string) error {
-	for _, savePath := range game.SavePaths {
-		expandedPath := ExpandPath(savePath)
+func (bm *BackupManager) createFolderBackup(game *GameInfo, backupPath string, limit *ioLimiter, onFile func()) error {
+	err := bm.walkSaveFiles(game, func(path, name string, d fs.DirEntry) error {
+		destPath := filepath.Join(backupPath, filepath.FromSlash(name))
 
-		err := filepath.WalkDir(expandedPath, func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return nil
-			}
+		// Crear directorio destino si no existe
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return err
+		}
 
-			if !d.IsDir() && bm.matchesPatterns(d.Name(), game.Patterns) && !bm.isExcluded(d.Name()) {
-				relPath, _ := filepath.Rel(expandedPath, path)
-				destPath := filepath.Join(backupPath, relPath)
+		// Copiar archivo
+		if err := bm.copySaveFile(path, destPath, limit); err != nil {
+			return fmt.Errorf("error copiando %s: %w", name, err)
+		}
+		onFile()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-				// Crear directorio destino si no existe
-				if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-					return err
-				}
+	err = bm.writeRegistryEntry(game, func(data []byte) error {
+		return os.WriteFile(filepath.Join(backupPath, registryEntryName), data, 0644)
+	})
+	if err != nil {
+		return err
+	}
+	return writeBackupMeta(game, func(data []byte) error {
+		return os.WriteFile(filepath.Join(backupPath, backupMetaEntryName), data, 0644)
+	})
+}
 
-				// Copiar archivo
-				return copyFile(path, destPath)
-			}
-			return nil
-		})
+// tempDir devuelve el directorio donde se construyen los backups antes de moverlos
+func (bm *BackupManager) tempDir() string {
+	if bm.config().TempDir != "" {
+		return ExpandPath(bm.config().TempDir)
+	}
+	return os.TempDir()
+}
 
-		if err != nil {
-			return err
-		}
+// moveIntoPlace mueve un archivo o carpeta a su destino final.
+// Si el origen está en otro sistema de archivos se copia a un nombre provisional y luego se renombra.
+func moveIntoPlace(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
 	}
 
-	return nil
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	partial := dst + ".partial"
+	os.RemoveAll(partial)
+	if info.IsDir() {
+		err = copyDir(src, partial)
+	} else {
+		err = copyFile(src, partial)
+	}
+	if err != nil {
+		os.RemoveAll(partial)
+		return err
+	}
+
+	if err := os.Rename(partial, dst); err != nil {
+		os.RemoveAll(partial)
+		return err
+	}
+	return os.RemoveAll(src)
 }
 
 // copyFile copia un archivo de origen a destino
@@ -635,69 +1219,130 @@ func copyFile(src, dst string) error {
 		return err
 	}
 	defer srcFile.Close()
+	return writeFileFrom(srcFile, dst)
+}
 
+// writeFileFrom crea dst con el contenido de src
+func writeFileFrom(src io.Reader, dst string) error {
 	dstFile, err := os.Create(dst)
 	if err != nil {
 		return err
 	}
 	defer dstFile.Close()
 
-	_, err = io.Copy(dstFile, srcFile)
+	_, err = io.Copy(dstFile, src)
 	return err
 }
 
-// cleanOldBackups elimina backups antiguos manteniendo solo los más recientes
-func (bm *BackupManager) cleanOldBackups(gameID string) error {
-	backupDir := filepath.Join(bm.Config.BackupDir, gameID)
+// copyDir copia recursivamente una carpeta
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
 
-	files, err := os.ReadDir(backupDir)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil // Ignorar enlaces simbólicos y archivos especiales
+		}
+		return copyFile(path, target)
+	})
+}
+
+// cleanOldBackups elimina backups antiguos manteniendo solo los más recientes, salvo los que aún no tienen
+// MinBackupAgeBeforeDelete
+func (bm *BackupManager) cleanOldBackups(gameID string) error {
+	// listBackups solo devuelve backups reales (no manifiestos ni historial), del más reciente al más antiguo
+	backups, err := bm.listBackups(gameID)
 	if err != nil {
 		return err
 	}
 
-	// Filtrar solo archivos de backup y ordenar por fecha
-	var backupFiles []fs.DirEntry
-	for _, file := range files {
-		if strings.Contains(file.Name(), gameID) {
-			backupFiles = append(backupFiles, file)
+	// Los backups fijados no cuentan para el límite
+	var rotating []BackupInfo
+	for _, backup := range backups {
+		if !backup.Pinned {
+			rotating = append(rotating, backup)
 		}
 	}
 
-	if len(backupFiles) <= bm.Config.MaxBackups {
+	if len(rotating) <= bm.config().MaxBackups {
 		return nil
 	}
 
-	// Ordenar por fecha de modificación (más reciente primero)
-	sort.Slice(backupFiles, func(i, j int) bool {
-		infoI, _ := backupFiles[i].Info()
-		infoJ, _ := backupFiles[j].Info()
-		return infoI.ModTime().After(infoJ.ModTime())
-	})
-
 	// Eliminar backups antiguos
-	for i := bm.Config.MaxBackups; i < len(backupFiles); i++ {
-		filePath := filepath.Join(backupDir, backupFiles[i].Name())
-		if err := os.RemoveAll(filePath); err != nil {
-			log.Printf("Error eliminando backup antiguo %s: %v", filePath, err)
+	for _, backup := range rotating[bm.config().MaxBackups:] {
+		if bm.tooRecentToRotate(backup) {
+			log.Printf("Backup %s conservado: tiene menos de %v", backup.Name, bm.config().MinBackupAgeBeforeDelete)
+			continue
+		}
+		if err := bm.removeBackup(gameID, backup); err != nil {
+			log.Printf("Error eliminando backup antiguo %s: %v", backup.Path, err)
 		} else {
-			log.Printf("Backup antiguo eliminado: %s", filePath)
+			log.Printf("Backup antiguo eliminado: %s", backup.Path)
 		}
 	}
 
 	return nil
 }
 
+// tooRecentToRotate indica si un backup es más reciente que MinBackupAgeBeforeDelete y la rotación no lo borra
+func (bm *BackupManager) tooRecentToRotate(backup BackupInfo) bool {
+	return time.Since(backup.Created) < bm.config().MinBackupAgeBeforeDelete
+}
+
+// resolveBackupFile devuelve la ruta completa de un backup validando que el nombre no escape del directorio del juego
+func (bm *BackupManager) resolveBackupFile(gameID, fileName string) (string, error) {
+	if fileName == "" || fileName != filepath.Base(fileName) || fileName == "." || fileName == ".." {
+		return "", fmt.Errorf("nombre de backup inválido: %s", fileName)
+	}
+
+	path := filepath.Join(bm.gameBackupDir(gameID), fileName)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("backup %s no encontrado: %v", fileName, err)
+	}
+	return path, nil
+}
+
 // LoadConfig carga la configuración desde un archivo JSON
 func (bm *BackupManager) LoadConfig(path string) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
+	bm.configM.Lock()
+	defer bm.configM.Unlock()
 	return json.Unmarshal(data, &bm.Config)
 }
 
-// SaveConfig guarda la configuración actual en un archivo JSON
+// config devuelve una copia de la configuración. Todo lo que puede ejecutarse mientras UpdateConfig la cambia
+// (la cola, el programador, la API) la lee por aquí.
+func (bm *BackupManager) config() BackupConfig {
+	bm.configM.RLock()
+	defer bm.configM.RUnlock()
+	return bm.Config
+}
+
+// setConfig cambia la configuración con el bloqueo tomado; fn no debe llamar a config
+func (bm *BackupManager) setConfig(fn func(config *BackupConfig)) {
+	bm.configM.Lock()
+	defer bm.configM.Unlock()
+	fn(&bm.Config)
+}
+
+// SaveConfig guarda la configuración actual en un archivo JSON. El bloqueo se mantiene hasta escribirlo para que
+// una pausa o un UpdateConfig simultáneos no acaben guardados en el orden inverso.
 func (bm *BackupManager) SaveConfig(path string) error {
+	bm.configM.RLock()
+	defer bm.configM.RUnlock()
 	data, err := json.MarshalIndent(bm.Config, "", "  ")
 	if err != nil {
 		return err
@@ -705,67 +1350,145 @@ func (bm *BackupManager) SaveConfig(path string) error {
 	return os.WriteFile(path, data, 0644)
 }
 
+// applyConfig sustituye la configuración por la que envía el frontend conservando los campos que solo cambia la
+// aplicación, con el bloqueo tomado: una pausa que llegue a la vez no se pierde
+func (bm *BackupManager) applyConfig(config BackupConfig) {
+	bm.setConfig(func(current *BackupConfig) { *current = withServerFields(config, *current) })
+}
+
+// withServerFields devuelve config, la configuración que envía el frontend, con los campos de current que solo
+// cambia la aplicación (la pausa de los backups automáticos y el token de la API): la copia del frontend puede ser
+// anterior a una pausa o a que se generara el token, y guardarla tal cual los borraría
+func withServerFields(config, current BackupConfig) BackupConfig {
+	config.AutoBackupPausedUntil = current.AutoBackupPausedUntil
+	config.APIToken = current.APIToken
+	return config
+}
+
+// databaseSchemaVersion es la versión del formato de game_saves.json. La 1 (sin versión) guardaba los juegos
+// en un objeto por ID; la 2 los guarda en una lista ordenada por ID para que el archivo se pueda comparar.
+const databaseSchemaVersion = 2
+
+// databaseFile es el contenido de game_saves.json
+type databaseFile struct {
+	SchemaVersion int                  `json:"schema_version,omitempty"`
+	Games         []*GameInfo          `json:"games"`
+	DetectedGames map[string]*GameInfo `json:"detected_games,omitempty"` // Versión 1
+	LastUpdate    time.Time            `json:"last_update"`
+}
+
 // LoadDatabase carga la base de datos de juegos detectados
 func (bm *BackupManager) LoadDatabase() error {
-	if _, err := os.Stat(bm.DatabasePath); os.IsNotExist(err) {
-		return nil // No hay base de datos, empezar limpio
-	}
-
-	data, err := os.ReadFile(bm.DatabasePath)
+	store, err := bm.database()
 	if err != nil {
 		return err
 	}
-
-	var dbData struct {
-		DetectedGames map[string]*GameInfo `json:"detected_games"`
-	}
-
-	if err := json.Unmarshal(data, &dbData); err != nil {
+	loaded, rewrite, err := store.Load()
+	if err != nil {
 		return err
 	}
-
-	bm.DetectedGames = dbData.DetectedGames
-	if bm.DetectedGames == nil {
-		bm.DetectedGames = make(map[string]*GameInfo)
+	if loaded == nil {
+		return nil // No hay base de datos, empezar limpio
 	}
 
-	// Inicializar mapas nil para evitar errores
-	for _, game := range bm.DetectedGames {
+	games := make(map[string]*GameInfo, len(loaded))
+	for _, game := range loaded {
+		// Inicializar mapas nil para evitar errores
 		if game.Metadata == nil {
 			game.Metadata = make(map[string]string)
 		}
 		if game.CustomPaths == nil {
 			game.CustomPaths = []string{}
 		}
+		// Versiones anteriores guardaban la carpeta de instalación en Metadata
+		if dir := game.Metadata["install_dir"]; dir != "" {
+			if game.InstallDir == "" {
+				game.InstallDir = dir
+			}
+			delete(game.Metadata, "install_dir")
+			rewrite = true
+		}
+		games[game.ID] = game
 	}
 
+	bm.mu.Lock()
+	bm.DetectedGames = games
+	bm.mu.Unlock()
+
+	// Juegos de versiones anteriores: IDs derivados de la ruta y carpetas no seguras, y formato de la versión 1
+	migrated := bm.migrateToStableIDs()
+	if bm.migrateUnsafeSlugs() || migrated || rewrite {
+		return bm.SaveDatabase()
+	}
 	return nil
 }
 
-// SaveDatabase guarda la base de datos de juegos detectados
+// SaveDatabase guarda la base de datos de juegos detectados, ordenada para que guardar sin cambios
+// produzca el mismo resultado: si los juegos no cambiaron no se escribe nada
 func (bm *BackupManager) SaveDatabase() error {
-	dbData := struct {
-		DetectedGames map[string]*GameInfo `json:"detected_games"`
-		LastUpdate    time.Time            `json:"last_update"`
-	}{
-		DetectedGames: bm.DetectedGames,
-		LastUpdate:    time.Now(),
+	store, err := bm.database()
+	if err != nil {
+		return err
 	}
-
-	data, err := json.MarshalIndent(dbData, "", "  ")
+	bm.dbMu.Lock()
+	defer bm.dbMu.Unlock()
+	changed, err := store.Save(bm.storedGames())
 	if err != nil {
 		return err
 	}
+	if changed {
+		bm.emitLibraryChanged()
+	}
+	return nil
+}
+
+// storedGames serializa los juegos ordenados por ID para guardarlos
+func (bm *BackupManager) storedGames() []storedGame {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	games := make([]storedGame, 0, len(bm.DetectedGames))
+	for _, game := range bm.DetectedGames {
+		// Copia con los patrones ordenados: su orden no importa y así no cambia lo guardado.
+		// SavePaths conserva su orden porque la primera ruta es el destino por defecto al restaurar.
+		stored := *game
+		stored.Patterns = append([]string(nil), game.Patterns...)
+		sort.Strings(stored.Patterns)
+		stored.Tags = normalizeTags(game.Tags)
+		// Vacíos como los deja LoadDatabase, para que cargar y volver a guardar no cambie el archivo
+		if stored.CustomPaths == nil {
+			stored.CustomPaths = []string{}
+		}
+		if stored.Metadata == nil {
+			stored.Metadata = map[string]string{}
+		}
+		data, err := json.Marshal(&stored)
+		if err != nil {
+			log.Printf("Error serializando %s: %v", game.Name, err)
+			continue
+		}
+		games = append(games, storedGame{ID: game.ID, Slug: game.Slug, Name: game.Name, Platform: game.Platform, Data: data})
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].ID < games[j].ID })
+	return games
+}
 
-	return os.WriteFile(bm.DatabasePath, data, 0644)
+// sameJSON compara dos documentos JSON sin tener en cuenta el espaciado
+func sameJSON(a, b []byte) bool {
+	var compactA, compactB bytes.Buffer
+	if json.Compact(&compactA, a) != nil || json.Compact(&compactB, b) != nil {
+		return false
+	}
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes())
 }
 
 // GetGameList devuelve la lista de juegos detectados
 func (bm *BackupManager) GetGameList() []*GameInfo {
+	bm.mu.RLock()
 	games := make([]*GameInfo, 0, len(bm.DetectedGames))
 	for _, game := range bm.DetectedGames {
 		games = append(games, game)
 	}
+	bm.mu.RUnlock()
 
 	// Ordenar por nombre
 	sort.Slice(games, func(i, j int) bool {
@@ -777,7 +1500,13 @@ func (bm *BackupManager) GetGameList() []*GameInfo {
 
 // AddCustomGame permite agregar manualmente un juego personalizado
 func (bm *BackupManager) AddCustomGame(name, savePath string, patterns []string) error {
-	gameID := bm.generateGameID(savePath)
+	slug := bm.generateSlug(savePath)
+
+	// La misma carpeta de guardado reemplaza al juego existente conservando su identidad
+	gameID := newGameID()
+	if existing, exists := bm.findGameBySlug(slug); exists {
+		gameID = existing.ID
+	}
 
 	// Verificar que la ruta existe
 	expandedPath := ExpandPath(savePath)
@@ -787,6 +1516,7 @@ func (bm *BackupManager) AddCustomGame(name, savePath string, patterns []string)
 
 	game := &GameInfo{
 		ID:          gameID,
+		Slug:        slug,
 		Name:        name,
 		Platform:    "custom",
 		SavePaths:   []string{savePath},
@@ -795,13 +1525,14 @@ func (bm *BackupManager) AddCustomGame(name, savePath string, patterns []string)
 		Metadata:    make(map[string]string),
 	}
 
-	bm.DetectedGames[gameID] = game
+	bm.setGame(game)
 
 	if err := bm.updateGameInfo(game); err != nil {
 		return err
 	}
 
 	log.Printf("Juego personalizado agregado: %s", name)
+	bm.checkPathOverlaps()
 	return bm.SaveDatabase()
 }
 
@@ -812,11 +1543,16 @@ func (bm *BackupManager) SearchGamesOnPCGW(gameName string) ([]GameSearchResult,
 
 // AddGameFromPCGW agrega un juego desde PCGamingWiki con configuración del usuario
 func (bm *BackupManager) AddGameFromPCGW(selection UserGameSelection) error {
-	gameID := bm.generateGameID(selection.Name)
+	slug := bm.generateGameIDFromName(selection.Name)
+	gameID := newGameID()
+	if existing, exists := bm.findGameBySlug(slug); exists {
+		gameID = existing.ID
+	}
 
 	// Crear GameInfo desde la selección
 	game := &GameInfo{
 		ID:          gameID,
+		Slug:        slug,
 		Name:        selection.Name,
 		Platform:    "pcgw", // PCGamingWiki source
 		SavePaths:   []string{},
@@ -831,6 +1567,7 @@ func (bm *BackupManager) AddGameFromPCGW(selection UserGameSelection) error {
 		game.Metadata["steam_app_id"] = selection.SelectedGame.SteamAppID
 		game.Metadata["release_date"] = selection.SelectedGame.ReleaseDate
 		game.Metadata["cover_url"] = selection.SelectedGame.CoverURL
+		game.RegistryKeys = selection.SelectedGame.RegistryKeys
 
 		// Usar las rutas de guardado de PCGW
 		for _, path := range selection.SelectedGame.SavePaths {
@@ -856,11 +1593,12 @@ func (bm *BackupManager) AddGameFromPCGW(selection UserGameSelection) error {
 	}
 
 	if !pathExists {
-		return fmt.Errorf("ninguna de las rutas de guardado especificadas existe")
+		return newAppError(ErrCodePathMissing, "ninguna de las rutas de guardado especificadas existe",
+			map[string]string{"game": selection.Name}, nil)
 	}
 
 	// Agregar al manager
-	bm.DetectedGames[gameID] = game
+	bm.setGame(game)
 
 	// Actualizar información del juego
 	if err := bm.updateGameInfo(game); err != nil {
@@ -868,12 +1606,13 @@ func (bm *BackupManager) AddGameFromPCGW(selection UserGameSelection) error {
 	}
 
 	log.Printf("Juego agregado desde PCGamingWiki: %s", selection.Name)
+	bm.checkPathOverlaps()
 	return bm.SaveDatabase()
 }
 
 // GetDefaultBackupPath devuelve la ruta por defecto para backups del usuario
 func (bm *BackupManager) GetDefaultBackupPath() string {
-	return bm.Config.BackupDir
+	return bm.config().BackupDir
 }
 
 // SetBackupPath permite al usuario cambiar la ruta de backup
@@ -892,27 +1631,45 @@ func (bm *BackupManager) SetBackupPath(newPath string) error {
 	}
 	os.Remove(testFile)
 
-	bm.Config.BackupDir = expandedPath
+	bm.setConfig(func(config *BackupConfig) { config.BackupDir = expandedPath })
 	return nil
 }
 
-// ValidateGamePaths valida que las rutas de un juego existen
-func (bm *BackupManager) ValidateGamePaths(gameID string) ([]string, []string) {
-	game, exists := bm.DetectedGames[gameID]
+// ValidateGamePaths valida que las rutas de un juego existen (las rutas con comodines se expanden).
+// Las rutas con %GAME_DIR% de un juego sin carpeta de instalación se devuelven aparte, en needInstallDir, y las
+// que usan una unidad que el prefijo de Wine no tiene en dosdevices (se buscan en drive_c), en unmappedDrives.
+func (bm *BackupManager) ValidateGamePaths(gameID string) (validPaths, invalidPaths, needInstallDir, unmappedDrives []string) {
+	game, exists := bm.getGame(gameID)
 	if !exists {
-		return []string{}, []string{}
+		return []string{}, []string{}, []string{}, []string{}
 	}
 
-	var validPaths, invalidPaths []string
-
+	needInstallDir = pathsNeedingInstallDir(game)
+	unmappedDrives = []string{}
+	prefix := gamePrefix(game)
 	for _, path := range game.SavePaths {
-		expandedPath := ExpandPath(path)
-		if _, err := os.Stat(expandedPath); err == nil {
-			validPaths = append(validPaths, expandedPath)
-		} else {
+		if slices.Contains(needInstallDir, path) {
+			continue
+		}
+		// Una unidad que el prefijo no tiene se busca en drive_c, pero se avisa: seguramente falta asignarla
+		if resolved := strings.ReplaceAll(path, gameDirPlaceholder, game.InstallDir); gameDrivePrefix(game, resolved) != "" && unmappedDrive(resolved, prefix) {
+			unmappedDrives = append(unmappedDrives, path)
+		}
+		expandedPath := ExpandGamePath(game, path)
+		single := &GameInfo{SavePaths: []string{path}, InstallDir: game.InstallDir, Metadata: map[string]string{"wine_prefix": prefix}}
+		roots := bm.saveRoots(single)
+
+		found := false
+		for _, root := range roots {
+			if _, err := os.Stat(root.Path); err == nil {
+				validPaths = append(validPaths, root.Path)
+				found = true
+			}
+		}
+		if !found {
 			invalidPaths = append(invalidPaths, expandedPath)
 		}
 	}
 
-	return validPaths, invalidPaths
+	return validPaths, invalidPaths, needInstallDir, unmappedDrives
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Los backups en segundo plano cambian campos de los juegos mientras el frontend, la API y SaveDatabase los
+// leen; con go test -race este test falla si algún acceso no pasa por bm.mu
+func TestGameFieldsConcurrentAccess(t *testing.T) {
+	bm, _ := newTestManager(t)
+	bm.Config.MaxAutoBackupsPerDay = 100
+	game := bm.DetectedGames["g"]
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 3; i++ {
+			bm.recordAutoBackup("g")
+			if _, err := bm.CreateBackupWithOptions("g", BackupOptions{Trigger: "auto"}); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 3; i++ {
+			bm.autoBackupAllowed(game, time.Now())
+			if err := bm.updateGameInfo(game); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		for _, copied := range bm.humanGames(bm.GetGameList()) {
+			_ = copied.LastBackup
+			_ = copied.TotalSize
+		}
+		bm.storedGames()
+		bm.serveMetrics(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil))
+	}
+	wg.Wait()
+
+	snapshot := func() *GameInfo { c := *game; return &c }()
+	if snapshot.LastBackup.IsZero() || snapshot.AutoBackupCounter.Count != 3 || snapshot.TotalSize != 6 {
+		t.Errorf("LastBackup %v, contador %d, tamaño %d", snapshot.LastBackup, snapshot.AutoBackupCounter.Count, snapshot.TotalSize)
+	}
+}
+
+// Una ruta de guardado que es un archivo se respalda sola, aunque no coincida con los patrones y sin el resto
+// de su carpeta, y se restaura en el mismo sitio
+func TestSingleFileSavePathRoundTrip(t *testing.T) {
+	for _, compress := range []bool{true, false} {
+		bm, dir := newTestManager(t)
+		bm.Config.CompressionEnabled = compress
+		docs := filepath.Join(dir, "docs")
+		writeTestFiles(t, docs, map[string]string{
+			"profile.dat": "partida",
+			"other.sav":   "de otro juego",
+		})
+		game := bm.DetectedGames["g"]
+		game.SavePaths = append(game.SavePaths, filepath.Join(docs, "profile.dat"))
+		game.Patterns = []string{"*.sav"}
+
+		if err := bm.updateGameInfo(game); err != nil {
+			t.Fatal(err)
+		}
+		if snapshot := bm.gameSnapshot(game); snapshot.FileCount != 3 || snapshot.TotalSize != int64(3+3+len("partida")) {
+			t.Errorf("comprimido %v: %d archivos y %d bytes", compress, snapshot.FileCount, snapshot.TotalSize)
+		}
+
+		info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifest, err := loadManifest(filepath.Join(bm.Config.BackupDir, game.Slug, info.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, file := range manifest.Files {
+			if file.Path != backupMetaEntryName {
+				names = append(names, file.Path)
+			}
+		}
+		sort.Strings(names)
+		if want := []string{"a.sav", "profile.dat", "sub/b.sav"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("comprimido %v: manifiesto con %v, se esperaba %v", compress, names, want)
+		}
+
+		// Se restaura tanto si el archivo cambió como si se borró
+		for _, remove := range []bool{false, true} {
+			if remove {
+				os.Remove(filepath.Join(docs, "profile.dat"))
+			} else {
+				writeTestFiles(t, docs, map[string]string{"profile.dat": "cambiada"})
+			}
+			if _, err := bm.RestoreBackup("g", info.Name, RestoreOptions{Force: true, Mode: RestoreOverwrite}); err != nil {
+				t.Fatal(err)
+			}
+			assertFiles(t, docs, map[string]string{"profile.dat": "partida", "other.sav": "de otro juego"})
+			assertFiles(t, filepath.Join(dir, "saves"), map[string]string{"a.sav": "aaa", "sub/b.sav": "bbb"})
+			for _, stray := range []string{filepath.Join(dir, "saves", "profile.dat"), filepath.Join(docs, "profile.dat", "profile.dat")} {
+				if _, err := os.Lstat(stray); err == nil {
+					t.Errorf("comprimido %v: restaurado también en %s", compress, stray)
+				}
+			}
+		}
+	}
+}
+
+// Un backup con registro restaurado después de desactivar la copia del registro no deja registry.reg en las partidas
+func TestRestoreSkipsRegistryWhenDisabled(t *testing.T) {
+	bm, dir := newTestManager(t)
+	game := bm.DetectedGames["g"]
+	info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backupPath := filepath.Join(bm.Config.BackupDir, game.Slug, info.Name)
+	registry := []byte("Windows Registry Editor Version 5.00\n\n[HKEY_CURRENT_USER\\Software\\G]\n\"Slot\"=dword:00000001\n")
+	if err := rewriteBackupEntries(backupPath, map[string][]byte{registryEntryName: registry}); err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := bm.RestoreBackup("g", info.Name, RestoreOptions{Force: true, Mode: RestoreOverwrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "saves", registryEntryName)); err == nil {
+		t.Errorf("se restauró %s como archivo de partida", registryEntryName)
+	}
+	if record.FilesWritten != 2 || record.RegistryKeys != 0 {
+		t.Errorf("%d archivos escritos y %d claves del registro", record.FilesWritten, record.RegistryKeys)
+	}
+	assertFiles(t, filepath.Join(dir, "saves"), map[string]string{"a.sav": "aaa", "sub/b.sav": "bbb"})
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backupMigrationEvent es el evento emitido al mover los backups a otra ubicación
+const backupMigrationEvent = "backup-path:migrate-progress"
+
+// partialCopySuffix marca las copias a medias; una migración interrumpida las vuelve a copiar
+const partialCopySuffix = ".winesave-partial"
+
+// BackupPathOptions controla cómo se cambia la ruta de backup
+type BackupPathOptions struct {
+	Migrate   bool `json:"migrate"`    // Copiar los backups existentes a la nueva ubicación
+	DeleteOld bool `json:"delete_old"` // Borrar los originales tras copiarlos (solo con Migrate)
+}
+
+// MigrationProgress es el contenido del evento de progreso al mover los backups
+type MigrationProgress struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	FilesDone  int    `json:"files_done"`
+	FilesTotal int    `json:"files_total"`
+	BytesDone  int64  `json:"bytes_done"`
+	BytesTotal int64  `json:"bytes_total"`
+	Current    string `json:"current"`
+	Done       bool   `json:"done"`
+}
+
+// MigrationReport resume una migración del directorio de backups
+type MigrationReport struct {
+	From         string        `json:"from"`
+	To           string        `json:"to"`
+	Games        int           `json:"games"` // Carpetas de juegos copiadas
+	FilesCopied  int           `json:"files_copied"`
+	FilesSkipped int           `json:"files_skipped"` // Ya estaban verificados en el destino (migración reanudada)
+	BytesCopied  int64         `json:"bytes_copied"`
+	LeftBehind   []string      `json:"left_behind"` // Entradas de la ubicación anterior que no son de WineSave
+	Duration     time.Duration `json:"duration"`
+}
+
+// MigrateBackupDir copia los backups de todos los juegos a newPath verificando cada archivo y, solo si todo
+// se copió, cambia Config.BackupDir. Los originales se conservan hasta ConfirmBackupDirMigration.
+// Si se interrumpe, volver a llamarla salta los archivos que ya están verificados en el destino.
+func (bm *BackupManager) MigrateBackupDir(newPath string) (*MigrationReport, error) {
+	from := bm.config().BackupDir
+	to := ExpandPath(newPath)
+
+	if filepath.Clean(from) == filepath.Clean(to) {
+		return nil, fmt.Errorf("%s ya es la ubicación de los backups", to)
+	}
+	if isWithin(from, to) || isWithin(to, from) {
+		return nil, fmt.Errorf("la nueva ubicación no puede estar dentro de la actual ni contenerla")
+	}
+	if err := os.MkdirAll(to, 0755); err != nil {
+		return nil, fmt.Errorf("error creando directorio de backup: %v", err)
+	}
+	if !isWritableDir(to) {
+		return nil, fmt.Errorf("no se puede escribir en el directorio especificado: %s", to)
+	}
+
+	report, err := bm.copyBackupDir(from, to)
+	if err != nil {
+		return report, err
+	}
+
+	bm.setConfig(func(config *BackupConfig) { config.BackupDir = to })
+	log.Printf("Backups copiados a %s (%d archivos, %d ya estaban); los originales siguen en %s",
+		to, report.FilesCopied, report.FilesSkipped, from)
+	return report, nil
+}
+
+// ConfirmBackupDirMigration borra los backups de la ubicación anterior tras comprobar que cada archivo
+// existe con el mismo tamaño en la actual. Las carpetas que no son de WineSave no se tocan.
+func (bm *BackupManager) ConfirmBackupDirMigration(oldPath string) error {
+	from := ExpandPath(oldPath)
+	to := bm.config().BackupDir
+	if filepath.Clean(from) == filepath.Clean(to) {
+		return fmt.Errorf("%s es la ubicación actual de los backups", from)
+	}
+
+	dirs, _, err := bm.migrationDirs(from)
+	if err != nil {
+		return fmt.Errorf("error leyendo la ubicación anterior: %v", err)
+	}
+
+	var errs []error
+	for _, dir := range dirs {
+		if err := checkMigratedDir(filepath.Join(from, dir), filepath.Join(to, dir)); err != nil {
+			errs = append(errs, fmt.Errorf("%s no se borró: %v", dir, err))
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(from, dir)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	os.Remove(from) // Solo si quedó vacía
+
+	if len(errs) == 0 {
+		log.Printf("Backups de la ubicación anterior borrados: %s", from)
+	}
+	return errors.Join(errs...)
+}
+
+// SetBackupPathWithOptions cambia la ruta de backup y, si se pide, copia los backups existentes
+// y borra los originales
+func (bm *BackupManager) SetBackupPathWithOptions(newPath string, opts BackupPathOptions) error {
+	err := bm.setBackupPathWithOptions(newPath, opts)
+	bm.recordActivity(ActivityEntry{
+		Type:    ActivityConfig,
+		Params:  map[string]string{"backup_dir": newPath},
+		Summary: fmt.Sprintf("Directorio de backups cambiado a %s", newPath),
+	}, err)
+	return err
+}
+
+// setBackupPathWithOptions hace el cambio de SetBackupPathWithOptions
+func (bm *BackupManager) setBackupPathWithOptions(newPath string, opts BackupPathOptions) error {
+	if !opts.Migrate || filepath.Clean(ExpandPath(newPath)) == filepath.Clean(bm.config().BackupDir) {
+		return bm.SetBackupPath(newPath)
+	}
+
+	report, err := bm.MigrateBackupDir(newPath)
+	if err != nil {
+		return err
+	}
+	if opts.DeleteOld {
+		return bm.ConfirmBackupDirMigration(report.From)
+	}
+	return nil
+}
+
+// migrationDirs devuelve las carpetas de la ubicación de backups que pertenecen a WineSave
+// (las de juegos registrados y las que tienen historial) y, aparte, el resto de entradas
+func (bm *BackupManager) migrationDirs(root string) ([]string, []string, error) {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var dirs, others []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			_, known := bm.findGameBySlug(entry.Name())
+			if _, err := os.Stat(filepath.Join(root, entry.Name(), historyFileName)); known || err == nil {
+				dirs = append(dirs, entry.Name())
+				continue
+			}
+		}
+		others = append(others, entry.Name())
+	}
+	return dirs, others, nil
+}
+
+// copyBackupDir copia las carpetas de juegos de from a to verificando el SHA-256 de cada archivo.
+// Un fallo deja en el destino lo ya verificado para poder reanudar.
+func (bm *BackupManager) copyBackupDir(from, to string) (*MigrationReport, error) {
+	start := time.Now()
+	report := &MigrationReport{From: from, To: to, LeftBehind: []string{}}
+	defer func() { report.Duration = time.Since(start) }()
+
+	dirs, others, err := bm.migrationDirs(from)
+	if err != nil {
+		return report, fmt.Errorf("error leyendo la ubicación actual: %v", err)
+	}
+	report.Games = len(dirs)
+	report.LeftBehind = append(report.LeftBehind, others...)
+
+	type migrationFile struct {
+		rel  string
+		size int64
+	}
+	var files []migrationFile
+	var needed int64
+	progress := &migrationProgressWriter{bm: bm, progress: MigrationProgress{From: from, To: to}}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(filepath.Join(from, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(from, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, migrationFile{rel: rel, size: info.Size()})
+			progress.progress.BytesTotal += info.Size()
+			if _, err := os.Stat(filepath.Join(to, rel)); err != nil {
+				needed += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("error leyendo backups de %s: %v", dir, err)
+		}
+	}
+	progress.progress.FilesTotal = len(files)
+
+	if free, _, err := diskUsage(existingParent(to)); err == nil && int64(free) < needed {
+		return report, fmt.Errorf("espacio insuficiente en %s: se necesitan %d bytes y hay %d libres", to, needed, free)
+	}
+
+	log.Printf("Copiando %d archivos de backup de %s a %s", len(files), from, to)
+
+	for _, file := range files {
+		src := filepath.Join(from, file.rel)
+		dst := filepath.Join(to, file.rel)
+		progress.progress.Current = file.rel
+		progress.emit(false)
+
+		// Reanudación: lo que ya está en el destino solo se acepta si es idéntico
+		if _, err := os.Stat(dst); err == nil {
+			same, err := sameFileContent(src, dst)
+			if err != nil {
+				return report, fmt.Errorf("error comparando %s: %v", file.rel, err)
+			}
+			if !same {
+				return report, fmt.Errorf("ya existe %s en la nueva ubicación con otro contenido", dst)
+			}
+			report.FilesSkipped++
+			progress.progress.BytesDone += file.size
+			progress.progress.FilesDone++
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return report, fmt.Errorf("error creando directorio destino: %v", err)
+		}
+
+		// Copiar a un nombre temporal: un archivo con el nombre final siempre está completo y verificado
+		partial := dst + partialCopySuffix
+		_, err := copyFileWithProgress(src, partial, progress, bm.copyCheck(""))
+		if err == nil {
+			err = os.Rename(partial, dst)
+		}
+		if err != nil {
+			os.Remove(partial)
+			return report, fmt.Errorf("error copiando %s: %v", file.rel, err)
+		}
+
+		if info, err := os.Stat(src); err == nil {
+			os.Chtimes(dst, info.ModTime(), info.ModTime())
+		}
+		report.FilesCopied++
+		report.BytesCopied += file.size
+		progress.progress.FilesDone++
+	}
+
+	progress.progress.Current = ""
+	progress.progress.Done = true
+	progress.emit(true)
+	return report, nil
+}
+
+// checkMigratedDir comprueba que todos los archivos de una carpeta existen con el mismo tamaño en su copia
+func checkMigratedDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		copied, err := os.Stat(filepath.Join(dst, rel))
+		if err != nil {
+			return fmt.Errorf("falta %s en la nueva ubicación", rel)
+		}
+		if copied.Size() != info.Size() {
+			return fmt.Errorf("%s tiene otro tamaño en la nueva ubicación", rel)
+		}
+		return nil
+	})
+}
+
+// sameFileContent compara dos archivos por tamaño y SHA-256
+func sameFileContent(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+
+	hashA, err := fileSHA256(a)
+	if err != nil {
+		return false, err
+	}
+	hashB, err := fileSHA256(b)
+	if err != nil {
+		return false, err
+	}
+	return hashA == hashB, nil
+}
+
+// fileSHA256 calcula el SHA-256 de un archivo
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash, _, err := hashReader(file)
+	return hash, err
+}
+
+// migrationProgressWriter cuenta los bytes copiados y emite eventos de progreso limitados en frecuencia
+type migrationProgressWriter struct {
+	bm       *BackupManager
+	progress MigrationProgress
+	lastEmit time.Time
+}
+
+func (w *migrationProgressWriter) Write(p []byte) (int, error) {
+	w.progress.BytesDone += int64(len(p))
+	w.emit(false)
+	return len(p), nil
+}
+
+// emit envía el progreso si pasó suficiente tiempo desde el último evento (o siempre con force)
+func (w *migrationProgressWriter) emit(force bool) {
+	if force || time.Since(w.lastEmit) >= 250*time.Millisecond {
+		w.lastEmit = time.Now()
+		w.bm.emit(backupMigrationEvent, w.progress)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"runtime"
+)
+
+// backupMetaEntryName es la entrada de cada backup con los datos del juego. No es una partida:
+// no se restaura ni cuenta al comparar backups, pero sí está en el manifiesto.
+const backupMetaEntryName = "winesave-meta.json"
+
+// BackupGameMeta son los datos del juego guardados dentro de cada backup, para saber qué contiene
+// y dónde iban sus archivos aunque se pierda game_saves.json o se lleve el backup a otro equipo
+type BackupGameMeta struct {
+	GameID         string            `json:"game_id"`
+	Slug           string            `json:"slug"`
+	Name           string            `json:"name"`
+	Platform       string            `json:"platform"`
+	SavePaths      []string          `json:"save_paths"` // Tal como estaban en el juego, sin expandir
+	InstallDir     string            `json:"install_dir,omitempty"`
+	Patterns       []string          `json:"patterns"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	BackupRegistry bool              `json:"backup_registry,omitempty"`
+	RegistryKeys   []string          `json:"registry_keys,omitempty"`
+	OS             string            `json:"os"` // Sistema en el que se hizo el backup
+}
+
+// writeBackupMeta escribe los datos del juego en el backup con write
+func writeBackupMeta(game *GameInfo, write func(data []byte) error) error {
+	data, err := json.MarshalIndent(BackupGameMeta{
+		GameID:         game.ID,
+		Slug:           game.Slug,
+		Name:           game.Name,
+		Platform:       game.Platform,
+		SavePaths:      game.SavePaths,
+		InstallDir:     game.InstallDir,
+		Patterns:       game.Patterns,
+		Metadata:       game.Metadata,
+		BackupRegistry: game.BackupRegistry,
+		RegistryKeys:   game.RegistryKeys,
+		OS:             runtime.GOOS,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return write(data)
+}
+
+// readBackupMeta lee los datos del juego de un backup; os.ErrNotExist en backups de versiones anteriores
+func readBackupMeta(backupPath string) (*BackupGameMeta, error) {
+	data, err := readBackupFile(backupPath, backupMetaEntryName)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta BackupGameMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
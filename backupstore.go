@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BackupStore guarda los backups de los juegos. La única implementación es fsBackupStore: la carpeta de backups
+// con un ZIP o una carpeta por backup. Tiene que seguir siendo así, porque restaurar, exportar, comparar, calcular
+// el espacio de una restauración y reparar leen los backups directamente de disco con resolveBackupFile, sin
+// pasar por la BackupStore.
+type BackupStore interface {
+	// Put guarda el backup que se lee de r (un ZIP) con el nombre manifest.Backup junto a su manifiesto, y devuelve
+	// su ruta. Un manifiesto sin archivos (no se pudo calcular) no se guarda. Los backups construidos en disco
+	// llegan como *localBackup, la única forma de pasar un backup en carpeta.
+	Put(gameID string, manifest *BackupManifest, r io.Reader) (string, error)
+	// List devuelve los backups de un juego, del más reciente al más antiguo
+	List(gameID string) ([]BackupInfo, error)
+	// Open recorre los archivos de un backup, sin los datos del juego (winesave-meta.json)
+	Open(gameID, name string, fn func(entry backupFileEntry) error) error
+	// Delete borra un backup con su manifiesto y su registro en el historial
+	Delete(gameID string, backup BackupInfo) error
+	// Verify comprueba los archivos de un backup contra su manifiesto leyendo como mucho rate bytes/s (0 = sin límite)
+	Verify(gameID, name string, rate int64) (*VerifyResult, error)
+}
+
+// localBackup es un backup recién construido en disco (ZIP o carpeta) que se pasa a BackupStore.Put. Al leerlo
+// devuelve el ZIP; fsBackupStore lo mueve a su sitio sin leerlo y así también admite las carpetas.
+type localBackup struct {
+	path string
+	file *os.File // Se abre al primer Read
+}
+
+func (b *localBackup) Read(p []byte) (int, error) {
+	if b.file == nil {
+		file, err := os.Open(b.path)
+		if err != nil {
+			return 0, err
+		}
+		if info, err := file.Stat(); err == nil && info.IsDir() {
+			file.Close()
+			return 0, fmt.Errorf("el backup en carpeta %s no se puede leer como un archivo", filepath.Base(b.path))
+		}
+		b.file = file
+	}
+	return b.file.Read(p)
+}
+
+func (b *localBackup) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	b.file = nil
+	return err
+}
+
+// backupStore devuelve dónde se guardan los backups: la carpeta de backups
+func (bm *BackupManager) backupStore() BackupStore {
+	return &fsBackupStore{bm: bm}
+}
+
+// fsBackupStore guarda cada backup en <BackupDir>/<juego>/<juego>_<fecha>[.zip], con su manifiesto y su índice
+// al lado y el historial en history.json
+type fsBackupStore struct {
+	bm *BackupManager
+}
+
+func (s *fsBackupStore) Put(gameID string, manifest *BackupManifest, r io.Reader) (string, error) {
+	name := manifest.Backup
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("nombre de backup inválido: %s", name)
+	}
+	backupDir := s.bm.gameBackupDir(gameID)
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return "", fmt.Errorf("error creando directorio de backup: %v", err)
+	}
+	backupPath := filepath.Join(backupDir, name)
+	if local, ok := r.(*localBackup); ok {
+		local.Close()
+		if err := moveIntoPlace(local.path, backupPath); err != nil {
+			return "", fmt.Errorf("error moviendo backup a %s: %v", backupDir, err)
+		}
+	} else if err := writeBackupStream(r, backupPath); err != nil {
+		return "", fmt.Errorf("error guardando backup en %s: %v", backupDir, err)
+	}
+	if len(manifest.Files) == 0 {
+		return backupPath, nil
+	}
+	// Sin manifiesto el backup sigue siendo válido: se genera al verificarlo
+	if err := writeManifest(backupPath, manifest); err != nil {
+		log.Printf("Error guardando manifiesto de %s: %v", name, err)
+	} else if s.bm.config().ContentIndex {
+		if err := writeContentIndex(backupPath, manifest); err != nil {
+			log.Printf("Error guardando el índice de %s: %v", name, err)
+		}
+	}
+	return backupPath, nil
+}
+
+// writeBackupStream escribe en path el ZIP que se lee de r, sin dejar un backup a medias si falla
+func writeBackupStream(r io.Reader, path string) error {
+	partial := path + ".partial"
+	file, err := os.Create(partial)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(partial, path)
+	}
+	if err != nil {
+		os.Remove(partial)
+	}
+	return err
+}
+
+func (s *fsBackupStore) List(gameID string) ([]BackupInfo, error) {
+	folder := s.bm.backupFolder(gameID)
+	backupDir := filepath.Join(s.bm.config().BackupDir, folder)
+
+	files, err := os.ReadDir(backupDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []BackupInfo{}, nil
+		}
+		return nil, err
+	}
+
+	// El índice aporta los datos que no se deducen del nombre (p. ej. si está fijado)
+	index, err := s.bm.loadBackupIndex(gameID)
+	if err != nil {
+		log.Printf("Error leyendo historial de %s: %v", gameID, err)
+		index = &backupIndex{}
+	}
+
+	backups := []BackupInfo{}
+	for _, file := range files {
+		created, ok := parseBackupName(folder, file.Name())
+		if !ok {
+			continue
+		}
+
+		compressed := !file.IsDir() && strings.HasSuffix(file.Name(), ".zip")
+		if !compressed && !file.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(backupDir, file.Name())
+		info := BackupInfo{
+			Name:       file.Name(),
+			Path:       path,
+			Size:       backupSize(path),
+			Created:    created,
+			Compressed: compressed,
+		}
+		if record := index.find(file.Name()); record != nil {
+			info.Pinned = record.Pinned
+			info.SaveInfo = record.SaveInfo
+			info.WholeProfile = record.WholeProfile
+			info.LastVerified = record.VerifiedAt
+			info.Throttled = record.Throttled
+			if compressed {
+				info.CompressionRatio = compressionRatio(info.Size, record.SourceBytes)
+			}
+		}
+		info.LastRestoredAt = index.lastRestored(file.Name())
+		info.Current = index.CurrentState == file.Name()
+		backups = append(backups, info)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+
+	return backups, nil
+}
+
+func (s *fsBackupStore) Open(gameID, name string, fn func(entry backupFileEntry) error) error {
+	backupPath, err := s.bm.resolveBackupFile(gameID, name)
+	if err != nil {
+		return err
+	}
+	return forEachBackupEntry(backupPath, fn)
+}
+
+func (s *fsBackupStore) Delete(gameID string, backup BackupInfo) error {
+	if err := os.RemoveAll(backup.Path); err != nil {
+		return err
+	}
+	for _, sidecar := range sidecarPaths(backup.Path) {
+		os.Remove(sidecar)
+	}
+
+	index, err := s.bm.loadBackupIndex(gameID)
+	if err != nil {
+		return err
+	}
+	index.remove(backup.Name)
+	if index.CurrentState == backup.Name {
+		index.CurrentState = ""
+	}
+	return s.bm.saveBackupIndex(gameID, index)
+}
+
+func (s *fsBackupStore) Verify(gameID, name string, rate int64) (*VerifyResult, error) {
+	backupPath, err := s.bm.resolveBackupFile(gameID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerifyResult{
+		GameID:  gameID,
+		Backup:  name,
+		Corrupt: []string{},
+		Missing: []string{},
+	}
+
+	manifest, err := loadManifest(backupPath)
+	if os.IsNotExist(err) {
+		// Backups de versiones anteriores sin manifiesto: se genera ahora y sirve para las siguientes
+		// comprobaciones. Leerlo completo ya valida los CRC de un ZIP; una carpeta no tiene con qué compararse.
+		if manifest, err = buildManifest(gameID, backupPath); err != nil {
+			return nil, err
+		}
+		if err := writeManifest(backupPath, manifest); err != nil {
+			return nil, fmt.Errorf("error guardando manifiesto de %s: %v", name, err)
+		}
+		log.Printf("Manifiesto generado para %s (%d archivos)", name, len(manifest.Files))
+		result.ManifestCreated = true
+	} else if err != nil {
+		return nil, fmt.Errorf("el backup %s no tiene un manifiesto de checksums válido: %v", name, err)
+	}
+
+	limit := newRateLimiter(rate)
+	for _, entry := range manifest.Files {
+		result.Checked++
+
+		rc, err := openBackupFile(backupPath, entry.Path)
+		if os.IsNotExist(err) {
+			result.Missing = append(result.Missing, entry.Path)
+			continue
+		}
+		if err != nil {
+			result.Corrupt = append(result.Corrupt, entry.Path)
+			continue
+		}
+		sum, _, err := hashReader(limit.reader(rc))
+		rc.Close()
+		if err != nil || sum != entry.SHA256 {
+			result.Corrupt = append(result.Corrupt, entry.Path)
+		}
+	}
+
+	result.Valid = len(result.Corrupt) == 0 && len(result.Missing) == 0
+	return result, nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Backups creados por fsBackupStore antes de que existiera la interfaz BackupStore: uno en ZIP y uno en carpeta,
+// con su manifiesto, su índice de contenido y el historial
+const (
+	fixtureDir      = "testdata/backupstore"
+	fixtureGameSlug = "g"
+	fixtureZip      = "g_2026-10-17_01-48-14.zip"
+	fixtureFolder   = "g_2026-10-17_01-48-15"
+)
+
+// fixtureStore copia la carpeta de backups de ejemplo a una carpeta temporal y devuelve su BackupStore
+func fixtureStore(t *testing.T) (*BackupManager, BackupStore) {
+	t.Helper()
+	bm, dir := newTestManager(t)
+	bm.Config.BackupDir = filepath.Join(dir, "fixture")
+	bm.Config.ContentIndex = true
+	if err := copyDir(fixtureDir, bm.Config.BackupDir); err != nil {
+		t.Fatal(err)
+	}
+	return bm, bm.backupStore()
+}
+
+// readTree lee todos los archivos de dir (ruta relativa con / -> contenido)
+func readTree(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	files := make(map[string][]byte)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestFsBackupStoreListFixture(t *testing.T) {
+	_, store := fixtureStore(t)
+	backups, err := store.List("g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("%d backups, se esperaban 2", len(backups))
+	}
+	// Del más reciente al más antiguo
+	if backups[0].Name != fixtureFolder || backups[0].Compressed {
+		t.Errorf("primer backup %s (comprimido %v), se esperaba la carpeta %s", backups[0].Name, backups[0].Compressed, fixtureFolder)
+	}
+	if backups[1].Name != fixtureZip || !backups[1].Compressed {
+		t.Errorf("segundo backup %s (comprimido %v), se esperaba el ZIP %s", backups[1].Name, backups[1].Compressed, fixtureZip)
+	}
+	for _, backup := range backups {
+		if backup.Size != backupSize(backup.Path) || backup.Created.IsZero() {
+			t.Errorf("%s: tamaño %d, fecha %v", backup.Name, backup.Size, backup.Created)
+		}
+	}
+}
+
+func TestFsBackupStoreOpenAndVerifyFixture(t *testing.T) {
+	_, store := fixtureStore(t)
+	want := map[string]map[string]string{
+		fixtureZip:    {"a.sav": "aaa", "sub/b.sav": "bbb"},
+		fixtureFolder: {"a.sav": "aaa2", "sub/b.sav": "bbb"},
+	}
+	for name, files := range want {
+		got := make(map[string]string)
+		err := store.Open("g", name, func(entry backupFileEntry) error {
+			rc, err := entry.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			data, err := io.ReadAll(rc)
+			got[entry.Name] = string(data)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, files) {
+			t.Errorf("%s: archivos %v, se esperaban %v", name, got, files)
+		}
+
+		result, err := store.Verify("g", name, 0)
+		if err != nil || !result.Valid || result.ManifestCreated {
+			t.Errorf("%s: Verify = %+v, %v", name, result, err)
+		}
+	}
+}
+
+// Put con los mismos backups y manifiestos deja en la carpeta del juego exactamente los mismos archivos
+func TestFsBackupStorePutFixture(t *testing.T) {
+	fixture := readTree(t, filepath.Join(fixtureDir, fixtureGameSlug))
+	for _, local := range []bool{true, false} {
+		bm, dir := newTestManager(t)
+		bm.Config.ContentIndex = true
+		store := bm.backupStore()
+
+		for _, name := range []string{fixtureZip, fixtureFolder} {
+			source := filepath.Join(fixtureDir, fixtureGameSlug, name)
+			manifest, err := loadManifest(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var r io.Reader
+			info, _ := os.Stat(source)
+			switch {
+			case local || info.IsDir():
+				work := filepath.Join(dir, "work", name)
+				if info.IsDir() {
+					err = copyDir(source, work)
+				} else {
+					os.MkdirAll(filepath.Dir(work), 0755)
+					err = copyFile(source, work)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				r = &localBackup{path: work}
+			default:
+				data, err := os.ReadFile(source)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r = bytes.NewReader(data)
+			}
+
+			path, err := store.Put("g", manifest, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(bm.Config.BackupDir, fixtureGameSlug, name); path != want {
+				t.Errorf("Put = %s, se esperaba %s", path, want)
+			}
+		}
+
+		got := readTree(t, filepath.Join(bm.Config.BackupDir, fixtureGameSlug))
+		for rel, data := range fixture {
+			if rel == "history.json" || rel == "game.json" {
+				continue // Los escriben recordBackup y SaveDatabase, no Put
+			}
+			if !bytes.Equal(got[rel], data) {
+				t.Errorf("local=%v: %s distinto del de la carpeta de ejemplo", local, rel)
+			}
+			delete(got, rel)
+		}
+		if len(got) != 0 {
+			t.Errorf("local=%v: archivos de más: %v", local, reflect.ValueOf(got).MapKeys())
+		}
+	}
+}
+
+func TestFsBackupStoreDeleteFixture(t *testing.T) {
+	bm, store := fixtureStore(t)
+	backups, err := store.List("g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, backup := range backups {
+		if backup.Name == fixtureZip {
+			if err := store.Delete("g", backup); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Se van el ZIP, su manifiesto y su índice; lo demás sigue ahí
+	want := []string{}
+	for rel := range readTree(t, filepath.Join(fixtureDir, fixtureGameSlug)) {
+		if rel != fixtureZip && rel != manifestPath(fixtureZip) && rel != contentIndexPath(fixtureZip) {
+			want = append(want, rel)
+		}
+	}
+	got := []string{}
+	for rel := range readTree(t, filepath.Join(bm.Config.BackupDir, fixtureGameSlug)) {
+		got = append(got, rel)
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("archivos tras borrar %s: %v, se esperaban %v", fixtureZip, got, want)
+	}
+
+	index, err := bm.loadBackupIndex("g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.find(fixtureZip) != nil || index.find(fixtureFolder) == nil {
+		t.Errorf("historial tras borrar %s: %+v", fixtureZip, index.Records)
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bestPCGWMatch elige el resultado de PCGamingWiki cuyo nombre coincide exactamente, o el primero
+func bestPCGWMatch(results []GameSearchResult, name string) GameSearchResult {
+	for _, result := range results {
+		if strings.EqualFold(result.Name, name) {
+			return result
+		}
+	}
+	return results[0]
+}
+
+// gameDirPaths devuelve las rutas de PCGamingWiki dentro de la carpeta del juego
+func gameDirPaths(paths []string) []string {
+	inGameDir := []string{}
+	for _, path := range paths {
+		if strings.Contains(path, gameDirPlaceholder) {
+			inGameDir = append(inGameDir, filepath.FromSlash(strings.ReplaceAll(path, `\`, "/")))
+		}
+	}
+	return inGameDir
+}
+
+// existingSavePaths expande las rutas de PCGamingWiki y devuelve solo las que existen en este equipo.
+// Las rutas dentro de la carpeta del juego conservan %GAME_DIR%, que se resuelve con su InstallDir (gameDir);
+// sin gameDir se descartan.
+func existingSavePaths(paths []string, gameDir string) []string {
+	existing := []string{}
+	for _, path := range paths {
+		if strings.Contains(path, gameDirPlaceholder) {
+			if _, err := os.Stat(ExpandGamePath(&GameInfo{InstallDir: gameDir}, path)); gameDir != "" && err == nil {
+				existing = append(existing, filepath.FromSlash(strings.ReplaceAll(path, `\`, "/")))
+			}
+			continue
+		}
+		expanded := filepath.FromSlash(strings.ReplaceAll(ExpandPath(path), `\`, "/"))
+		if _, err := os.Stat(expanded); err == nil {
+			existing = append(existing, expanded)
+		}
+	}
+	return existing
+}
+
+// autoConfirmScore es la puntuación mínima para aceptar un resultado de PCGamingWiki sin preguntar al usuario
+const autoConfirmScore = 0.85
+
+// nameMatchScore puntúa de 0 a 1 cuánto se parece un título de PCGamingWiki al nombre pedido
+func nameMatchScore(query, title string) float64 {
+	a := []rune(slugifyName(query))
+	b := []rune(slugifyName(title))
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if string(a) == string(b) {
+		return 1
+	}
+
+	// Distancia de Levenshtein normalizada
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(prev[len(b)])/float64(max(len(a), len(b)))
+}
+
+// resolveGameByName busca un juego en PCGamingWiki y devuelve todas las coincidencias con sus rutas de guardado
+// en este equipo. Solo se confirma automáticamente si hay exactamente una coincidencia de alta confianza.
+func (bm *BackupManager) resolveGameByName(name string) DetailedGameInfo {
+	info := DetailedGameInfo{Name: name, SavePaths: []string{}, Candidates: []GameCandidate{}}
+
+	if bm.PCGWClient == nil {
+		info.Reason = "cliente de PCGamingWiki no disponible"
+		return info
+	}
+
+	results, err := bm.PCGWClient.SearchGames(name)
+	if err != nil {
+		info.Reason = fmt.Sprintf("error consultando PCGamingWiki: %v", err)
+		return info
+	}
+	if len(results) == 0 {
+		info.Reason = "juego no encontrado en PCGamingWiki"
+		return info
+	}
+
+	for _, result := range results {
+		info.Candidates = append(info.Candidates, GameCandidate{
+			Name:         result.Name,
+			PageID:       result.PageID,
+			SteamAppID:   result.SteamAppID,
+			ReleaseDate:  result.ReleaseDate,
+			CoverURL:     result.CoverURL,
+			SavePaths:    existingSavePaths(result.SavePaths, ""),
+			GameDirPaths: gameDirPaths(result.SavePaths),
+			RegistryKeys: result.RegistryKeys,
+			Score:        nameMatchScore(name, result.Name),
+		})
+	}
+	sort.SliceStable(info.Candidates, func(i, j int) bool {
+		return info.Candidates[i].Score > info.Candidates[j].Score
+	})
+
+	var confident []GameCandidate
+	for _, candidate := range info.Candidates {
+		if len(candidate.SavePaths) > 0 {
+			info.Available = true
+			if candidate.Score >= autoConfirmScore {
+				confident = append(confident, candidate)
+			}
+		}
+	}
+	if !info.Available {
+		info.Reason = "no se encontraron rutas de guardado en este equipo"
+		for _, candidate := range info.Candidates {
+			if len(candidate.GameDirPaths) > 0 {
+				info.Reason = "las partidas están en la carpeta del juego: indica dónde está instalado"
+				break
+			}
+		}
+		return info
+	}
+
+	if len(confident) == 1 {
+		match := confident[0]
+		info.AutoConfirm = true
+		info.PageID = match.PageID
+		info.SteamAppID = match.SteamAppID
+		info.ReleaseDate = match.ReleaseDate
+		info.CoverURL = match.CoverURL
+		info.SavePaths = match.SavePaths
+	} else {
+		info.Reason = "varias coincidencias en PCGamingWiki: elige el juego correcto"
+	}
+	return info
+}
+
+// findGameByName busca un juego ya registrado por el nombre con el que se pidió
+func (bm *BackupManager) findGameByName(name string) (*GameInfo, bool) {
+	if game, exists := bm.findGameBySlug(bm.generateGameIDFromName(name)); exists {
+		return game, true
+	}
+	// Los juegos confirmados por el usuario guardan el nombre original de la búsqueda
+	for _, game := range bm.GetGameList() {
+		if strings.EqualFold(game.Metadata["pcgw_query"], strings.TrimSpace(name)) {
+			return game, true
+		}
+	}
+	return nil, false
+}
+
+// GetAvailableGamesForBackup resuelve una lista de nombres e indica cuáles tienen partidas que respaldar.
+// Los nombres sin AutoConfirm deben confirmarse con ConfirmGameSelections antes del backup.
+func (bm *BackupManager) GetAvailableGamesForBackup(names []string) []DetailedGameInfo {
+	games := make([]DetailedGameInfo, 0, len(names))
+	for _, name := range names {
+		// Los juegos ya registrados no necesitan consultar PCGamingWiki
+		if game, exists := bm.findGameByName(name); exists && bm.gameExists(game) {
+			games = append(games, DetailedGameInfo{
+				Name:        game.Name,
+				PageID:      game.Metadata["pcgw_page_id"],
+				CoverURL:    game.Metadata["cover_url"],
+				SavePaths:   game.SavePaths,
+				Available:   true,
+				AutoConfirm: true,
+				Candidates:  []GameCandidate{},
+			})
+			continue
+		}
+		games = append(games, bm.resolveGameByName(name))
+	}
+	return games
+}
+
+// ConfirmGameSelections registra los juegos que el usuario eligió entre las coincidencias de PCGamingWiki.
+// Name debe ser el nombre tal como se pidió en GetAvailableGamesForBackup.
+func (bm *BackupManager) ConfirmGameSelections(selections []UserGameSelection) error {
+	var errs []error
+	for _, selection := range selections {
+		if err := bm.confirmGameSelection(selection); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", selection.Name, err))
+		}
+	}
+
+	bm.checkPathOverlaps()
+	if err := bm.SaveDatabase(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// confirmGameSelection registra el juego elegido para un nombre, reemplazando una confirmación anterior
+func (bm *BackupManager) confirmGameSelection(selection UserGameSelection) error {
+	if selection.SelectedGame == nil {
+		return fmt.Errorf("no se eligió ninguna coincidencia")
+	}
+
+	match := GameCandidate{
+		Name:         selection.SelectedGame.Name,
+		PageID:       selection.SelectedGame.PageID,
+		SteamAppID:   selection.SelectedGame.SteamAppID,
+		ReleaseDate:  selection.SelectedGame.ReleaseDate,
+		CoverURL:     selection.SelectedGame.CoverURL,
+		SavePaths:    existingSavePaths(selection.SelectedGame.SavePaths, ""),
+		RegistryKeys: selection.SelectedGame.RegistryKeys,
+	}
+	if selection.CustomPath != "" {
+		match.SavePaths = append(match.SavePaths, existingSavePaths([]string{selection.CustomPath}, "")...)
+	}
+	installDir := ""
+	if selection.InstallDir != "" {
+		installDir = filepath.Clean(ExpandPath(selection.InstallDir))
+		match.SavePaths = append(match.SavePaths, existingSavePaths(selection.SelectedGame.SavePaths, installDir)...)
+	}
+	if len(match.SavePaths) == 0 {
+		return fmt.Errorf("no se encontraron rutas de guardado de %s en este equipo", match.Name)
+	}
+
+	if game, exists := bm.findGameByName(selection.Name); exists {
+		if game.Metadata["pcgw_page_id"] == match.PageID {
+			return nil
+		}
+		if game.Platform != "pcgw" || !game.LastBackup.IsZero() {
+			return fmt.Errorf("ya existe un juego con ese nombre y sus backups (%s)", game.ID)
+		}
+		bm.deleteGame(game.ID)
+	}
+
+	game := bm.addPCGWGame(selection.Name, match)
+	if selection.CustomPath != "" {
+		game.CustomPaths = append(game.CustomPaths, ExpandPath(selection.CustomPath))
+	}
+	if installDir != "" {
+		game.InstallDir = installDir
+		if err := bm.updateGameInfo(game); err != nil {
+			log.Printf("Error actualizando info del juego %s: %v", game.ID, err)
+		}
+	}
+	return nil
+}
+
+// addPCGWGame registra un juego a partir de una coincidencia de PCGamingWiki pedida con el nombre query
+func (bm *BackupManager) addPCGWGame(query string, match GameCandidate) *GameInfo {
+	game := &GameInfo{
+		ID:           newGameID(),
+		Slug:         bm.generateGameIDFromName(match.Name),
+		Name:         match.Name,
+		Platform:     "pcgw",
+		SavePaths:    match.SavePaths,
+		Patterns:     SaveFilePatterns,
+		CustomPaths:  []string{},
+		RegistryKeys: match.RegistryKeys,
+		Metadata: map[string]string{
+			"pcgw_page_id": match.PageID,
+			"pcgw_query":   strings.TrimSpace(query),
+			"steam_app_id": match.SteamAppID,
+			"release_date": match.ReleaseDate,
+			"cover_url":    match.CoverURL,
+		},
+	}
+	bm.setGame(game)
+	if err := bm.updateGameInfo(game); err != nil {
+		log.Printf("Error actualizando info del juego %s: %v", game.ID, err)
+	}
+	return game
+}
+
+// CreateBackupForSelectedGames registra (si hace falta) y respalda varios juegos por nombre
+func (bm *BackupManager) CreateBackupForSelectedGames(names []string, backupPath string) (*BatchBackupResult, error) {
+	if backupPath != "" && ExpandPath(backupPath) != bm.config().BackupDir {
+		if err := bm.SetBackupPath(backupPath); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &BatchBackupResult{
+		TotalGames: len(names),
+		Errors:     []string{},
+		BackupPath: bm.config().BackupDir,
+		Results:    make([]GameBackupResult, 0, len(names)),
+	}
+
+	for _, name := range names {
+		game := bm.backupSelectedGame(name)
+		if game.Success {
+			result.SuccessCount++
+		} else {
+			result.ErrorCount++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", name, game.Error))
+		}
+		result.Results = append(result.Results, game)
+	}
+
+	log.Printf("Backup por lotes completado: %d/%d exitosos", result.SuccessCount, result.TotalGames)
+	bm.checkPathOverlaps()
+	return result, bm.SaveDatabase()
+}
+
+// backupSelectedGame registra un juego por nombre si no existe (solo con AutoConfirm) y lo respalda, omitiéndolo si no tiene cambios
+func (bm *BackupManager) backupSelectedGame(name string) GameBackupResult {
+	start := time.Now()
+	result := GameBackupResult{Name: name}
+
+	game, exists := bm.findGameByName(name)
+	if !exists {
+		info := bm.resolveGameByName(name)
+		if !info.Available || !info.AutoConfirm {
+			result.Error = info.Reason
+			result.Duration = time.Since(start)
+			return result
+		}
+		game = bm.addPCGWGame(name, GameCandidate{
+			Name:        name,
+			PageID:      info.PageID,
+			SteamAppID:  info.SteamAppID,
+			ReleaseDate: info.ReleaseDate,
+			CoverURL:    info.CoverURL,
+			SavePaths:   info.SavePaths,
+		})
+	}
+	gameID := game.ID
+	result.GameID = gameID
+
+	// No duplicar backups de juegos sin cambios
+	if bm.unchangedSinceLastBackup(gameID) {
+		result.Success = true
+		result.Skipped = true
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	job, err := bm.RunBackup(gameID, BackupOptions{Trigger: "manual"})
+	if job != nil {
+		result.OperationID = job.ID
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	result.Success = true
+	result.BackupPath = job.BackupPath
+	result.SizeBytes = backupSize(job.BackupPath)
+	result.FileCount = job.FilesTotal
+	result.Duration = time.Since(start)
+	return result
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// defaultStoreExtensions son extensiones de partidas que ya suelen estar comprimidas: se guardan sin
+// volver a comprimir en los backups ZIP
+var defaultStoreExtensions = []string{
+	".zip", ".gz", ".7z", ".rar", ".xz", ".bz2", ".zst", ".lz4",
+	".pak", ".mca", ".png", ".jpg", ".jpeg", ".webp", ".ogg", ".mp3", ".mp4",
+}
+
+// compressibleExtensions son formatos de texto que siempre se comprimen sin mirar su contenido
+var compressibleExtensions = map[string]bool{
+	".txt": true, ".ini": true, ".cfg": true, ".json": true, ".xml": true,
+	".lua": true, ".yaml": true, ".yml": true, ".csv": true, ".vdf": true,
+}
+
+// entropySampleSize es cuánto se lee del principio de un archivo para estimar si ya está comprimido
+const entropySampleSize = 4 << 10
+
+// storeEntropyThreshold es la entropía (bits por byte) a partir de la cual comprimir no ahorra espacio
+const storeEntropyThreshold = 7.5
+
+// zipMethod elige cómo guardar un archivo en el ZIP: sin comprimir si su extensión está en StoreExtensions
+// o, con StoreEntropyCheck y una extensión desconocida, si una muestra de su contenido parece ya comprimida
+func (bm *BackupManager) zipMethod(path string) uint16 {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, store := range bm.config().StoreExtensions {
+		if strings.EqualFold(store, ext) {
+			return zip.Store
+		}
+	}
+
+	if bm.config().StoreEntropyCheck && !compressibleExtensions[ext] {
+		if file, err := bm.openSaveFile(path); err == nil {
+			entropy := sampleEntropy(file)
+			file.Close()
+			if entropy >= storeEntropyThreshold {
+				return zip.Store
+			}
+		}
+	}
+	return zip.Deflate
+}
+
+// sampleEntropy calcula la entropía de Shannon (bits por byte) del principio de r
+func sampleEntropy(r io.Reader) float64 {
+	sample := make([]byte, entropySampleSize)
+	n, _ := io.ReadFull(r, sample)
+	if n == 0 {
+		return 0
+	}
+
+	var counts [256]int
+	for _, b := range sample[:n] {
+		counts[b]++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// compressionRatio devuelve el tamaño del backup respecto al de las partidas (0 si no se conoce)
+func compressionRatio(size, sourceBytes int64) float64 {
+	if sourceBytes <= 0 {
+		return 0
+	}
+	return float64(size) / float64(sourceBytes)
+}
+
+// zipMethodName devuelve el nombre del método de compresión de una entrada para el manifiesto
+func zipMethodName(method uint16) string {
+	switch method {
+	case zip.Store:
+		return "store"
+	case zip.Deflate:
+		return "deflate"
+	}
+	return "other"
+}
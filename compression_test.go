@@ -0,0 +1,153 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// randomText devuelve size bytes aleatorios, que no se pueden comprimir
+func randomText(t *testing.T, size int) string {
+	t.Helper()
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSampleEntropy(t *testing.T) {
+	if got := sampleEntropy(bytes.NewReader(nil)); got != 0 {
+		t.Errorf("entropía de un archivo vacío: %v", got)
+	}
+	if got := sampleEntropy(strings.NewReader(strings.Repeat("a", 8192))); got != 0 {
+		t.Errorf("entropía de un solo byte repetido: %v", got)
+	}
+	if got := sampleEntropy(strings.NewReader(strings.Repeat("ab", 4096))); got != 1 {
+		t.Errorf("entropía de dos bytes alternos: %v", got)
+	}
+	if got := sampleEntropy(strings.NewReader(randomText(t, entropySampleSize))); got < storeEntropyThreshold {
+		t.Errorf("entropía de datos aleatorios: %v", got)
+	}
+}
+
+func TestZipMethod(t *testing.T) {
+	bm, dir := newTestManager(t)
+	bm.Config.StoreExtensions = defaultStoreExtensions
+	noise := randomText(t, 2*entropySampleSize)
+	writeTestFiles(t, dir, map[string]string{
+		"world.mca":   noise,
+		"SHOT.PNG":    "no importa el contenido",
+		"slot.sav":    strings.Repeat("partida ", 1000),
+		"slot2.sav":   noise,
+		"config.json": noise,
+	})
+
+	tests := []struct {
+		name    string
+		entropy bool
+		want    uint16
+	}{
+		{"world.mca", false, zip.Store},
+		{"SHOT.PNG", false, zip.Store}, // Sin distinguir mayúsculas
+		{"slot.sav", false, zip.Deflate},
+		{"slot2.sav", false, zip.Deflate}, // Sin StoreEntropyCheck solo cuenta la extensión
+		{"slot.sav", true, zip.Deflate},
+		{"slot2.sav", true, zip.Store},
+		{"config.json", true, zip.Deflate}, // Los formatos de texto se comprimen siempre
+		{"missing.sav", true, zip.Deflate},
+	}
+	for _, test := range tests {
+		bm.Config.StoreEntropyCheck = test.entropy
+		if got := bm.zipMethod(filepath.Join(dir, test.name)); got != test.want {
+			t.Errorf("zipMethod(%s) con entropía %v = %s, se esperaba %s",
+				test.name, test.entropy, zipMethodName(got), zipMethodName(test.want))
+		}
+	}
+}
+
+// Un backup con archivos guardados sin comprimir y comprimidos registra cada método en el manifiesto, se
+// verifica y se restaura igual que uno solo con Deflate, con y sin compresión en paralelo
+func TestMixedStoreDeflateRoundTrip(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			bm, dir := newTestManager(t)
+			bm.Config.CompressionWorkers = workers
+			bm.Config.StoreExtensions = defaultStoreExtensions
+			bm.Config.StoreEntropyCheck = true
+			saves := filepath.Join(dir, "saves")
+			files := map[string]string{
+				"a.sav":             "aaa",
+				"sub/b.sav":         "bbb",
+				"region/r.0.0.mca":  randomText(t, 64<<10),
+				"slot.dat":          randomText(t, 64<<10),
+				"options.txt":       strings.Repeat("volume=10\n", 5000),
+				"screenshots/1.png": "",
+			}
+			writeTestFiles(t, saves, files)
+			wantMethods := map[string]string{
+				"a.sav":             "deflate",
+				"sub/b.sav":         "deflate",
+				"region/r.0.0.mca":  "store",
+				"slot.dat":          "store",
+				"options.txt":       "deflate",
+				"screenshots/1.png": "store",
+			}
+
+			game := bm.DetectedGames["g"]
+			info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			backupPath := filepath.Join(bm.Config.BackupDir, game.Slug, info.Name)
+
+			reader, err := zip.OpenReader(backupPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+			for _, file := range reader.File {
+				if want, ok := wantMethods[file.Name]; ok && zipMethodName(file.Method) != want {
+					t.Errorf("%s guardado con %s, se esperaba %s", file.Name, zipMethodName(file.Method), want)
+				}
+			}
+
+			manifest, err := loadManifest(backupPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range manifest.Files {
+				if want, ok := wantMethods[entry.Path]; ok && entry.Method != want {
+					t.Errorf("manifiesto: %s con %q, se esperaba %q", entry.Path, entry.Method, want)
+				}
+			}
+
+			// Lo guardado sin comprimir domina el tamaño: la proporción queda cerca de 1 pero por debajo
+			if info.CompressionRatio <= 0.5 || info.CompressionRatio >= 1 {
+				t.Errorf("proporción de compresión %v", info.CompressionRatio)
+			}
+
+			result, err := bm.VerifyBackup("g", info.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Valid || result.Checked != len(files)+1 { // Más winesave-meta.json
+				t.Errorf("verificación: válido %v, %d revisados, dañados %v, faltan %v",
+					result.Valid, result.Checked, result.Corrupt, result.Missing)
+			}
+
+			if err := os.RemoveAll(saves); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := bm.RestoreBackup("g", info.Name, RestoreOptions{Force: true, Mode: RestoreOverwrite}); err != nil {
+				t.Fatal(err)
+			}
+			assertFiles(t, saves, files)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithServerFieldsKeepsPauseAndToken(t *testing.T) {
+	bm, _ := newTestManager(t)
+	until := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	bm.Config.AutoBackupPausedUntil = until
+	bm.Config.APIToken = "token-del-servidor"
+
+	// El frontend envía la configuración que leyó antes de pausar y de generar el token
+	sent := bm.Config
+	sent.AutoBackupPausedUntil = time.Time{}
+	sent.APIToken = ""
+	sent.MaxBackups = 12
+
+	bm.applyConfig(sent)
+	merged := bm.config()
+	if !merged.AutoBackupPausedUntil.Equal(until) {
+		t.Errorf("auto_backup_paused_until = %v, se esperaba %v", merged.AutoBackupPausedUntil, until)
+	}
+	if merged.APIToken != "token-del-servidor" {
+		t.Errorf("api_token = %q, se esperaba el del servidor", merged.APIToken)
+	}
+	if merged.MaxBackups != 12 {
+		t.Errorf("max_backups = %d, se esperaba el enviado (12)", merged.MaxBackups)
+	}
+}
+
+// Una pausa hecha después de que el frontend leyera la configuración sigue en config.json tras UpdateConfig
+func TestPauseSurvivesUpdateConfig(t *testing.T) {
+	bm, dir := newTestManager(t)
+	configPath := filepath.Join(dir, "config.json")
+	stale := bm.config()
+
+	until, err := bm.PauseAutoBackup(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.MaxBackups = 7
+	bm.applyConfig(stale)
+	if err := bm.SaveConfig(configPath); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, _ := newTestManager(t)
+	if err := loaded.LoadConfig(configPath); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.config(); !got.AutoBackupPausedUntil.Equal(until) || got.MaxBackups != 7 {
+		t.Errorf("pausa hasta %v y max_backups %d, se esperaba %v y 7", got.AutoBackupPausedUntil, got.MaxBackups, until)
+	}
+	if !loaded.autoBackupPaused(time.Now()) {
+		t.Error("los backups automáticos no siguen en pausa")
+	}
+}
+
+// La interfaz cambia la configuración mientras la cola, el programador y la API la leen; con go test -race este
+// test falla si alguno la lee sin pasar por bm.config()
+func TestConfigConcurrentAccess(t *testing.T) {
+	bm, dir := newTestManager(t)
+	configPath := filepath.Join(dir, "config.json")
+	bm.Config.APIToken = "token"
+	defer bm.ShutdownQueue(10 * time.Second)
+	api := bm.requireToken(bm.apiRoutes())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			// Lo que hace UpdateConfig con una copia de la configuración anterior a la pausa
+			config := bm.config()
+			config.AutoBackup = i%2 == 0
+			config.MaxBackups = 5 + i%2
+			config.BackgroundIOThrottleMBps = i % 2
+			bm.applyConfig(config)
+			if err := bm.SaveConfig(configPath); err != nil {
+				t.Error(err)
+			}
+
+			if _, err := bm.PauseAutoBackup(time.Hour); err != nil {
+				t.Error(err)
+			}
+			bm.ResumeAutoBackup()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if _, err := bm.RunBackup("g", BackupOptions{Trigger: "auto"}); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		bm.autoBackupPaused(time.Now())
+		bm.runScheduledBackups()
+		request := httptest.NewRequest("GET", "/api/games", nil)
+		request.Header.Set(apiTokenHeader, "token")
+		api.ServeHTTP(httptest.NewRecorder(), request)
+	}
+	wg.Wait()
+}
+
+// freePort devuelve un puerto local libre
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// apiListening indica si la API responde en un puerto
+func apiListening(port int) bool {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func TestUpdateAPIRestartsOnPortChange(t *testing.T) {
+	bm, _ := newTestManager(t)
+	bm.Config.APIEnabled = true
+	bm.Config.APIToken = "token"
+	bm.Config.APIPort = freePort(t)
+	defer bm.StopAPI(time.Second)
+	if err := bm.StartAPI(); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := bm.config()
+	config := previous
+	config.APIPort = freePort(t)
+	bm.applyConfig(config)
+	if err := bm.updateAPI(previous); err != nil {
+		t.Fatal(err)
+	}
+	if !apiListening(config.APIPort) {
+		t.Errorf("la API no escucha en el puerto nuevo %d", config.APIPort)
+	}
+	if apiListening(previous.APIPort) {
+		t.Errorf("la API sigue escuchando en el puerto anterior %d", previous.APIPort)
+	}
+
+	previous = bm.config()
+	config.APIEnabled = false
+	bm.applyConfig(config)
+	if err := bm.updateAPI(previous); err != nil {
+		t.Fatal(err)
+	}
+	if apiListening(config.APIPort) {
+		t.Error("la API sigue escuchando tras desactivarla")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// defaultStaleBackupDays es la antigüedad a partir de la cual un backup se considera desactualizado si StaleBackupDays es 0
+const defaultStaleBackupDays = 7
+
+// CoverageEntry es un juego del informe de cobertura con los datos para priorizarlo
+type CoverageEntry struct {
+	GameID      string    `json:"game_id"`
+	Name        string    `json:"name"`
+	Platform    string    `json:"platform"`
+	TotalSize   int64     `json:"total_size"`
+	BackupCount int       `json:"backup_count"`
+	Unverified  int       `json:"unverified"`  // Backups que nunca pasaron una comprobación de integridad
+	LastBackup  time.Time `json:"last_backup"` // Cero si no tiene backups
+	LastPlayed  time.Time `json:"last_played"` // Cero si no se conoce
+	Error       string    `json:"error,omitempty"`
+}
+
+// CoverageReport lista los juegos sin protección suficiente. Un juego puede aparecer en varias listas.
+// Cada lista está ordenada del juego jugado más recientemente al que menos.
+type CoverageReport struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	StaleDays   int       `json:"stale_days"`
+
+	NeverBackedUp []CoverageEntry `json:"never_backed_up"`
+	Stale         []CoverageEntry `json:"stale"`      // Último backup más antiguo que StaleDays
+	Failed        []CoverageEntry `json:"failed"`     // El último intento de backup falló (según el registro de actividad)
+	Changed       []CoverageEntry `json:"changed"`    // Partidas modificadas después del último backup
+	Unverified    []CoverageEntry `json:"unverified"` // Con backups nunca verificados
+}
+
+// GetBackupCoverageReport indica qué juegos no tienen backups, los tienen desactualizados, fallaron en el último
+// intento o tienen partidas sin respaldar
+func (bm *BackupManager) GetBackupCoverageReport() (*CoverageReport, error) {
+	staleDays := bm.config().StaleBackupDays
+	if staleDays <= 0 {
+		staleDays = defaultStaleBackupDays
+	}
+
+	now := time.Now()
+	report := &CoverageReport{
+		GeneratedAt:   now,
+		StaleDays:     staleDays,
+		NeverBackedUp: []CoverageEntry{},
+		Stale:         []CoverageEntry{},
+		Failed:        []CoverageEntry{},
+		Changed:       []CoverageEntry{},
+		Unverified:    []CoverageEntry{},
+	}
+
+	failures, err := bm.lastBackupFailures()
+	if err != nil {
+		return nil, err
+	}
+
+	staleBefore := now.AddDate(0, 0, -staleDays)
+	for _, game := range bm.GetGameList() {
+		backups, err := bm.listBackups(game.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := CoverageEntry{
+			GameID:      game.ID,
+			Name:        game.Name,
+			Platform:    game.Platform,
+			TotalSize:   game.TotalSize,
+			BackupCount: len(backups),
+			LastPlayed:  game.LastPlayed,
+		}
+		if len(backups) > 0 {
+			entry.LastBackup = backups[0].Created
+		}
+		for _, backup := range backups {
+			if backup.LastVerified.IsZero() {
+				entry.Unverified++
+			}
+		}
+
+		if failure, failed := failures[game.ID]; failed {
+			failedEntry := entry
+			failedEntry.Error = failure
+			report.Failed = append(report.Failed, failedEntry)
+		}
+
+		if len(backups) == 0 {
+			report.NeverBackedUp = append(report.NeverBackedUp, entry)
+			continue
+		}
+		if entry.LastBackup.Before(staleBefore) {
+			report.Stale = append(report.Stale, entry)
+		}
+		if entry.Unverified > 0 {
+			report.Unverified = append(report.Unverified, entry)
+		}
+
+		changed, err := bm.HasChangesSinceLastBackup(game.ID)
+		if err != nil {
+			log.Printf("No se pudo comprobar si %s tiene cambios: %v", game.Name, err)
+			continue
+		}
+		if changed {
+			report.Changed = append(report.Changed, entry)
+		}
+	}
+
+	for _, list := range [][]CoverageEntry{report.NeverBackedUp, report.Stale, report.Failed, report.Changed, report.Unverified} {
+		sortCoverage(list)
+	}
+	return report, nil
+}
+
+// BackupAllStale encola un backup de cada juego del informe de cobertura y devuelve los IDs de los trabajos
+func (bm *BackupManager) BackupAllStale() ([]string, error) {
+	report, err := bm.GetBackupCoverageReport()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []string{}
+	queued := make(map[string]bool)
+	for _, list := range [][]CoverageEntry{report.Failed, report.NeverBackedUp, report.Changed, report.Stale} {
+		for _, entry := range list {
+			if queued[entry.GameID] {
+				continue
+			}
+			queued[entry.GameID] = true
+
+			jobID, err := bm.EnqueueBackup(entry.GameID, BackupOptions{Trigger: "coverage"})
+			if err != nil {
+				log.Printf("Error encolando backup de %s: %v", entry.Name, err)
+				continue
+			}
+			jobs = append(jobs, jobID)
+		}
+	}
+	return jobs, nil
+}
+
+// lastBackupFailures devuelve, por juego, el error de su último intento de backup si falló
+func (bm *BackupManager) lastBackupFailures() (map[string]string, error) {
+	entries, err := bm.GetActivity(0, []string{ActivityBackup})
+	if err != nil {
+		return nil, err
+	}
+
+	failures := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, entry := range entries { // Más reciente primero
+		if seen[entry.GameID] {
+			continue
+		}
+		seen[entry.GameID] = true
+		if entry.Outcome == ActivityFailure {
+			failures[entry.GameID] = entry.Error
+		}
+	}
+	return failures, nil
+}
+
+// sortCoverage ordena por última partida y, a igualdad, por tamaño de las partidas
+func sortCoverage(entries []CoverageEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].LastPlayed.Equal(entries[j].LastPlayed) {
+			return entries[i].LastPlayed.After(entries[j].LastPlayed)
+		}
+		return entries[i].TotalSize > entries[j].TotalSize
+	})
+}
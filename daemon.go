@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// runDaemon ejecuta WineSave sin ventana: programador de backups y API local (si está habilitada)
+// hasta recibir SIGINT o SIGTERM. Al salir guarda configuración y base de datos como al cerrar la ventana.
+func runDaemon(app *App) {
+	app.initBackupManager()
+	bm := app.backupManager
+	if issues := bm.reportPreflight(); len(issues) > 0 {
+		log.Printf("[WARN] La comprobación inicial encontró %d problemas", len(issues))
+	}
+	bm.StartScheduler()
+	app.startAPI()
+	log.Printf("[INFO] WineSave en modo servicio: %d juegos, backups en %s", len(bm.GetGameList()), bm.config().BackupDir)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	sig := <-signals
+	signal.Stop(signals)
+	log.Printf("[INFO] Señal %v recibida, cerrando", sig)
+
+	app.OnBeforeClose(context.Background())
+	app.OnShutdown(context.Background())
+}
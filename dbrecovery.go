@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sufijos de las copias de la base de datos junto a game_saves.json
+const (
+	databaseBackupSuffix  = ".bak"      // Versión anterior válida, se actualiza en cada guardado
+	databaseCorruptSuffix = ".corrupt-" // Archivo ilegible apartado al cargar, seguido de la fecha
+)
+
+// Tipos de problema de arranque
+const (
+	StartupDatabaseCorrupt = "database_corrupt" // game_saves.json no se pudo leer y se apartó
+)
+
+// StartupIssue es un problema encontrado al arrancar que el usuario debe conocer
+type StartupIssue struct {
+	Kind          string    `json:"kind"`
+	Message       string    `json:"message"`
+	Error         string    `json:"error"`
+	CorruptPath   string    `json:"corrupt_path"`   // Copia apartada del archivo ilegible
+	RecoveredFrom string    `json:"recovered_from"` // Copia de la que se recuperaron los datos; vacío = se empezó sin juegos
+	Time          time.Time `json:"time"`
+}
+
+// GetStartupIssues devuelve los problemas encontrados al arrancar
+func (bm *BackupManager) GetStartupIssues() []StartupIssue {
+	bm.startupM.Lock()
+	defer bm.startupM.Unlock()
+	return append([]StartupIssue{}, bm.startupIssues...)
+}
+
+// parseDatabase lee el contenido de game_saves.json
+func parseDatabase(data []byte) (databaseFile, error) {
+	var dbData databaseFile
+	err := json.Unmarshal(data, &dbData)
+	return dbData, err
+}
+
+// recoverDatabase aparta un game_saves.json ilegible como game_saves.json.corrupt-<fecha> para que el siguiente
+// guardado no lo sobrescriba, e intenta cargar la copia .bak. Registra el problema para GetStartupIssues.
+func (bm *BackupManager) recoverDatabase(parseErr error) (databaseFile, error) {
+	issue := StartupIssue{
+		Kind:  StartupDatabaseCorrupt,
+		Error: parseErr.Error(),
+		Time:  time.Now(),
+	}
+
+	issue.CorruptPath = bm.DatabasePath + databaseCorruptSuffix + issue.Time.Format(backupTimestampLayout)
+	if err := os.Rename(bm.DatabasePath, issue.CorruptPath); err != nil {
+		// Sin apartarlo, un guardado posterior borraría los datos: no se continúa
+		return databaseFile{}, fmt.Errorf("base de datos ilegible (%v) y no se pudo apartar: %v", parseErr, err)
+	}
+
+	var dbData databaseFile
+	backupPath := bm.DatabasePath + databaseBackupSuffix
+	if data, err := os.ReadFile(backupPath); err == nil {
+		if parsed, err := parseDatabase(data); err == nil {
+			dbData = parsed
+			issue.RecoveredFrom = backupPath
+		}
+	}
+
+	if issue.RecoveredFrom != "" {
+		issue.Message = fmt.Sprintf("La base de datos de juegos estaba dañada: se apartó en %s y se recuperó la copia anterior %s",
+			issue.CorruptPath, issue.RecoveredFrom)
+	} else {
+		issue.Message = fmt.Sprintf("La base de datos de juegos estaba dañada y no hay copia anterior válida: se apartó en %s "+
+			"y la biblioteca empieza vacía. Repara el archivo y restáuralo con RestoreCorruptDatabase.", issue.CorruptPath)
+	}
+	log.Print(issue.Message)
+
+	bm.startupM.Lock()
+	bm.startupIssues = append(bm.startupIssues, issue)
+	bm.startupM.Unlock()
+	return dbData, nil
+}
+
+// RestoreCorruptDatabase vuelve a usar una copia apartada por recoverDatabase una vez reparada a mano.
+// La base de datos actual se guarda como .bak antes de sustituirla.
+func (bm *BackupManager) RestoreCorruptDatabase(corruptPath string) error {
+	dir, base := filepath.Split(bm.DatabasePath)
+	if filepath.Clean(filepath.Dir(corruptPath)) != filepath.Clean(dir) ||
+		!strings.HasPrefix(filepath.Base(corruptPath), base+databaseCorruptSuffix) {
+		return fmt.Errorf("%s no es una copia apartada de la base de datos", corruptPath)
+	}
+
+	data, err := os.ReadFile(corruptPath)
+	if err != nil {
+		return err
+	}
+	if _, err := parseDatabase(data); err != nil {
+		return fmt.Errorf("el archivo sigue sin poder leerse: %v", err)
+	}
+
+	bm.dbMu.Lock()
+	if current, err := os.ReadFile(bm.DatabasePath); err == nil {
+		if err := os.WriteFile(bm.DatabasePath+databaseBackupSuffix, current, 0644); err != nil {
+			bm.dbMu.Unlock()
+			return fmt.Errorf("error guardando copia de la base de datos actual: %v", err)
+		}
+	}
+	err = os.Rename(corruptPath, bm.DatabasePath)
+	bm.dbMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	bm.startupM.Lock()
+	issues := bm.startupIssues[:0]
+	for _, issue := range bm.startupIssues {
+		if issue.CorruptPath != corruptPath {
+			issues = append(issues, issue)
+		}
+	}
+	bm.startupIssues = issues
+	bm.startupM.Unlock()
+
+	log.Printf("Base de datos restaurada desde %s", corruptPath)
+	return bm.LoadDatabase()
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// deletedDirName es la carpeta, junto a los backups de un juego, con las partidas que el juego borró
+const deletedDirName = "deleted"
+
+// Límites por defecto de la carpeta deleted de cada juego
+const (
+	defaultDeletedFilesMaxCount = 200
+	defaultDeletedFilesMaxBytes = 512 << 20 // 512 MiB
+)
+
+// DeletedFile es una partida que el juego borró, guardada en deleted/<fecha>/ con su última versión respaldada
+type DeletedFile struct {
+	Name      string    `json:"name"`       // <fecha>/<ruta dentro del backup>: identifica el archivo en RecoverDeletedFile
+	Path      string    `json:"path"`       // Ruta dentro del backup, como en el original
+	DeletedAt time.Time `json:"deleted_at"` // Cuándo se vio que faltaba (en el backup siguiente a su borrado)
+	Size      int64     `json:"size"`
+	Modified  time.Time `json:"modified"` // Última modificación de la versión guardada
+}
+
+// SetKeepDeletedFiles activa o desactiva la protección de partidas borradas de un juego: en cada backup, las
+// partidas del backup anterior que ya no están en sus rutas se copian a deleted/ antes de que la rotación las pierda
+func (bm *BackupManager) SetKeepDeletedFiles(gameID string, enabled bool) (*GameInfo, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+	game.KeepDeletedFiles = enabled
+	return game, bm.SaveDatabase()
+}
+
+// GetDeletedFiles devuelve las partidas borradas guardadas de un juego, de la más reciente a la más antigua
+func (bm *BackupManager) GetDeletedFiles(gameID string) ([]DeletedFile, error) {
+	if _, exists := bm.getGame(gameID); !exists {
+		return nil, errGameNotFound(gameID)
+	}
+	return bm.listDeletedFiles(gameID)
+}
+
+// RecoverDeletedFile copia una partida borrada (por su DeletedFile.Name) a destPath. Si destPath es una carpeta
+// se copia dentro con su nombre. No sobrescribe archivos existentes.
+func (bm *BackupManager) RecoverDeletedFile(gameID, name, destPath string) error {
+	if _, exists := bm.getGame(gameID); !exists {
+		return errGameNotFound(gameID)
+	}
+	deletedDir := filepath.Join(bm.gameBackupDir(gameID), deletedDirName)
+	src := filepath.Join(deletedDir, filepath.FromSlash(name))
+	if !isWithin(deletedDir, src) || src == deletedDir {
+		return fmt.Errorf("nombre de archivo borrado inválido: %s", name)
+	}
+	info, err := os.Stat(src)
+	if err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("archivo borrado %s no encontrado", name)
+	}
+
+	destPath = ExpandPath(strings.TrimSpace(destPath))
+	if destPath == "" {
+		return fmt.Errorf("hay que indicar dónde recuperar el archivo")
+	}
+	if dest, err := os.Stat(destPath); err == nil && dest.IsDir() {
+		destPath = filepath.Join(destPath, filepath.Base(src))
+	}
+	if _, err := os.Stat(destPath); err == nil {
+		return fmt.Errorf("ya existe %s", destPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+	if err := copyFile(src, destPath); err != nil {
+		return fmt.Errorf("error recuperando %s: %v", name, err)
+	}
+	os.Chtimes(destPath, info.ModTime(), info.ModTime())
+	log.Printf("Partida borrada %s recuperada en %s", name, destPath)
+	return nil
+}
+
+// keepDeletedFiles copia a deleted/<fecha>/ las partidas del último backup (o de la copia actual) que ya no
+// están en las rutas del juego y siguen coincidiendo con sus patrones, y aplica los límites de la carpeta.
+// Se llama antes de crear cada backup de un juego con KeepDeletedFiles.
+func (bm *BackupManager) keepDeletedFiles(game *GameInfo) error {
+	previous := ""
+	if mirror := filepath.Join(bm.gameBackupDir(game.ID), currentMirrorName); bm.config().CurrentMirror {
+		if _, err := os.Stat(mirror); err == nil {
+			previous = mirror
+		}
+	}
+	if previous == "" {
+		backups, err := bm.listBackups(game.ID)
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			return nil
+		}
+		previous = backups[0].Path
+	}
+
+	current := make(map[string]bool)
+	err := bm.walkSaveFiles(game, func(_, name string, _ fs.DirEntry) error {
+		current[name] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	dir := filepath.Join(bm.gameBackupDir(game.ID), deletedDirName, now.Format(backupTimestampLayout))
+	kept := 0
+	err = forEachBackupEntry(previous, func(entry backupFileEntry) error {
+		if current[entry.Name] || (game.BackupRegistry && entry.Name == registryEntryName) ||
+			!bm.includesFile(game, filepath.Base(filepath.FromSlash(entry.Name))) {
+			return nil
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(entry.Name))
+		if !isWithin(dir, dest) {
+			return nil
+		}
+		if err := writeBackupEntry(entry, dest); err != nil {
+			return fmt.Errorf("error guardando la partida borrada %s: %v", entry.Name, err)
+		}
+		kept++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if kept == 0 {
+		return nil
+	}
+	log.Printf("%s: %d partidas borradas guardadas en %s", game.Name, kept, dir)
+	return bm.pruneDeletedFiles(game.ID)
+}
+
+// writeBackupEntry copia una entrada de un backup a dest conservando su fecha de modificación
+func writeBackupEntry(entry backupFileEntry, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	src, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dest, entry.Modified, entry.Modified)
+}
+
+// listDeletedFiles recorre deleted/<fecha>/ de un juego, de la fecha más reciente a la más antigua
+func (bm *BackupManager) listDeletedFiles(gameID string) ([]DeletedFile, error) {
+	deletedDir := filepath.Join(bm.gameBackupDir(gameID), deletedDirName)
+	files := []DeletedFile{}
+	err := filepath.WalkDir(deletedDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(deletedDir, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.SplitN(filepath.ToSlash(rel), "/", 2)
+		if len(parts) != 2 {
+			return nil
+		}
+		deletedAt, err := time.ParseInLocation(backupTimestampLayout, parts[0], time.Local)
+		if err != nil {
+			return nil // Carpeta que no creó WineSave
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, DeletedFile{
+			Name:      filepath.ToSlash(rel),
+			Path:      parts[1],
+			DeletedAt: deletedAt,
+			Size:      info.Size(),
+			Modified:  info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if !files[i].DeletedAt.Equal(files[j].DeletedAt) {
+			return files[i].DeletedAt.After(files[j].DeletedAt)
+		}
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// pruneDeletedFiles borra las partidas borradas más antiguas de un juego que superan los límites de la configuración
+func (bm *BackupManager) pruneDeletedFiles(gameID string) error {
+	maxCount := bm.config().DeletedFilesMaxCount
+	if maxCount <= 0 {
+		maxCount = defaultDeletedFilesMaxCount
+	}
+	maxBytes := bm.config().DeletedFilesMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultDeletedFilesMaxBytes
+	}
+
+	files, err := bm.listDeletedFiles(gameID)
+	if err != nil {
+		return err
+	}
+	deletedDir := filepath.Join(bm.gameBackupDir(gameID), deletedDirName)
+	var total int64
+	for i, file := range files {
+		total += file.Size
+		if i < maxCount && total <= maxBytes {
+			continue
+		}
+		path := filepath.Join(deletedDir, filepath.FromSlash(file.Name))
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		// Quitar las carpetas que se quedan vacías, hasta la de la fecha incluida
+		for dir := filepath.Dir(path); dir != deletedDir && isWithin(deletedDir, dir); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
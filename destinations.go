@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DestinationResult es el resultado de copiar un backup a uno de los destinos adicionales del juego
+type DestinationResult struct {
+	Dir     string `json:"dir"`
+	Path    string `json:"path"`
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+}
+
+// SetExtraBackupDirs configura los destinos adicionales donde se copia cada backup del juego
+func (bm *BackupManager) SetExtraBackupDirs(gameID string, dirs []string) (*GameInfo, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		expanded := filepath.Clean(ExpandPath(dir))
+		if !filepath.IsAbs(expanded) {
+			return nil, fmt.Errorf("el destino debe ser una ruta absoluta: %s", dir)
+		}
+		if isWithin(bm.config().BackupDir, expanded) || isWithin(expanded, bm.config().BackupDir) {
+			return nil, fmt.Errorf("el destino no puede estar dentro del directorio de backups ni contenerlo: %s", dir)
+		}
+		if seen[expanded] {
+			continue
+		}
+		seen[expanded] = true
+		cleaned = append(cleaned, expanded)
+	}
+
+	game.ExtraBackupDirs = cleaned
+	return game, bm.SaveDatabase()
+}
+
+// copyToExtraDestinations copia un backup recién creado a cada destino adicional del juego.
+// Un destino que falla no afecta al backup principal ni a los demás destinos.
+func (bm *BackupManager) copyToExtraDestinations(game *GameInfo, backupPath string) []DestinationResult {
+	results := make([]DestinationResult, 0, len(game.ExtraBackupDirs))
+	for _, dir := range game.ExtraBackupDirs {
+		result := DestinationResult{Dir: dir}
+		path, err := bm.copyToDestination(game, dir, backupPath)
+		if err != nil {
+			result.Error = err.Error()
+			log.Printf("Error copiando backup de %s a %s: %v", game.Name, dir, err)
+		} else {
+			result.Path = path
+			result.Success = true
+			log.Printf("Backup copiado a destino adicional: %s", path)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// copyToDestination copia un backup y su manifiesto a <dir>/<carpeta del juego>, verifica la copia
+// y aplica la retención en ese destino
+func (bm *BackupManager) copyToDestination(game *GameInfo, dir, backupPath string) (string, error) {
+	root := ExpandPath(dir)
+	if mountPoint, unmounted := unmountedMountPoint(root); unmounted {
+		return "", fmt.Errorf("%w: %s", ErrPathUnmounted, mountPoint)
+	}
+
+	destDir := filepath.Join(root, bm.backupFolder(game.ID))
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return "", fmt.Errorf("error creando directorio de backup: %v", err)
+	}
+
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		return "", err
+	}
+
+	// Copiar a un nombre provisional: un backup con el nombre final siempre está completo
+	dst := filepath.Join(destDir, filepath.Base(backupPath))
+	partial := dst + ".partial"
+	os.RemoveAll(partial)
+	if info.IsDir() {
+		err = copyDir(backupPath, partial)
+		if err == nil {
+			err = checkMigratedDir(backupPath, partial)
+		}
+	} else {
+		err = copyFile(backupPath, partial)
+		if err == nil {
+			var same bool
+			if same, err = sameFileContent(backupPath, partial); err == nil && !same {
+				err = fmt.Errorf("la copia no coincide con el original")
+			}
+		}
+	}
+	if err == nil {
+		os.RemoveAll(dst)
+		err = os.Rename(partial, dst)
+	}
+	if err != nil {
+		os.RemoveAll(partial)
+		return "", err
+	}
+
+	for _, sidecar := range sidecarPaths(backupPath) {
+		if _, err := os.Stat(sidecar); err == nil {
+			if err := copyFile(sidecar, dst+strings.TrimPrefix(sidecar, backupPath)); err != nil {
+				log.Printf("Error copiando %s a %s: %v", filepath.Base(sidecar), destDir, err)
+			}
+		}
+	}
+
+	if err := bm.cleanDestinationBackups(game.ID, destDir); err != nil {
+		log.Printf("Error limpiando backups antiguos en %s: %v", destDir, err)
+	}
+	return dst, nil
+}
+
+// cleanDestinationBackups aplica MaxBackups y MinBackupAgeBeforeDelete en un destino adicional con independencia
+// del principal.
+// Los backups fijados en el historial del juego tampoco se borran aquí.
+func (bm *BackupManager) cleanDestinationBackups(gameID, destDir string) error {
+	folder := bm.backupFolder(gameID)
+	files, err := os.ReadDir(destDir)
+	if err != nil {
+		return err
+	}
+
+	index, err := bm.loadBackupIndex(gameID)
+	if err != nil {
+		return err
+	}
+
+	var rotating []BackupInfo
+	for _, file := range files {
+		created, ok := parseBackupName(folder, file.Name())
+		if !ok || (!file.IsDir() && !strings.HasSuffix(file.Name(), ".zip")) {
+			continue
+		}
+		if record := index.find(file.Name()); record != nil && record.Pinned {
+			continue
+		}
+		rotating = append(rotating, BackupInfo{Name: file.Name(), Path: filepath.Join(destDir, file.Name()), Created: created})
+	}
+
+	if len(rotating) <= bm.config().MaxBackups {
+		return nil
+	}
+	sort.Slice(rotating, func(i, j int) bool {
+		return rotating[i].Created.After(rotating[j].Created)
+	})
+
+	for _, backup := range rotating[bm.config().MaxBackups:] {
+		if bm.tooRecentToRotate(backup) {
+			continue
+		}
+		if err := os.RemoveAll(backup.Path); err != nil {
+			log.Printf("Error eliminando backup antiguo %s: %v", backup.Path, err)
+			continue
+		}
+		for _, sidecar := range sidecarPaths(backup.Path) {
+			os.Remove(sidecar)
+		}
+		log.Printf("Backup antiguo eliminado: %s", backup.Path)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// GameDetector es un escáner de juegos que se puede registrar para participar en ScanForGames.
+// Detect devuelve los juegos encontrados sin modificar la base de datos; la mezcla la hace ScanForGames.
+// Los juegos se identifican por su Slug (o por ID si no tienen); el ID estable lo asigna ScanForGames.
+type GameDetector interface {
+	Name() string
+	Detect(bm *BackupManager) ([]*GameInfo, error)
+}
+
+var (
+	detectorsMu sync.RWMutex
+	detectors   []GameDetector
+)
+
+func init() {
+	RegisterDetector(knownGamesDetector{})
+	RegisterDetector(epicManifestDetector{}) // Antes de las rutas genéricas: es más preciso
+	RegisterDetector(steamUserdataDetector{})
+
+	// Orden estable para que la plataforma asignada a una ruta compartida sea siempre la misma
+	platforms := make([]string, 0, len(CommonSavePaths))
+	for platform := range CommonSavePaths {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	for _, platform := range platforms {
+		RegisterDetector(commonPathsDetector{platform: platform, paths: CommonSavePaths[platform]})
+	}
+
+	RegisterDetector(wineDetector{})
+}
+
+// RegisterDetector agrega un detector al registro. Un detector con el mismo nombre reemplaza al anterior.
+func RegisterDetector(d GameDetector) {
+	detectorsMu.Lock()
+	defer detectorsMu.Unlock()
+
+	for i, existing := range detectors {
+		if existing.Name() == d.Name() {
+			detectors[i] = d
+			return
+		}
+	}
+	detectors = append(detectors, d)
+}
+
+// UnregisterDetector quita un detector del registro por nombre
+func UnregisterDetector(name string) {
+	detectorsMu.Lock()
+	defer detectorsMu.Unlock()
+
+	for i, existing := range detectors {
+		if existing.Name() == name {
+			detectors = append(detectors[:i], detectors[i+1:]...)
+			return
+		}
+	}
+}
+
+// RegisteredDetectors devuelve los detectores en el orden en que se ejecutan
+func RegisteredDetectors() []GameDetector {
+	detectorsMu.RLock()
+	defer detectorsMu.RUnlock()
+	return append([]GameDetector(nil), detectors...)
+}
+
+// knownGamesDetector agrega los juegos de KnownGames cuyas rutas existen
+type knownGamesDetector struct{}
+
+func (knownGamesDetector) Name() string { return "known-games" }
+
+func (knownGamesDetector) Detect(bm *BackupManager) ([]*GameInfo, error) {
+	ids := make([]string, 0, len(KnownGames))
+	for id := range KnownGames {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	games := []*GameInfo{}
+	for _, id := range ids {
+		game := KnownGames[id]
+		if !bm.gameExists(game) {
+			continue
+		}
+
+		newGame := *game // Copiar estructura
+		newGame.Slug = game.ID
+		newGame.ID = ""
+		if newGame.Metadata == nil {
+			newGame.Metadata = make(map[string]string)
+		}
+		if newGame.CustomPaths == nil {
+			newGame.CustomPaths = []string{}
+		}
+		games = append(games, &newGame)
+	}
+	return games, nil
+}
+
+// commonPathsDetector busca carpetas de guardado en las ubicaciones comunes de una plataforma
+type commonPathsDetector struct {
+	platform string
+	paths    []string
+}
+
+func (d commonPathsDetector) Name() string { return d.platform }
+
+func (d commonPathsDetector) Detect(bm *BackupManager) ([]*GameInfo, error) {
+	games := []*GameInfo{}
+	var errs []error
+	for _, basePath := range d.paths {
+		expandedPath := ExpandPath(basePath)
+		found, err := bm.scanDirectory(expandedPath, d.platform)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error escaneando %s: %v", expandedPath, err))
+		}
+		games = append(games, found...)
+	}
+	return games, errors.Join(errs...)
+}
+
+// wineDetector busca carpetas de guardado dentro de los prefijos de Wine y Proton
+type wineDetector struct{}
+
+func (wineDetector) Name() string { return "wine" }
+
+func (wineDetector) Detect(bm *BackupManager) ([]*GameInfo, error) {
+	games := []*GameInfo{}
+	var errs []error
+	for _, prefix := range findWinePrefixes() {
+		for _, dir := range wineSaveDirs(prefix) {
+			found, err := bm.scanDirectory(dir, "wine")
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error escaneando %s: %v", dir, err))
+			}
+			for _, game := range found {
+				game.Metadata["wine_prefix"] = prefix
+			}
+			games = append(games, found...)
+		}
+	}
+	return games, errors.Join(errs...)
+}
+
+// scanProgressEvent es el evento emitido durante un escaneo de juegos
+const scanProgressEvent = "scan:progress"
+
+// ScanProgress es el contenido del evento de progreso del escaneo
+type ScanProgress struct {
+	Detector    string `json:"detector"`
+	Directory   string `json:"directory"`
+	DirsScanned int    `json:"dirs_scanned"`
+	Candidates  int    `json:"candidates"`
+	Done        bool   `json:"done"`
+}
+
+// scanReporter acumula el progreso de un escaneo y emite eventos limitados en frecuencia
+type scanReporter struct {
+	bm       *BackupManager
+	mu       sync.Mutex
+	progress ScanProgress
+	lastEmit time.Time
+}
+
+// update modifica el progreso y lo emite si pasó suficiente tiempo desde el último evento
+func (r *scanReporter) update(fn func(p *ScanProgress)) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fn(&r.progress)
+	if r.progress.Done || time.Since(r.lastEmit) >= 250*time.Millisecond {
+		r.lastEmit = time.Now()
+		r.bm.emit(scanProgressEvent, r.progress)
+	}
+}
+
+// currentScan devuelve el reporte del escaneo en curso (nil si no hay ninguno)
+func (bm *BackupManager) currentScan() *scanReporter {
+	bm.scanM.Lock()
+	defer bm.scanM.Unlock()
+	return bm.scan
+}
+
+// setCurrentScan registra o quita el reporte del escaneo en curso
+func (bm *BackupManager) setCurrentScan(r *scanReporter) {
+	bm.scanM.Lock()
+	defer bm.scanM.Unlock()
+	bm.scan = r
+}
+
+// scanDirectory escanea un directorio en busca de posibles carpetas de guardado y devuelve los juegos que aún no están en la base de datos
+func (bm *BackupManager) scanDirectory(path, platform string) ([]*GameInfo, error) {
+	games := []*GameInfo{}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return games, nil // Directorio no existe, continuar
+	}
+
+	reporter := bm.currentScan()
+	err := filepath.WalkDir(path, func(currentPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Continuar con otros directorios
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		reporter.update(func(p *ScanProgress) {
+			p.Directory = currentPath
+			p.DirsScanned++
+		})
+
+		// Verificar si este directorio parece contener archivos de guardado
+		if bm.looksLikeSaveDirectory(currentPath) {
+			slug := bm.generateSlug(currentPath)
+			if _, exists := bm.findGameBySlug(slug); !exists {
+				games = append(games, &GameInfo{
+					Slug:        slug,
+					Name:        bm.inferGameName(currentPath),
+					Platform:    platform,
+					SavePaths:   []string{currentPath},
+					Patterns:    SaveFilePatterns,
+					CustomPaths: []string{},
+					Metadata:    make(map[string]string),
+				})
+				reporter.update(func(p *ScanProgress) { p.Candidates++ })
+			}
+		}
+
+		return nil
+	})
+	return games, err
+}
+
+// runDetectors ejecuta los detectores registrados y agrega a la base de datos los juegos nuevos (solo si persist)
+func (bm *BackupManager) runDetectors(result *ScanResult, persist bool) {
+	reporter := &scanReporter{bm: bm}
+	bm.setCurrentScan(reporter)
+	defer bm.setCurrentScan(nil)
+
+	seen := make(map[string]bool)
+	for _, detector := range RegisteredDetectors() {
+		reporter.update(func(p *ScanProgress) { p.Detector = detector.Name() })
+
+		games, err := detector.Detect(bm)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("Detector %s: %v", detector.Name(), err))
+		}
+
+		for _, game := range games {
+			// Detectores externos que aún identifican los juegos por ID
+			if game.Slug == "" {
+				game.Slug = sanitizePathComponent(game.ID)
+			}
+
+			// El primer detector que encuentra un juego gana
+			if existing, exists := bm.findGameBySlug(game.Slug); exists || seen[game.Slug] {
+				if exists && persist {
+					bm.updateInstallDir(existing, game.InstallDir)
+				}
+				continue
+			}
+			seen[game.Slug] = true
+
+			// Una carpeta movida no es un juego nuevo: se ofrece reubicar el existente
+			if relocation, ok := bm.findRelocatedGame(game); ok {
+				result.Relocated = append(result.Relocated, *relocation)
+				log.Printf("Posible reubicación de %s: %s", relocation.Name, relocation.NewPath)
+				continue
+			}
+
+			if game.Metadata == nil {
+				game.Metadata = make(map[string]string)
+			}
+			game.Metadata["detector"] = detector.Name()
+			game.ID = newGameID()
+
+			if persist {
+				bm.setGame(game)
+			}
+			result.NewGames = append(result.NewGames, game)
+			log.Printf("Nuevo juego detectado por %s: %s", detector.Name(), game.Name)
+		}
+
+		// Sincronizar con los juegos realmente nuevos (sin duplicados ni ya conocidos)
+		reporter.update(func(p *ScanProgress) { p.Candidates = len(result.NewGames) })
+	}
+
+	reporter.update(func(p *ScanProgress) {
+		p.Directory = ""
+		p.Done = true
+	})
+}
+
+// updateInstallDir guarda la carpeta de instalación que un detector encontró para un juego ya conocido,
+// con la que se resuelve %GAME_DIR% en sus rutas (p. ej. tras reinstalarlo en otra biblioteca de Steam)
+func (bm *BackupManager) updateInstallDir(game *GameInfo, installDir string) {
+	if installDir == "" || game.InstallDir == installDir {
+		return
+	}
+	log.Printf("Carpeta de instalación de %s: %s", game.Name, installDir)
+	game.InstallDir = installDir
+}
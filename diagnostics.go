@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// diagnosticsActivityEntries son las entradas del registro de actividad que incluye ExportDiagnostics
+const diagnosticsActivityEntries = 200
+
+// Marcadores con los que ExportDiagnostics sustituye los datos personales
+const (
+	redactedHome  = "<HOME>"
+	redactedUser  = "<USER>"
+	redactedValue = "<REDACTED>"
+)
+
+// Diagnostics es la información para reportar un problema que escribe ExportDiagnostics
+type Diagnostics struct {
+	Generated time.Time `json:"generated"`
+	OS        string    `json:"os"`
+	Arch      string    `json:"arch"`
+	GoVersion string    `json:"go_version"`
+
+	Config      BackupConfig        `json:"config"`
+	Environment *EnvironmentReport  `json:"environment"`
+	Database    DiagnosticsDatabase `json:"database"`
+	Startup     []StartupIssue      `json:"startup_issues"`
+	Activity    []ActivityEntry     `json:"activity"` // Las más recientes primero
+}
+
+// DiagnosticsDatabase resume la base de datos de juegos, sin los metadatos de cada juego
+type DiagnosticsDatabase struct {
+	Path    string            `json:"path"`
+	Backend string            `json:"backend"`
+	Library *LibrarySummary   `json:"library,omitempty"`
+	Games   []DiagnosticsGame `json:"games"`
+}
+
+// DiagnosticsGame son los datos de un juego que sirven para diagnosticar sus backups
+type DiagnosticsGame struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Platform   string    `json:"platform"`
+	Status     string    `json:"status,omitempty"`
+	SavePaths  []string  `json:"save_paths"`
+	Patterns   []string  `json:"patterns"`
+	TotalSize  int64     `json:"total_size"`
+	FileCount  int       `json:"file_count"`
+	LastBackup time.Time `json:"last_backup"`
+}
+
+// ExportDiagnostics escribe en w, en JSON, la configuración, el informe de entorno, un resumen de la base de datos, los problemas de
+// arranque y la actividad reciente, con la carpeta personal y los nombres de usuario sustituidos por <HOME> y
+// <USER> (y los valores de Config.DiagnosticsRedact por <REDACTED>) para poder publicarlo en un reporte.
+func (bm *BackupManager) ExportDiagnostics(w io.Writer) error {
+	diagnostics := Diagnostics{
+		Generated:   time.Now(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		GoVersion:   runtime.Version(),
+		Config:      bm.diagnosticsConfig(),
+		Environment: bm.GetEnvironmentReport(),
+		Database: DiagnosticsDatabase{
+			Path:    bm.DatabasePath,
+			Backend: bm.config().DatabaseBackend,
+			Games:   []DiagnosticsGame{},
+		},
+		Startup: bm.GetStartupIssues(),
+	}
+	if diagnostics.Database.Backend == "" {
+		diagnostics.Database.Backend = "json"
+	}
+
+	if library, err := bm.GetLibrarySummary(); err == nil {
+		diagnostics.Database.Library = library
+	}
+	for _, game := range bm.GetGameList() {
+		diagnostics.Database.Games = append(diagnostics.Database.Games, DiagnosticsGame{
+			ID:         game.ID,
+			Name:       game.Name,
+			Platform:   game.Platform,
+			Status:     game.Status,
+			SavePaths:  game.SavePaths,
+			Patterns:   game.Patterns,
+			TotalSize:  game.TotalSize,
+			FileCount:  game.FileCount,
+			LastBackup: game.LastBackup,
+		})
+	}
+
+	activity, err := bm.GetActivity(diagnosticsActivityEntries, nil)
+	if err != nil {
+		// Sin registro de actividad el resto sigue sirviendo
+		activity = []ActivityEntry{{Summary: "no se pudo leer el registro de actividad", Error: err.Error()}}
+	}
+	diagnostics.Activity = activity
+
+	data, err := json.MarshalIndent(diagnostics, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error generando el diagnóstico: %v", err)
+	}
+	if _, err := io.WriteString(w, bm.diagnosticsRedactor().redact(string(data))+"\n"); err != nil {
+		return fmt.Errorf("error escribiendo el diagnóstico: %v", err)
+	}
+	return nil
+}
+
+// diagnosticsConfig devuelve una copia de la configuración sin secretos: el token de la API y el usuario y la
+// contraseña que pueda llevar la URL de PCGamingWiki
+func (bm *BackupManager) diagnosticsConfig() BackupConfig {
+	config := bm.config()
+	if config.APIToken != "" {
+		config.APIToken = redactedValue
+	}
+	if parsed, err := url.Parse(config.PCGWBaseURL); err == nil && parsed.User != nil {
+		parsed.User = url.User(redactedValue)
+		config.PCGWBaseURL = parsed.String()
+	}
+	return config
+}
+
+// redactor sustituye datos personales en el texto de un diagnóstico
+type redactor struct {
+	rules []redactRule
+}
+
+type redactRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// pathSeparator reconoce / y \ también escapada en JSON (\\)
+const pathSeparator = `(?:/|\\{1,2})`
+
+// addPath oculta un directorio (y lo que tenga debajo) con placeholder, con cualquier separador y sin
+// distinguir mayúsculas
+func (r *redactor) addPath(dir, placeholder string) {
+	dir = strings.TrimRight(strings.ReplaceAll(dir, `\`, "/"), "/")
+	if dir == "" {
+		return
+	}
+	parts := strings.Split(dir, "/")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	r.rules = append(r.rules, redactRule{
+		pattern:     regexp.MustCompile(`(?i)` + strings.Join(parts, pathSeparator) + `($|[/\\"])`),
+		replacement: placeholder + "${1}",
+	})
+}
+
+// addName oculta un nombre solo cuando es un componente completo de una ruta (p. ej. /home/<nombre>/ o
+// \Users\<nombre>), para no tocar textos en los que aparece por casualidad
+func (r *redactor) addName(name, placeholder string) {
+	if name == "" {
+		return
+	}
+	r.rules = append(r.rules, redactRule{
+		pattern:     regexp.MustCompile(`(?i)([/\\])` + regexp.QuoteMeta(name) + `($|[/\\"])`),
+		replacement: "${1}" + placeholder + "${2}",
+	})
+}
+
+// addValue oculta un texto allí donde aparezca
+func (r *redactor) addValue(value, placeholder string) {
+	if value == "" {
+		return
+	}
+	r.rules = append(r.rules, redactRule{
+		pattern:     regexp.MustCompile(`(?i)` + regexp.QuoteMeta(value)),
+		replacement: placeholder,
+	})
+}
+
+func (r *redactor) redact(text string) string {
+	for _, rule := range r.rules {
+		// Dos pasadas: las coincidencias seguidas (/ana/ana/) comparten el separador
+		for i := 0; i < 2; i++ {
+			text = rule.pattern.ReplaceAllString(text, rule.replacement)
+		}
+	}
+	return text
+}
+
+// diagnosticsRedactor oculta la carpeta personal, el usuario actual, los usuarios de los prefijos de Wine
+// y los valores de Config.DiagnosticsRedact
+func (bm *BackupManager) diagnosticsRedactor() *redactor {
+	r := &redactor{}
+	// El token también puede aparecer fuera de la configuración, p. ej. en un error registrado
+	r.addValue(bm.config().APIToken, redactedValue)
+	for _, value := range bm.config().DiagnosticsRedact {
+		r.addValue(value, redactedValue)
+	}
+	// Primero la carpeta personal entera: así /home/<usuario> queda como <HOME> y no como /home/<USER>
+	if home, err := os.UserHomeDir(); err == nil {
+		r.addPath(home, redactedHome)
+	}
+
+	names := make(map[string]bool)
+	if current, err := user.Current(); err == nil {
+		// En Windows Username es DOMINIO\usuario
+		names[filepath.Base(strings.ReplaceAll(current.Username, `\`, "/"))] = true
+	}
+	names[os.Getenv("USER")] = true
+	names[os.Getenv("USERNAME")] = true
+	for _, prefix := range findWinePrefixes() {
+		users, _ := os.ReadDir(filepath.Join(prefix, "drive_c", "users"))
+		for _, entry := range users {
+			if entry.IsDir() {
+				names[entry.Name()] = true
+			}
+		}
+	}
+	// Nombres genéricos de los prefijos que no identifican a nadie
+	for _, generic := range []string{"", "Public", "steamuser", "Default", "All Users"} {
+		delete(names, generic)
+	}
+
+	// Los nombres largos primero, por si uno contiene a otro
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, name := range sorted {
+		r.addName(name, redactedUser)
+	}
+	return r
+}
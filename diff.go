@@ -0,0 +1,287 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// BackupDiffEntry describe un archivo que difiere entre dos estados de un backup
+type BackupDiffEntry struct {
+	Path    string `json:"path"`
+	OldSize int64  `json:"old_size"`
+	NewSize int64  `json:"new_size"`
+}
+
+// BackupDiff resume las diferencias entre dos backups de un mismo juego
+type BackupDiff struct {
+	GameID    string            `json:"game_id"`
+	From      string            `json:"from"`
+	To        string            `json:"to"`
+	Added     []BackupDiffEntry `json:"added"`
+	Removed   []BackupDiffEntry `json:"removed"`
+	Changed   []BackupDiffEntry `json:"changed"`
+	Unchanged int               `json:"unchanged"`
+
+	// Solo para comparaciones contra los archivos actuales
+	BaseBackupTime   time.Time `json:"base_backup_time,omitempty"`
+	NoPreviousBackup bool      `json:"no_previous_backup"`
+}
+
+// backupEntry representa un archivo dentro de un backup (tamaño y CRC32)
+type backupEntry struct {
+	Size  int64
+	CRC32 uint32
+}
+
+// readBackupEntries lee el directorio central de un backup comprimido o, en uno en carpeta,
+// calcula el CRC32 de cada archivo para poder compararlo con los ZIP
+func readBackupEntries(path string) (map[string]backupEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		entries := make(map[string]backupEntry)
+		err := forEachBackupEntry(path, func(entry backupFileEntry) error {
+			rc, err := entry.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			hash := crc32.NewIEEE()
+			size, err := io.Copy(hash, rc)
+			if err != nil {
+				return fmt.Errorf("error leyendo %s: %v", entry.Name, err)
+			}
+			entries[entry.Name] = backupEntry{Size: size, CRC32: hash.Sum32()}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo backup %s: %v", info.Name(), err)
+		}
+		return entries, nil
+	}
+
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo backup %s: %v", info.Name(), err)
+	}
+	defer reader.Close()
+
+	entries := make(map[string]backupEntry, len(reader.File))
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || file.Name == backupMetaEntryName {
+			continue
+		}
+		entries[file.Name] = backupEntry{
+			Size:  int64(file.UncompressedSize64),
+			CRC32: file.CRC32,
+		}
+	}
+	return entries, nil
+}
+
+// diffEntries compara dos conjuntos de archivos y clasifica las diferencias
+func diffEntries(from, to map[string]backupEntry) *BackupDiff {
+	diff := &BackupDiff{
+		Added:   []BackupDiffEntry{},
+		Removed: []BackupDiffEntry{},
+		Changed: []BackupDiffEntry{},
+	}
+
+	for name, old := range from {
+		current, exists := to[name]
+		switch {
+		case !exists:
+			diff.Removed = append(diff.Removed, BackupDiffEntry{Path: name, OldSize: old.Size})
+		case current.Size != old.Size || current.CRC32 != old.CRC32:
+			diff.Changed = append(diff.Changed, BackupDiffEntry{Path: name, OldSize: old.Size, NewSize: current.Size})
+		default:
+			diff.Unchanged++
+		}
+	}
+
+	for name, current := range to {
+		if _, exists := from[name]; !exists {
+			diff.Added = append(diff.Added, BackupDiffEntry{Path: name, NewSize: current.Size})
+		}
+	}
+
+	for _, list := range [][]BackupDiffEntry{diff.Added, diff.Removed, diff.Changed} {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Path < list[j].Path
+		})
+	}
+
+	return diff
+}
+
+// DiffBackups compara dos backups de un juego y lista los archivos añadidos, eliminados y modificados
+func (bm *BackupManager) DiffBackups(gameID, fileA, fileB string) (*BackupDiff, error) {
+	if _, exists := bm.getGame(gameID); !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	pathA, err := bm.resolveBackupFile(gameID, fileA)
+	if err != nil {
+		return nil, err
+	}
+	pathB, err := bm.resolveBackupFile(gameID, fileB)
+	if err != nil {
+		return nil, err
+	}
+
+	entriesA, err := readBackupEntries(pathA)
+	if err != nil {
+		return nil, err
+	}
+	entriesB, err := readBackupEntries(pathB)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffEntries(entriesA, entriesB)
+	diff.GameID = gameID
+	diff.From = fileA
+	diff.To = fileB
+	return diff, nil
+}
+
+// GetChangesSinceLastBackup compara los archivos de guardado actuales con el último backup del juego
+func (bm *BackupManager) GetChangesSinceLastBackup(gameID string) (*BackupDiff, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	// Con la unidad desmontada todos los archivos aparecerían como eliminados
+	if err := bm.checkSaveMounts(game); err != nil {
+		return nil, err
+	}
+
+	// Evitar hashear directorios enormes por error
+	if err := bm.checkBackupLimits(game); err != nil {
+		return nil, err
+	}
+
+	live, err := bm.readLiveEntries(game)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivos de guardado: %v", err)
+	}
+
+	backups, err := bm.listBackups(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("error listando backups: %v", err)
+	}
+
+	if len(backups) == 0 {
+		diff := diffEntries(map[string]backupEntry{}, live)
+		diff.GameID = gameID
+		diff.To = "live"
+		diff.NoPreviousBackup = true
+		return diff, nil
+	}
+
+	// Tras una restauración, los archivos se comparan con el backup restaurado
+	base := backups[0]
+	for _, backup := range backups {
+		if backup.Current {
+			base = backup
+			break
+		}
+	}
+
+	entries, err := readBackupEntries(base.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffEntries(entries, live)
+	diff.GameID = gameID
+	diff.From = base.Name
+	diff.To = "live"
+	diff.BaseBackupTime = base.Created
+
+	if base.Current && diff.hasChanges() {
+		if err := bm.clearCurrentState(gameID); err != nil {
+			log.Printf("Error actualizando el estado restaurado de %s: %v", gameID, err)
+		}
+	}
+	return diff, nil
+}
+
+// errNewerSaveFound corta el recorrido de HasChangesSinceLastBackup en el primer archivo más reciente
+var errNewerSaveFound = errors.New("archivo más reciente que el último backup")
+
+// HasChangesSinceLastBackup indica rápidamente si algún archivo de guardado se modificó después del último backup,
+// comparando fechas de modificación y deteniéndose en el primero más reciente. Sin backups, basta con que haya
+// algún archivo. No detecta archivos eliminados: para eso está GetChangesSinceLastBackup.
+func (bm *BackupManager) HasChangesSinceLastBackup(gameID string) (bool, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return false, errGameNotFound(gameID)
+	}
+	if err := bm.checkSaveMounts(game); err != nil {
+		return false, err
+	}
+
+	lastBackup := game.LastBackup
+	err := bm.walkSaveFiles(game, func(path, name string, d fs.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(lastBackup) {
+			return errNewerSaveFound
+		}
+		return nil
+	})
+	if errors.Is(err, errNewerSaveFound) {
+		return true, nil
+	}
+	return false, err
+}
+
+// readLiveEntries calcula tamaño y CRC32 de los archivos de guardado actuales con los mismos nombres que en el ZIP
+func (bm *BackupManager) readLiveEntries(game *GameInfo) (map[string]backupEntry, error) {
+	entries := make(map[string]backupEntry)
+
+	err := bm.walkSaveFiles(game, func(path, name string, d fs.DirEntry) error {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil // Archivo bloqueado o eliminado durante el recorrido
+		}
+		defer file.Close()
+
+		hash := crc32.NewIEEE()
+		size, err := io.Copy(hash, file)
+		if err != nil {
+			return nil
+		}
+
+		entries[name] = backupEntry{Size: size, CRC32: hash.Sum32()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Las claves del registro cuentan como un archivo más para detectar cambios
+	if data, err := bm.registrySlice(game); err == nil && data != nil {
+		entries[registryEntryName] = backupEntry{Size: int64(len(data)), CRC32: crc32.ChecksumIEEE(data)}
+	}
+	return entries, nil
+}
+
+// hasChanges indica si la comparación encontró algún archivo nuevo, eliminado o modificado
+func (d *BackupDiff) hasChanges() bool {
+	return d.NoPreviousBackup || len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// documentsDir devuelve la carpeta de documentos del usuario y de dónde sale: XDG_DOCUMENTS_DIR de
+// user-dirs.dirs (que cambia con el idioma del escritorio, p. ej. ~/Documentos) o ~/Documents
+func documentsDir() (string, string) {
+	home, _ := os.UserHomeDir()
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	file, err := os.Open(filepath.Join(configHome, "user-dirs.dirs"))
+	if err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			value, found := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "XDG_DOCUMENTS_DIR=")
+			if !found {
+				continue
+			}
+			value = strings.ReplaceAll(strings.Trim(value, `"`), "$HOME", home)
+			if filepath.IsAbs(value) {
+				return value, "user-dirs.dirs"
+			}
+		}
+	}
+	return filepath.Join(home, "Documents"), "home"
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// documentsDir devuelve la carpeta de documentos del usuario y de dónde sale: la carpeta conocida de Windows,
+// que sigue las redirecciones (p. ej. a OneDrive), o %USERPROFILE%\Documents si no se puede consultar
+func documentsDir() (string, string) {
+	if path, err := windows.KnownFolderPath(windows.FOLDERID_Documents, 0); err == nil && path != "" {
+		return path, "known_folder"
+	}
+	return filepath.Join(os.Getenv("USERPROFILE"), "Documents"), "USERPROFILE"
+}
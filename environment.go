@@ -0,0 +1,152 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// environmentVariables son las variables que usa ExpandPath, en el orden del informe
+var environmentVariables = []string{
+	"USERPROFILE", "APPDATA", "LOCALAPPDATA", "PROGRAMFILES", "PROGRAMFILES(X86)", "PROGRAMDATA",
+	"HOME", "XDG_CONFIG_HOME", "XDG_DATA_HOME",
+}
+
+// EnvironmentEntry es una ruta del informe de entorno con su estado en el disco
+type EnvironmentEntry struct {
+	Name     string `json:"name"`  // Variable, plantilla de CommonSavePaths o ruta
+	Value    string `json:"value"` // Ruta resuelta; vacío = variable sin definir
+	Exists   bool   `json:"exists"`
+	Readable bool   `json:"readable"` // Se puede listar su contenido
+	Source   string `json:"source,omitempty"`
+	Note     string `json:"note,omitempty"` // Por qué puede fallar la detección con este valor
+
+	Platforms []string `json:"platforms,omitempty"` // Raíces de guardado: plataformas que la usan
+	Games     []string `json:"games,omitempty"`     // Prefijos: juegos registrados que lo usan
+	Detected  bool     `json:"detected,omitempty"`  // Prefijos: lo encuentra findWinePrefixes
+}
+
+// EnvironmentReport son los valores con los que se detectan los juegos, para diagnosticar escaneos que no
+// encuentran nada
+type EnvironmentReport struct {
+	OS           string             `json:"os"`
+	Variables    []EnvironmentEntry `json:"variables"`
+	Documents    EnvironmentEntry   `json:"documents"`
+	SaveRoots    []EnvironmentEntry `json:"save_roots"`
+	SteamRoots   []EnvironmentEntry `json:"steam_roots"`
+	WinePrefixes []EnvironmentEntry `json:"wine_prefixes"`
+}
+
+// GetEnvironmentReport resuelve las variables de ExpandPath, la carpeta de documentos, las raíces de
+// CommonSavePaths, las instalaciones de Steam y los prefijos de Wine (detectados y de juegos registrados),
+// indicando de cada ruta si existe y se puede leer
+func (bm *BackupManager) GetEnvironmentReport() *EnvironmentReport {
+	report := &EnvironmentReport{
+		OS:           runtime.GOOS,
+		Variables:    []EnvironmentEntry{},
+		SaveRoots:    []EnvironmentEntry{},
+		SteamRoots:   []EnvironmentEntry{},
+		WinePrefixes: []EnvironmentEntry{},
+	}
+
+	for _, name := range environmentVariables {
+		entry := pathEntry(name, os.Getenv(name))
+		if entry.Value == "" && runtime.GOOS != "windows" && name != "HOME" && !strings.HasPrefix(name, "XDG_") {
+			entry.Note = "sin definir fuera de Windows: las rutas con %" + name + "% solo se encuentran dentro de prefijos de Wine"
+		} else if entry.Value != "" && !entry.Exists {
+			entry.Note = "la carpeta no existe"
+		}
+		report.Variables = append(report.Variables, entry)
+	}
+
+	documents, source := documentsDir()
+	report.Documents = pathEntry("Documents", documents)
+	report.Documents.Source = source
+	if expected := ExpandPath("%USERPROFILE%/Documents"); runtime.GOOS == "windows" &&
+		!strings.EqualFold(filepath.Clean(expected), filepath.Clean(documents)) {
+		report.Documents.Note = "redirigida: las rutas con %USERPROFILE%/Documents apuntan a " + expected
+	}
+
+	roots := make(map[string][]string)
+	for platform, paths := range CommonSavePaths {
+		for _, path := range paths {
+			roots[path] = append(roots[path], platform)
+		}
+	}
+	for _, template := range sortedKeys(roots) {
+		entry := pathEntry(template, ExpandPath(template))
+		for _, name := range environmentVariables {
+			// ExpandPath cambia las variables sin definir por nada: %APPDATA%/Ubisoft queda como /Ubisoft
+			if strings.Contains(template, "%"+name+"%") && os.Getenv(name) == "" {
+				entry.Note = "usa %" + name + "%, que no está definida"
+				break
+			}
+		}
+		entry.Platforms = roots[template]
+		sort.Strings(entry.Platforms)
+		report.SaveRoots = append(report.SaveRoots, entry)
+	}
+
+	for _, root := range steamRoots() {
+		report.SteamRoots = append(report.SteamRoots, pathEntry("Steam", root))
+	}
+
+	prefixes := make(map[string]*EnvironmentEntry)
+	prefixEntry := func(prefix string) *EnvironmentEntry {
+		prefix = filepath.Clean(prefix)
+		if entry, exists := prefixes[prefix]; exists {
+			return entry
+		}
+		entry := pathEntry(prefix, prefix)
+		prefixes[prefix] = &entry
+		return &entry
+	}
+	for _, prefix := range findWinePrefixes() {
+		prefixEntry(prefix).Detected = true
+	}
+	for _, game := range bm.GetGameList() {
+		if prefix := gamePrefix(game); prefix != "" {
+			entry := prefixEntry(prefix)
+			entry.Games = append(entry.Games, game.Name)
+		}
+	}
+	for _, prefix := range sortedKeys(prefixes) {
+		entry := prefixes[prefix]
+		if !entry.Detected && entry.Exists {
+			entry.Note = "no se detecta al escanear: fuera de las ubicaciones habituales"
+		}
+		report.WinePrefixes = append(report.WinePrefixes, *entry)
+	}
+	return report
+}
+
+// pathEntry comprueba si path existe y si se puede listar
+func pathEntry(name, path string) EnvironmentEntry {
+	entry := EnvironmentEntry{Name: name, Value: path}
+	if path == "" {
+		return entry
+	}
+	if _, err := os.Stat(path); err != nil {
+		return entry
+	}
+	entry.Exists = true
+	if dir, err := os.Open(path); err == nil {
+		_, err = dir.Readdirnames(1)
+		entry.Readable = err == nil || err == io.EOF
+		dir.Close()
+	}
+	return entry
+}
+
+// sortedKeys devuelve las claves de un mapa ordenadas
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
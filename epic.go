@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// epicManifestsDir es la carpeta de manifiestos de instalación del Epic Games Launcher
+const epicManifestsDir = "%PROGRAMDATA%/Epic/EpicGamesLauncher/Data/Manifests"
+
+// epicManifest son los campos usados de un manifiesto .item de Epic
+type epicManifest struct {
+	DisplayName         string `json:"DisplayName"`
+	AppName             string `json:"AppName"`
+	CatalogItemID       string `json:"CatalogItemId"`
+	InstallLocation     string `json:"InstallLocation"`
+	MainGameAppName     string `json:"MainGameAppName"`
+	IsIncompleteInstall bool   `json:"bIsIncompleteInstall"`
+}
+
+// epicManifestDirs devuelve las carpetas de manifiestos del sistema y de los prefijos de Wine
+func epicManifestDirs() []string {
+	dirs := []string{}
+	if os.Getenv("PROGRAMDATA") != "" {
+		dirs = append(dirs, filepath.FromSlash(ExpandPath(epicManifestsDir)))
+	}
+	for _, prefix := range findWinePrefixes() {
+		dirs = append(dirs, filepath.Join(prefix, "drive_c", "ProgramData", "Epic", "EpicGamesLauncher", "Data", "Manifests"))
+	}
+	return dirs
+}
+
+// readEpicManifests lee los juegos instalados desde los manifiestos .item de una carpeta
+func readEpicManifests(dir string) ([]epicManifest, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.item"))
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := []epicManifest{}
+	var errs []error
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		var manifest epicManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			errs = append(errs, fmt.Errorf("error leyendo %s: %v", filepath.Base(file), err))
+			continue
+		}
+
+		// Las DLC y las instalaciones a medias no son juegos
+		if manifest.IsIncompleteInstall || manifest.DisplayName == "" {
+			continue
+		}
+		if manifest.MainGameAppName != "" && manifest.MainGameAppName != manifest.AppName {
+			continue
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, errors.Join(errs...)
+}
+
+// epicManifestDetector detecta los juegos instalados con Epic y busca sus rutas de guardado en PCGamingWiki
+type epicManifestDetector struct{}
+
+func (epicManifestDetector) Name() string { return "epic-manifests" }
+
+func (epicManifestDetector) Detect(bm *BackupManager) ([]*GameInfo, error) {
+	games := []*GameInfo{}
+	var errs []error
+	for _, dir := range epicManifestDirs() {
+		manifests, err := readEpicManifests(dir)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		for _, manifest := range manifests {
+			slug := bm.generateGameIDFromName(manifest.DisplayName)
+			if _, exists := bm.findGameBySlug(slug); exists {
+				continue
+			}
+
+			game, err := bm.epicGameFromManifest(slug, manifest)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", manifest.DisplayName, err))
+				continue
+			}
+			if game != nil {
+				games = append(games, game)
+			}
+		}
+	}
+	return games, errors.Join(errs...)
+}
+
+// epicGameFromManifest resuelve las rutas de guardado de un juego de Epic. Devuelve nil si ninguna existe.
+func (bm *BackupManager) epicGameFromManifest(slug string, manifest epicManifest) (*GameInfo, error) {
+	if bm.PCGWClient == nil {
+		return nil, errPCGWUnreachable("cliente de PCGamingWiki no disponible", nil)
+	}
+
+	results, err := bm.PCGWClient.SearchGames(manifest.DisplayName)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	match := bestPCGWMatch(results, manifest.DisplayName)
+	savePaths := existingSavePaths(match.SavePaths, manifest.InstallLocation)
+	if len(savePaths) == 0 {
+		return nil, nil
+	}
+
+	return &GameInfo{
+		Slug:        slug,
+		Name:        manifest.DisplayName,
+		Platform:    "epic",
+		SavePaths:   savePaths,
+		Patterns:    SaveFilePatterns,
+		CustomPaths: []string{},
+		InstallDir:  manifest.InstallLocation,
+		Metadata: map[string]string{
+			"epic_app_name":   manifest.AppName,
+			"epic_catalog_id": manifest.CatalogItemID,
+			"pcgw_page_id":    match.PageID,
+			"steam_app_id":    match.SteamAppID,
+			"cover_url":       match.CoverURL,
+		},
+	}, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"time"
+)
+
+// ErrInsufficientHistory indica que no hay backups anteriores con duración registrada para estimar
+var ErrInsufficientHistory = errors.New("no hay suficientes backups anteriores para estimar la duración")
+
+// estimateSampleSize es cuántos backups recientes se usan para calcular la velocidad
+const estimateSampleSize = 5
+
+// EstimateBackupDuration estima cuánto tardará un backup del juego extrapolando el tamaño actual de sus
+// archivos con la velocidad (bytes por segundo) de sus últimos backups
+func (bm *BackupManager) EstimateBackupDuration(gameID string) (time.Duration, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return 0, errGameNotFound(gameID)
+	}
+
+	index, err := bm.loadBackupIndex(gameID)
+	if err != nil {
+		return 0, err
+	}
+
+	records := slices.Clone(index.Records)
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+
+	var sampleBytes int64
+	var sampleDuration time.Duration
+	samples := 0
+	for _, record := range records {
+		if record.Duration <= 0 || record.SourceBytes <= 0 {
+			continue
+		}
+		sampleBytes += record.SourceBytes
+		sampleDuration += record.Duration
+		if samples++; samples == estimateSampleSize {
+			break
+		}
+	}
+	if samples == 0 {
+		return 0, ErrInsufficientHistory
+	}
+
+	_, currentBytes, err := bm.countBackupFiles(game)
+	if err != nil {
+		return 0, err
+	}
+
+	bytesPerSecond := float64(sampleBytes) / sampleDuration.Seconds()
+	return time.Duration(float64(currentBytes) / bytesPerSecond * float64(time.Second)), nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestIsExcludedPath(t *testing.T) {
+	bm, _ := newTestManager(t)
+	bm.Config.ExcludePatterns = []string{"logs/**", "**/crashes", "cache/", "*.tmp"}
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"logs", true, true},
+		{"logs/today.txt", false, true},
+		{"logs/2026/10/today.txt", false, true},
+		{"Logs/today.txt", false, true}, // Sin distinguir mayúsculas, como isExcluded
+		{"profile/logs/today.txt", false, false},
+		{"logs.sav", false, false},
+		{"crashes", true, true},
+		{"profile/crashes", true, true},
+		{"profile/a/b/crashes", true, true},
+		{"profile/crashes.sav", false, false},
+		{"profile/crashes/dump.dmp", false, false}, // Se poda el directorio: su contenido nunca se evalúa
+		{"cache", true, true},
+		{"cache", false, false}, // "cache/" solo excluye directorios
+		{"profile/cache", true, false},
+		{"slot1.tmp", false, false}, // Los patrones sin "/" son de isExcluded
+	}
+	for _, test := range tests {
+		if got := bm.isExcludedPath(test.rel, test.isDir); got != test.want {
+			t.Errorf("isExcludedPath(%q, %v) = %v, se esperaba %v", test.rel, test.isDir, got, test.want)
+		}
+	}
+}
+
+// Los directorios excluidos se podan con fs.SkipDir en vez de recorrerse y descartar archivo por archivo
+func TestSkipEntryPrunesExcludedDirs(t *testing.T) {
+	bm, dir := newTestManager(t)
+	bm.Config.ExcludePatterns = []string{"logs/**", "**/crashes"}
+	root := filepath.Join(dir, "saves")
+	writeTestFiles(t, root, map[string]string{
+		"logs/today.txt":           "log",
+		"profile/crashes/dump.dmp": "dump",
+		"profile/slot1.sav":        "slot",
+	})
+	game := bm.DetectedGames["g"]
+
+	pruned := []string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		skip, err := bm.skipEntry(game, root, path, d)
+		if errors.Is(err, fs.SkipDir) {
+			rel, _ := filepath.Rel(root, path)
+			pruned = append(pruned, filepath.ToSlash(rel))
+		} else if skip {
+			t.Errorf("%s se descartó sin podar su directorio", path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(pruned)
+	if want := []string{"logs", "profile/crashes"}; !reflect.DeepEqual(pruned, want) {
+		t.Errorf("directorios podados %v, se esperaban %v", pruned, want)
+	}
+}
+
+func TestBackupExcludesSubdirectories(t *testing.T) {
+	bm, dir := newTestManager(t)
+	bm.Config.ExcludePatterns = []string{"logs/**", "**/crashes", "*.tmp"}
+	root := filepath.Join(dir, "saves")
+	writeTestFiles(t, root, map[string]string{
+		"logs/today.txt":             "log",
+		"logs/old/yesterday.txt":     "log",
+		"profile/crashes/dump.dmp":   "dump",
+		"profile/a/crashes/dump.dmp": "dump",
+		"profile/slot1.sav":          "slot",
+		"profile/slot1.tmp":          "tmp",
+		"profile/logs/keep.txt":      "no es la carpeta logs de la raíz",
+	})
+	game := bm.DetectedGames["g"]
+
+	want := []string{"a.sav", "profile/logs/keep.txt", "profile/slot1.sav", "sub/b.sav"}
+	if got := walkedNames(t, bm, game); !reflect.DeepEqual(got, want) {
+		t.Errorf("entradas %v, se esperaban %v", got, want)
+	}
+
+	info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := loadManifest(filepath.Join(bm.Config.BackupDir, game.Slug, info.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, file := range manifest.Files {
+		if file.Path != backupMetaEntryName {
+			names = append(names, file.Path)
+		}
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("manifiesto con %v, se esperaba %v", names, want)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsafeEntry indica una entrada de archivo que escribiría fuera del directorio de destino
+var ErrUnsafeEntry = errors.New("entrada de archivo no segura")
+
+// maxSymlinkTarget limita el tamaño del destino de un enlace simbólico dentro de un ZIP
+const maxSymlinkTarget = 4096
+
+// cleanEntryName normaliza el nombre de una entrada y rechaza rutas absolutas, letras de unidad y componentes ".."
+func cleanEntryName(name string) (string, error) {
+	// Algunos compresores de Windows usan "\" como separador
+	slashed := strings.ReplaceAll(name, `\`, "/")
+
+	if slashed == "" || strings.HasPrefix(slashed, "/") {
+		return "", fmt.Errorf("%w: ruta absoluta %q", ErrUnsafeEntry, name)
+	}
+	if len(slashed) >= 2 && slashed[1] == ':' {
+		return "", fmt.Errorf("%w: letra de unidad en %q", ErrUnsafeEntry, name)
+	}
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%w: componente \"..\" en %q", ErrUnsafeEntry, name)
+		}
+	}
+
+	cleaned := path.Clean(slashed)
+	if cleaned == "." || !filepath.IsLocal(filepath.FromSlash(cleaned)) {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeEntry, name)
+	}
+	return cleaned, nil
+}
+
+// safeJoin une un destino con el nombre de una entrada, garantizando que el resultado queda dentro del destino
+// incluso si algún directorio intermedio ya existente es un enlace simbólico
+func safeJoin(root, name string) (string, error) {
+	cleaned, err := cleanEntryName(name)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(root, filepath.FromSlash(cleaned))
+
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return target, nil // El destino aún no existe: no puede haber enlaces en él
+		}
+		return "", err
+	}
+
+	// Resolver el directorio existente más profundo del camino hacia el destino
+	dir := filepath.Dir(target)
+	for {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			if !isWithin(resolvedRoot, resolved) {
+				return "", fmt.Errorf("%w: %q sale del destino a través de un enlace simbólico", ErrUnsafeEntry, name)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if dir == root || len(dir) <= len(root) {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	// El propio archivo no puede ser un enlace existente hacia fuera
+	if info, err := os.Lstat(target); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		resolved, err := filepath.EvalSymlinks(target)
+		if err != nil || !isWithin(resolvedRoot, resolved) {
+			return "", fmt.Errorf("%w: %q es un enlace simbólico hacia fuera del destino", ErrUnsafeEntry, name)
+		}
+	}
+
+	return target, nil
+}
+
+// isWithin indica si path está dentro de root (o es root)
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// checkSymlinkTarget comprueba que un enlace simbólico de una entrada apunta dentro del archivo
+func checkSymlinkTarget(name, linkTarget string) error {
+	slashed := strings.ReplaceAll(linkTarget, `\`, "/")
+	if slashed == "" || strings.HasPrefix(slashed, "/") || (len(slashed) >= 2 && slashed[1] == ':') {
+		return fmt.Errorf("%w: el enlace %q apunta a una ruta absoluta", ErrUnsafeEntry, name)
+	}
+
+	cleanedName, err := cleanEntryName(name)
+	if err != nil {
+		return err
+	}
+	resolved := path.Join(path.Dir(cleanedName), slashed)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return fmt.Errorf("%w: el enlace %q apunta fuera del archivo", ErrUnsafeEntry, name)
+	}
+	return nil
+}
+
+// readSymlinkEntry lee el destino de un enlace simbólico guardado en un ZIP
+func readSymlinkEntry(open func() (io.ReadCloser, error)) (string, error) {
+	rc, err := open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxSymlinkTarget+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxSymlinkTarget {
+		return "", errors.New("destino de enlace simbólico demasiado largo")
+	}
+	return string(data), nil
+}
+
+// extractEntry escribe una entrada de archivo dentro de root aplicando todas las comprobaciones de seguridad
+// y verifica lo escrito según check
+func extractEntry(root string, entry backupFileEntry, check copyCheck) (string, error) {
+	target, err := safeJoin(root, entry.Name)
+	if err != nil {
+		return "", err
+	}
+
+	if entry.Mode&fs.ModeSymlink != 0 {
+		linkTarget, err := readSymlinkEntry(entry.Open)
+		if err != nil {
+			return "", err
+		}
+		if err := checkSymlinkTarget(entry.Name, linkTarget); err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return "", err
+		}
+		os.Remove(target)
+		return target, os.Symlink(filepath.FromSlash(linkTarget), target)
+	}
+
+	if !entry.Mode.IsRegular() {
+		return "", fmt.Errorf("%w: %q no es un archivo normal", ErrUnsafeEntry, entry.Name)
+	}
+	return target, writeRestoredFile(target, entry.Modified, entry.Open, check)
+}
+
+// validateArchive revisa todas las entradas de un ZIP antes de aceptarlo (p. ej. al importar)
+func validateArchive(zipPath string) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if _, err := cleanEntryName(file.Name); err != nil {
+			if file.FileInfo().IsDir() && path.Clean(strings.ReplaceAll(file.Name, `\`, "/")) == "." {
+				continue
+			}
+			return err
+		}
+
+		mode := file.Mode()
+		switch {
+		case mode&fs.ModeSymlink != 0:
+			linkTarget, err := readSymlinkEntry(file.Open)
+			if err != nil {
+				return err
+			}
+			if err := checkSymlinkTarget(file.Name, linkTarget); err != nil {
+				return err
+			}
+		case mode.IsDir(), mode.IsRegular():
+		default:
+			return fmt.Errorf("%w: %q no es un archivo normal", ErrUnsafeEntry, file.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// testZipEntry es una entrada de un ZIP de prueba; con Symlink, Body es el destino del enlace
+type testZipEntry struct {
+	Name    string
+	Body    string
+	Symlink bool
+}
+
+// writeTestZip crea un ZIP con las entradas tal cual, sin normalizar sus nombres
+func writeTestZip(t *testing.T, path string, entries []testZipEntry) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.Name, Method: zip.Deflate}
+		if entry.Symlink {
+			header.SetMode(fs.ModeSymlink | 0777)
+		} else {
+			header.SetMode(0644)
+		}
+		writer, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(entry.Body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// maliciousArchives son archivos que intentan escribir fuera del destino al extraerlos
+var maliciousArchives = map[string][]testZipEntry{
+	"dot-dot":         {{Name: "ok.sav", Body: "ok"}, {Name: "../escape.txt", Body: "x"}},
+	"nested dot-dot":  {{Name: "saves/../../escape.txt", Body: "x"}},
+	"backslash":       {{Name: `..\escape.txt`, Body: "x"}},
+	"absolute":        {{Name: "/tmp/escape.txt", Body: "x"}},
+	"absolute win":    {{Name: `\Windows\escape.txt`, Body: "x"}},
+	"drive letter":    {{Name: "C:/escape.txt", Body: "x"}},
+	"drive relative":  {{Name: "c:escape.txt", Body: "x"}},
+	"symlink out":     {{Name: "link", Body: "../../outside", Symlink: true}},
+	"symlink abs":     {{Name: "link", Body: "/etc/passwd", Symlink: true}},
+	"symlink drive":   {{Name: "link", Body: `C:\Windows\System32`, Symlink: true}},
+	"symlink nested":  {{Name: "a/b/link", Body: "../../../outside", Symlink: true}},
+	"symlink in path": {{Name: "out", Body: "..", Symlink: true}, {Name: "out/escape.txt", Body: "x"}},
+}
+
+func TestCleanEntryName(t *testing.T) {
+	valid := map[string]string{
+		"save.sav":           "save.sav",
+		"dir/sub/save.sav":   "dir/sub/save.sav",
+		`dir\save.sav`:       "dir/save.sav",
+		"./dir//save.sav":    "dir/save.sav",
+		"dir/./save.sav":     "dir/save.sav",
+		"..save/file.sav":    "..save/file.sav", // Empieza por ".." pero no es el componente ".."
+		"dir/..hidden.sav":   "dir/..hidden.sav",
+		"Program Files/x.cf": "Program Files/x.cf",
+	}
+	for name, want := range valid {
+		got, err := cleanEntryName(name)
+		if err != nil || got != want {
+			t.Errorf("cleanEntryName(%q) = %q, %v; se esperaba %q", name, got, err, want)
+		}
+	}
+
+	invalid := []string{
+		"", ".", "./", "..", "../x", "a/../../x", "a/..", `..\x`, `a\..\..\x`,
+		"/etc/passwd", `\Windows\win.ini`, "//server/share/x",
+		"C:/x", `C:\x`, "c:x", "Z:",
+	}
+	for _, name := range invalid {
+		if got, err := cleanEntryName(name); !errors.Is(err, ErrUnsafeEntry) {
+			t.Errorf("cleanEntryName(%q) = %q, %v; se esperaba ErrUnsafeEntry", name, got, err)
+		}
+	}
+}
+
+func TestCheckSymlinkTarget(t *testing.T) {
+	tests := []struct {
+		name, target string
+		ok           bool
+	}{
+		{"link", "save.sav", true},
+		{"dir/link", "../save.sav", true},
+		{"a/b/link", "../../save.sav", true},
+		{"dir/link", "sub/../save.sav", true},
+		{"link", "../outside", false},
+		{"dir/link", "../../outside", false},
+		{"a/b/link", "../../../outside", false},
+		{"link", `..\outside`, false},
+		{"link", "/etc/passwd", false},
+		{"link", `\Windows`, false},
+		{"link", `C:\Windows`, false},
+		{"link", "c:relative", false},
+		{"link", "", false},
+		{"../link", "save.sav", false},
+	}
+	for _, test := range tests {
+		err := checkSymlinkTarget(test.name, test.target)
+		if test.ok && err != nil {
+			t.Errorf("checkSymlinkTarget(%q, %q): %v", test.name, test.target, err)
+		}
+		if !test.ok && !errors.Is(err, ErrUnsafeEntry) {
+			t.Errorf("checkSymlinkTarget(%q, %q) = %v, se esperaba ErrUnsafeEntry", test.name, test.target, err)
+		}
+	}
+}
+
+func TestSafeJoinExistingSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	outside := filepath.Join(dir, "outside")
+	writeTestFiles(t, root, map[string]string{"inside/file.sav": "ok"})
+	writeTestFiles(t, outside, map[string]string{"secret.txt": "x"})
+	if err := os.Symlink(outside, filepath.Join(root, "out")); err != nil {
+		t.Skipf("no se pueden crear enlaces simbólicos: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "secret.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("inside", filepath.Join(root, "alias")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"out/new.sav", "out/deeper/new.sav", "secret.txt", "../x", "/x"} {
+		if target, err := safeJoin(root, name); !errors.Is(err, ErrUnsafeEntry) {
+			t.Errorf("safeJoin(%q) = %q, %v; se esperaba ErrUnsafeEntry", name, target, err)
+		}
+	}
+	for name, want := range map[string]string{
+		"inside/file.sav": filepath.Join(root, "inside", "file.sav"),
+		"alias/new.sav":   filepath.Join(root, "alias", "new.sav"), // El enlace apunta dentro del destino
+		"new/dir/x.sav":   filepath.Join(root, "new", "dir", "x.sav"),
+	} {
+		if got, err := safeJoin(root, name); err != nil || got != want {
+			t.Errorf("safeJoin(%q) = %q, %v; se esperaba %q", name, got, err, want)
+		}
+	}
+}
+
+func TestValidateArchive(t *testing.T) {
+	dir := t.TempDir()
+	for name, entries := range maliciousArchives {
+		path := filepath.Join(dir, name+".zip")
+		writeTestZip(t, path, entries)
+		if err := validateArchive(path); !errors.Is(err, ErrUnsafeEntry) {
+			t.Errorf("%s: validateArchive = %v, se esperaba ErrUnsafeEntry", name, err)
+		}
+	}
+
+	good := filepath.Join(dir, "good.zip")
+	writeTestZip(t, good, []testZipEntry{
+		{Name: "save.sav", Body: "ok"},
+		{Name: `profiles\1\slot.sav`, Body: "ok"},
+		{Name: "profiles/latest", Body: "1", Symlink: true},
+	})
+	if err := validateArchive(good); err != nil {
+		t.Errorf("good.zip: %v", err)
+	}
+}
+
+func TestImportBackupRejectsMaliciousArchives(t *testing.T) {
+	bm, dir := newTestManager(t)
+	for name, entries := range maliciousArchives {
+		path := filepath.Join(dir, "import", name+".zip")
+		writeTestZip(t, path, entries)
+		if _, err := bm.ImportBackup("g", path, time.Time{}); !errors.Is(err, ErrUnsafeEntry) {
+			t.Errorf("%s: ImportBackup = %v, se esperaba ErrUnsafeEntry", name, err)
+		}
+	}
+	if backups, _ := bm.listBackups("g"); len(backups) != 0 {
+		t.Errorf("se importaron %d backups hostiles", len(backups))
+	}
+}
+
+func TestRestoreRejectsMaliciousArchives(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("los enlaces simbólicos necesitan permisos especiales en Windows")
+	}
+	for name, entries := range maliciousArchives {
+		t.Run(name, func(t *testing.T) {
+			bm, dir := newTestManager(t)
+			backupName := "g_2024-01-02_03-04-05.zip"
+			writeTestZip(t, filepath.Join(bm.Config.BackupDir, "g", backupName), entries)
+
+			_, err := bm.RestoreBackup("g", backupName, RestoreOptions{Force: true, Mode: RestoreOverwrite})
+			if !errors.Is(err, ErrUnsafeEntry) {
+				t.Errorf("RestoreBackup = %v, se esperaba ErrUnsafeEntry", err)
+			}
+			for _, escaped := range []string{filepath.Join(dir, "escape.txt"), filepath.Join(dir, "saves", "link")} {
+				if _, err := os.Lstat(escaped); err == nil {
+					t.Errorf("la restauración creó %s", escaped)
+				}
+			}
+			if data, err := os.ReadFile(filepath.Join(dir, "saves", "a.sav")); err != nil || string(data) != "aaa" {
+				t.Errorf("la restauración rechazada cambió las partidas: %q, %v", data, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// defaultFileLockRetries es el número de reintentos por defecto al abrir un archivo de partida bloqueado
+const defaultFileLockRetries = 5
+
+// fileLockBaseDelay es la espera antes del primer reintento; se duplica en cada uno (100 ms, 200 ms, 400 ms...)
+const fileLockBaseDelay = 100 * time.Millisecond
+
+// openSaveFile abre un archivo de partida reintentando mientras otro proceso lo tenga bloqueado.
+// Un juego que se está cerrando puede mantener bloqueadas sus partidas unos instantes (sobre todo en Windows).
+func (bm *BackupManager) openSaveFile(path string) (*os.File, error) {
+	delay := fileLockBaseDelay
+	for attempt := 0; ; attempt++ {
+		file, err := os.Open(path)
+		if err == nil || !isFileLocked(err) || attempt >= bm.config().FileLockRetries {
+			return file, err
+		}
+		log.Printf("%s está bloqueado por otro proceso, reintentando en %v", path, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// copySaveFile copia y verifica un archivo de partida reintentando si está bloqueado y respetando el límite de E/S
+func (bm *BackupManager) copySaveFile(src, dst string, limit *ioLimiter) error {
+	srcFile, err := bm.openSaveFile(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+	_, err = copyVerified(limit.reader(srcFile), dst, bm.copyCheck(""))
+	return err
+}
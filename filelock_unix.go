@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// isFileLocked indica si un error al abrir un archivo se debe a que otro proceso lo tiene bloqueado.
+// Fuera de Windows los bloqueos son orientativos y no impiden abrir el archivo.
+func isFileLocked(err error) bool {
+	return false
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// GameFilter selecciona juegos de la biblioteca; los criterios vacíos no filtran
+type GameFilter struct {
+	Tag         string `json:"tag"`          // Juegos con esta etiqueta
+	Platform    string `json:"platform"`     // "steam", "epic", "custom"...
+	ChangedOnly bool   `json:"changed_only"` // Solo juegos con cambios desde su último backup (o sin backups)
+}
+
+// FilterGames devuelve los juegos que cumplen el filtro, ordenados por nombre
+func (bm *BackupManager) FilterGames(filter GameFilter) []*GameInfo {
+	games := bm.GetGamesByTag(filter.Tag)
+	matched := []*GameInfo{}
+	for _, game := range games {
+		if filter.Platform != "" && !strings.EqualFold(game.Platform, filter.Platform) {
+			continue
+		}
+		if filter.ChangedOnly && bm.unchangedSinceLastBackup(game.ID) {
+			continue
+		}
+		matched = append(matched, game)
+	}
+	return matched
+}
+
+// BackupGamesByFilter encola un backup de cada juego que cumple el filtro y devuelve los IDs de los trabajos
+// para seguir su progreso (GetOperationStatus). Los juegos sin cambios desde su último backup se omiten siempre,
+// como en CreateBackupForSelectedGames.
+func (bm *BackupManager) BackupGamesByFilter(filter GameFilter) ([]string, error) {
+	if filter.Tag == "" && filter.Platform == "" && !filter.ChangedOnly {
+		return nil, fmt.Errorf("el filtro no tiene ningún criterio: usa la cola para respaldar toda la biblioteca")
+	}
+	filter.ChangedOnly = true
+
+	jobIDs := []string{}
+	for _, game := range bm.FilterGames(filter) {
+		jobID, err := bm.EnqueueBackup(game.ID, BackupOptions{Trigger: "manual"})
+		if err != nil {
+			return jobIDs, fmt.Errorf("error encolando backup de %s: %v", game.Name, err)
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	log.Printf("Backups encolados por filtro %+v: %d juegos", filter, len(jobIDs))
+	return jobIDs, nil
+}
+
+// unchangedSinceLastBackup indica si un juego ya tiene backup y sus partidas no cambiaron desde entonces
+func (bm *BackupManager) unchangedSinceLastBackup(gameID string) bool {
+	diff, err := bm.GetChangesSinceLastBackup(gameID)
+	return err == nil && !diff.NoPreviousBackup && !diff.hasChanges()
+}
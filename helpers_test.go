@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestManager crea un BackupManager en una carpeta temporal con el juego "g", cuyos archivos de partida son
+// saves/a.sav y saves/sub/b.sav. Devuelve el gestor y la carpeta temporal.
+func newTestManager(t testing.TB) (*BackupManager, string) {
+	t.Helper()
+	dir := t.TempDir()
+	saves := filepath.Join(dir, "saves")
+	writeTestFiles(t, saves, map[string]string{
+		"a.sav":     "aaa",
+		"sub/b.sav": "bbb",
+	})
+
+	bm := &BackupManager{
+		Config: BackupConfig{
+			BackupDir:          filepath.Join(dir, "backups"),
+			MaxBackups:         5,
+			CompressionEnabled: true,
+		},
+		DetectedGames: make(map[string]*GameInfo),
+		DatabasePath:  filepath.Join(dir, "game_saves.json"),
+	}
+	bm.DetectedGames["g"] = &GameInfo{
+		ID:        "g",
+		Name:      "G",
+		Slug:      "g",
+		SavePaths: []string{saves},
+		Patterns:  []string{"*"},
+		Metadata:  make(map[string]string),
+	}
+	return bm, dir
+}
+
+// writeTestFiles crea en dir los archivos de files (ruta relativa con / -> contenido)
+func writeTestFiles(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupTimestampLayout es el formato de fecha usado en los nombres de los backups
+const backupTimestampLayout = "2006-01-02_15-04-05"
+
+// parseBackupName extrae la fecha de un nombre de backup con el formato <carpeta>_<timestamp>[.zip]
+func parseBackupName(folder, name string) (time.Time, bool) {
+	base := strings.TrimSuffix(name, ".zip")
+	prefix := folder + "_"
+	if !strings.HasPrefix(base, prefix) {
+		return time.Time{}, false
+	}
+
+	created, err := time.ParseInLocation(backupTimestampLayout, strings.TrimPrefix(base, prefix), time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return created, true
+}
+
+// listBackups devuelve los backups de un juego ordenados del más reciente al más antiguo
+func (bm *BackupManager) listBackups(gameID string) ([]BackupInfo, error) {
+	return bm.backupStore().List(gameID)
+}
+
+// backupSize calcula el tamaño de un backup, recorriendo la carpeta si no está comprimido
+func backupSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// historyFileName es el índice por juego con los registros de cada backup
+const historyFileName = "history.json"
+
+// BackupRecord es la entrada del índice de historial de un backup
+type BackupRecord struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Source    string    `json:"source"` // "backup" o "import"
+	Size      int64     `json:"size"`
+	FileCount int       `json:"file_count"`
+	Pinned    bool      `json:"pinned"` // Los backups fijados no se eliminan por rotación ni cuota
+
+	Duration    time.Duration `json:"duration,omitempty"`     // Tiempo que tardó el backup (0 en importados y antiguos)
+	Throttled   bool          `json:"throttled,omitempty"`    // Se creó con el límite de E/S de los backups automáticos
+	SourceBytes int64         `json:"source_bytes,omitempty"` // Tamaño de los archivos de guardado respaldados, sin comprimir
+
+	SaveInfo *SaveMetadata `json:"save_info,omitempty"` // Contexto de la partida extraído al crear el backup
+
+	WholeProfile bool `json:"whole_profile,omitempty"` // Contiene todo el perfil del prefijo, no solo las partidas del juego
+
+	VerifiedAt       time.Time `json:"verified_at,omitempty"` // Última verificación contra el manifiesto
+	Quarantined      bool      `json:"quarantined,omitempty"` // Dañado: movido a la carpeta de cuarentena
+	QuarantineReason string    `json:"quarantine_reason,omitempty"`
+	QuarantinedAt    time.Time `json:"quarantined_at,omitempty"`
+}
+
+// backupIndex es el contenido de history.json
+type backupIndex struct {
+	Records []BackupRecord `json:"records"`
+
+	Restores     []RestoreRecord `json:"restores,omitempty"`      // Más reciente primero
+	CurrentState string          `json:"current_state,omitempty"` // Backup restaurado al que corresponden los archivos actuales
+}
+
+// loadBackupIndex lee el índice de historial de un juego (vacío si no existe)
+func (bm *BackupManager) loadBackupIndex(gameID string) (*backupIndex, error) {
+	index := &backupIndex{Records: []BackupRecord{}}
+
+	data, err := os.ReadFile(filepath.Join(bm.gameBackupDir(gameID), historyFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("error leyendo historial de %s: %v", gameID, err)
+	}
+	return index, nil
+}
+
+// saveBackupIndex guarda el índice de historial de un juego
+func (bm *BackupManager) saveBackupIndex(gameID string, index *backupIndex) error {
+	sort.Slice(index.Records, func(i, j int) bool {
+		return index.Records[i].CreatedAt.After(index.Records[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(bm.gameBackupDir(gameID), historyFileName), data, 0644)
+}
+
+// find devuelve el registro de un backup por nombre
+func (idx *backupIndex) find(name string) *BackupRecord {
+	for i := range idx.Records {
+		if idx.Records[i].Name == name {
+			return &idx.Records[i]
+		}
+	}
+	return nil
+}
+
+// lastRestored devuelve cuándo se restauró por última vez un backup (cero si nunca)
+func (idx *backupIndex) lastRestored(name string) time.Time {
+	for _, restore := range idx.Restores {
+		if restore.Backup == name {
+			return restore.RestoredAt
+		}
+	}
+	return time.Time{}
+}
+
+// upsert agrega o reemplaza el registro de un backup
+func (idx *backupIndex) upsert(record BackupRecord) {
+	if existing := idx.find(record.Name); existing != nil {
+		*existing = record
+		return
+	}
+	idx.Records = append(idx.Records, record)
+}
+
+// remove elimina el registro de un backup
+func (idx *backupIndex) remove(name string) {
+	for i := range idx.Records {
+		if idx.Records[i].Name == name {
+			idx.Records = append(idx.Records[:i], idx.Records[i+1:]...)
+			return
+		}
+	}
+}
+
+// registerBackup genera el manifiesto de un backup y lo registra en el historial del juego
+func (bm *BackupManager) registerBackup(gameID, backupPath, source string, createdAt time.Time) (*BackupRecord, error) {
+	manifest, err := buildManifest(gameID, backupPath)
+	if err != nil {
+		return nil, err
+	}
+	manifest.CreatedAt = createdAt
+	if err := writeManifest(backupPath, manifest); err != nil {
+		return nil, fmt.Errorf("error guardando manifiesto: %v", err)
+	}
+	if bm.config().ContentIndex {
+		if err := writeContentIndex(backupPath, manifest); err != nil {
+			log.Printf("Error guardando el índice de %s: %v", filepath.Base(backupPath), err)
+		}
+	}
+	return bm.recordBackup(gameID, backupPath, source, createdAt, manifest)
+}
+
+// recordBackup registra en el historial del juego un backup ya guardado con su manifiesto
+func (bm *BackupManager) recordBackup(gameID, backupPath, source string, createdAt time.Time, manifest *BackupManifest) (*BackupRecord, error) {
+	record := BackupRecord{
+		Name:      filepath.Base(backupPath),
+		CreatedAt: createdAt,
+		Source:    source,
+		Size:      backupSize(backupPath),
+		FileCount: len(manifest.Files),
+	}
+
+	index, err := bm.loadBackupIndex(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if existing := index.find(record.Name); existing != nil {
+		record.Pinned = existing.Pinned
+		record.SaveInfo = existing.SaveInfo
+		record.WholeProfile = existing.WholeProfile
+	}
+	index.upsert(record)
+	// Un backup nuevo de los archivos actuales sustituye a la marca de restauración
+	if source == "backup" {
+		index.CurrentState = ""
+	}
+	if err := bm.saveBackupIndex(gameID, index); err != nil {
+		return nil, fmt.Errorf("error guardando historial: %v", err)
+	}
+
+	return &record, nil
+}
+
+// updateBackupRecord modifica el registro de un backup existente en el historial
+func (bm *BackupManager) updateBackupRecord(gameID, name string, update func(*BackupRecord)) error {
+	index, err := bm.loadBackupIndex(gameID)
+	if err != nil {
+		return err
+	}
+
+	record := index.find(name)
+	if record == nil {
+		return fmt.Errorf("backup %s no registrado en el historial de %s", name, gameID)
+	}
+	update(record)
+
+	return bm.saveBackupIndex(gameID, index)
+}
+
+// upsertBackupRecord modifica el registro de un backup, creándolo si el backup es anterior al historial
+func (bm *BackupManager) upsertBackupRecord(gameID, name, backupPath string, update func(*BackupRecord)) error {
+	index, err := bm.loadBackupIndex(gameID)
+	if err != nil {
+		return err
+	}
+
+	record := index.find(name)
+	if record == nil {
+		created, _ := parseBackupName(bm.backupFolder(gameID), name)
+		index.upsert(BackupRecord{Name: name, CreatedAt: created, Source: "backup", Size: backupSize(backupPath)})
+		record = index.find(name)
+	}
+	update(record)
+
+	return bm.saveBackupIndex(gameID, index)
+}
+
+// removeBackup elimina un backup junto con su manifiesto y su registro en el historial
+func (bm *BackupManager) removeBackup(gameID string, backup BackupInfo) error {
+	return bm.backupStore().Delete(gameID, backup)
+}
+
+// GlobalBackupEntry es un backup dentro del listado global de actividad
+type GlobalBackupEntry struct {
+	GameID   string    `json:"game_id"`
+	GameName string    `json:"game_name"`
+	Backup   string    `json:"backup"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Created  time.Time `json:"created"`
+	Pinned   bool      `json:"pinned"`
+
+	WholeProfile bool      `json:"whole_profile"`
+	LastVerified time.Time `json:"last_verified"`
+
+	Quarantined      bool   `json:"quarantined"`
+	QuarantineReason string `json:"quarantine_reason,omitempty"`
+}
+
+// GetBackupHistory devuelve los backups de un juego del más reciente al más antiguo,
+// incluidos los que están en cuarentena (marcados, no se pueden restaurar)
+func (bm *BackupManager) GetBackupHistory(gameID string) ([]BackupInfo, error) {
+	if _, exists := bm.getGame(gameID); !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	backups, err := bm.listBackups(gameID)
+	if err != nil {
+		return nil, err
+	}
+	quarantined, err := bm.listQuarantinedBackups(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if len(quarantined) == 0 {
+		return backups, nil
+	}
+
+	backups = append(backups, quarantined...)
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+	return backups, nil
+}
+
+// GetAllBackups recorre las carpetas de backup de todos los juegos y devuelve una lista ordenada por fecha
+func (bm *BackupManager) GetAllBackups() ([]GlobalBackupEntry, error) {
+	dirs, err := os.ReadDir(bm.config().BackupDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []GlobalBackupEntry{}, nil
+		}
+		return nil, err
+	}
+
+	entries := []GlobalBackupEntry{}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+
+		// Los backups de juegos eliminados de la base de datos también se listan, con la carpeta como ID
+		gameID, gameName := dir.Name(), dir.Name()
+		if game, exists := bm.findGameBySlug(dir.Name()); exists {
+			gameID, gameName = game.ID, game.Name
+		}
+
+		backups, err := bm.listBackups(gameID)
+		if err != nil {
+			return nil, fmt.Errorf("error listando backups de %s: %v", dir.Name(), err)
+		}
+
+		for _, backup := range backups {
+			entries = append(entries, GlobalBackupEntry{
+				GameID:   gameID,
+				GameName: gameName,
+				Backup:   backup.Name,
+				Path:     backup.Path,
+				Size:     backup.Size,
+				Created:  backup.Created,
+				Pinned:   backup.Pinned,
+
+				WholeProfile: backup.WholeProfile,
+				LastVerified: backup.LastVerified,
+			})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Created.After(entries[j].Created)
+	})
+
+	return entries, nil
+}
+
+// SetBackupPinned fija o libera un backup para protegerlo de la rotación y de la cuota
+func (bm *BackupManager) SetBackupPinned(gameID, fileName string, pinned bool) error {
+	backupPath, err := bm.resolveBackupFile(gameID, fileName)
+	if err != nil {
+		return err
+	}
+
+	return bm.upsertBackupRecord(gameID, fileName, backupPath, func(record *BackupRecord) {
+		record.Pinned = pinned
+	})
+}
+
+// quotaEvictedEvent se emite por cada backup eliminado al aplicar la cuota
+const quotaEvictedEvent = "backup:quota-evicted"
+
+// enforceBackupQuota elimina los backups más antiguos no fijados de todos los juegos hasta quedar bajo la cuota.
+// El backup más reciente de cada juego nunca se elimina.
+func (bm *BackupManager) enforceBackupQuota() ([]GlobalBackupEntry, error) {
+	quota := bm.config().MaxTotalBackupSize
+	if quota <= 0 {
+		return nil, nil
+	}
+
+	entries, err := bm.GetAllBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+	if total <= quota {
+		return nil, nil
+	}
+
+	// entries está ordenado del más reciente al más antiguo
+	latest := make(map[string]bool)
+	var candidates []GlobalBackupEntry
+	for _, entry := range entries {
+		if !latest[entry.GameID] {
+			latest[entry.GameID] = true
+			continue
+		}
+		if !entry.Pinned {
+			candidates = append(candidates, entry)
+		}
+	}
+
+	evicted := []GlobalBackupEntry{}
+	for i := len(candidates) - 1; i >= 0 && total > quota; i-- {
+		entry := candidates[i]
+		backup := BackupInfo{Name: entry.Backup, Path: entry.Path}
+		if err := bm.removeBackup(entry.GameID, backup); err != nil {
+			log.Printf("Error eliminando backup %s por cuota: %v", entry.Path, err)
+			continue
+		}
+
+		total -= entry.Size
+		evicted = append(evicted, entry)
+		log.Printf("Backup eliminado por cuota: %s (%d bytes)", entry.Path, entry.Size)
+		bm.emit(quotaEvictedEvent, entry)
+	}
+
+	if total > quota {
+		log.Printf("La cuota de backups sigue excedida (%d de %d bytes): solo quedan backups fijados o recientes", total, quota)
+	}
+
+	return evicted, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// byteUnits son las unidades de formatBytes, en potencias de 1024
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+
+// pointDecimalLanguages son los idiomas que escriben los decimales con punto; el resto (como el español) con coma
+var pointDecimalLanguages = map[string]bool{
+	"en": true, "ja": true, "zh": true, "ko": true, "he": true, "th": true, "hi": true,
+}
+
+// decimalSeparator devuelve el separador decimal de un idioma ("es", "en-US", "pt_BR"...); vacío = español
+func decimalSeparator(locale string) string {
+	language, _, _ := strings.Cut(strings.ReplaceAll(strings.ToLower(locale), "_", "-"), "-")
+	if pointDecimalLanguages[language] {
+		return "."
+	}
+	return ","
+}
+
+// formatDecimal escribe value con decimals decimales y el separador del idioma
+func formatDecimal(value float64, decimals int, locale string) string {
+	return strings.Replace(strconv.FormatFloat(value, 'f', decimals, 64), ".", decimalSeparator(locale), 1)
+}
+
+// formatBytes escribe un tamaño en unidades binarias: "512 B", "1,5 MiB", "12 GiB" (sin decimales desde 10)
+func formatBytes(size int64, locale string) string {
+	if size < 1024 && size > -1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size)
+	unit := 0
+	for (value >= 1024 || value <= -1024) && unit < len(byteUnits)-1 {
+		value /= 1024
+		unit++
+	}
+	decimals := 1
+	if value >= 10 || value <= -10 {
+		decimals = 0
+	}
+	return formatDecimal(value, decimals, locale) + " " + byteUnits[unit]
+}
+
+// formatDuration escribe una duración para mostrarla: "850 ms", "12,5 s", "4 min 05 s", "2 h 03 min"
+func formatDuration(d time.Duration, locale string) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%d ms", d.Milliseconds())
+	case d < time.Minute:
+		return formatDecimal(d.Seconds(), 1, locale) + " s"
+	case d < time.Hour:
+		d = d.Round(time.Second)
+		return fmt.Sprintf("%d min %02d s", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%d h %02d min", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// humanGames devuelve copias de los juegos con HumanSize para el frontend; los de la biblioteca no se tocan
+// (HumanSize no debe acabar en la base de datos)
+func (bm *BackupManager) humanGames(games []*GameInfo) []*GameInfo {
+	copies := make([]*GameInfo, len(games))
+	for i, game := range games {
+		copies[i] = bm.gameSnapshot(game)
+		copies[i].HumanSize = formatBytes(copies[i].TotalSize, bm.config().Locale)
+	}
+	return copies
+}
+
+// humanBackups rellena HumanSize de una lista de backups
+func (bm *BackupManager) humanBackups(backups []BackupInfo) []BackupInfo {
+	for i := range backups {
+		backups[i].HumanSize = formatBytes(backups[i].Size, bm.config().Locale)
+	}
+	return backups
+}
+
+// humanScan rellena HumanDuration de un escaneo y cambia sus juegos por copias con HumanSize
+func (bm *BackupManager) humanScan(result *ScanResult) *ScanResult {
+	if result == nil {
+		return nil
+	}
+	result.HumanDuration = formatDuration(result.ScanTime, bm.config().Locale)
+	result.NewGames = bm.humanGames(result.NewGames)
+	result.Updated = bm.humanGames(result.Updated)
+	return result
+}
+
+// humanSummary rellena HumanSize de un resumen de uso y de su juego con más backups
+func (bm *BackupManager) humanSummary(summary *UsageSummary) *UsageSummary {
+	if summary == nil {
+		return nil
+	}
+	summary.HumanSize = formatBytes(summary.Bytes, bm.config().Locale)
+	if summary.MostBackedUp != nil {
+		summary.MostBackedUp.HumanSize = formatBytes(summary.MostBackedUp.Bytes, bm.config().Locale)
+	}
+	return summary
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size   int64
+		locale string
+		want   string
+	}{
+		{0, "es", "0 B"},
+		{512, "es", "512 B"},
+		{1023, "es", "1023 B"},
+		{1024, "es", "1,0 KiB"},
+		{1536, "es", "1,5 KiB"},
+		{1536, "en", "1.5 KiB"},
+		{1536, "en-US", "1.5 KiB"},
+		{1536, "pt_BR", "1,5 KiB"},
+		{1536, "", "1,5 KiB"},
+		{10 << 20, "es", "10 MiB"},
+		{1073741824, "en", "1.0 GiB"},
+		{3 << 30, "es", "3,0 GiB"},
+		{12 << 30, "es", "12 GiB"},
+		{5 << 40, "en", "5.0 TiB"},
+		{2048 << 50, "es", "2048 PiB"}, // No hay unidad mayor que PiB
+		{-1536, "es", "-1,5 KiB"},
+		{-100, "es", "-100 B"},
+	}
+	for _, test := range tests {
+		if got := formatBytes(test.size, test.locale); got != test.want {
+			t.Errorf("formatBytes(%d, %q) = %q, se esperaba %q", test.size, test.locale, got, test.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d      time.Duration
+		locale string
+		want   string
+	}{
+		{0, "es", "0 ms"},
+		{850 * time.Millisecond, "es", "850 ms"},
+		{time.Second, "es", "1,0 s"},
+		{12300 * time.Millisecond, "es", "12,3 s"},
+		{12300 * time.Millisecond, "en", "12.3 s"},
+		{time.Minute, "es", "1 min 00 s"},
+		{4*time.Minute + 5*time.Second, "es", "4 min 05 s"},
+		{4*time.Minute + 5600*time.Millisecond, "es", "4 min 06 s"},
+		{time.Hour, "es", "1 h 00 min"},
+		{time.Hour + 2*time.Minute, "en", "1 h 02 min"},
+		{26*time.Hour + 59*time.Minute + 40*time.Second, "es", "27 h 00 min"},
+	}
+	for _, test := range tests {
+		if got := formatDuration(test.d, test.locale); got != test.want {
+			t.Errorf("formatDuration(%v, %q) = %q, se esperaba %q", test.d, test.locale, got, test.want)
+		}
+	}
+}
+
+// Los campos Human* se rellenan en copias: los juegos de la biblioteca no cambian
+func TestHumanGamesCopies(t *testing.T) {
+	bm, _ := newTestManager(t)
+	bm.Config.Locale = "en"
+	game := bm.DetectedGames["g"]
+	game.TotalSize = 1536
+
+	copies := bm.humanGames([]*GameInfo{game})
+	if copies[0].HumanSize != "1.5 KiB" || copies[0].TotalSize != 1536 {
+		t.Errorf("copia con HumanSize %q y TotalSize %d", copies[0].HumanSize, copies[0].TotalSize)
+	}
+	if game.HumanSize != "" {
+		t.Errorf("el juego de la biblioteca tiene HumanSize %q", game.HumanSize)
+	}
+
+	scan := bm.humanScan(&ScanResult{ScanTime: 850 * time.Millisecond, NewGames: []*GameInfo{game}})
+	if scan.HumanDuration != "850 ms" || scan.NewGames[0].HumanSize != "1.5 KiB" {
+		t.Errorf("escaneo con HumanDuration %q y HumanSize %q", scan.HumanDuration, scan.NewGames[0].HumanSize)
+	}
+	if game.HumanSize != "" {
+		t.Errorf("humanScan cambió el juego de la biblioteca: %q", game.HumanSize)
+	}
+}
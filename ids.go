@@ -0,0 +1,201 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxPathComponentLen deja margen para el sufijo de fecha, la extensión y el manifiesto de cada backup
+const maxPathComponentLen = 100
+
+// windowsReservedNames no se pueden usar como nombre de archivo en Windows, ni siquiera con extensión
+var windowsReservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// sanitizePathComponent convierte un texto en un componente de ruta válido en Windows, macOS y Linux.
+// Todo nombre que acabe formando parte de una ruta (IDs de juego, carpetas de backup) debe pasar por aquí.
+func sanitizePathComponent(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			b.WriteRune('-')
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	// Windows ignora los puntos y espacios finales, lo que provoca colisiones y rutas inaccesibles
+	cleaned := strings.TrimRight(strings.TrimSpace(b.String()), ". ")
+	if cleaned == "" {
+		return "unknown-game"
+	}
+
+	if len(cleaned) > maxPathComponentLen {
+		cut := maxPathComponentLen
+		for cut > 0 && !utf8.RuneStart(cleaned[cut]) {
+			cut--
+		}
+		cleaned = strings.TrimRight(cleaned[:cut], ". ")
+	}
+
+	base := cleaned
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+	if windowsReservedNames[strings.ToLower(base)] {
+		cleaned = "_" + cleaned
+	}
+
+	return cleaned
+}
+
+// slugifyName convierte un título en un identificador en minúsculas sin acentos ni signos de puntuación
+func slugifyName(name string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), name)
+	if err != nil {
+		folded = name
+	}
+
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(folded) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if r == '\'' || r == '’' || r == '.' {
+			continue // "Assassin's", "S.T.A.L.K.E.R." sin guiones sueltos
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimRight(b.String(), "-")
+}
+
+// newGameID genera el ID estable de un juego nuevo, independiente de su nombre y de sus rutas
+func newGameID() string {
+	return uuid.NewString()
+}
+
+// backupFolder devuelve la carpeta de backups de un juego (su slug). Para juegos que ya no están
+// en la base de datos el ID recibido es el propio nombre de la carpeta (ver GetAllBackups).
+func (bm *BackupManager) backupFolder(gameID string) string {
+	if game, exists := bm.getGame(gameID); exists && game.Slug != "" {
+		return game.Slug
+	}
+	return gameID
+}
+
+// gameBackupDir devuelve la ruta de la carpeta de backups de un juego
+func (bm *BackupManager) gameBackupDir(gameID string) string {
+	return filepath.Join(bm.config().BackupDir, bm.backupFolder(gameID))
+}
+
+// findGameBySlug busca un juego por su slug
+func (bm *BackupManager) findGameBySlug(slug string) (*GameInfo, bool) {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	for _, game := range bm.DetectedGames {
+		if game.Slug == slug {
+			return game, true
+		}
+	}
+	return nil, false
+}
+
+// generateGameIDFromName genera el identificador legible de un juego agregado por nombre (PCGamingWiki, selección
+// por lotes...), que desde los IDs estables se guarda como su slug. Si ya pertenece a otro juego con distinto
+// nombre se agrega un hash corto del nombre.
+func (bm *BackupManager) generateGameIDFromName(name string) string {
+	sum := sha1.Sum([]byte(strings.ToLower(strings.TrimSpace(name))))
+	hash := hex.EncodeToString(sum[:])[:6]
+
+	base := slugifyName(name)
+	if base == "" {
+		base = "game-" + hash
+	}
+	slug := sanitizePathComponent(base)
+
+	existing, exists := bm.findGameBySlug(slug)
+	if !exists || strings.EqualFold(strings.TrimSpace(existing.Name), strings.TrimSpace(name)) {
+		return slug
+	}
+
+	slug = sanitizePathComponent(base + "-" + hash)
+	if existing, exists := bm.findGameBySlug(slug); exists && !strings.EqualFold(existing.Name, name) {
+		return bm.uniqueSlug(slug)
+	}
+	return slug
+}
+
+// uniqueSlug agrega un sufijo numérico si el slug ya pertenece a otro juego
+func (bm *BackupManager) uniqueSlug(slug string) string {
+	candidate := slug
+	for i := 2; ; i++ {
+		if _, exists := bm.findGameBySlug(candidate); !exists {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", slug, i)
+	}
+}
+
+// migrateToStableIDs asigna un UUID a los juegos de versiones anteriores, cuyo ID derivaba de la ruta o del nombre.
+// El ID anterior pasa a ser el slug, así que la carpeta de backups no se mueve.
+func (bm *BackupManager) migrateToStableIDs() bool {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	changed := false
+	for oldID, game := range bm.DetectedGames {
+		if game.Slug != "" {
+			continue
+		}
+
+		game.Slug = oldID
+		game.ID = newGameID()
+		delete(bm.DetectedGames, oldID)
+		bm.DetectedGames[game.ID] = game
+		log.Printf("ID estable asignado a %s: %s (carpeta %s)", game.Name, game.ID, game.Slug)
+		changed = true
+	}
+	return changed
+}
+
+// migrateUnsafeSlugs mueve las carpetas de backup cuyo slug no es un componente de ruta seguro.
+// Devuelve si cambió algo para que el llamador guarde la base de datos.
+func (bm *BackupManager) migrateUnsafeSlugs() bool {
+	changed := false
+	for _, game := range bm.GetGameList() {
+		newSlug := sanitizePathComponent(game.Slug)
+		if newSlug == game.Slug {
+			continue
+		}
+		newSlug = bm.uniqueSlug(newSlug)
+
+		oldSlug := game.Slug
+		if err := bm.migrateBackupDir(game, newSlug); err != nil {
+			log.Printf("Error migrando backups de %s a %s: %v", oldSlug, newSlug, err)
+			continue
+		}
+		log.Printf("Carpeta de backups migrada: %q -> %q", oldSlug, newSlug)
+		changed = true
+	}
+	return changed
+}
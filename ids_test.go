@@ -0,0 +1,100 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateGameIDFromNamePunctuation(t *testing.T) {
+	bm, _ := newTestManager(t)
+	tests := map[string]string{
+		"Divinity: Original Sin 2":            "divinity-original-sin-2",
+		"Divinity: Original Sin 2 ":           "divinity-original-sin-2",
+		"S.T.A.L.K.E.R.: Shadow of Chernobyl": "stalker-shadow-of-chernobyl",
+		"Assassin's Creed® II":                "assassins-creed-ii",
+		"Pokémon: Let’s Go, Pikachu!":         "pokemon-lets-go-pikachu",
+		"Half-Life 2: Episode One":            "half-life-2-episode-one",
+		"Fate/Stay Night [Réalta Nua]":        "fate-stay-night-realta-nua",
+		`AC\DC: <Live> | "Tour"?*`:            "ac-dc-live-tour",
+		"Ōkami HD":                            "okami-hd",
+		"東方紅魔郷":                               "東方紅魔郷",
+		"CON":                                 "_con", // Nombre reservado en Windows
+	}
+	for name, want := range tests {
+		if got := bm.generateGameIDFromName(name); got != want {
+			t.Errorf("generateGameIDFromName(%q) = %q, se esperaba %q", name, got, want)
+		}
+	}
+
+	// Un título sin letras ni números se identifica por el hash de su nombre
+	id := bm.generateGameIDFromName("???")
+	if !strings.HasPrefix(id, "game-") || len(id) != len("game-")+6 {
+		t.Errorf("ID de un título sin letras: %q", id)
+	}
+	if other := bm.generateGameIDFromName("!!!"); other == id {
+		t.Errorf("dos títulos distintos sin letras comparten el ID %q", id)
+	}
+}
+
+func TestGenerateGameIDFromNameCollisions(t *testing.T) {
+	bm, _ := newTestManager(t)
+	bm.setGame(&GameInfo{ID: newGameID(), Slug: "doom", Name: "DOOM", Metadata: map[string]string{}})
+
+	// El mismo juego (sin distinguir mayúsculas ni espacios) conserva su ID
+	if got := bm.generateGameIDFromName(" doom "); got != "doom" {
+		t.Errorf("el mismo juego recibió el ID %q", got)
+	}
+
+	// Otro juego con el mismo slug recibe un sufijo con el hash de su nombre, siempre el mismo
+	first := bm.generateGameIDFromName("Doom!")
+	if first == "doom" || !strings.HasPrefix(first, "doom-") || len(first) != len("doom-")+6 {
+		t.Fatalf("un juego distinto con el mismo slug recibió %q", first)
+	}
+	if again := bm.generateGameIDFromName("Doom!"); again != first {
+		t.Errorf("el ID no es determinista: %q y %q", first, again)
+	}
+	if other := bm.generateGameIDFromName("Doom?"); other == first {
+		t.Errorf("dos juegos distintos comparten el ID %q", first)
+	}
+
+	// Si el ID con hash también está ocupado por otro juego se numera
+	bm.setGame(&GameInfo{ID: newGameID(), Slug: first, Name: "Otro", Metadata: map[string]string{}})
+	if got := bm.generateGameIDFromName("Doom!"); got != first+"-2" {
+		t.Errorf("con el hash ocupado se recibió %q, se esperaba %q", got, first+"-2")
+	}
+}
+
+// Dos juegos distintos que dan el mismo slug, agregados desde PCGamingWiki, tienen carpetas de backup distintas
+func TestAddGameFromPCGWSlugCollision(t *testing.T) {
+	bm, dir := newTestManager(t)
+	paths := []string{filepath.Join(dir, "one"), filepath.Join(dir, "two")}
+	for _, path := range paths {
+		writeTestFiles(t, path, map[string]string{"slot.sav": path})
+	}
+
+	for i, name := range []string{"Divinity: Original Sin 2", "Divinity - Original Sin 2"} {
+		if err := bm.AddGameFromPCGW(UserGameSelection{Name: name, CustomPath: paths[i]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	slugs := map[string]string{}
+	for _, game := range bm.GetGameList() {
+		if strings.HasPrefix(game.Slug, "divinity-original-sin-2") {
+			slugs[game.Name] = game.Slug
+		}
+	}
+	if len(slugs) != 2 || slugs["Divinity: Original Sin 2"] != "divinity-original-sin-2" ||
+		slugs["Divinity - Original Sin 2"] == slugs["Divinity: Original Sin 2"] {
+		t.Errorf("slugs %v", slugs)
+	}
+
+	// Volver a agregar el primero actualiza el juego existente en vez de crear otro
+	if err := bm.AddGameFromPCGW(UserGameSelection{Name: "Divinity: Original Sin 2", CustomPath: paths[0]}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(bm.GetGameList()); got != 3 {
+		t.Errorf("%d juegos tras repetir el alta, se esperaban 3", got)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Cómo se comprobó si un juego está instalado (GameInfo.InstallCheck); vacío = no hay con qué comprobarlo
+const (
+	InstallCheckSteam = "steam"       // appmanifest_<appid>.acf en alguna biblioteca de Steam
+	InstallCheckEpic  = "epic"        // Manifiesto .item del Epic Games Launcher
+	InstallCheckDir   = "install_dir" // Existe su carpeta de instalación (InstallDir)
+)
+
+// installState indica si un juego sigue instalado según su tienda o su carpeta de instalación, no según sus
+// partidas, que suelen quedarse al desinstalarlo. check es cómo se comprobó; vacío si no se pudo.
+func installState(game *GameInfo) (installed bool, check string) {
+	if appName := game.Metadata["epic_app_name"]; appName != "" {
+		return epicInstalled(appName), InstallCheckEpic
+	}
+	// Los juegos de otras tiendas también pueden tener steam_app_id (de PCGamingWiki): solo cuenta en los de Steam
+	if appID := game.Metadata["steam_app_id"]; appID != "" && game.Platform == "steam" {
+		return steamInstalled(appID), InstallCheckSteam
+	}
+	if game.InstallDir != "" {
+		info, err := os.Stat(game.InstallDir)
+		return err == nil && info.IsDir(), InstallCheckDir
+	}
+	return false, ""
+}
+
+// steamInstalled indica si alguna biblioteca de Steam tiene el appmanifest del juego (Steam lo borra al desinstalar)
+func steamInstalled(appID string) bool {
+	for _, root := range steamRoots() {
+		for _, library := range steamLibraries(root) {
+			if _, err := os.Stat(filepath.Join(library, "steamapps", "appmanifest_"+appID+".acf")); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// epicInstalled indica si el Epic Games Launcher tiene un manifiesto de instalación completa del juego
+func epicInstalled(appName string) bool {
+	for _, dir := range epicManifestDirs() {
+		manifests, _ := readEpicManifests(dir)
+		for _, manifest := range manifests {
+			if manifest.AppName == appName {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// VerifyResult es el resultado de comprobar un backup contra su manifiesto
+type VerifyResult struct {
+	GameID  string   `json:"game_id"`
+	Backup  string   `json:"backup"`
+	Valid   bool     `json:"valid"`
+	Checked int      `json:"checked"`
+	Corrupt []string `json:"corrupt"`
+	Missing []string `json:"missing"`
+
+	Quarantined     bool `json:"quarantined"`      // El backup dañado se movió a la cuarentena
+	ManifestCreated bool `json:"manifest_created"` // No tenía manifiesto (versiones anteriores): se generó con su contenido actual
+}
+
+// RepairedEntry indica de qué backup se recuperó un archivo dañado
+type RepairedEntry struct {
+	Path   string `json:"path"`
+	Source string `json:"source"`
+}
+
+// RepairReport resume una reparación de backup
+type RepairReport struct {
+	GameID        string          `json:"game_id"`
+	Backup        string          `json:"backup"`
+	Repaired      []RepairedEntry `json:"repaired"`
+	Unrecoverable []string        `json:"unrecoverable"`
+	Quarantined   bool            `json:"quarantined"` // Quedaron archivos irrecuperables: se movió a la cuarentena
+}
+
+// readBackupFile lee el contenido de un archivo dentro de un backup (ZIP o carpeta)
+func readBackupFile(backupPath, name string) ([]byte, error) {
+	rc, err := openBackupFile(backupPath, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// hashBackupFile calcula el SHA-256 de un archivo dentro de un backup sin cargarlo entero en memoria
+func hashBackupFile(backupPath, name string) (string, error) {
+	rc, err := openBackupFile(backupPath, name)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	sum, _, err := hashReader(rc)
+	return sum, err
+}
+
+// zipEntryReader cierra junto con la entrada el ZIP que la contiene
+type zipEntryReader struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
+
+func (r *zipEntryReader) Close() error {
+	err := r.ReadCloser.Close()
+	if closeErr := r.archive.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// openBackupFile abre un archivo dentro de un backup (ZIP o carpeta) para leerlo como flujo
+func openBackupFile(backupPath, name string) (io.ReadCloser, error) {
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return os.Open(filepath.Join(backupPath, filepath.FromSlash(name)))
+	}
+
+	reader, err := zip.OpenReader(backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range reader.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		return &zipEntryReader{ReadCloser: rc, archive: reader}, nil
+	}
+
+	reader.Close()
+	return nil, os.ErrNotExist
+}
+
+// VerifyBackup comprueba cada archivo de un backup contra los checksums de su manifiesto.
+// Si está dañado lo pone en cuarentena.
+func (bm *BackupManager) VerifyBackup(gameID, fileName string) (*VerifyResult, error) {
+	return bm.verifyBackup(gameID, fileName, 0)
+}
+
+// verifyBackup hace la comprobación de VerifyBackup leyendo como mucho rate bytes/s (0 = sin límite)
+func (bm *BackupManager) verifyBackup(gameID, fileName string, rate int64) (*VerifyResult, error) {
+	result, err := bm.backupStore().Verify(gameID, fileName, rate)
+	if err != nil {
+		return nil, err
+	}
+	backupPath, err := bm.resolveBackupFile(gameID, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Valid {
+		err := bm.upsertBackupRecord(gameID, fileName, backupPath, func(record *BackupRecord) {
+			record.VerifiedAt = time.Now()
+		})
+		if err != nil {
+			log.Printf("Error guardando historial de %s: %v", gameID, err)
+		}
+		return result, nil
+	}
+
+	// Un backup dañado no debe ocupar el sitio de uno bueno en la rotación ni ofrecerse para restaurar
+	reason := fmt.Sprintf("%d archivos dañados, %d ausentes", len(result.Corrupt), len(result.Missing))
+	if err := bm.quarantineBackup(gameID, fileName, reason); err != nil {
+		log.Printf("Error poniendo en cuarentena %s: %v", fileName, err)
+	} else {
+		result.Quarantined = true
+	}
+	return result, nil
+}
+
+// CheckArchive comprueba que un backup se puede restaurar sin extraerlo: que el ZIP tiene un directorio central
+// válido y que cada entrada se abre y se lee completa (detecta archivos truncados que el tamaño no delata).
+// Devuelve el primer fallo encontrado.
+func (bm *BackupManager) CheckArchive(gameID, fileName string) error {
+	backupPath, err := bm.resolveBackupFile(gameID, fileName)
+	if err != nil {
+		return err
+	}
+	return checkArchive(backupPath)
+}
+
+// checkArchive recorre un backup (ZIP o carpeta) leyendo cada entrada hasta el final
+func checkArchive(backupPath string) error {
+	return forEachBackupEntry(backupPath, func(entry backupFileEntry) error {
+		if _, err := cleanEntryName(entry.Name); err != nil {
+			return err
+		}
+
+		rc, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("error abriendo %s: %v", entry.Name, err)
+		}
+		defer rc.Close()
+
+		// Leer hasta el final valida el CRC de las entradas comprimidas
+		if _, err := io.Copy(io.Discard, rc); err != nil {
+			return fmt.Errorf("error leyendo %s: %v", entry.Name, err)
+		}
+		return nil
+	})
+}
+
+// findIntactCopy busca en otros backups del juego una copia intacta (mismo hash) de un archivo.
+// Devuelve la ruta del backup que la contiene y su nombre.
+func (bm *BackupManager) findIntactCopy(gameID, exclude string, entry ManifestEntry) (string, string) {
+	backups, err := bm.listBackups(gameID)
+	if err != nil {
+		return "", ""
+	}
+
+	for _, backup := range backups {
+		if backup.Name == exclude {
+			continue
+		}
+
+		manifest, err := loadManifest(backup.Path)
+		if err != nil {
+			continue
+		}
+
+		for _, candidate := range manifest.Files {
+			if candidate.Path != entry.Path || candidate.SHA256 != entry.SHA256 {
+				continue
+			}
+			if sum, err := hashBackupFile(backup.Path, entry.Path); err == nil && sum == entry.SHA256 {
+				return backup.Path, backup.Name
+			}
+			break
+		}
+	}
+
+	return "", ""
+}
+
+// RepairBackup reemplaza los archivos dañados de un backup con copias intactas de otros backups del mismo juego.
+// Solo si quedan archivos sin ninguna copia intacta pone el backup en cuarentena.
+func (bm *BackupManager) RepairBackup(gameID, fileName string) (*RepairReport, error) {
+	// Sin VerifyBackup: pondría el backup dañado en cuarentena antes de poder repararlo
+	verify, err := bm.backupStore().Verify(gameID, fileName, 0)
+	if err != nil {
+		return nil, err
+	}
+	backupPath, err := bm.resolveBackupFile(gameID, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RepairReport{
+		GameID:        gameID,
+		Backup:        fileName,
+		Repaired:      []RepairedEntry{},
+		Unrecoverable: []string{},
+	}
+	if verify.Valid {
+		bm.markVerified(gameID, fileName, backupPath)
+		return report, nil
+	}
+
+	report.Repaired, report.Unrecoverable, err = bm.repairEntries(gameID, fileName, backupPath, append(verify.Corrupt, verify.Missing...))
+	if err != nil {
+		return nil, err
+	}
+	if len(report.Unrecoverable) == 0 {
+		bm.markVerified(gameID, fileName, backupPath)
+		return report, nil
+	}
+
+	reason := fmt.Sprintf("%d archivos dañados sin copias intactas para repararlos", len(report.Unrecoverable))
+	if err := bm.quarantineBackup(gameID, fileName, reason); err != nil {
+		log.Printf("Error poniendo en cuarentena %s: %v", fileName, err)
+	} else {
+		report.Quarantined = true
+	}
+	return report, nil
+}
+
+// repairEntries reemplaza los archivos damaged de un backup con copias intactas: primero la copia del mismo
+// backup en los destinos adicionales del juego y después los demás backups. Devuelve los recuperados y los
+// que no tienen ninguna copia intacta.
+func (bm *BackupManager) repairEntries(gameID, fileName, backupPath string, damaged []string) ([]RepairedEntry, []string, error) {
+	manifest, err := loadManifest(backupPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range damaged {
+		wanted[name] = true
+	}
+
+	repaired, unrecoverable := []RepairedEntry{}, []string{}
+	replacements := make(map[string][]byte)
+	for _, entry := range manifest.Files {
+		if !wanted[entry.Path] {
+			continue
+		}
+		copyPath := bm.findDestinationCopy(gameID, fileName, entry)
+		source := copyPath
+		if copyPath == "" {
+			copyPath, source = bm.findIntactCopy(gameID, fileName, entry)
+		}
+		if copyPath == "" {
+			unrecoverable = append(unrecoverable, entry.Path)
+			continue
+		}
+		// Solo se carga en memoria la copia elegida para reescribirla en el backup
+		data, err := readBackupFile(copyPath, entry.Path)
+		if err != nil {
+			unrecoverable = append(unrecoverable, entry.Path)
+			continue
+		}
+		replacements[entry.Path] = data
+		repaired = append(repaired, RepairedEntry{Path: entry.Path, Source: source})
+	}
+
+	if len(replacements) == 0 {
+		return repaired, unrecoverable, nil
+	}
+
+	if err := rewriteBackupEntries(backupPath, replacements); err != nil {
+		return nil, nil, fmt.Errorf("error reparando backup %s: %v", fileName, err)
+	}
+
+	log.Printf("Backup %s reparado: %d archivos recuperados, %d irrecuperables",
+		fileName, len(repaired), len(unrecoverable))
+	return repaired, unrecoverable, nil
+}
+
+// destinationCopies devuelve las copias de un backup en los destinos adicionales del juego que existen
+func (bm *BackupManager) destinationCopies(gameID, fileName string) []string {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil
+	}
+	copies := []string{}
+	for _, dir := range game.ExtraBackupDirs {
+		path := filepath.Join(ExpandPath(dir), bm.backupFolder(gameID), fileName)
+		if _, err := os.Stat(path); err == nil {
+			copies = append(copies, path)
+		}
+	}
+	return copies
+}
+
+// findDestinationCopy busca una copia intacta (mismo hash) de un archivo en las copias del mismo backup de los
+// destinos adicionales y devuelve su ruta
+func (bm *BackupManager) findDestinationCopy(gameID, fileName string, entry ManifestEntry) string {
+	for _, path := range bm.destinationCopies(gameID, fileName) {
+		if sum, err := hashBackupFile(path, entry.Path); err == nil && sum == entry.SHA256 {
+			return path
+		}
+	}
+	return ""
+}
+
+// rewriteBackupEntries reescribe archivos concretos de un backup (ZIP o carpeta)
+func rewriteBackupEntries(backupPath string, replacements map[string][]byte) error {
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		for name, data := range replacements {
+			target := filepath.Join(backupPath, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(target, data, 0644); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Un ZIP no se puede modificar en el sitio: se reconstruye copiando las entradas sanas sin recomprimir
+	reader, err := zip.OpenReader(backupPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tmpPath := backupPath + ".repair.tmp"
+	tmpFile, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	writer := zip.NewWriter(tmpFile)
+	written := make(map[string]bool)
+	err = func() error {
+		for _, file := range reader.File {
+			data, replace := replacements[file.Name]
+			if !replace {
+				if err := writer.Copy(file); err != nil {
+					return err
+				}
+				continue
+			}
+
+			header := file.FileHeader
+			entry, err := writer.CreateHeader(&header)
+			if err != nil {
+				return err
+			}
+			if _, err := entry.Write(data); err != nil {
+				return err
+			}
+			written[file.Name] = true
+		}
+
+		// Entradas que faltaban por completo en el ZIP
+		for name, data := range replacements {
+			if written[name] {
+				continue
+			}
+			entry, err := writer.Create(name)
+			if err != nil {
+				return err
+			}
+			if _, err := entry.Write(data); err != nil {
+				return err
+			}
+		}
+		return writer.Close()
+	}()
+	tmpFile.Close()
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	reader.Close()
+	return os.Rename(tmpPath, backupPath)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// corruptBackupEntry cambia el contenido de una entrada de un backup sin tocar su manifiesto
+func corruptBackupEntry(t *testing.T, backupPath, name string) {
+	t.Helper()
+	if err := rewriteBackupEntries(backupPath, map[string][]byte{name: []byte("dañado")}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepairBackupFromOtherBackup(t *testing.T) {
+	for _, compress := range []bool{true, false} {
+		bm, _ := newTestManager(t)
+		bm.Config.CompressionEnabled = compress
+		game := bm.DetectedGames["g"]
+		if _, err := bm.CreateBackupWithOptions("g", BackupOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Second)
+		info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		backupPath := filepath.Join(bm.Config.BackupDir, game.Slug, info.Name)
+		corruptBackupEntry(t, backupPath, "a.sav")
+
+		report, err := bm.RepairBackup("g", info.Name)
+		if err != nil {
+			t.Fatalf("comprimido %v: %v", compress, err)
+		}
+		if len(report.Repaired) != 1 || report.Repaired[0].Path != "a.sav" || len(report.Unrecoverable) != 0 || report.Quarantined {
+			t.Errorf("comprimido %v: informe %+v", compress, report)
+		}
+
+		if _, err := os.Stat(backupPath); err != nil {
+			t.Fatalf("comprimido %v: el backup reparado no está en su sitio: %v", compress, err)
+		}
+		if quarantined, _ := bm.listQuarantinedBackups("g"); len(quarantined) != 0 {
+			t.Errorf("comprimido %v: backups en cuarentena %v", compress, quarantined)
+		}
+		result, err := bm.VerifyBackup("g", info.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Valid || result.Quarantined {
+			t.Errorf("comprimido %v: tras reparar, válido %v, dañados %v", compress, result.Valid, result.Corrupt)
+		}
+		if data, _ := readBackupFile(backupPath, "a.sav"); string(data) != "aaa" {
+			t.Errorf("comprimido %v: a.sav = %q", compress, data)
+		}
+	}
+}
+
+// Sin otra copia intacta el backup no se puede reparar y va a la cuarentena
+func TestRepairBackupUnrecoverable(t *testing.T) {
+	bm, _ := newTestManager(t)
+	game := bm.DetectedGames["g"]
+	info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	corruptBackupEntry(t, filepath.Join(bm.Config.BackupDir, game.Slug, info.Name), "a.sav")
+
+	report, err := bm.RepairBackup("g", info.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Repaired) != 0 || len(report.Unrecoverable) != 1 || !report.Quarantined {
+		t.Errorf("informe %+v", report)
+	}
+	if quarantined, _ := bm.listQuarantinedBackups("g"); len(quarantined) != 1 {
+		t.Errorf("%d backups en cuarentena, se esperaba 1", len(quarantined))
+	}
+}
+
+func TestHashBackupFile(t *testing.T) {
+	for _, compress := range []bool{true, false} {
+		bm, _ := newTestManager(t)
+		bm.Config.CompressionEnabled = compress
+		game := bm.DetectedGames["g"]
+		info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		backupPath := filepath.Join(bm.Config.BackupDir, game.Slug, info.Name)
+
+		want, _, _ := hashReader(strings.NewReader("bbb"))
+		if sum, err := hashBackupFile(backupPath, "sub/b.sav"); err != nil || sum != want {
+			t.Errorf("comprimido %v: hash de sub/b.sav = %q, %v", compress, sum, err)
+		}
+		if _, err := hashBackupFile(backupPath, "no-existe.sav"); !os.IsNotExist(err) {
+			t.Errorf("comprimido %v: se esperaba un error de archivo inexistente, se obtuvo %v", compress, err)
+		}
+	}
+}
+
+// La comprobación con límite lee las entradas a través del limitador en vez de cargarlas enteras
+func TestVerifyBackupRate(t *testing.T) {
+	bm, _ := newTestManager(t)
+	game := bm.DetectedGames["g"]
+	writeTestFiles(t, game.SavePaths[0], map[string]string{"grande.sav": strings.Repeat("x", 2048)})
+	info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// El cubo empieza lleno con un segundo de lectura: con 1 KB/s, 2 KB más los demás archivos tardan más de un segundo
+	start := time.Now()
+	result, err := bm.verifyBackup("g", info.Name, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid {
+		t.Errorf("dañados %v, ausentes %v", result.Corrupt, result.Missing)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("la comprobación con límite tardó %v", elapsed)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// integrityPassInterval es cada cuánto la comprobación en segundo plano busca backups pendientes de verificar
+const integrityPassInterval = time.Hour
+
+// integrityCheckRate limita la lectura de la comprobación en segundo plano (bytes/s) para no saturar el disco
+const integrityCheckRate = 20 << 20
+
+// integritySummaryEvent se emite al terminar cada comprobación de integridad
+const integritySummaryEvent = "integrity:summary"
+
+// IntegrityCheckSummary resume una comprobación de integridad de backups
+type IntegrityCheckSummary struct {
+	GameID      string        `json:"game_id,omitempty"` // Vacío = todos los juegos
+	StartedAt   time.Time     `json:"started_at"`
+	Duration    time.Duration `json:"duration"`
+	Checked     int           `json:"checked"`
+	Valid       int           `json:"valid"`
+	Quarantined []string      `json:"quarantined"` // "<juego>/<backup>" dañados y movidos a la cuarentena
+	Errors      []string      `json:"errors"`      // Backups que no se pudieron verificar (p. ej. sin manifiesto)
+}
+
+// RunIntegrityCheckNow verifica todos los backups de un juego sin esperar a la comprobación programada
+func (bm *BackupManager) RunIntegrityCheckNow(gameID string) (*IntegrityCheckSummary, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	backups, err := bm.listBackups(gameID)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]GlobalBackupEntry, 0, len(backups))
+	for _, backup := range backups {
+		entries = append(entries, GlobalBackupEntry{GameID: gameID, GameName: game.Name, Backup: backup.Name})
+	}
+
+	summary := bm.verifyBackups(entries, 0)
+	summary.GameID = gameID
+	bm.reportIntegrityCheck(summary)
+	return summary, nil
+}
+
+// runIntegrityChecks lanza en segundo plano, como mucho una vez por integrityPassInterval, la verificación de
+// los backups que no se han comprobado en el intervalo de IntegrityCheckSchedule
+func (bm *BackupManager) runIntegrityChecks() {
+	interval, err := parseSchedule(bm.config().IntegrityCheckSchedule)
+	if err != nil || interval == 0 {
+		return
+	}
+
+	now := time.Now()
+	bm.integrityM.Lock()
+	if bm.integrityRunning || now.Sub(bm.integrityLastPass) < integrityPassInterval {
+		bm.integrityM.Unlock()
+		return
+	}
+	bm.integrityRunning = true
+	bm.integrityLastPass = now
+	bm.integrityM.Unlock()
+
+	go func() {
+		defer func() {
+			bm.integrityM.Lock()
+			bm.integrityRunning = false
+			bm.integrityM.Unlock()
+		}()
+
+		entries, err := bm.GetAllBackups()
+		if err != nil {
+			log.Printf("Error listando backups para la comprobación de integridad: %v", err)
+			return
+		}
+
+		var due []GlobalBackupEntry
+		for _, entry := range entries {
+			if now.Sub(entry.LastVerified) >= interval {
+				due = append(due, entry)
+			}
+		}
+		if len(due) == 0 {
+			return
+		}
+
+		log.Printf("Comprobación de integridad: %d backups pendientes de verificar", len(due))
+		bm.reportIntegrityCheck(bm.verifyBackups(due, integrityCheckRate))
+	}()
+}
+
+// verifyBackups verifica una lista de backups limitando la lectura a rate bytes/s (0 = sin límite).
+// VerifyBackup pone en cuarentena los dañados.
+func (bm *BackupManager) verifyBackups(entries []GlobalBackupEntry, rate int64) *IntegrityCheckSummary {
+	summary := &IntegrityCheckSummary{
+		StartedAt:   time.Now(),
+		Quarantined: []string{},
+		Errors:      []string{},
+	}
+
+	for _, entry := range entries {
+		result, err := bm.verifyBackup(entry.GameID, entry.Backup, rate)
+		if err != nil {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s/%s: %v", entry.GameName, entry.Backup, err))
+			continue
+		}
+		summary.Checked++
+		if result.Valid {
+			summary.Valid++
+		} else if result.Quarantined {
+			summary.Quarantined = append(summary.Quarantined, entry.GameName+"/"+entry.Backup)
+		} else {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s/%s: dañado, no se pudo poner en cuarentena", entry.GameName, entry.Backup))
+		}
+	}
+
+	summary.Duration = time.Since(summary.StartedAt)
+	return summary
+}
+
+// reportIntegrityCheck registra el resumen de una comprobación en el log y en el registro de actividad y lo emite
+func (bm *BackupManager) reportIntegrityCheck(summary *IntegrityCheckSummary) {
+	log.Printf("Comprobación de integridad completada: %d verificados, %d correctos, %d en cuarentena, %d errores (%v)",
+		summary.Checked, summary.Valid, len(summary.Quarantined), len(summary.Errors), summary.Duration.Round(time.Second))
+
+	var err error
+	if len(summary.Quarantined) > 0 {
+		err = fmt.Errorf("%d backups dañados movidos a la cuarentena", len(summary.Quarantined))
+	}
+	entry := ActivityEntry{
+		Type: ActivityIntegrity,
+		Params: map[string]string{
+			"checked":     fmt.Sprint(summary.Checked),
+			"quarantined": fmt.Sprint(len(summary.Quarantined)),
+		},
+		Summary: fmt.Sprintf("Comprobación de integridad: %d backups verificados, %d en cuarentena", summary.Checked, len(summary.Quarantined)),
+	}
+	if summary.GameID != "" {
+		if game, exists := bm.getGame(summary.GameID); exists {
+			entry.GameID, entry.GameName = game.ID, game.Name
+			entry.Params["game"] = game.Name
+		}
+	}
+	bm.recordActivity(entry, err)
+
+	bm.emit(integritySummaryEvent, summary)
+}
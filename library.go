@@ -0,0 +1,94 @@
+package main
+
+import (
+	"sort"
+)
+
+// libraryChangedEvent se emite con el LibrarySummary nuevo cada vez que cambia game_saves.json
+const libraryChangedEvent = "library:changed"
+
+// libraryStatusOK agrupa en LibrarySummary.Statuses los juegos sin problemas (Status vacío)
+const libraryStatusOK = "ok"
+
+// LibraryGroup es el número de juegos y el tamaño de sus partidas de una plataforma, estado o etiqueta
+type LibraryGroup struct {
+	Name      string `json:"name"`
+	Games     int    `json:"games"`
+	TotalSize int64  `json:"total_size"`
+}
+
+// LibrarySummary agrupa la biblioteca para la barra lateral
+type LibrarySummary struct {
+	TotalGames  int            `json:"total_games"`
+	TotalSize   int64          `json:"total_size"`
+	WithBackups int            `json:"with_backups"`
+	Platforms   []LibraryGroup `json:"platforms"`
+	Statuses    []LibraryGroup `json:"statuses"`
+	Tags        []LibraryGroup `json:"tags"`
+}
+
+// GetLibrarySummary cuenta los juegos y suma el tamaño de sus partidas por plataforma, estado y etiqueta.
+// Usa los tamaños guardados en la base de datos, sin recorrer las carpetas.
+func (bm *BackupManager) GetLibrarySummary() (*LibrarySummary, error) {
+	summary := &LibrarySummary{}
+	platforms := make(map[string]*LibraryGroup)
+	statuses := make(map[string]*LibraryGroup)
+	tags := make(map[string]*LibraryGroup)
+	add := func(groups map[string]*LibraryGroup, name string, game *GameInfo) {
+		group, exists := groups[name]
+		if !exists {
+			group = &LibraryGroup{Name: name}
+			groups[name] = group
+		}
+		group.Games++
+		group.TotalSize += game.TotalSize
+	}
+
+	for _, game := range bm.GetGameList() {
+		summary.TotalGames++
+		summary.TotalSize += game.TotalSize
+		if !game.LastBackup.IsZero() {
+			summary.WithBackups++
+		}
+
+		add(platforms, game.Platform, game)
+		status := game.Status
+		if status == "" {
+			status = libraryStatusOK
+		}
+		add(statuses, status, game)
+		for _, tag := range game.Tags {
+			add(tags, tag, game)
+		}
+	}
+
+	summary.Platforms = sortedGroups(platforms)
+	summary.Statuses = sortedGroups(statuses)
+	summary.Tags = sortedGroups(tags)
+	return summary, nil
+}
+
+// emitLibraryChanged emite el resumen de la biblioteca tras guardar la base de datos
+func (bm *BackupManager) emitLibraryChanged() {
+	if bm.emitEvent == nil {
+		return
+	}
+	if summary, err := bm.GetLibrarySummary(); err == nil {
+		bm.emit(libraryChangedEvent, summary)
+	}
+}
+
+// sortedGroups ordena los grupos de más a menos juegos y, a igualdad, por nombre
+func sortedGroups(groups map[string]*LibraryGroup) []LibraryGroup {
+	sorted := make([]LibraryGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Games != sorted[j].Games {
+			return sorted[i].Games > sorted[j].Games
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
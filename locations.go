@@ -0,0 +1,202 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// legacyBackupDirName es la carpeta de backups que usaban las versiones anteriores dentro del directorio personal
+const legacyBackupDirName = "WineSaveBackups"
+
+// minSuggestedFreeBytes es el espacio libre a partir del cual una ubicación se puede recomendar
+const minSuggestedFreeBytes = 5 << 30 // 5 GiB
+
+// volumeInfo es un volumen montado donde se podrían guardar backups
+type volumeInfo struct {
+	MountPoint string
+	FileSystem string
+	Removable  bool
+	Network    bool
+	ReadOnly   bool
+}
+
+// LocationOptions controla qué volúmenes incluye SuggestBackupLocationsWithOptions
+type LocationOptions struct {
+	IncludeRemovable bool `json:"include_removable"`
+	IncludeNetwork   bool `json:"include_network"`
+}
+
+// LocationSuggestion es una ubicación posible para los backups, con el espacio de su volumen
+type LocationSuggestion struct {
+	Path        string `json:"path"`
+	MountPoint  string `json:"mount_point"`
+	FileSystem  string `json:"file_system"`
+	FreeBytes   int64  `json:"free_bytes"`
+	TotalBytes  int64  `json:"total_bytes"`
+	Default     bool   `json:"default"` // Ubicación por defecto de la plataforma
+	Current     bool   `json:"current"` // Config.BackupDir actual
+	Removable   bool   `json:"removable"`
+	Network     bool   `json:"network"`
+	Recommended bool   `json:"recommended"` // La primera de la lista con espacio suficiente
+	Reason      string `json:"reason"`
+}
+
+// platformDefaultBackupDir devuelve la ubicación de backups recomendada para el sistema operativo
+func platformDefaultBackupDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(home, "Documents", "WineSave")
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "WineSave")
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" || !filepath.IsAbs(dataHome) {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "winesave")
+}
+
+// defaultBackupDir conserva ~/WineSaveBackups si ya existe; si no, usa la ubicación de la plataforma
+func defaultBackupDir() string {
+	home, err := os.UserHomeDir()
+	if err == nil {
+		legacy := filepath.Join(home, legacyBackupDirName)
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy
+		}
+	}
+	return platformDefaultBackupDir()
+}
+
+// SuggestBackupLocations propone ubicaciones para los backups en los discos locales con escritura
+func (bm *BackupManager) SuggestBackupLocations() ([]LocationSuggestion, error) {
+	return bm.SuggestBackupLocationsWithOptions(LocationOptions{})
+}
+
+// SuggestBackupLocationsWithOptions propone ubicaciones ordenadas de mejor a peor: primero los discos locales
+// y, dentro de ellos, los que tienen más espacio libre
+func (bm *BackupManager) SuggestBackupLocationsWithOptions(opts LocationOptions) ([]LocationSuggestion, error) {
+	volumes, err := listVolumes()
+	if err != nil {
+		log.Printf("Error listando volúmenes: %v", err)
+	}
+
+	suggestions := []LocationSuggestion{}
+	seen := make(map[string]bool)
+	usedVolumes := make(map[string]bool)
+	add := func(suggestion LocationSuggestion) {
+		if seen[suggestion.Path] {
+			return
+		}
+		seen[suggestion.Path] = true
+		if volume, ok := volumeForPath(volumes, suggestion.Path); ok {
+			suggestion.MountPoint = volume.MountPoint
+			suggestion.FileSystem = volume.FileSystem
+			suggestion.Removable = volume.Removable
+			suggestion.Network = volume.Network
+			usedVolumes[volume.MountPoint] = true
+		}
+		if free, total, err := diskUsage(existingParent(suggestion.Path)); err == nil {
+			suggestion.FreeBytes = int64(free)
+			suggestion.TotalBytes = int64(total)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	add(LocationSuggestion{Path: bm.config().BackupDir, Current: true, Reason: "ubicación actual"})
+	add(LocationSuggestion{Path: platformDefaultBackupDir(), Default: true, Reason: "ubicación por defecto"})
+	for i := range suggestions {
+		suggestions[i].Default = suggestions[i].Default || suggestions[i].Path == platformDefaultBackupDir()
+	}
+
+	for _, volume := range volumes {
+		if volume.ReadOnly || usedVolumes[volume.MountPoint] {
+			continue
+		}
+		if (volume.Removable && !opts.IncludeRemovable) || (volume.Network && !opts.IncludeNetwork) {
+			continue
+		}
+		if !isWritableDir(volume.MountPoint) {
+			continue
+		}
+
+		reason := "disco local"
+		switch {
+		case volume.Network:
+			reason = "unidad de red"
+		case volume.Removable:
+			reason = "unidad extraíble"
+		}
+		add(LocationSuggestion{Path: filepath.Join(volume.MountPoint, legacyBackupDirName), Reason: reason})
+	}
+
+	// Discos locales primero y, dentro de cada grupo, más espacio libre primero
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if localA, localB := !a.Removable && !a.Network, !b.Removable && !b.Network; localA != localB {
+			return localA
+		}
+		return a.FreeBytes > b.FreeBytes
+	})
+
+	for i := range suggestions {
+		if suggestions[i].FreeBytes >= minSuggestedFreeBytes {
+			suggestions[i].Recommended = true
+			break
+		}
+	}
+	for i := range suggestions {
+		if suggestions[i].FreeBytes < minSuggestedFreeBytes {
+			suggestions[i].Reason += "; poco espacio libre"
+		}
+	}
+
+	return suggestions, nil
+}
+
+// volumeForPath devuelve el volumen con el punto de montaje más profundo que contiene la ruta
+func volumeForPath(volumes []volumeInfo, path string) (volumeInfo, bool) {
+	var best volumeInfo
+	found := false
+	for _, volume := range volumes {
+		if isWithin(volume.MountPoint, path) && (!found || len(volume.MountPoint) > len(best.MountPoint)) {
+			best = volume
+			found = true
+		}
+	}
+	return best, found
+}
+
+// existingParent devuelve la ruta o su antecesor más cercano que existe (para consultar el espacio libre)
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// isWritableDir comprueba creando y borrando un archivo temporal
+func isWritableDir(dir string) bool {
+	file, err := os.CreateTemp(dir, ".winesave-write-test-*")
+	if err != nil {
+		return false
+	}
+	file.Close()
+	os.Remove(file.Name())
+	return true
+}
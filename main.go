@@ -3,13 +3,16 @@ package main
 import (
 	"context"
 	"embed"
+	"flag"
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 //go:embed frontend/dist
@@ -30,6 +33,8 @@ func NewApp() *App {
 func (a *App) OnStartup(ctx context.Context) {
 	a.ctx = ctx
 	a.initBackupManager()
+	a.backupManager.StartScheduler()
+	a.startAPI()
 	log.Println("[INFO] Aplicación iniciada correctamente")
 }
 
@@ -45,19 +50,45 @@ func (a *App) initBackupManager() {
 				CompressionEnabled: true,
 				ExcludePatterns:    []string{"*.tmp", "*.log", "*.cache"},
 				AutoBackup:         false,
+				MaxBackupFiles:     50000,
+				MaxBackupBytes:     20 << 30,
+				DefaultSchedule:    "daily",
 			},
 			DetectedGames: make(map[string]*GameInfo),
 			DatabasePath:  "game_saves.json",
 		}
 	}
+	if a.ctx != nil { // Sin ventana (modo servicio) no hay frontend al que avisar
+		bm.SetEventEmitter(func(event string, data interface{}) {
+			runtime.EventsEmit(a.ctx, event, data)
+		})
+	}
 	a.backupManager = bm
 }
 
+// startAPI inicia la API local si está habilitada y guarda el token generado
+func (a *App) startAPI() {
+	if !a.backupManager.config().APIEnabled {
+		return
+	}
+	if err := a.backupManager.StartAPI(); err != nil {
+		log.Printf("[WARN] Error iniciando la API local: %v", err)
+		return
+	}
+	if err := a.backupManager.SaveConfig("config.json"); err != nil {
+		log.Printf("[ERROR] Error guardando configuración: %v", err)
+	}
+}
+
 // OnDomReady se ejecuta cuando el frontend está listo
 func (a *App) OnDomReady(ctx context.Context) {
 	if err := a.backupManager.LoadDatabase(); err != nil {
 		log.Printf("[WARN] Error cargando base de datos: %v", err)
 	}
+	// Con el frontend listo para recibir el aviso: problemas de permisos o unidades antes del primer backup
+	if issues := a.backupManager.reportPreflight(); len(issues) > 0 {
+		log.Printf("[WARN] La comprobación inicial encontró %d problemas", len(issues))
+	}
 }
 
 // OnBeforeClose se ejecuta antes de cerrar la aplicación
@@ -73,6 +104,12 @@ func (a *App) OnBeforeClose(ctx context.Context) (prevent bool) {
 
 // OnShutdown se ejecuta cuando la aplicación se está cerrando
 func (a *App) OnShutdown(ctx context.Context) {
+	a.backupManager.StopScheduler()
+	a.backupManager.StopAPI(5 * time.Second)
+	a.backupManager.ShutdownQueue(30 * time.Second)
+	if err := a.backupManager.CloseDatabase(); err != nil {
+		log.Printf("[ERROR] Error cerrando base de datos: %v", err)
+	}
 	log.Println("[INFO] Aplicación cerrada")
 }
 
@@ -81,18 +118,67 @@ func (a *App) OnShutdown(ctx context.Context) {
 // ScanGames escanea y detecta juegos automáticamente
 func (a *App) ScanGames() (*ScanResult, error) {
 	log.Println("[INFO] Escaneo iniciado desde frontend...")
-	return a.backupManager.ScanForGames()
+	result, err := a.backupManager.ScanForGames()
+	return a.backupManager.humanScan(result), err
+}
+
+// PreviewScan muestra lo que encontraría un escaneo sin guardar nada
+func (a *App) PreviewScan() (*ScanResult, error) {
+	log.Println("[INFO] Previsualización de escaneo iniciada desde frontend...")
+	result, err := a.backupManager.PreviewScan()
+	return a.backupManager.humanScan(result), err
 }
 
 // GetGameList devuelve la lista de juegos detectados
 func (a *App) GetGameList() []*GameInfo {
-	return a.backupManager.GetGameList()
+	return a.backupManager.humanGames(a.backupManager.GetGameList())
 }
 
 // CreateBackup crea un backup de un juego específico
 func (a *App) CreateBackup(gameID string) error {
 	log.Printf("[INFO] Creando backup para juego: %s", gameID)
-	return a.backupManager.CreateBackup(gameID)
+	_, err := a.backupManager.RunBackup(gameID, BackupOptions{Trigger: "manual"})
+	return err
+}
+
+// GetAvailableGamesForBackup indica qué juegos de una lista tienen partidas que respaldar
+func (a *App) GetAvailableGamesForBackup(names []string) []DetailedGameInfo {
+	log.Printf("[INFO] Buscando rutas de guardado para %d juego(s)", len(names))
+	return a.backupManager.GetAvailableGamesForBackup(names)
+}
+
+// ConfirmGameSelections registra los juegos elegidos por el usuario entre varias coincidencias
+func (a *App) ConfirmGameSelections(selections []UserGameSelection) error {
+	log.Printf("[INFO] Confirmando %d juego(s) elegidos", len(selections))
+	return a.backupManager.ConfirmGameSelections(selections)
+}
+
+// CreateBackupForSelectedGames respalda varios juegos por nombre
+func (a *App) CreateBackupForSelectedGames(names []string, backupPath string) (*BatchBackupResult, error) {
+	log.Printf("[INFO] Backup por lotes de %d juego(s) en %s", len(names), backupPath)
+	return a.backupManager.CreateBackupForSelectedGames(names, backupPath)
+}
+
+// EnqueueBackup encola un backup sin esperar a que termine y devuelve el ID del trabajo
+func (a *App) EnqueueBackup(gameID string, opts BackupOptions) (string, error) {
+	log.Printf("[INFO] Encolando backup para juego: %s", gameID)
+	return a.backupManager.EnqueueBackup(gameID, opts)
+}
+
+// GetOperationStatus devuelve el estado y el último progreso de una operación de la cola
+func (a *App) GetOperationStatus(operationID string) (*BackupJob, error) {
+	return a.backupManager.GetOperationStatus(operationID)
+}
+
+// CancelOperation cancela una operación pendiente de la cola
+func (a *App) CancelOperation(operationID string) error {
+	log.Printf("[INFO] Cancelando operación %s", operationID)
+	return a.backupManager.CancelOperation(operationID)
+}
+
+// GetQueueStatus devuelve el estado de la cola de backups
+func (a *App) GetQueueStatus() QueueStatus {
+	return a.backupManager.GetQueueStatus()
 }
 
 // AddCustomGame agrega un juego personalizado
@@ -118,47 +204,142 @@ func (a *App) GetDefaultBackupPath() string {
 	return a.backupManager.GetDefaultBackupPath()
 }
 
-// SetBackupPath cambia la ruta de backup
+// SetBackupPath cambia la ruta de backup sin mover los backups existentes (ver MigrateBackupDir)
 func (a *App) SetBackupPath(newPath string) error {
 	log.Printf("[INFO] Cambiando ruta de backup a: %s", newPath)
-	return a.backupManager.SetBackupPath(newPath)
+	if dirs, _, err := a.backupManager.migrationDirs(a.backupManager.config().BackupDir); err == nil && len(dirs) > 0 {
+		log.Printf("[WARN] Los backups de %d juego(s) siguen en %s; usa MigrateBackupDir para moverlos", len(dirs), a.backupManager.config().BackupDir)
+	}
+	return a.backupManager.SetBackupPathWithOptions(newPath, BackupPathOptions{})
+}
+
+// MigrateBackupDir copia los backups a una nueva ubicación y la activa si todo se copió.
+// Los originales se borran después con ConfirmBackupDirMigration.
+func (a *App) MigrateBackupDir(newPath string) (*MigrationReport, error) {
+	log.Printf("[INFO] Migrando backups a: %s", newPath)
+	report, err := a.backupManager.MigrateBackupDir(newPath)
+	if err != nil {
+		return report, err
+	}
+	return report, a.backupManager.SaveConfig("config.json")
+}
+
+// ConfirmBackupDirMigration borra los backups de la ubicación anterior tras una migración
+func (a *App) ConfirmBackupDirMigration(oldPath string) error {
+	log.Printf("[INFO] Borrando backups de la ubicación anterior: %s", oldPath)
+	return a.backupManager.ConfirmBackupDirMigration(oldPath)
+}
+
+// SetBackupPathWithOptions cambia la ruta de backup moviendo los backups existentes si se pide.
+// El progreso se emite con el evento "backup-path:migrate-progress".
+func (a *App) SetBackupPathWithOptions(newPath string, opts BackupPathOptions) error {
+	log.Printf("[INFO] Cambiando ruta de backup a: %s (mover backups: %v)", newPath, opts.Migrate)
+	if err := a.backupManager.SetBackupPathWithOptions(newPath, opts); err != nil {
+		return err
+	}
+	// Los backups ya se movieron: la configuración debe apuntar a la nueva ubicación aunque la app se cierre
+	return a.backupManager.SaveConfig("config.json")
+}
+
+// InitializeDefaults propone la configuración inicial (ubicación de backups y lanzadores detectados).
+// La interfaz la guarda con UpdateConfig cuando el usuario la confirma.
+func (a *App) InitializeDefaults() (*SetupSummary, error) {
+	return a.backupManager.InitializeDefaults()
 }
 
-// ValidateGamePaths valida las rutas de guardado de un juego
+// SuggestBackupLocations propone ubicaciones para los backups con su espacio libre
+func (a *App) SuggestBackupLocations() ([]LocationSuggestion, error) {
+	return a.backupManager.SuggestBackupLocations()
+}
+
+// SuggestBackupLocationsWithOptions propone ubicaciones incluyendo, si se pide, unidades extraíbles o de red
+func (a *App) SuggestBackupLocationsWithOptions(opts LocationOptions) ([]LocationSuggestion, error) {
+	return a.backupManager.SuggestBackupLocationsWithOptions(opts)
+}
+
+// ValidateGamePaths valida las rutas de guardado de un juego; "need_install_dir" son las que
+// esperan a que se indique su carpeta de instalación
 func (a *App) ValidateGamePaths(gameID string) (map[string][]string, error) {
-	valid, invalid := a.backupManager.ValidateGamePaths(gameID)
-	return map[string][]string{"valid": valid, "invalid": invalid}, nil
+	valid, invalid, needInstallDir, unmappedDrives := a.backupManager.ValidateGamePaths(gameID)
+	return map[string][]string{
+		"valid":            valid,
+		"invalid":          invalid,
+		"need_install_dir": needInstallDir,
+		"unmapped_drive":   unmappedDrives,
+	}, nil
 }
 
 // GetConfig devuelve la configuración actual
 func (a *App) GetConfig() BackupConfig {
-	return a.backupManager.Config
+	return a.backupManager.config()
 }
 
 // UpdateConfig actualiza la configuración
 func (a *App) UpdateConfig(config BackupConfig) error {
-	a.backupManager.Config = config
-	return a.backupManager.SaveConfig("config.json")
+	if err := validatePCGWBaseURL(config.PCGWBaseURL); err != nil {
+		return err
+	}
+	if err := validateRestoreMode(config.DefaultRestoreMode); err != nil {
+		return err
+	}
+	if config.DatabaseBackend != a.backupManager.config().DatabaseBackend {
+		if err := a.backupManager.SetDatabaseBackend(config.DatabaseBackend); err != nil {
+			return err
+		}
+	}
+	previous := a.backupManager.config()
+	a.backupManager.applyConfig(config)
+	if a.backupManager.PCGWClient != nil {
+		a.backupManager.PCGWClient.SetBaseURL(config.PCGWBaseURL)
+	}
+	// StartAPI no hace nada con un servidor en marcha: un puerto nuevo exige detenerlo antes
+	if err := a.backupManager.updateAPI(previous); err != nil {
+		log.Printf("[WARN] Error iniciando la API local: %v", err)
+	}
+	err := a.backupManager.SaveConfig("config.json")
+	a.backupManager.recordActivity(ActivityEntry{Type: ActivityConfig, Summary: "Configuración actualizada"}, err)
+	return err
 }
 
 // GetGameInfo devuelve información detallada de un juego
 func (a *App) GetGameInfo(gameID string) (*GameInfo, error) {
-	game, exists := a.backupManager.DetectedGames[gameID]
+	game, exists := a.backupManager.getGame(gameID)
 	if !exists {
-		return nil, fmt.Errorf("juego con ID %s no encontrado", gameID)
+		return nil, errGameNotFound(gameID)
 	}
 	if err := a.backupManager.updateGameInfo(game); err != nil {
 		log.Printf("[WARN] Error actualizando info del juego %s: %v", gameID, err)
 	}
-	return game, nil
+	return a.backupManager.humanGames([]*GameInfo{game})[0], nil
+}
+
+// UpdateGame edita un juego conservando su ID y su historial de backups
+func (a *App) UpdateGame(gameID string, update GameUpdate) (*GameInfo, error) {
+	return a.backupManager.UpdateGame(gameID, update)
+}
+
+// RelocateGame mueve un juego a la carpeta donde aparecieron sus archivos
+func (a *App) RelocateGame(gameID, newPath string) (*GameInfo, error) {
+	log.Printf("[INFO] Reubicando %s en %s", gameID, newPath)
+	return a.backupManager.RelocateGame(gameID, newPath)
+}
+
+// RescanGame revisa las rutas de un juego e informa si sus archivos se movieron a otra carpeta.
+// Wails solo transmite un valor (más error): la reubicación sugerida va en el propio resultado.
+func (a *App) RescanGame(gameID string) (*RescanResult, error) {
+	game, relocation, err := a.backupManager.RescanGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	return &RescanResult{Game: game, Relocation: relocation}, nil
 }
 
 // RemoveGame elimina un juego detectado
 func (a *App) RemoveGame(gameID string) error {
-	if _, exists := a.backupManager.DetectedGames[gameID]; !exists {
-		return fmt.Errorf("juego con ID %s no encontrado", gameID)
+	if _, exists := a.backupManager.getGame(gameID); !exists {
+		return errGameNotFound(gameID)
 	}
-	delete(a.backupManager.DetectedGames, gameID)
+	a.backupManager.deleteGame(gameID)
 	return a.backupManager.SaveDatabase()
 }
 
@@ -167,27 +348,432 @@ func (a *App) ValidatePath(path string) bool {
 	return a.backupManager.gameExists(&GameInfo{SavePaths: []string{ExpandPath(path)}})
 }
 
+// ResolvePath devuelve la ruta expandida y si existe, para mostrarla en vivo al configurar rutas.
+// Wails solo transmite un valor (más error), por eso se devuelve una estructura.
+func (a *App) ResolvePath(path string) PathResolution {
+	resolved, exists := ResolvePath(path)
+	_, unmounted := unmountedMountPoint(resolved)
+	return PathResolution{Path: resolved, Exists: exists, Unmounted: unmounted}
+}
+
 // GetBackupHistory devuelve el historial de backups de un juego
 func (a *App) GetBackupHistory(gameID string) ([]BackupInfo, error) {
-	// Implementar si se requiere
-	return []BackupInfo{}, nil
+	backups, err := a.backupManager.GetBackupHistory(gameID)
+	return a.backupManager.humanBackups(backups), err
+}
+
+// GetAllBackups devuelve los backups de todos los juegos ordenados por fecha
+func (a *App) GetAllBackups() ([]GlobalBackupEntry, error) {
+	return a.backupManager.GetAllBackups()
+}
+
+// DiffBackups compara dos backups de un juego
+func (a *App) DiffBackups(gameID, fileA, fileB string) (*BackupDiff, error) {
+	return a.backupManager.DiffBackups(gameID, fileA, fileB)
+}
+
+// GetChangesSinceLastBackup muestra qué archivos cambiaron desde el último backup
+func (a *App) GetChangesSinceLastBackup(gameID string) (*BackupDiff, error) {
+	return a.backupManager.GetChangesSinceLastBackup(gameID)
+}
+
+// ImportBackup importa un archivo ZIP o carpeta existente como backup de un juego
+func (a *App) ImportBackup(gameID string, archivePath string, takenAt time.Time) (*BackupInfo, error) {
+	log.Printf("[INFO] Importando backup para %s desde %s", gameID, archivePath)
+	return a.backupManager.ImportBackup(gameID, archivePath, takenAt)
+}
+
+// VerifyBackup comprueba un backup contra su manifiesto de checksums
+func (a *App) VerifyBackup(gameID, fileName string) (*VerifyResult, error) {
+	return a.backupManager.VerifyBackup(gameID, fileName)
+}
+
+// CheckArchive comprueba que un backup se puede leer entero sin extraerlo
+func (a *App) CheckArchive(gameID, fileName string) error {
+	return a.backupManager.CheckArchive(gameID, fileName)
+}
+
+// RepairBackup repara un backup dañado usando copias intactas de otros backups
+func (a *App) RepairBackup(gameID, fileName string) (*RepairReport, error) {
+	log.Printf("[INFO] Reparando backup %s de %s", fileName, gameID)
+	return a.backupManager.RepairBackup(gameID, fileName)
+}
+
+// ExportBackup copia un backup a un destino elegido por el usuario
+func (a *App) ExportBackup(gameID, backupFileName, destPath string) error {
+	log.Printf("[INFO] Exportando backup %s de %s a %s", backupFileName, gameID, destPath)
+	return a.backupManager.ExportBackup(gameID, backupFileName, destPath)
+}
+
+// SetGameSchedule cambia la programación de backups automáticos de un juego
+func (a *App) SetGameSchedule(gameID, schedule string) error {
+	return a.backupManager.SetGameSchedule(gameID, schedule)
+}
+
+// GetSchedulePreview devuelve la próxima ejecución prevista para cada juego
+func (a *App) GetSchedulePreview() []ScheduleEntry {
+	return a.backupManager.GetSchedulePreview()
+}
+
+// SetBackupPinned fija o libera un backup
+func (a *App) SetBackupPinned(gameID, fileName string, pinned bool) error {
+	return a.backupManager.SetBackupPinned(gameID, fileName, pinned)
+}
+
+// RestoreBackup restaura un backup en las rutas de guardado del juego
+func (a *App) RestoreBackup(gameID, fileName string, opts RestoreOptions) (*RestoreRecord, error) {
+	log.Printf("[INFO] Restaurando backup %s de %s", fileName, gameID)
+	return a.backupManager.RestoreBackup(gameID, fileName, opts)
+}
+
+// GetRestoreHistory devuelve las restauraciones de un juego
+func (a *App) GetRestoreHistory(gameID string) (*RestoreHistory, error) {
+	return a.backupManager.GetRestoreHistory(gameID)
+}
+
+// SetRegistryBackup activa o desactiva el respaldo de las claves del registro de Wine de un juego
+func (a *App) SetRegistryBackup(gameID string, enabled bool, keys []string) (*GameInfo, error) {
+	return a.backupManager.SetRegistryBackup(gameID, enabled, keys)
+}
+
+// SetExtraBackupDirs configura los destinos adicionales donde se copia cada backup de un juego
+func (a *App) SetExtraBackupDirs(gameID string, dirs []string) (*GameInfo, error) {
+	return a.backupManager.SetExtraBackupDirs(gameID, dirs)
+}
+
+// EstimateBackupDuration estima cuánto tardará el próximo backup de un juego según sus backups anteriores
+func (a *App) EstimateBackupDuration(gameID string) (time.Duration, error) {
+	return a.backupManager.EstimateBackupDuration(gameID)
+}
+
+// ValidatePrefixes comprueba los prefijos de Wine y marca los juegos cuyo prefijo falta o está dañado
+func (a *App) ValidatePrefixes() ([]PrefixHealth, error) {
+	return a.backupManager.ValidatePrefixes()
+}
+
+// RebindGamePrefix asocia un juego a otro prefijo de Wine
+func (a *App) RebindGamePrefix(gameID, prefixPath string) (*GameInfo, error) {
+	log.Printf("[INFO] Cambiando prefijo de %s a %s", gameID, prefixPath)
+	return a.backupManager.RebindGamePrefix(gameID, prefixPath)
+}
+
+// GetSteamAccounts devuelve las cuentas de Steam encontradas, para elegir dónde restaurar partidas de Steam Cloud
+func (a *App) GetSteamAccounts() []SteamAccount {
+	return a.backupManager.GetSteamAccounts()
+}
+
+// SetBackupWholeProfile activa o desactiva el respaldo del perfil completo del prefijo de un juego
+func (a *App) SetBackupWholeProfile(gameID string, enabled bool) (*GameInfo, error) {
+	log.Printf("[INFO] Perfil completo para %s: %v", gameID, enabled)
+	return a.backupManager.SetBackupWholeProfile(gameID, enabled)
+}
+
+// RunIntegrityCheckNow verifica ahora todos los backups de un juego
+func (a *App) RunIntegrityCheckNow(gameID string) (*IntegrityCheckSummary, error) {
+	log.Printf("[INFO] Comprobando la integridad de los backups de %s", gameID)
+	return a.backupManager.RunIntegrityCheckNow(gameID)
+}
+
+// PauseAutoBackup suspende los backups automáticos durante duration y devuelve cuándo se reanudan
+func (a *App) PauseAutoBackup(duration time.Duration) (time.Time, error) {
+	log.Printf("[INFO] Pausando backups automáticos durante %v", duration)
+	until, err := a.backupManager.PauseAutoBackup(duration)
+	if err != nil {
+		return until, err
+	}
+	return until, a.backupManager.SaveConfig("config.json")
+}
+
+// ResumeAutoBackup reanuda los backups automáticos en pausa
+func (a *App) ResumeAutoBackup() error {
+	a.backupManager.ResumeAutoBackup()
+	return a.backupManager.SaveConfig("config.json")
+}
+
+// ListProfiles devuelve los perfiles de usuario encontrados en las rutas de guardado de un juego
+func (a *App) ListProfiles(gameID string) ([]GameProfile, error) {
+	return a.backupManager.ListProfiles(gameID)
+}
+
+// SetBackupProfiles separa los perfiles elegidos de un juego en juegos con sus propios backups
+func (a *App) SetBackupProfiles(gameID string, profileIDs []string) ([]*GameInfo, error) {
+	log.Printf("[INFO] Perfiles a respaldar de %s: %v", gameID, profileIDs)
+	return a.backupManager.SetBackupProfiles(gameID, profileIDs)
+}
+
+// SetTags sustituye las etiquetas de un juego
+func (a *App) SetTags(gameID string, tags []string) (*GameInfo, error) {
+	return a.backupManager.SetTags(gameID, tags)
+}
+
+// GetGamesByTag devuelve los juegos con una etiqueta; sin etiqueta, todos
+func (a *App) GetGamesByTag(tag string) []*GameInfo {
+	return a.backupManager.humanGames(a.backupManager.GetGamesByTag(tag))
+}
+
+// GetAllTags devuelve las etiquetas usadas en la biblioteca
+func (a *App) GetAllTags() []string {
+	return a.backupManager.GetAllTags()
+}
+
+// RenameGameSlug cambia el nombre de la carpeta de backups de un juego; con dryRun solo muestra lo que cambiaría
+func (a *App) RenameGameSlug(gameID, newSlug string, dryRun bool) (*SlugRenamePlan, error) {
+	if !dryRun {
+		log.Printf("[WARN] Renombrando la carpeta de backups de %s a %s", gameID, newSlug)
+	}
+	return a.backupManager.RenameGameSlug(gameID, newSlug, dryRun)
+}
+
+// CheckRestoreSpace devuelve, por volumen, el espacio que ocuparía restaurar un backup y el espacio libre
+func (a *App) CheckRestoreSpace(gameID, fileName string, opts RestoreOptions) ([]RestoreSpace, error) {
+	return a.backupManager.CheckRestoreSpace(gameID, fileName, opts)
+}
+
+// RebuildDatabaseFromBackups agrega a la biblioteca los juegos de las carpetas de backups que no están en ella
+func (a *App) RebuildDatabaseFromBackups() (int, error) {
+	log.Printf("[INFO] Reconstruyendo la biblioteca desde %s", a.backupManager.config().BackupDir)
+	return a.backupManager.RebuildDatabaseFromBackups()
+}
+
+// GetLibrarySummary devuelve los juegos y el tamaño de sus partidas por plataforma, estado y etiqueta
+func (a *App) GetLibrarySummary() (*LibrarySummary, error) {
+	return a.backupManager.GetLibrarySummary()
+}
+
+// BackupGamesByFilter encola backups de los juegos que cumplen un filtro y devuelve los IDs de los trabajos
+func (a *App) BackupGamesByFilter(filter GameFilter) ([]string, error) {
+	log.Printf("[INFO] Backup por filtro: %+v", filter)
+	return a.backupManager.BackupGamesByFilter(filter)
+}
+
+// RelocateGamePaths cambia la carpeta anterior por la nueva en las rutas de un juego reinstalado en otro sitio
+func (a *App) RelocateGamePaths(gameID, oldRoot, newRoot string) error {
+	log.Printf("[INFO] Reubicando rutas de %s: %s -> %s", gameID, oldRoot, newRoot)
+	return a.backupManager.RelocateGamePaths(gameID, oldRoot, newRoot)
+}
+
+// FindLikelyNewLocation busca carpetas que contienen los archivos del último backup de un juego
+func (a *App) FindLikelyNewLocation(gameID string) ([]string, error) {
+	return a.backupManager.FindLikelyNewLocation(gameID)
+}
+
+// SetKeepDeletedFiles activa o desactiva la protección de partidas borradas de un juego
+func (a *App) SetKeepDeletedFiles(gameID string, enabled bool) (*GameInfo, error) {
+	log.Printf("[INFO] Protección de partidas borradas para %s: %v", gameID, enabled)
+	return a.backupManager.SetKeepDeletedFiles(gameID, enabled)
+}
+
+// GetDeletedFiles devuelve las partidas que el juego borró y WineSave guardó
+func (a *App) GetDeletedFiles(gameID string) ([]DeletedFile, error) {
+	return a.backupManager.GetDeletedFiles(gameID)
+}
+
+// RecoverDeletedFile copia una partida borrada a destPath
+func (a *App) RecoverDeletedFile(gameID, name, destPath string) error {
+	log.Printf("[INFO] Recuperando partida borrada %s de %s en %s", name, gameID, destPath)
+	return a.backupManager.RecoverDeletedFile(gameID, name, destPath)
+}
+
+// GenerateSummary resume los backups de los últimos period (0 = todo el registro de actividad)
+func (a *App) GenerateSummary(period time.Duration) (*UsageSummary, error) {
+	summary, err := a.backupManager.GenerateSummary(period)
+	return a.backupManager.humanSummary(summary), err
+}
+
+// ScrubAllBackups revisa todos los backups, repara los dañados que tienen copias intactas y pone en cuarentena el resto
+func (a *App) ScrubAllBackups() (*ScrubReport, error) {
+	log.Println("[INFO] Revisando todos los backups")
+	report, err := a.backupManager.ScrubAllBackups()
+	if err == nil && len(report.Unrecoverable) > 0 {
+		log.Printf("[WARN] %d backups dañados sin reparación", len(report.Unrecoverable))
+	}
+	return report, err
+}
+
+// GetLastScrubReport devuelve el informe de la última revisión completa de los backups (nil si no hay ninguna)
+func (a *App) GetLastScrubReport() (*ScrubReport, error) {
+	return a.backupManager.GetLastScrubReport()
+}
+
+// GetEnvironmentReport devuelve las variables, carpetas y prefijos con los que se detectan los juegos
+func (a *App) GetEnvironmentReport() *EnvironmentReport {
+	return a.backupManager.GetEnvironmentReport()
+}
+
+// ExportDiagnostics guarda en destPath la información para reportar un problema, sin rutas ni usuarios personales
+func (a *App) ExportDiagnostics(destPath string) error {
+	log.Printf("[INFO] Exportando diagnóstico a %s", destPath)
+	file, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("error creando %s: %v", destPath, err)
+	}
+	if err := a.backupManager.ExportDiagnostics(file); err != nil {
+		file.Close()
+		log.Printf("[ERROR] Error exportando diagnóstico: %v", err)
+		return err
+	}
+	return file.Close()
+}
+
+// GetStorageBreakdown devuelve cuánto ocupan los backups, la cuarentena, los temporales y los registros
+func (a *App) GetStorageBreakdown() (*StorageBreakdown, error) {
+	return a.backupManager.GetStorageBreakdown()
+}
+
+// CleanupStorage elimina los datos prescindibles de las categorías indicadas más antiguos que olderThan
+func (a *App) CleanupStorage(categories []string, olderThan time.Duration) (*CleanupReport, error) {
+	log.Printf("[INFO] Limpiando almacenamiento %v (más antiguo que %v)", categories, olderThan)
+	return a.backupManager.CleanupStorage(categories, olderThan)
+}
+
+// SuggestPatterns sugiere patrones para las extensiones que no incluye un juego, vistas en sus backups manuales
+func (a *App) SuggestPatterns(gameID string) []string {
+	patterns, err := a.backupManager.SuggestPatterns(gameID)
+	if err != nil {
+		log.Printf("[WARN] Error sugiriendo patrones: %v", err)
+		return []string{}
+	}
+	return patterns
+}
+
+// GetStartupIssues devuelve los problemas encontrados al arrancar, como una base de datos dañada
+func (a *App) GetStartupIssues() []StartupIssue {
+	return a.backupManager.GetStartupIssues()
+}
+
+// RestoreCorruptDatabase vuelve a usar una base de datos dañada que el usuario reparó a mano
+func (a *App) RestoreCorruptDatabase(corruptPath string) error {
+	log.Printf("[INFO] Restaurando base de datos desde %s", corruptPath)
+	return a.backupManager.RestoreCorruptDatabase(corruptPath)
+}
+
+// PreflightCheck vuelve a comprobar los permisos del directorio de backups y de las rutas de guardado
+func (a *App) PreflightCheck() []PreflightIssue {
+	return a.backupManager.PreflightCheck()
+}
+
+// GetQuarantinedBackups lista los backups dañados apartados a la cuarentena
+func (a *App) GetQuarantinedBackups() ([]GlobalBackupEntry, error) {
+	return a.backupManager.GetQuarantinedBackups()
+}
+
+// PurgeQuarantinedBackups elimina definitivamente los backups en cuarentena
+func (a *App) PurgeQuarantinedBackups() (int, error) {
+	log.Println("[INFO] Vaciando la cuarentena de backups")
+	return a.backupManager.PurgeQuarantinedBackups()
+}
+
+// FindPathOverlaps lista las rutas de guardado compartidas entre juegos distintos
+func (a *App) FindPathOverlaps() ([]Overlap, error) {
+	return a.backupManager.FindPathOverlaps()
+}
+
+// GetBackupCoverageReport lista los juegos sin backups, con backups antiguos o fallidos, o con cambios sin respaldar
+func (a *App) GetBackupCoverageReport() (*CoverageReport, error) {
+	return a.backupManager.GetBackupCoverageReport()
+}
+
+// BackupAllStale encola un backup de cada juego del informe de cobertura
+func (a *App) BackupAllStale() ([]string, error) {
+	log.Println("[INFO] Encolando backups de los juegos sin cobertura")
+	return a.backupManager.BackupAllStale()
+}
+
+// HasChangesSinceLastBackup indica si hay partidas modificadas después del último backup (comprobación rápida por fechas)
+func (a *App) HasChangesSinceLastBackup(gameID string) (bool, error) {
+	return a.backupManager.HasChangesSinceLastBackup(gameID)
+}
+
+// GetGamesPlayedWithin devuelve los juegos jugados en los últimos días indicados
+func (a *App) GetGamesPlayedWithin(days int) []*GameInfo {
+	return a.backupManager.humanGames(a.backupManager.GetGamesPlayedWithin(days))
+}
+
+// GetActivity devuelve la actividad reciente (escaneos, backups, restauraciones, cambios de configuración)
+func (a *App) GetActivity(limit int, types []string) ([]ActivityEntry, error) {
+	return a.backupManager.GetActivity(limit, types)
+}
+
+// SetSavePathConfig configura la lectura de la carpeta de partidas desde la configuración del juego (nil la quita)
+func (a *App) SetSavePathConfig(gameID string, config *SavePathConfig) (*GameInfo, error) {
+	return a.backupManager.SetSavePathConfig(gameID, config)
+}
+
+// RunSelfTest comprueba el proceso completo de backup y restauración con un juego de prueba
+func (a *App) RunSelfTest() (*SelfTestReport, error) {
+	log.Println("[INFO] Ejecutando autoprueba")
+	report, err := a.backupManager.SelfTest()
+	if err == nil && !report.Passed {
+		log.Println("[WARN] La autoprueba encontró errores")
+	}
+	return report, err
 }
 
 // ------------------- Tipos de datos -------------------
 
 type BackupInfo struct {
+	Name       string    `json:"name"`
 	Path       string    `json:"path"`
 	Size       int64     `json:"size"`
 	Created    time.Time `json:"created"`
 	Compressed bool      `json:"compressed"`
+	Pinned     bool      `json:"pinned"`
+
+	SaveInfo       *SaveMetadata `json:"save_info,omitempty"`
+	LastRestoredAt time.Time     `json:"last_restored_at"` // Cero si nunca se restauró
+	Current        bool          `json:"current"`          // Los archivos actuales corresponden a este backup restaurado
+
+	Destinations []DestinationResult `json:"destinations,omitempty"` // Copias en los destinos adicionales del juego
+
+	WholeProfile bool `json:"whole_profile"` // Perfil completo del prefijo: puede ser grande y su restauración afecta a otros juegos
+
+	Throttled        bool    `json:"throttled,omitempty"` // Se creó con el límite de E/S de los backups automáticos
+	CompressionRatio float64 `json:"compression_ratio"`   // Tamaño del ZIP respecto a las partidas (0,4 = 60 % menos); 0 si no se conoce
+
+	LastVerified time.Time `json:"last_verified"` // Última verificación de integridad; cero si nunca se verificó
+
+	Quarantined      bool   `json:"quarantined"` // Dañado y apartado a la cuarentena: no se puede restaurar
+	QuarantineReason string `json:"quarantine_reason,omitempty"`
+
+	OperationID string `json:"operation_id,omitempty"` // Solo en el backup recién creado: operación que lo creó
+
+	HumanSize string `json:"human_size,omitempty"` // Size para mostrar
+}
+
+// RescanResult es el resultado de RescanGame; Relocation es nil si no se encontró otra carpeta
+type RescanResult struct {
+	Game       *GameInfo       `json:"game"`
+	Relocation *GameRelocation `json:"relocation"`
+}
+
+type PathResolution struct {
+	Path      string `json:"path"`
+	Exists    bool   `json:"exists"`
+	Unmounted bool   `json:"unmounted"` // La ruta no existe porque su unidad no está montada
 }
 
 type BatchBackupResult struct {
-	TotalGames   int      `json:"total_games"`
-	SuccessCount int      `json:"success_count"`
-	ErrorCount   int      `json:"error_count"`
-	Errors       []string `json:"errors"`
-	BackupPath   string   `json:"backup_path"`
+	TotalGames   int                `json:"total_games"`
+	SuccessCount int                `json:"success_count"` // Incluye los omitidos por no tener cambios
+	ErrorCount   int                `json:"error_count"`
+	Errors       []string           `json:"errors"`
+	BackupPath   string             `json:"backup_path"`
+	Results      []GameBackupResult `json:"results"` // Uno por cada juego pedido, en el mismo orden
+}
+
+// GameBackupResult es el resultado del backup de un juego dentro de un lote
+type GameBackupResult struct {
+	GameID     string        `json:"game_id"`     // Vacío si el juego no se pudo resolver
+	Name       string        `json:"name"`        // Nombre tal como se pidió
+	Success    bool          `json:"success"`     // También true cuando se omite por no tener cambios
+	BackupPath string        `json:"backup_path"` // Vacío si no se creó backup
+	SizeBytes  int64         `json:"size_bytes"`
+	FileCount  int           `json:"file_count"`
+	Duration   time.Duration `json:"duration"` // Nanosegundos, como ScanResult.ScanTime
+	Skipped    bool          `json:"skipped"`  // Sin cambios desde el último backup
+	Error      string        `json:"error"`
+
+	OperationID string `json:"operation_id,omitempty"` // Trabajo de la cola que hizo el backup
 }
 
 type DetailedGameInfo struct {
@@ -199,12 +785,35 @@ type DetailedGameInfo struct {
 	SavePaths   []string `json:"save_paths"`
 	Available   bool     `json:"available"`
 	Reason      string   `json:"reason"`
+	// Sin AutoConfirm el usuario debe elegir entre Candidates y llamar a ConfirmGameSelections
+	AutoConfirm bool            `json:"auto_confirm"`
+	Candidates  []GameCandidate `json:"candidates"`
+}
+
+// GameCandidate es una coincidencia de PCGamingWiki para un nombre pedido
+type GameCandidate struct {
+	Name         string   `json:"name"`
+	PageID       string   `json:"page_id"`
+	SteamAppID   string   `json:"steam_app_id"`
+	ReleaseDate  string   `json:"release_date"`
+	CoverURL     string   `json:"cover_url"`
+	SavePaths    []string `json:"save_paths"`     // Solo las que existen en este equipo
+	GameDirPaths []string `json:"game_dir_paths"` // Rutas dentro de la carpeta del juego (%GAME_DIR%): necesitan su InstallDir
+	RegistryKeys []string `json:"registry_keys"`  // Claves de registro según la wiki
+	Score        float64  `json:"score"`          // Parecido con el nombre pedido, de 0 a 1
 }
 
 // ------------------- main -------------------
 
 func main() {
+	daemon := flag.Bool("daemon", false, "ejecutar sin ventana: backups programados y API local hasta recibir SIGINT/SIGTERM")
+	flag.Parse()
+
 	app := NewApp()
+	if *daemon {
+		runDaemon(app)
+		return
+	}
 
 	err := wails.Run(&options.App{
 		Title:            "Game Save Backup Manager",
@@ -223,6 +832,7 @@ func main() {
 		Bind: []interface{}{
 			app, // <- Esto es lo que expone tus métodos al frontend
 		},
+		ErrorFormatter: formatAppError,
 	})
 
 	if err != nil {
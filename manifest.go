@@ -0,0 +1,193 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// manifestSuffix es la extensión del archivo de checksums que acompaña a cada backup
+const manifestSuffix = ".manifest.json"
+
+// contentIndexSuffix es la extensión del índice en texto plano opcional de cada backup (Config.ContentIndex)
+const contentIndexSuffix = ".index.txt"
+
+// ManifestEntry describe un archivo dentro de un backup con su checksum
+type ManifestEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+
+	Method         string `json:"method,omitempty"`          // Solo ZIP: "store" (sin comprimir) o "deflate"
+	CompressedSize int64  `json:"compressed_size,omitempty"` // Solo ZIP: tamaño dentro del archivo
+}
+
+// BackupManifest es el archivo de checksums guardado junto a cada backup
+type BackupManifest struct {
+	GameID    string          `json:"game_id"`
+	Backup    string          `json:"backup"`
+	CreatedAt time.Time       `json:"created_at"`
+	Files     []ManifestEntry `json:"files"`
+}
+
+// manifestPath devuelve la ruta del manifiesto de un backup
+func manifestPath(backupPath string) string {
+	return backupPath + manifestSuffix
+}
+
+// contentIndexPath devuelve la ruta del índice en texto plano de un backup
+func contentIndexPath(backupPath string) string {
+	return backupPath + contentIndexSuffix
+}
+
+// sidecarPaths devuelve los archivos que acompañan a un backup y se copian, mueven y borran con él
+func sidecarPaths(backupPath string) []string {
+	return []string{manifestPath(backupPath), contentIndexPath(backupPath)}
+}
+
+// isSidecar indica si una ruta es un archivo que acompaña a un backup y no un backup
+func isSidecar(path string) bool {
+	return strings.HasSuffix(path, manifestSuffix) || strings.HasSuffix(path, contentIndexSuffix)
+}
+
+// hashReader calcula el SHA-256 de un lector y devuelve el número de bytes leídos
+func hashReader(r io.Reader) (string, int64, error) {
+	hash := sha256.New()
+	size, err := io.Copy(hash, r)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// buildManifest lee el contenido de un backup (ZIP o carpeta) y calcula el checksum de cada archivo
+func buildManifest(gameID, backupPath string) (*BackupManifest, error) {
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &BackupManifest{
+		GameID:    gameID,
+		Backup:    filepath.Base(backupPath),
+		CreatedAt: time.Now(),
+		Files:     []ManifestEntry{},
+	}
+
+	if info.IsDir() {
+		err = filepath.WalkDir(backupPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			rel, err := filepath.Rel(backupPath, path)
+			if err != nil {
+				return err
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			sum, size, err := hashReader(file)
+			if err != nil {
+				return err
+			}
+			manifest.Files = append(manifest.Files, ManifestEntry{Path: filepath.ToSlash(rel), Size: size, SHA256: sum})
+			return nil
+		})
+	} else {
+		err = hashZipEntries(backupPath, func(entry ManifestEntry) {
+			manifest.Files = append(manifest.Files, entry)
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error generando manifiesto de %s: %v", info.Name(), err)
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+
+	return manifest, nil
+}
+
+// hashZipEntries calcula el checksum de cada entrada de un ZIP
+func hashZipEntries(zipPath string, fn func(entry ManifestEntry)) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %v", file.Name, err)
+		}
+		sum, size, err := hashReader(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", file.Name, err)
+		}
+
+		fn(ManifestEntry{
+			Path:           file.Name,
+			Size:           size,
+			SHA256:         sum,
+			Method:         zipMethodName(file.Method),
+			CompressedSize: int64(file.CompressedSize64),
+		})
+	}
+
+	return nil
+}
+
+// writeManifest guarda el manifiesto junto al backup
+func writeManifest(backupPath string, manifest *BackupManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath(backupPath), data, 0644)
+}
+
+// writeContentIndex guarda junto al backup un índice en texto plano con una línea "<ruta>\t<tamaño>" por archivo,
+// precedido de una cabecera con el nombre y la fecha del backup, para buscar con grep sin la aplicación
+func writeContentIndex(backupPath string, manifest *BackupManifest) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s %s\n", filepath.Base(backupPath), manifest.CreatedAt.Format(time.RFC3339))
+	for _, entry := range manifest.Files {
+		fmt.Fprintf(&b, "%s\t%d\n", entry.Path, entry.Size)
+	}
+	return os.WriteFile(contentIndexPath(backupPath), []byte(b.String()), 0644)
+}
+
+// loadManifest lee el manifiesto de un backup
+func loadManifest(backupPath string) (*BackupManifest, error) {
+	data, err := os.ReadFile(manifestPath(backupPath))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
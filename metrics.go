@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// backupMetrics son los contadores de backups desde que arrancó la aplicación
+type backupMetrics struct {
+	backups       atomic.Int64
+	failures      atomic.Int64
+	bytesBackedUp atomic.Int64
+}
+
+// recordBackupMetrics actualiza los contadores con el resultado de un backup
+func (bm *BackupManager) recordBackupMetrics(info *BackupInfo, err error) {
+	if err != nil {
+		bm.metrics.failures.Add(1)
+		return
+	}
+	bm.metrics.backups.Add(1)
+	if info != nil {
+		bm.metrics.bytesBackedUp.Add(info.Size)
+	}
+}
+
+// serveMetrics responde con las métricas en el formato de texto de Prometheus: los contadores desde el
+// arranque y, por juego, la fecha del último backup y los backups guardados según el historial
+func (bm *BackupManager) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	writeMetric(w, "winesave_backups_total", "counter", "Backups creados desde el arranque.", bm.metrics.backups.Load())
+	writeMetric(w, "winesave_backup_failures_total", "counter", "Backups fallidos desde el arranque.", bm.metrics.failures.Load())
+	writeMetric(w, "winesave_bytes_backed_up_total", "counter", "Bytes escritos en backups desde el arranque.", bm.metrics.bytesBackedUp.Load())
+
+	games := bm.GetGameList()
+	for i, game := range games {
+		games[i] = bm.gameSnapshot(game) // La cola de backups puede estar cambiando LastBackup
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].Name < games[j].Name })
+
+	fmt.Fprintln(w, "# HELP winesave_last_backup_timestamp_seconds Fecha del último backup del juego (0 si no tiene).")
+	fmt.Fprintln(w, "# TYPE winesave_last_backup_timestamp_seconds gauge")
+	for _, game := range games {
+		var timestamp int64
+		if !game.LastBackup.IsZero() {
+			timestamp = game.LastBackup.Unix()
+		}
+		fmt.Fprintf(w, "winesave_last_backup_timestamp_seconds{%s} %d\n", gameLabels(game), timestamp)
+	}
+
+	fmt.Fprintln(w, "# HELP winesave_backups_stored Backups guardados del juego.")
+	fmt.Fprintln(w, "# TYPE winesave_backups_stored gauge")
+	var sizes []string
+	for _, game := range games {
+		backups, err := bm.listBackups(game.ID)
+		if err != nil {
+			continue
+		}
+		var size int64
+		for _, backup := range backups {
+			size += backup.Size
+		}
+		fmt.Fprintf(w, "winesave_backups_stored{%s} %d\n", gameLabels(game), len(backups))
+		sizes = append(sizes, fmt.Sprintf("winesave_backup_store_bytes{%s} %d\n", gameLabels(game), size))
+	}
+
+	fmt.Fprintln(w, "# HELP winesave_backup_store_bytes Tamaño de los backups guardados del juego.")
+	fmt.Fprintln(w, "# TYPE winesave_backup_store_bytes gauge")
+	for _, line := range sizes {
+		io.WriteString(w, line)
+	}
+}
+
+// writeMetric escribe una métrica sin etiquetas con su ayuda y su tipo
+func writeMetric(w io.Writer, name, kind, help string, value int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+}
+
+// gameLabels devuelve las etiquetas de un juego en las métricas
+func gameLabels(game *GameInfo) string {
+	return fmt.Sprintf(`game_id="%s",name="%s"`, escapeLabel(game.ID), escapeLabel(game.Name))
+}
+
+// escapeLabel escapa un valor de etiqueta de Prometheus
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// currentMirrorName es la carpeta, dentro de la carpeta de backups del juego, con la copia sin comprimir
+// de la última partida cuando CurrentMirror está activado
+const currentMirrorName = "current"
+
+// updateCurrentMirror reemplaza la copia current/ del juego por el estado actual de sus archivos.
+// La copia nueva se construye al lado y solo sustituye a la anterior si se completó.
+func (bm *BackupManager) updateCurrentMirror(game *GameInfo, limit *ioLimiter, onFile func()) (string, error) {
+	backupDir := bm.gameBackupDir(game.ID)
+	mirrorPath := filepath.Join(backupDir, currentMirrorName)
+	partial := mirrorPath + ".partial"
+	old := mirrorPath + ".old"
+
+	os.RemoveAll(partial)
+	if err := os.MkdirAll(partial, 0755); err != nil {
+		return "", fmt.Errorf("error creando copia actual: %v", err)
+	}
+	if err := bm.createFolderBackup(game, partial, limit, onFile); err != nil {
+		os.RemoveAll(partial)
+		return "", err
+	}
+
+	os.RemoveAll(old)
+	if err := os.Rename(mirrorPath, old); err != nil && !os.IsNotExist(err) {
+		os.RemoveAll(partial)
+		return "", fmt.Errorf("error reemplazando copia actual: %v", err)
+	}
+	if err := os.Rename(partial, mirrorPath); err != nil {
+		os.Rename(old, mirrorPath)
+		os.RemoveAll(partial)
+		return "", fmt.Errorf("error reemplazando copia actual: %v", err)
+	}
+	os.RemoveAll(old)
+
+	log.Printf("Copia actual de %s actualizada: %s", game.Name, mirrorPath)
+	return mirrorPath, nil
+}
+
+// snapshotDue indica si toca guardar un backup comprimido del juego según SnapshotSchedule.
+// Sin programación (o si no es válida) se guarda uno en cada backup.
+func (bm *BackupManager) snapshotDue(gameID string, now time.Time) bool {
+	interval, err := parseSchedule(bm.config().SnapshotSchedule)
+	if err != nil {
+		log.Printf("Programación de instantáneas no válida, se guarda una en cada backup: %v", err)
+		return true
+	}
+	if interval == 0 {
+		return true
+	}
+
+	backups, err := bm.listBackups(gameID)
+	if err != nil || len(backups) == 0 {
+		return true
+	}
+	return now.Sub(backups[0].Created) >= interval
+}
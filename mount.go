@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrPathUnmounted indica que una ruta de guardado está en una unidad que no está montada.
+// No significa que los archivos se hayan perdido: el backup se omite hasta que la unidad vuelva.
+var ErrPathUnmounted = errors.New("la unidad de la ruta de guardado no está montada")
+
+// checkSaveMounts comprueba que ninguna ruta de guardado del juego está en una unidad desmontada
+func (bm *BackupManager) checkSaveMounts(game *GameInfo) error {
+	for _, savePath := range effectiveSavePaths(game) {
+		expanded := ExpandPath(savePath)
+		if hasGlobMeta(expanded) {
+			expanded = globBase(expanded)
+		}
+		if mountPoint, unmounted := unmountedMountPoint(expanded); unmounted {
+			return fmt.Errorf("%w: %s (%s)", ErrPathUnmounted, mountPoint, savePath)
+		}
+	}
+	return nil
+}
+
+// unmountedMountPoint devuelve el punto de montaje del que cuelga una ruta inexistente si ese punto no está montado.
+// Una ruta que existe nunca se considera desmontada.
+func unmountedMountPoint(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	if _, err := os.Stat(path); err == nil {
+		return "", false
+	}
+
+	if runtime.GOOS == "windows" {
+		volume := filepath.VolumeName(path)
+		if volume == "" {
+			return "", false
+		}
+		if _, err := os.Stat(volume + `\`); err != nil {
+			return volume, true
+		}
+		return "", false
+	}
+
+	path = filepath.Clean(path)
+	mounted, known := readMountPoints()
+
+	// El punto de montaje más profundo del que cuelga la ruta
+	best := ""
+	for _, candidate := range mountPointCandidates(path) {
+		if isWithin(candidate, path) && len(candidate) > len(best) {
+			best = candidate
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+
+	if known {
+		return best, !mounted[best]
+	}
+	// Sin tabla de montajes (macOS): un punto de montaje que no existe no está montado
+	if _, err := os.Stat(best); err != nil {
+		return best, true
+	}
+	return "", false
+}
+
+// mountPointCandidates devuelve los puntos de montaje que podrían contener la ruta:
+// los de /etc/fstab y las ubicaciones habituales de unidades extraíbles
+func mountPointCandidates(path string) []string {
+	candidates := readFstabMountPoints()
+
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	switch {
+	case len(parts) >= 5 && parts[1] == "run" && parts[2] == "media":
+		candidates = append(candidates, "/"+filepath.Join(parts[1:5]...)) // /run/media/<usuario>/<etiqueta>
+	case len(parts) >= 3 && parts[1] == "media":
+		// udisks usa /media/<usuario>/<etiqueta>; los montajes manuales suelen ser /media/<etiqueta>
+		if current, err := user.Current(); err == nil && parts[2] == current.Username && len(parts) >= 4 {
+			candidates = append(candidates, "/"+filepath.Join(parts[1:4]...))
+		} else {
+			candidates = append(candidates, "/"+filepath.Join(parts[1:3]...))
+		}
+	case len(parts) >= 3 && (parts[1] == "mnt" || parts[1] == "Volumes"):
+		candidates = append(candidates, "/"+filepath.Join(parts[1:3]...))
+	}
+	return candidates
+}
+
+// mountEntry es una línea de /proc/self/mounts
+type mountEntry struct {
+	Device     string
+	MountPoint string
+	FSType     string
+	Options    []string
+}
+
+// readMounts lee los montajes activos; devuelve error si el sistema no tiene /proc (macOS, Windows)
+func readMounts() ([]mountEntry, error) {
+	file, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var mounts []mountEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		mounts = append(mounts, mountEntry{
+			Device:     unescapeMountPath(fields[0]),
+			MountPoint: unescapeMountPath(fields[1]),
+			FSType:     fields[2],
+			Options:    strings.Split(fields[3], ","),
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// readMountPoints lee los puntos de montaje activos; known es false si el sistema no tiene /proc
+func readMountPoints() (map[string]bool, bool) {
+	mounts, err := readMounts()
+	if err != nil {
+		return nil, false
+	}
+
+	mounted := make(map[string]bool, len(mounts))
+	for _, mount := range mounts {
+		mounted[mount.MountPoint] = true
+	}
+	return mounted, true
+}
+
+// readFstabMountPoints lee los puntos de montaje configurados en /etc/fstab (sin la raíz ni swap)
+func readFstabMountPoints() []string {
+	file, err := os.Open("/etc/fstab")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var points []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		point := unescapeMountPath(fields[1])
+		if point == "/" || !strings.HasPrefix(point, "/") {
+			continue
+		}
+		points = append(points, filepath.Clean(point))
+	}
+	return points
+}
+
+// unescapeMountPath decodifica los escapes octales (\040 para el espacio) de fstab y /proc/self/mounts
+func unescapeMountPath(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if n, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Tipos de solapamiento entre rutas de guardado de juegos distintos
+const (
+	OverlapDuplicate = "duplicate" // Los dos juegos usan la misma carpeta
+	OverlapContains  = "contains"  // La carpeta de un juego contiene la del otro
+)
+
+// Overlap es un par de rutas de guardado de juegos distintos que comparten archivos: el backup de cada
+// juego incluye las partidas del otro. En OverlapContains, Path es la carpeta que contiene a OtherPath.
+type Overlap struct {
+	Kind          string `json:"kind"`
+	GameID        string `json:"game_id"`
+	GameName      string `json:"game_name"`
+	Path          string `json:"path"`
+	OtherGameID   string `json:"other_game_id"`
+	OtherGameName string `json:"other_game_name"`
+	OtherPath     string `json:"other_path"`
+
+	Suggestion       string `json:"suggestion"`
+	SuggestedExclude string `json:"suggested_exclude,omitempty"` // Patrón de exclusión que separa las partidas del otro juego
+}
+
+// gameSavePath es una ruta de guardado ya expandida de un juego
+type gameSavePath struct {
+	game *GameInfo
+	path string
+}
+
+// FindPathOverlaps busca rutas de guardado repetidas o anidadas entre juegos distintos
+func (bm *BackupManager) FindPathOverlaps() ([]Overlap, error) {
+	var paths []gameSavePath
+	for _, game := range bm.GetGameList() {
+		for _, root := range bm.saveRoots(game) {
+			paths = append(paths, gameSavePath{game: game, path: filepath.Clean(root.Path)})
+		}
+	}
+
+	overlaps := []Overlap{}
+	seen := make(map[string]bool)
+	for i, a := range paths {
+		for _, b := range paths[i+1:] {
+			if a.game.ID == b.game.ID || isProfileOf(a.game, b.game) {
+				continue // Un perfil separado repite a propósito las rutas del juego original
+			}
+			overlap, found := pathOverlap(a, b)
+			if !found {
+				continue
+			}
+			key := overlap.GameID + "|" + overlap.Path + "|" + overlap.OtherGameID + "|" + overlap.OtherPath
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			overlaps = append(overlaps, overlap)
+		}
+	}
+
+	sort.Slice(overlaps, func(i, j int) bool {
+		if overlaps[i].GameName != overlaps[j].GameName {
+			return overlaps[i].GameName < overlaps[j].GameName
+		}
+		return overlaps[i].OtherGameName < overlaps[j].OtherGameName
+	})
+	return overlaps, nil
+}
+
+// pathOverlap compara dos rutas de guardado y, si se solapan, describe el solapamiento
+func pathOverlap(a, b gameSavePath) (Overlap, bool) {
+	keyA, keyB := pathCompareKey(a.path), pathCompareKey(b.path)
+	switch {
+	case keyA == keyB:
+		return Overlap{
+			Kind:          OverlapDuplicate,
+			GameID:        a.game.ID,
+			GameName:      a.game.Name,
+			Path:          a.path,
+			OtherGameID:   b.game.ID,
+			OtherGameName: b.game.Name,
+			OtherPath:     b.path,
+			Suggestion: fmt.Sprintf("%s y %s usan la misma carpeta: quita la ruta de uno de ellos o acótala a la subcarpeta de sus partidas",
+				a.game.Name, b.game.Name),
+		}, true
+	case isWithin(keyA, keyB):
+		return containsOverlap(a, b), true
+	case isWithin(keyB, keyA):
+		return containsOverlap(b, a), true
+	}
+	return Overlap{}, false
+}
+
+// containsOverlap describe el solapamiento en el que la carpeta de parent contiene la de child
+func containsOverlap(parent, child gameSavePath) Overlap {
+	rel, _ := filepath.Rel(parent.path, child.path)
+	exclude := filepath.ToSlash(rel) + "/"
+	return Overlap{
+		Kind:          OverlapContains,
+		GameID:        parent.game.ID,
+		GameName:      parent.game.Name,
+		Path:          parent.path,
+		OtherGameID:   child.game.ID,
+		OtherGameName: child.game.Name,
+		OtherPath:     child.path,
+		Suggestion: fmt.Sprintf("La carpeta de %s contiene las partidas de %s: acota su ruta a la subcarpeta de sus partidas o excluye \"%s\"",
+			parent.game.Name, child.game.Name, exclude),
+		SuggestedExclude: exclude,
+	}
+}
+
+// pathCompareKey normaliza una ruta para compararla; en Windows las rutas no distinguen mayúsculas
+func pathCompareKey(path string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// checkPathOverlaps actualiza los avisos de solapamiento (PathWarnings) de todos los juegos
+func (bm *BackupManager) checkPathOverlaps() {
+	overlaps, err := bm.FindPathOverlaps()
+	if err != nil {
+		log.Printf("Error comprobando solapamientos de rutas: %v", err)
+		return
+	}
+
+	warnings := make(map[string][]string)
+	for _, overlap := range overlaps {
+		warnings[overlap.GameID] = append(warnings[overlap.GameID], overlap.Suggestion)
+		warnings[overlap.OtherGameID] = append(warnings[overlap.OtherGameID], overlap.Suggestion)
+	}
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	for id, game := range bm.DetectedGames {
+		game.PathWarnings = warnings[id]
+	}
+	if len(overlaps) > 0 {
+		log.Printf("Se encontraron %d rutas de guardado compartidas entre juegos", len(overlaps))
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// saveRoot es un directorio (o archivo) concreto a respaldar junto con el prefijo que tendrán sus entradas en el backup
+type saveRoot struct {
+	Path   string
+	Prefix string
+	File   bool // La ruta de guardado es un único archivo: se respalda aunque no coincida con los patrones
+}
+
+// hasGlobMeta indica si una ruta contiene comodines (*, ?, [)
+func hasGlobMeta(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// globBase devuelve la parte de la ruta anterior al primer componente con comodines
+func globBase(pattern string) string {
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	for i, part := range parts {
+		if hasGlobMeta(part) {
+			return filepath.FromSlash(strings.Join(parts[:i], "/"))
+		}
+	}
+	return pattern
+}
+
+// expandGlob expande una ruta con comodines, admitiendo ** para cualquier número de directorios
+func expandGlob(pattern string) []string {
+	if !hasGlobMeta(pattern) {
+		return []string{pattern}
+	}
+
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	doubleStar := -1
+	for i, part := range parts {
+		if part == "**" {
+			doubleStar = i
+			break
+		}
+	}
+
+	if doubleStar == -1 {
+		matches, _ := filepath.Glob(pattern)
+		return matches
+	}
+
+	prefix := filepath.FromSlash(strings.Join(parts[:doubleStar], "/"))
+	rest := filepath.FromSlash(strings.Join(parts[doubleStar+1:], "/"))
+
+	// dir/** es dir entera: devolver también cada subcarpeta haría recorrer sus archivos varias veces
+	if rest == "" {
+		var results []string
+		for _, base := range expandGlob(prefix) {
+			if info, err := os.Stat(base); err == nil && info.IsDir() {
+				results = append(results, base)
+			}
+		}
+		return results
+	}
+
+	seen := make(map[string]bool)
+	var results []string
+	for _, base := range expandGlob(prefix) {
+		filepath.WalkDir(base, func(current string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+
+			candidates := []string{current}
+			if rest != "" {
+				candidates = expandGlob(filepath.Join(current, rest))
+			}
+			for _, match := range candidates {
+				if _, err := os.Stat(match); err == nil && !seen[match] {
+					seen[match] = true
+					results = append(results, match)
+				}
+			}
+			return nil
+		})
+	}
+
+	return results
+}
+
+// gameDirPlaceholder es la carpeta de instalación del juego en las rutas ({{P|game}} en PCGamingWiki)
+const gameDirPlaceholder = "%GAME_DIR%"
+
+// ExpandGamePath expande una ruta de un juego: %GAME_DIR% con su carpeta de instalación y el resto como ExpandPath.
+// Sin InstallDir, %GAME_DIR% se queda sin sustituir y la ruta no existe. Fuera de Windows, las rutas con letra
+// de unidad (D:\Games\...) de un juego con prefijo de Wine se traducen con sus dosdevices.
+func ExpandGamePath(game *GameInfo, path string) string {
+	gameDir := game.InstallDir != "" && strings.Contains(path, gameDirPlaceholder)
+	if gameDir {
+		path = strings.ReplaceAll(path, gameDirPlaceholder, game.InstallDir)
+	}
+	if prefix := gameDrivePrefix(game, path); prefix != "" {
+		if expanded, err := expandInPrefix(path, prefix); err == nil {
+			return expanded
+		}
+	}
+	if !gameDir {
+		return ExpandPath(path)
+	}
+	path = ExpandPath(path)
+	// Las rutas de PCGamingWiki usan \ como separador
+	return filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+}
+
+// gameDrivePrefix devuelve el prefijo de Wine en el que hay que buscar una ruta con letra de unidad del juego,
+// o "" si la ruta no tiene letra, el juego no tiene prefijo o se está en Windows
+func gameDrivePrefix(game *GameInfo, path string) string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	if _, _, ok := cutDriveLetter(path); !ok {
+		return ""
+	}
+	return gamePrefix(game)
+}
+
+// resolveGameDir devuelve las rutas con %GAME_DIR% sustituido por la carpeta de instalación del juego, si se conoce,
+// y las rutas con letra de unidad traducidas a su prefijo de Wine
+func resolveGameDir(game *GameInfo, paths []string) []string {
+	resolved := make([]string, len(paths))
+	for i, path := range paths {
+		resolved[i] = path
+		if (game.InstallDir != "" && strings.Contains(path, gameDirPlaceholder)) || gameDrivePrefix(game, path) != "" {
+			resolved[i] = ExpandGamePath(game, path)
+		}
+	}
+	return resolved
+}
+
+// pathsNeedingInstallDir devuelve las rutas de guardado con %GAME_DIR% que no se pueden resolver porque
+// el juego no tiene carpeta de instalación
+func pathsNeedingInstallDir(game *GameInfo) []string {
+	paths := []string{}
+	if game.InstallDir != "" {
+		return paths
+	}
+	for _, path := range game.SavePaths {
+		if strings.Contains(path, gameDirPlaceholder) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// saveRoots expande las rutas de guardado de un juego a directorios concretos.
+// Las rutas con comodines prefijan sus entradas con la parte que coincidió (p. ej. el ID de usuario de Steam)
+// para que varias coincidencias no colisionen dentro del backup.
+func (bm *BackupManager) saveRoots(game *GameInfo) []saveRoot {
+	var roots []saveRoot
+
+	for _, savePath := range effectiveSavePaths(game) {
+		// Una ruta que solo difiere en mayúsculas de la que existe sigue siendo la misma carpeta
+		expandedPath := matchGlobCase(ExpandPath(savePath))
+		if !hasGlobMeta(expandedPath) {
+			// Un archivo se guarda con su nombre en la raíz del backup
+			if isRegularFile(expandedPath) {
+				roots = append(roots, saveRoot{Path: expandedPath, Prefix: filepath.Base(expandedPath), File: true})
+			} else {
+				roots = append(roots, saveRoot{Path: expandedPath})
+			}
+			continue
+		}
+
+		base := globBase(expandedPath)
+		for _, match := range expandGlob(expandedPath) {
+			rel, err := filepath.Rel(base, match)
+			if err != nil {
+				continue
+			}
+			roots = append(roots, saveRoot{Path: match, Prefix: filepath.ToSlash(rel), File: isRegularFile(match)})
+		}
+	}
+
+	return roots
+}
+
+// isRegularFile indica si path existe y es un archivo normal
+func isRegularFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// entryName construye el nombre de una entrada del backup a partir de su raíz
+func (r saveRoot) entryName(filePath string) (string, error) {
+	rel, err := filepath.Rel(r.Path, filePath)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(r.Prefix, filepath.ToSlash(rel)), nil
+}
+
+// matchPathGlob indica si los componentes de una ruta coinciden con un patrón por componentes,
+// donde "**" coincide con cualquier número de directorios (incluido ninguno)
+func matchPathGlob(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchPathGlob(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchPathGlob(pattern[1:], parts[1:])
+}
+
+// matchPathCase devuelve la ruta sustituyendo cada componente que no existe tal cual por uno existente que solo
+// difiere en mayúsculas (p. ej. prefijos de Wine con Documents y documents en sistemas que las distinguen).
+// Desde el primer componente sin equivalente, el resto de la ruta se deja como está.
+func matchPathCase(p string) string {
+	if _, err := os.Lstat(p); err == nil {
+		return p
+	}
+	parent := filepath.Dir(p)
+	if parent == p {
+		return p
+	}
+	parent = matchPathCase(parent)
+	return filepath.Join(parent, matchNameCase(parent, filepath.Base(p)))
+}
+
+// matchNameCase busca en dir una entrada con el mismo nombre sin distinguir mayúsculas; la coincidencia exacta tiene prioridad
+func matchNameCase(dir, name string) string {
+	if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+		return name
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return name
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), name) {
+			return entry.Name()
+		}
+	}
+	return name
+}
+
+// matchGlobCase corrige las mayúsculas de la parte fija de una ruta con comodines
+func matchGlobCase(pattern string) string {
+	base := globBase(pattern)
+	if base == pattern {
+		return matchPathCase(pattern)
+	}
+	return filepath.Join(matchPathCase(base), strings.TrimPrefix(pattern[len(base):], string(filepath.Separator)))
+}
+
+// matchEntryCase corrige las mayúsculas de una entrada de backup según las carpetas que ya existen en root.
+// Las entradas inválidas se devuelven sin cambios para que las rechace la validación de la extracción.
+func matchEntryCase(root, name string) string {
+	cleaned, err := cleanEntryName(name)
+	if err != nil {
+		return name
+	}
+	target := matchPathCase(filepath.Join(root, filepath.FromSlash(cleaned)))
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return name
+	}
+	return filepath.ToSlash(rel)
+}
+
+// isCaseInsensitiveDir comprueba si el sistema de archivos de dir (o de su antecesor existente) no distingue mayúsculas
+func isCaseInsensitiveDir(dir string) bool {
+	file, err := os.CreateTemp(existingParent(dir), ".winesave-case-")
+	if err != nil {
+		return false
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	_, err = os.Stat(filepath.Join(filepath.Dir(file.Name()), strings.ToUpper(filepath.Base(file.Name()))))
+	return err == nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// walkedNames devuelve los nombres de entrada que produce walkSaveFiles, en orden
+func walkedNames(t *testing.T, bm *BackupManager, game *GameInfo) []string {
+	t.Helper()
+	names := []string{}
+	err := bm.walkSaveFiles(game, func(path, name string, d fs.DirEntry) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSaveRootsSteamUserdataWildcard(t *testing.T) {
+	bm, dir := newTestManager(t)
+	userdata := filepath.Join(dir, "Steam", "userdata")
+	writeTestFiles(t, userdata, map[string]string{
+		"11111111/570/remote/slot1.sav": "a",
+		"22222222/570/remote/slot1.sav": "b",
+		"22222222/570/remote/cfg/x.cfg": "c",
+		"22222222/440/remote/other.sav": "d", // Otro juego
+	})
+	game := bm.DetectedGames["g"]
+	game.SavePaths = []string{filepath.Join(userdata, "*", "570", "remote")}
+
+	roots := bm.saveRoots(game)
+	if len(roots) != 2 {
+		t.Fatalf("%d raíces, se esperaban 2: %+v", len(roots), roots)
+	}
+	want := []string{
+		"11111111/570/remote/slot1.sav",
+		"22222222/570/remote/cfg/x.cfg",
+		"22222222/570/remote/slot1.sav",
+	}
+	if got := walkedNames(t, bm, game); !reflect.DeepEqual(got, want) {
+		t.Errorf("entradas %v, se esperaban %v", got, want)
+	}
+}
+
+func TestSaveRootsTrailingDoubleStar(t *testing.T) {
+	bm, dir := newTestManager(t)
+	saves := filepath.Join(dir, "deep")
+	writeTestFiles(t, saves, map[string]string{
+		"top.sav":       "1",
+		"a/one.sav":     "2",
+		"a/b/two.sav":   "3",
+		"a/b/c/tri.sav": "4",
+	})
+	game := bm.DetectedGames["g"]
+	game.SavePaths = []string{filepath.Join(saves, "**")}
+
+	if roots := bm.saveRoots(game); len(roots) != 1 || roots[0].Path != saves {
+		t.Fatalf("raíces %+v, se esperaba solo %s", roots, saves)
+	}
+	want := []string{"a/b/c/tri.sav", "a/b/two.sav", "a/one.sav", "top.sav"}
+	if got := walkedNames(t, bm, game); !reflect.DeepEqual(got, want) {
+		t.Errorf("entradas %v, se esperaban %v", got, want)
+	}
+	if files, _, err := bm.countBackupFiles(game); err != nil || files != len(want) {
+		t.Errorf("countBackupFiles = %d, %v; se esperaban %d", files, err, len(want))
+	}
+}
+
+func TestWalkSaveFilesOverlappingRoots(t *testing.T) {
+	bm, dir := newTestManager(t)
+	saves := filepath.Join(dir, "nested")
+	writeTestFiles(t, saves, map[string]string{
+		"profile/p.sav":              "1",
+		"profile/mods/profile/m.sav": "2",
+		"other/profile/settings.sav": "3",
+	})
+	game := bm.DetectedGames["g"]
+	game.SavePaths = []string{filepath.Join(saves, "**", "profile")}
+
+	want := []string{"other/profile/settings.sav", "profile/mods/profile/m.sav", "profile/p.sav"}
+	if got := walkedNames(t, bm, game); !reflect.DeepEqual(got, want) {
+		t.Errorf("entradas %v, se esperaban %v", got, want)
+	}
+}
+
+func TestBackupTrailingDoubleStarHasNoDuplicates(t *testing.T) {
+	bm, dir := newTestManager(t)
+	saves := filepath.Join(dir, "deep")
+	writeTestFiles(t, saves, map[string]string{
+		"a/one.sav":   "1",
+		"a/b/two.sav": "2",
+	})
+	game := bm.DetectedGames["g"]
+	game.SavePaths = []string{filepath.Join(saves, "**")}
+
+	info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := loadManifest(filepath.Join(bm.Config.BackupDir, game.Slug, info.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, file := range manifest.Files {
+		if file.Path != backupMetaEntryName {
+			names = append(names, file.Path)
+		}
+	}
+	sort.Strings(names)
+	if want := []string{"a/b/two.sav", "a/one.sav"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("manifiesto con %v, se esperaba %v", names, want)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io/fs"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// learnExtensions registra las extensiones de los archivos de las rutas del juego que sus patrones no incluyen,
+// para sugerir patrones nuevos. Se llama tras cada backup manual: el usuario está respaldando esa carpeta a
+// propósito, así que lo que contiene son partidas aunque el juego use extensiones poco habituales.
+func (bm *BackupManager) learnExtensions(game *GameInfo) {
+	if game.BackupWholeProfile {
+		return // Ya se respalda todo el perfil
+	}
+
+	unmatched := make(map[string]int)
+	seen := make(map[string]bool) // Las raíces pueden solaparse
+	for _, root := range bm.saveRoots(game) {
+		if root.File {
+			continue
+		}
+		filepath.WalkDir(root.Path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if skip, err := bm.skipEntry(game, root.Path, path, d); skip || err != nil {
+				return err
+			}
+			if d.IsDir() || bm.includesFile(game, d.Name()) || seen[path] {
+				return nil
+			}
+			seen[path] = true
+			if ext := filepath.Ext(d.Name()); ext != "" && ext != d.Name() { // Sin extensión o archivo oculto: no hay patrón que sugerir
+				unmatched[strings.ToLower(ext)]++
+			}
+			return nil
+		})
+	}
+
+	if len(unmatched) == 0 {
+		unmatched = nil
+	}
+	bm.updateGame(game, func(game *GameInfo) { game.UnmatchedExtensions = unmatched })
+	if err := bm.SaveDatabase(); err != nil {
+		log.Printf("Error guardando las extensiones vistas de %s: %v", game.Name, err)
+	}
+}
+
+// SuggestPatterns devuelve patrones ("*.ext") para las extensiones que aparecieron en las rutas del juego
+// durante el último backup manual y que sus patrones no incluyen, de la más frecuente a la menos.
+// Se aplican con UpdateGame añadiéndolos a Patterns.
+func (bm *BackupManager) SuggestPatterns(gameID string) ([]string, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	extensions := make([]string, 0, len(game.UnmatchedExtensions))
+	for ext := range game.UnmatchedExtensions {
+		// Los patrones pueden haber cambiado desde el último backup manual
+		if sample := "file" + ext; !bm.includesFile(game, sample) && !bm.isExcluded(sample) {
+			extensions = append(extensions, ext)
+		}
+	}
+	sort.Slice(extensions, func(i, j int) bool {
+		a, b := game.UnmatchedExtensions[extensions[i]], game.UnmatchedExtensions[extensions[j]]
+		if a != b {
+			return a > b
+		}
+		return extensions[i] < extensions[j]
+	})
+
+	patterns := make([]string, len(extensions))
+	for i, ext := range extensions {
+		patterns[i] = "*" + ext
+	}
+	return patterns, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
@@ -34,12 +35,13 @@ type PCGWGameData struct {
 }
 
 type GameSearchResult struct {
-	Name        string   `json:"name"`
-	PageID      string   `json:"page_id"`
-	SteamAppID  string   `json:"steam_app_id"`
-	ReleaseDate string   `json:"release_date"`
-	CoverURL    string   `json:"cover_url"`
-	SavePaths   []string `json:"save_paths"`
+	Name         string   `json:"name"`
+	PageID       string   `json:"page_id"`
+	SteamAppID   string   `json:"steam_app_id"`
+	ReleaseDate  string   `json:"release_date"`
+	CoverURL     string   `json:"cover_url"`
+	SavePaths    []string `json:"save_paths"`
+	RegistryKeys []string `json:"registry_keys"` // Claves de HKEY_CURRENT_USER de las filas de registro de la wiki
 }
 
 // PCGamingWiki API client
@@ -48,14 +50,43 @@ type PCGWClient struct {
 	httpClient *http.Client
 }
 
+// defaultPCGWBaseURL es la API pública de PCGamingWiki
+const defaultPCGWBaseURL = "https://www.pcgamingwiki.com/w/api.php"
+
 // NewPCGWClient creates a new PCGamingWiki API client
 func NewPCGWClient() *PCGWClient {
 	return &PCGWClient{
-		baseURL:    "https://www.pcgamingwiki.com/w/api.php",
+		baseURL:    defaultPCGWBaseURL,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
+// SetBaseURL apunta el cliente a otra API de MediaWiki (un espejo o un proxy con caché); vacío = la pública
+func (c *PCGWClient) SetBaseURL(baseURL string) {
+	if baseURL == "" {
+		baseURL = defaultPCGWBaseURL
+	}
+	c.baseURL = strings.TrimRight(baseURL, "?")
+}
+
+// validatePCGWBaseURL comprueba que la URL de la API de PCGamingWiki es una URL http(s) absoluta; vacío = la pública
+func validatePCGWBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("URL de PCGamingWiki inválida: %v", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("URL de PCGamingWiki inválida: %s (debe empezar por http:// o https://)", baseURL)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("URL de PCGamingWiki inválida: %s (debe ser la dirección de api.php, sin parámetros)", baseURL)
+	}
+	return nil
+}
+
 // SearchGames busca juegos en PCGamingWiki por nombre y obtiene automáticamente las rutas de guardado
 func (c *PCGWClient) SearchGames(gameName string) ([]GameSearchResult, error) {
 	// Escape the game name for URL
@@ -67,7 +98,7 @@ func (c *PCGWClient) SearchGames(gameName string) ([]GameSearchResult, error) {
 
 	resp, err := c.httpClient.Get(searchURL)
 	if err != nil {
-		return nil, fmt.Errorf("error making request: %v", err)
+		return nil, errPCGWUnreachable("error making request", err)
 	}
 	defer resp.Body.Close()
 
@@ -88,12 +119,15 @@ func (c *PCGWClient) SearchGames(gameName string) ([]GameSearchResult, error) {
 			PageID:      item.Title.PageID,
 			SteamAppID:  item.Title.AppID,
 			ReleaseDate: item.Title.Released,
-			CoverURL:    item.Title.Cover,
+			CoverURL:    c.resolveCoverURL(item.Title.Cover),
 		}
 
-		// Obtener automáticamente las rutas de guardado para cada juego
-		if savePaths, err := c.GetGameSaveData(game.PageID); err == nil && len(savePaths) > 0 {
-			game.SavePaths = savePaths
+		// Obtener automáticamente las rutas de guardado y las claves de registro de cada juego
+		if wikitext, err := c.getWikitext(game.PageID); err == nil {
+			if savePaths := c.parseSaveDataFromWikitext(wikitext); len(savePaths) > 0 {
+				game.SavePaths = savePaths
+			}
+			game.RegistryKeys = parseRegistryKeysFromWikitext(wikitext)
 		}
 
 		games = append(games, game)
@@ -104,27 +138,116 @@ func (c *PCGWClient) SearchGames(gameName string) ([]GameSearchResult, error) {
 
 // GetGameSaveData obtiene los datos de guardado de un juego específico
 func (c *PCGWClient) GetGameSaveData(pageID string) ([]string, error) {
+	wikitext, err := c.getWikitext(pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the wikitext to extract save data locations
+	return c.parseSaveDataFromWikitext(wikitext), nil
+}
+
+// getWikitext descarga el wikitext de una página de PCGamingWiki
+func (c *PCGWClient) getWikitext(pageID string) (string, error) {
 	// Get the wikitext content
 	wikitextURL := fmt.Sprintf("%s?action=parse&format=json&pageid=%s&prop=wikitext", c.baseURL, pageID)
 
 	resp, err := c.httpClient.Get(wikitextURL)
 	if err != nil {
-		return nil, fmt.Errorf("error getting wikitext: %v", err)
+		return "", errPCGWUnreachable("error getting wikitext", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading wikitext response: %v", err)
+		return "", fmt.Errorf("error reading wikitext response: %v", err)
 	}
 
 	var result PCGWGameData
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("error parsing wikitext JSON: %v", err)
+		return "", fmt.Errorf("error parsing wikitext JSON: %v", err)
 	}
 
-	// Parse the wikitext to extract save data locations
-	return c.parseSaveDataFromWikitext(result.Parse.Wikitext.Content), nil
+	return result.Parse.Wikitext.Content, nil
+}
+
+// resolveCoverURL convierte el Cover_URL de cargoquery en una URL absoluta de la imagen. La wiki puede devolver
+// una URL completa, una ruta relativa a la wiki o el nombre de un archivo ("File:Portada.jpg"), cuya URL se
+// pide a la API con imageinfo. Devuelve vacío si no se puede resolver.
+func (c *PCGWClient) resolveCoverURL(cover string) string {
+	cover = strings.TrimSpace(cover)
+	switch {
+	case cover == "":
+		return ""
+	case strings.HasPrefix(cover, "http://") || strings.HasPrefix(cover, "https://"):
+		return cover
+	}
+
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return ""
+	}
+	if strings.HasPrefix(cover, "//") {
+		return base.Scheme + ":" + cover
+	}
+
+	// Nombre de archivo, con o sin el espacio de nombres, o ruta de su página (/wiki/File:...)
+	title := cover
+	if index := strings.Index(title, "File:"); index >= 0 {
+		title = title[index:]
+	} else if strings.HasPrefix(cover, "/") {
+		ref, err := url.Parse(cover)
+		if err != nil {
+			return ""
+		}
+		return base.ResolveReference(ref).String()
+	} else {
+		title = "File:" + title
+	}
+	if unescaped, err := url.PathUnescape(title); err == nil {
+		title = unescaped
+	}
+
+	imageURL, err := c.imageURL(title)
+	if err != nil {
+		log.Printf("No se pudo obtener la URL de la portada %s: %v", title, err)
+		return ""
+	}
+	if ref, err := url.Parse(imageURL); err == nil {
+		return base.ResolveReference(ref).String()
+	}
+	return imageURL
+}
+
+// imageURL pide a la API la URL de la imagen de una página de archivo (File:...)
+func (c *PCGWClient) imageURL(title string) (string, error) {
+	infoURL := fmt.Sprintf("%s?action=query&prop=imageinfo&iiprop=url&format=json&titles=%s", c.baseURL, url.QueryEscape(title))
+
+	resp, err := c.httpClient.Get(infoURL)
+	if err != nil {
+		return "", errPCGWUnreachable("error getting imageinfo", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Query struct {
+			Pages map[string]struct {
+				ImageInfo []struct {
+					URL string `json:"url"`
+				} `json:"imageinfo"`
+			} `json:"pages"`
+		} `json:"query"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("error parsing imageinfo JSON: %v", err)
+	}
+
+	for _, page := range result.Query.Pages {
+		if len(page.ImageInfo) > 0 && page.ImageInfo[0].URL != "" {
+			return page.ImageInfo[0].URL, nil
+		}
+	}
+	return "", fmt.Errorf("image not found")
 }
 
 // parseSaveDataFromWikitext extrae las rutas de guardado del wikitext
@@ -277,7 +400,7 @@ func (c *PCGWClient) SearchGameBySteamID(steamAppID string) (*GameSearchResult,
 
 	resp, err := c.httpClient.Get(searchURL)
 	if err != nil {
-		return nil, fmt.Errorf("error making request: %v", err)
+		return nil, errPCGWUnreachable("error making request", err)
 	}
 	defer resp.Body.Close()
 
@@ -301,7 +424,7 @@ func (c *PCGWClient) SearchGameBySteamID(steamAppID string) (*GameSearchResult,
 		PageID:      item.Title.PageID,
 		SteamAppID:  item.Title.AppID,
 		ReleaseDate: item.Title.Released,
-		CoverURL:    item.Title.Cover,
+		CoverURL:    c.resolveCoverURL(item.Title.Cover),
 	}
 
 	// Get save data
@@ -312,3 +435,35 @@ func (c *PCGWClient) SearchGameBySteamID(steamAppID string) (*GameSearchResult,
 
 	return game, nil
 }
+
+// parseRegistryKeysFromWikitext extrae las claves de HKEY_CURRENT_USER de las filas de registro de la wiki
+// ({{P|hkcu}}\Software\...). Las de HKEY_LOCAL_MACHINE se ignoran: no están en user.reg.
+func parseRegistryKeysFromWikitext(wikitext string) []string {
+	keys := []string{}
+	seen := make(map[string]bool)
+
+	for rest := wikitext; ; {
+		index := strings.Index(strings.ToLower(rest), "{{p|hkcu}}")
+		if index < 0 {
+			break
+		}
+		rest = rest[index+len("{{p|hkcu}}"):]
+
+		end := strings.IndexAny(rest, "|}\n")
+		key := rest
+		if end >= 0 {
+			key = rest[:end]
+		}
+		key = strings.Trim(strings.TrimSpace(key), `\`)
+		if key == "" || strings.Contains(key, "{{") {
+			continue
+		}
+
+		key = `HKEY_CURRENT_USER\` + key
+		if !seen[strings.ToLower(key)] {
+			seen[strings.ToLower(key)] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
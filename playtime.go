@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// steamPlayTimes guarda las fechas de última partida leídas de cada archivo de Steam (localconfig.vdf,
+// appmanifest), para no volver a leerlos mientras no cambien: localconfig.vdf puede ocupar varios MB
+var steamPlayTimes = struct {
+	sync.Mutex
+	files map[string]playTimesFile
+}{files: make(map[string]playTimesFile)}
+
+// playTimesFile son las fechas de última partida por appid de un archivo, con su fecha de modificación
+type playTimesFile struct {
+	modified time.Time
+	times    map[string]time.Time
+}
+
+// GetGamesPlayedWithin devuelve los juegos jugados en los últimos days días, del más reciente al más antiguo
+func (bm *BackupManager) GetGamesPlayedWithin(days int) []*GameInfo {
+	since := time.Now().AddDate(0, 0, -days)
+	games := []*GameInfo{}
+	for _, game := range bm.GetGameList() {
+		if game.LastPlayed.After(since) {
+			games = append(games, game)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].LastPlayed.After(games[j].LastPlayed)
+	})
+	return games
+}
+
+// playedRecently indica si un juego entra en los backups automáticos según AutoBackupPlayedWithinDays
+func (bm *BackupManager) playedRecently(game *GameInfo, now time.Time) bool {
+	days := bm.config().AutoBackupPlayedWithinDays
+	if days <= 0 {
+		return true
+	}
+	return game.LastPlayed.After(now.AddDate(0, 0, -days))
+}
+
+// steamLastPlayed devuelve la última vez que Steam registró el juego como jugado: de localconfig.vdf de su
+// cuenta si es un juego de Steam Cloud, o de todas las cuentas y appmanifest si solo se conoce su appid
+func steamLastPlayed(game *GameInfo) time.Time {
+	appID := game.Metadata["steam_app_id"]
+	if appID == "" {
+		return time.Time{}
+	}
+
+	var files []string
+	if userdata, account := game.Metadata["steam_userdata"], game.Metadata["steam_account_id"]; userdata != "" && account != "" {
+		files = append(files, filepath.Join(userdata, account, "config", "localconfig.vdf"))
+	} else {
+		for _, root := range steamRoots() {
+			for _, account := range steamAccounts(root) {
+				files = append(files, filepath.Join(account.Userdata, account.ID, "config", "localconfig.vdf"))
+			}
+			files = append(files, filepath.Join(root, "steamapps", "appmanifest_"+appID+".acf"))
+		}
+	}
+
+	var latest time.Time
+	for _, file := range files {
+		if played := playTimesFromFile(file)[appID]; played.After(latest) {
+			latest = played
+		}
+	}
+	return latest
+}
+
+// playTimesFromFile lee (o toma de la caché) las fechas de última partida de un localconfig.vdf o appmanifest
+func playTimesFromFile(path string) map[string]time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	steamPlayTimes.Lock()
+	defer steamPlayTimes.Unlock()
+	if cached, ok := steamPlayTimes.files[path]; ok && cached.modified.Equal(info.ModTime()) {
+		return cached.times
+	}
+
+	times := make(map[string]time.Time)
+	if data, err := os.ReadFile(path); err == nil {
+		if parsed, err := parseVDF(data); err == nil {
+			// appmanifest_<appid>.acf
+			if state := parsed.child("AppState"); state.value("appid") != "" {
+				if played := unixTime(state.value("LastPlayed")); !played.IsZero() {
+					times[state.value("appid")] = played
+				}
+			}
+			// localconfig.vdf
+			apps := parsed.child("UserLocalConfigStore").child("Software").child("Valve").child("Steam").child("apps")
+			for appID, app := range apps.Children {
+				if played := unixTime(app.value("LastPlayed")); !played.IsZero() {
+					times[appID] = played
+				}
+			}
+		}
+	}
+
+	steamPlayTimes.files[path] = playTimesFile{modified: info.ModTime(), times: times}
+	return times
+}
+
+// unixTime convierte una fecha Unix en texto de Steam; 0 o vacío = fecha cero
+func unixTime(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
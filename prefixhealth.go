@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Estados de un juego (GameInfo.Status); vacío = sin problemas
+const (
+	GameStatusPrefixMissing = "prefix_missing" // El prefijo de Wine del juego ya no existe
+	GameStatusPrefixBroken  = "prefix_broken"  // El prefijo existe pero le falta drive_c o la carpeta del usuario
+)
+
+// PrefixHealth es el resultado de comprobar un prefijo de Wine/Proton
+type PrefixHealth struct {
+	Path       string   `json:"path"`
+	Exists     bool     `json:"exists"`
+	HasDriveC  bool     `json:"has_drive_c"`
+	HasUserDir bool     `json:"has_user_dir"`
+	User       string   `json:"user"` // Usuario de Windows encontrado en drive_c/users
+	Healthy    bool     `json:"healthy"`
+	Problem    string   `json:"problem"`
+	GameIDs    []string `json:"game_ids"` // Juegos asociados a este prefijo
+}
+
+// ValidatePrefixes comprueba los prefijos de los juegos registrados y los presentes en el sistema,
+// y marca con Status los juegos cuyo prefijo falta o está dañado
+func (bm *BackupManager) ValidatePrefixes() ([]PrefixHealth, error) {
+	health, changed := bm.checkPrefixes(true)
+	for _, prefix := range findWinePrefixes() {
+		if !containsPrefix(health, prefix) {
+			health = append(health, checkPrefix(prefix))
+		}
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].Path < health[j].Path })
+
+	if changed {
+		return health, bm.SaveDatabase()
+	}
+	return health, nil
+}
+
+// RebindGamePrefix asocia un juego a otro prefijo y vuelve a expandir dentro de él las rutas de guardado
+// que apuntaban al prefijo anterior. Las rutas fuera del prefijo no cambian.
+func (bm *BackupManager) RebindGamePrefix(gameID, prefixPath string) (*GameInfo, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	prefix := filepath.Clean(ExpandPath(prefixPath))
+	if health := checkPrefix(prefix); !health.Healthy {
+		return nil, fmt.Errorf("%s no es un prefijo de Wine válido: %s", prefix, health.Problem)
+	}
+
+	savePaths := make([]string, 0, len(game.SavePaths))
+	for _, savePath := range game.SavePaths {
+		if _, inPrefix := prefixPathToTemplate(savePath); !inPrefix {
+			savePaths = append(savePaths, savePath)
+			continue
+		}
+		expanded, err := expandInPrefix(savePath, prefix)
+		if err != nil {
+			return nil, err
+		}
+		savePaths = append(savePaths, expanded)
+	}
+
+	log.Printf("Prefijo de %s cambiado a %s: %v -> %v", game.Name, prefix, game.SavePaths, savePaths)
+	game.SavePaths = savePaths
+	if game.Metadata == nil {
+		game.Metadata = make(map[string]string)
+	}
+	game.Metadata["wine_prefix"] = prefix
+	game.Status = ""
+
+	if err := bm.updateGameInfo(game); err != nil {
+		log.Printf("Error actualizando info del juego %s: %v", gameID, err)
+	}
+	return game, bm.SaveDatabase()
+}
+
+// checkPrefixes comprueba los prefijos de los juegos registrados. Con update, actualiza el Status
+// de los juegos e indica si alguno cambió.
+func (bm *BackupManager) checkPrefixes(update bool) ([]PrefixHealth, bool) {
+	byPrefix := make(map[string]*PrefixHealth)
+	var order []string
+	changed := false
+
+	for _, game := range bm.GetGameList() {
+		prefix := gamePrefix(game)
+		if prefix == "" {
+			continue
+		}
+		prefix = filepath.Clean(prefix)
+
+		health, checked := byPrefix[prefix]
+		if !checked {
+			result := checkPrefix(prefix)
+			health = &result
+			byPrefix[prefix] = health
+			order = append(order, prefix)
+		}
+		health.GameIDs = append(health.GameIDs, game.ID)
+
+		if !update {
+			continue
+		}
+		status := ""
+		switch {
+		case !health.Exists:
+			status = GameStatusPrefixMissing
+		case !health.Healthy:
+			status = GameStatusPrefixBroken
+		}
+		// Solo se tocan los estados de prefijo
+		bm.updateGame(game, func(game *GameInfo) {
+			if game.Status != status && (game.Status == "" || isPrefixStatus(game.Status)) {
+				game.Status = status
+				changed = true
+			}
+		})
+	}
+
+	health := make([]PrefixHealth, 0, len(order))
+	for _, prefix := range order {
+		health = append(health, *byPrefix[prefix])
+	}
+	return health, changed
+}
+
+// checkPrefix comprueba que un prefijo existe y tiene drive_c y una carpeta de usuario
+func checkPrefix(prefix string) PrefixHealth {
+	health := PrefixHealth{Path: prefix, GameIDs: []string{}}
+
+	info, err := os.Stat(prefix)
+	if err != nil || !info.IsDir() {
+		health.Problem = "el prefijo no existe"
+		return health
+	}
+	health.Exists = true
+
+	if !isWinePrefix(prefix) {
+		health.Problem = "falta drive_c"
+		return health
+	}
+	health.HasDriveC = true
+
+	users, err := os.ReadDir(filepath.Join(prefix, "drive_c", "users"))
+	if err == nil {
+		for _, user := range users {
+			if user.IsDir() && !strings.EqualFold(user.Name(), "Public") {
+				health.User = user.Name()
+				health.HasUserDir = true
+				break
+			}
+		}
+	}
+	if !health.HasUserDir {
+		health.Problem = "falta la carpeta del usuario en drive_c/users"
+		return health
+	}
+
+	health.Healthy = true
+	return health
+}
+
+// isPrefixStatus indica si un estado de juego lo gestiona la comprobación de prefijos
+func isPrefixStatus(status string) bool {
+	return status == GameStatusPrefixMissing || status == GameStatusPrefixBroken
+}
+
+// containsPrefix indica si una lista de resultados ya incluye un prefijo
+func containsPrefix(health []PrefixHealth, prefix string) bool {
+	for _, h := range health {
+		if h.Path == filepath.Clean(prefix) {
+			return true
+		}
+	}
+	return false
+}
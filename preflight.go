@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// preflightEvent es el evento emitido al iniciar con los problemas encontrados por PreflightCheck
+const preflightEvent = "preflight:issues"
+
+// Tipos de problema de PreflightCheck
+const (
+	PreflightBackupDirUnwritable   = "backup_dir_unwritable"  // No se puede escribir en el directorio de backups
+	PreflightDestinationUnwritable = "destination_unwritable" // No se puede escribir en un destino adicional de un juego
+	PreflightSavePathUnreadable    = "save_path_unreadable"   // Una ruta de guardado existe pero no se puede leer
+	PreflightDriveMissing          = "drive_missing"          // La unidad de una ruta no está montada
+)
+
+// PreflightIssue es un problema de permisos o de unidades que haría fallar los backups
+type PreflightIssue struct {
+	Kind     string `json:"kind"`
+	GameID   string `json:"game_id,omitempty"` // Vacío para el directorio de backups
+	GameName string `json:"game_name,omitempty"`
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+}
+
+// PreflightCheck comprueba que se puede escribir en el directorio de backups y en los destinos adicionales,
+// que las rutas de guardado se pueden leer y que sus unidades están montadas. No crea ni modifica nada
+// salvo un archivo temporal de prueba. Las rutas de guardado que aún no existen no son un problema.
+func (bm *BackupManager) PreflightCheck() []PreflightIssue {
+	issues := []PreflightIssue{}
+	if issue, ok := checkWritableTarget(bm.config().BackupDir); !ok {
+		issue.Kind = preflightKind(issue.Kind, PreflightBackupDirUnwritable)
+		issues = append(issues, issue)
+	}
+
+	games := bm.GetGameList()
+	sort.Slice(games, func(i, j int) bool { return games[i].Name < games[j].Name })
+	checked := make(map[string]bool)
+	for _, game := range games {
+		for _, savePath := range effectiveSavePaths(game) {
+			issue, ok := checkReadableSavePath(savePath)
+			if ok {
+				continue
+			}
+			issue.GameID, issue.GameName = game.ID, game.Name
+			issues = append(issues, issue)
+		}
+
+		for _, dir := range game.ExtraBackupDirs {
+			if checked[dir] {
+				continue
+			}
+			checked[dir] = true
+			if issue, ok := checkWritableTarget(dir); !ok {
+				issue.Kind = preflightKind(issue.Kind, PreflightDestinationUnwritable)
+				issue.GameID, issue.GameName = game.ID, game.Name
+				issues = append(issues, issue)
+			}
+		}
+	}
+	return issues
+}
+
+// reportPreflight ejecuta PreflightCheck, registra los problemas y los emite al frontend
+func (bm *BackupManager) reportPreflight() []PreflightIssue {
+	issues := bm.PreflightCheck()
+	for _, issue := range issues {
+		log.Printf("Comprobación inicial: %s", issue.Message)
+	}
+	bm.emit(preflightEvent, issues)
+	return issues
+}
+
+// checkWritableTarget comprueba que se puede escribir en un directorio; si aún no existe, en su antecesor
+// más cercano, donde se crearía. Kind queda vacío salvo que la unidad no esté montada.
+func checkWritableTarget(dir string) (PreflightIssue, bool) {
+	expanded := filepath.Clean(ExpandPath(dir))
+	if mountPoint, unmounted := unmountedMountPoint(expanded); unmounted {
+		return PreflightIssue{
+			Kind:    PreflightDriveMissing,
+			Path:    expanded,
+			Message: fmt.Sprintf("la unidad %s de %s no está montada", mountPoint, expanded),
+		}, false
+	}
+
+	target := existingParent(expanded)
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		return PreflightIssue{Path: expanded, Message: fmt.Sprintf("%s no es un directorio", target)}, false
+	}
+	if !isWritableDir(target) {
+		return PreflightIssue{Path: expanded, Message: fmt.Sprintf("no se puede escribir en %s", target)}, false
+	}
+	return PreflightIssue{}, true
+}
+
+// checkReadableSavePath comprueba que una ruta de guardado existente se puede leer y que su unidad está montada
+func checkReadableSavePath(savePath string) (PreflightIssue, bool) {
+	expanded := ExpandPath(savePath)
+	if hasGlobMeta(expanded) {
+		expanded = globBase(expanded)
+	}
+	if mountPoint, unmounted := unmountedMountPoint(expanded); unmounted {
+		return PreflightIssue{
+			Kind:    PreflightDriveMissing,
+			Path:    savePath,
+			Message: fmt.Sprintf("la unidad %s de %s no está montada", mountPoint, savePath),
+		}, false
+	}
+
+	info, err := os.Stat(expanded)
+	if os.IsNotExist(err) {
+		return PreflightIssue{}, true
+	}
+	if err == nil {
+		err = tryRead(expanded, info.IsDir())
+	}
+	if err != nil {
+		return PreflightIssue{
+			Kind:    PreflightSavePathUnreadable,
+			Path:    savePath,
+			Message: fmt.Sprintf("no se puede leer %s: %v", savePath, err),
+		}, false
+	}
+	return PreflightIssue{}, true
+}
+
+// tryRead abre un archivo, o lista el primer elemento de una carpeta, para comprobar que se puede leer
+func tryRead(path string, dir bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if dir {
+		if _, err := file.Readdirnames(1); err != nil && err != io.EOF { // EOF = carpeta vacía
+			return err
+		}
+	}
+	return nil
+}
+
+// preflightKind devuelve kind si ya tiene valor (unidad no montada) o fallback si no
+func preflightKind(kind, fallback string) string {
+	if kind != "" {
+		return kind
+	}
+	return fallback
+}
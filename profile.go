@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrWholeProfileRestore indica que el backup contiene el perfil completo del prefijo: restaurarlo sobrescribe
+// la configuración y las partidas de todos los juegos de ese prefijo, no solo las del juego
+var ErrWholeProfileRestore = errors.New("el backup contiene el perfil completo del prefijo y sobrescribirá los datos de todos sus juegos")
+
+// profilePrunes son carpetas del perfil que nunca se respaldan en modo perfil completo (relativas a drive_c/users/<usuario>)
+var profilePrunes = []string{
+	"temp",
+	"appdata/local/temp",
+	"appdata/local/microsoft/windows/inetcache",
+	"appdata/local/microsoft/windows/temporary internet files",
+	"appdata/local/crashdumps",
+	"local settings/temp",
+	"local settings/temporary internet files",
+	"local settings/application data/microsoft/windows/temporary internet files",
+}
+
+// SetBackupWholeProfile activa o desactiva el respaldo del perfil completo del prefijo (drive_c/users/<usuario>)
+// en lugar de las rutas y patrones del juego, para juegos sin rutas de guardado documentadas
+func (bm *BackupManager) SetBackupWholeProfile(gameID string, enabled bool) (*GameInfo, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	if enabled {
+		profile, err := profileDir(game, "")
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("%s respaldará el perfil completo %s", game.Name, profile)
+	}
+
+	game.BackupWholeProfile = enabled
+	if err := bm.updateGameInfo(game); err != nil {
+		log.Printf("Error actualizando info del juego %s: %v", gameID, err)
+	}
+	return game, bm.SaveDatabase()
+}
+
+// profileDir devuelve la carpeta del usuario de Windows dentro del prefijo del juego, o de prefix si se indica
+func profileDir(game *GameInfo, prefix string) (string, error) {
+	if prefix == "" {
+		prefix = gamePrefix(game)
+	}
+	if prefix == "" {
+		return "", fmt.Errorf("%s no está asociado a un prefijo de Wine", game.Name)
+	}
+
+	profile := filepath.Join(prefix, "drive_c", "users", prefixUser(prefix))
+	if info, err := os.Stat(profile); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("el prefijo %s no tiene carpeta de usuario", prefix)
+	}
+	return profile, nil
+}
+
+// isProfilePruned indica si una carpeta del perfil (relativa a su raíz) es temporal y se omite
+func isProfilePruned(rel string) bool {
+	rel = strings.ToLower(rel)
+	for _, prune := range profilePrunes {
+		if rel == prune {
+			return true
+		}
+	}
+	return false
+}
+
+// isWholeProfileBackup indica si un backup se hizo en modo perfil completo según el historial del juego
+func (bm *BackupManager) isWholeProfileBackup(gameID, name string) (bool, error) {
+	index, err := bm.loadBackupIndex(gameID)
+	if err != nil {
+		return false, err
+	}
+	record := index.find(name)
+	return record != nil && record.WholeProfile, nil
+}
+
+// prefixGames devuelve los nombres de los otros juegos que comparten el prefijo de un juego
+func (bm *BackupManager) prefixGames(game *GameInfo, prefix string) []string {
+	var names []string
+	for _, other := range bm.GetGameList() {
+		if other.ID == game.ID {
+			continue
+		}
+		if otherPrefix := gamePrefix(other); otherPrefix != "" && filepath.Clean(otherPrefix) == filepath.Clean(prefix) {
+			names = append(names, other.Name)
+		}
+	}
+	return names
+}
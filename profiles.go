@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GameProfile es un perfil de usuario dentro de las partidas de un juego: la carpeta que coincide con el primer
+// comodín de sus rutas de guardado (p. ej. cada cuenta en userdata/*/<appid>/remote)
+type GameProfile struct {
+	ID        string   `json:"id"`   // Nombre de la carpeta del perfil
+	Name      string   `json:"name"` // Nombre de la cuenta de Steam si el ID es una; si no, el ID
+	SavePaths []string `json:"save_paths"`
+	GameID    string   `json:"game_id"` // Juego con los backups de este perfil; vacío si aún no se separó
+	Enabled   bool     `json:"enabled"` // Tiene juego propio y entra en los backups automáticos
+}
+
+// ListProfiles devuelve los perfiles que se encuentran en las rutas de guardado de un juego.
+// Con un juego de perfil, devuelve los del juego del que se separó.
+func (bm *BackupManager) ListProfiles(gameID string) ([]GameProfile, error) {
+	game, err := bm.profileParent(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string)
+	for _, account := range bm.GetSteamAccounts() {
+		labels[account.ID] = account.label()
+	}
+
+	byID := make(map[string]*GameProfile)
+	for _, savePath := range effectiveSavePaths(game) {
+		expanded := ExpandPath(savePath)
+		if !hasGlobMeta(expanded) {
+			continue // Sin comodín la ruta es común a todos los perfiles
+		}
+		base := globBase(expanded)
+		rel, err := filepath.Rel(base, expanded)
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+
+		matches, _ := filepath.Glob(filepath.Join(base, parts[0]))
+		for _, match := range matches {
+			if info, err := os.Stat(match); err != nil || !info.IsDir() {
+				continue
+			}
+			id := filepath.Base(match)
+			profile, exists := byID[id]
+			if !exists {
+				name := labels[id]
+				if name == "" {
+					name = id
+				}
+				profile = &GameProfile{ID: id, Name: name, SavePaths: []string{}}
+				byID[id] = profile
+			}
+			profile.SavePaths = append(profile.SavePaths, filepath.Join(append([]string{match}, parts[1:]...)...))
+		}
+	}
+
+	children := bm.profileGames(game.ID)
+	profiles := make([]GameProfile, 0, len(byID))
+	for id, profile := range byID {
+		if child, exists := children[id]; exists {
+			profile.GameID = child.ID
+			profile.Enabled = child.Schedule != "off"
+		}
+		profiles = append(profiles, *profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].ID < profiles[j].ID })
+	return profiles, nil
+}
+
+// SetBackupProfiles elige qué perfiles de un juego se respaldan. Cada perfil elegido pasa a ser un juego propio
+// con sus propios backups; los que dejan de estarlo y el juego original (que mezcla todos los perfiles)
+// quedan con la programación "off" pero conservan sus backups. Sin perfiles, el juego original vuelve a
+// respaldarse automáticamente. Devuelve los juegos de los perfiles elegidos.
+func (bm *BackupManager) SetBackupProfiles(gameID string, profileIDs []string) ([]*GameInfo, error) {
+	game, err := bm.profileParent(gameID)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := bm.ListProfiles(game.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool)
+	for _, id := range profileIDs {
+		found := false
+		for _, profile := range profiles {
+			found = found || profile.ID == id
+		}
+		if !found {
+			return nil, fmt.Errorf("perfil %s no encontrado en %s", id, game.Name)
+		}
+		selected[id] = true
+	}
+
+	// Programación que heredan los perfiles: la del juego original antes de separarlo
+	bm.mu.RLock()
+	schedule := game.Schedule
+	if game.Metadata["profiles_split"] != "" {
+		schedule = ""
+	}
+	bm.mu.RUnlock()
+
+	children := bm.profileGames(game.ID)
+	games := []*GameInfo{}
+	for _, profile := range profiles {
+		child, exists := children[profile.ID]
+		switch {
+		case selected[profile.ID] && !exists:
+			child = bm.newProfileGame(game, profile, schedule)
+			bm.setGame(child)
+			log.Printf("Perfil %s de %s separado como juego propio: %s", profile.ID, game.Name, child.ID)
+		case selected[profile.ID] && bm.gameSnapshot(child).Schedule == "off":
+			bm.updateGame(child, func(child *GameInfo) { child.Schedule = schedule })
+		case !selected[profile.ID] && exists:
+			bm.updateGame(child, func(child *GameInfo) { child.Schedule = "off" })
+		}
+		if selected[profile.ID] {
+			games = append(games, child)
+		}
+	}
+
+	bm.updateGame(game, func(game *GameInfo) {
+		if len(selected) > 0 && game.Metadata["profiles_split"] == "" {
+			game.Metadata["profiles_split"] = "true"
+			game.Schedule = "off"
+		} else if len(selected) == 0 && game.Metadata["profiles_split"] != "" {
+			delete(game.Metadata, "profiles_split")
+			game.Schedule = ""
+		}
+	})
+
+	for _, child := range games {
+		if err := bm.updateGameInfo(child); err != nil {
+			log.Printf("Error actualizando %s: %v", child.Name, err)
+		}
+	}
+	bm.checkPathOverlaps()
+	return games, bm.SaveDatabase()
+}
+
+// newProfileGame crea el juego de un perfil: copia la configuración del juego original con las rutas del perfil
+func (bm *BackupManager) newProfileGame(game *GameInfo, profile GameProfile, schedule string) *GameInfo {
+	metadata := make(map[string]string, len(game.Metadata)+2)
+	for key, value := range game.Metadata {
+		if key != "profiles_split" {
+			metadata[key] = value
+		}
+	}
+	metadata["profile_of"] = game.ID
+	metadata["profile_id"] = profile.ID
+
+	return &GameInfo{
+		ID:                   newGameID(),
+		Slug:                 bm.uniqueSlug(sanitizePathComponent(game.Slug + "-" + profile.ID)),
+		Name:                 fmt.Sprintf("%s (%s)", game.Name, profile.Name),
+		SavePaths:            profile.SavePaths,
+		Patterns:             append([]string(nil), game.Patterns...),
+		Platform:             game.Platform,
+		CustomPaths:          []string{},
+		Metadata:             metadata,
+		Schedule:             schedule,
+		MaxAutoBackupsPerDay: game.MaxAutoBackupsPerDay,
+		ExtraBackupDirs:      append([]string(nil), game.ExtraBackupDirs...),
+		LastPlayed:           game.LastPlayed,
+		Tags:                 append([]string(nil), game.Tags...),
+	}
+}
+
+// profileParent devuelve el juego del que se separan los perfiles: el propio juego o, si es un perfil, su original
+func (bm *BackupManager) profileParent(gameID string) (*GameInfo, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+	if parentID := game.Metadata["profile_of"]; parentID != "" {
+		if parent, exists := bm.getGame(parentID); exists {
+			return parent, nil
+		}
+	}
+	return game, nil
+}
+
+// profileGames devuelve los juegos de perfil separados de un juego, por ID de perfil
+func (bm *BackupManager) profileGames(gameID string) map[string]*GameInfo {
+	children := make(map[string]*GameInfo)
+	for _, game := range bm.GetGameList() {
+		if game.Metadata["profile_of"] == gameID {
+			children[game.Metadata["profile_id"]] = game
+		}
+	}
+	return children
+}
+
+// isProfileOf indica si uno de los dos juegos es un perfil separado del otro
+func isProfileOf(a, b *GameInfo) bool {
+	return a.Metadata["profile_of"] == b.ID || b.Metadata["profile_of"] == a.ID
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// quarantineDirName es la subcarpeta de cada juego donde se apartan los backups dañados.
+// Fuera de la carpeta del juego no cuentan para la rotación ni se ofrecen para restaurar.
+const quarantineDirName = "quarantine"
+
+// quarantinedEvent se emite cada vez que un backup dañado se pone en cuarentena
+const quarantinedEvent = "backup:quarantined"
+
+// quarantineBackup aparta un backup dañado (y su manifiesto) a la cuarentena del juego y anota el motivo en su historial
+func (bm *BackupManager) quarantineBackup(gameID, fileName, reason string) error {
+	backupPath, err := bm.resolveBackupFile(gameID, fileName)
+	if err != nil {
+		return err
+	}
+
+	quarantineDir := filepath.Join(bm.gameBackupDir(gameID), quarantineDirName)
+	if err := os.MkdirAll(quarantineDir, 0755); err != nil {
+		return fmt.Errorf("error creando carpeta de cuarentena: %v", err)
+	}
+	target := filepath.Join(quarantineDir, fileName)
+	if err := os.Rename(backupPath, target); err != nil {
+		return fmt.Errorf("error moviendo %s a cuarentena: %v", fileName, err)
+	}
+	for _, sidecar := range sidecarPaths(backupPath) {
+		if err := os.Rename(sidecar, target+strings.TrimPrefix(sidecar, backupPath)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error moviendo %s a cuarentena: %v", filepath.Base(sidecar), err)
+		}
+	}
+
+	err = bm.upsertBackupRecord(gameID, fileName, target, func(record *BackupRecord) {
+		record.Quarantined = true
+		record.QuarantineReason = reason
+		record.QuarantinedAt = time.Now()
+	})
+	if err != nil {
+		return fmt.Errorf("error guardando historial: %v", err)
+	}
+
+	log.Printf("Backup %s de %s en cuarentena: %s", fileName, gameID, reason)
+	bm.emit(quarantinedEvent, map[string]string{"game_id": gameID, "backup": fileName, "reason": reason})
+	return nil
+}
+
+// listQuarantinedBackups devuelve los backups en cuarentena de un juego, marcados con el motivo
+func (bm *BackupManager) listQuarantinedBackups(gameID string) ([]BackupInfo, error) {
+	folder := bm.backupFolder(gameID)
+	quarantineDir := filepath.Join(bm.config().BackupDir, folder, quarantineDirName)
+
+	files, err := os.ReadDir(quarantineDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []BackupInfo{}, nil
+		}
+		return nil, err
+	}
+
+	index, err := bm.loadBackupIndex(gameID)
+	if err != nil {
+		log.Printf("Error leyendo historial de %s: %v", gameID, err)
+		index = &backupIndex{}
+	}
+
+	backups := []BackupInfo{}
+	for _, file := range files {
+		created, ok := parseBackupName(folder, file.Name())
+		if !ok {
+			continue
+		}
+		compressed := !file.IsDir() && strings.HasSuffix(file.Name(), ".zip")
+		if !compressed && !file.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(quarantineDir, file.Name())
+		info := BackupInfo{
+			Name:        file.Name(),
+			Path:        path,
+			Size:        backupSize(path),
+			Created:     created,
+			Compressed:  compressed,
+			Quarantined: true,
+		}
+		if record := index.find(file.Name()); record != nil {
+			info.QuarantineReason = record.QuarantineReason
+			info.SaveInfo = record.SaveInfo
+			info.WholeProfile = record.WholeProfile
+		}
+		backups = append(backups, info)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+	return backups, nil
+}
+
+// GetQuarantinedBackups devuelve los backups en cuarentena de todos los juegos, del más reciente al más antiguo
+func (bm *BackupManager) GetQuarantinedBackups() ([]GlobalBackupEntry, error) {
+	dirs, err := os.ReadDir(bm.config().BackupDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []GlobalBackupEntry{}, nil
+		}
+		return nil, err
+	}
+
+	entries := []GlobalBackupEntry{}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+
+		gameID, gameName := dir.Name(), dir.Name()
+		if game, exists := bm.findGameBySlug(dir.Name()); exists {
+			gameID, gameName = game.ID, game.Name
+		}
+
+		backups, err := bm.listQuarantinedBackups(gameID)
+		if err != nil {
+			return nil, fmt.Errorf("error listando la cuarentena de %s: %v", dir.Name(), err)
+		}
+		for _, backup := range backups {
+			entries = append(entries, GlobalBackupEntry{
+				GameID:   gameID,
+				GameName: gameName,
+				Backup:   backup.Name,
+				Path:     backup.Path,
+				Size:     backup.Size,
+				Created:  backup.Created,
+
+				WholeProfile:     backup.WholeProfile,
+				Quarantined:      true,
+				QuarantineReason: backup.QuarantineReason,
+			})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Created.After(entries[j].Created)
+	})
+	return entries, nil
+}
+
+// PurgeQuarantinedBackups elimina definitivamente todos los backups en cuarentena y devuelve cuántos se eliminaron
+func (bm *BackupManager) PurgeQuarantinedBackups() (int, error) {
+	entries, err := bm.GetQuarantinedBackups()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, entry := range entries {
+		if err := bm.removeBackup(entry.GameID, BackupInfo{Name: entry.Backup, Path: entry.Path}); err != nil {
+			return purged, fmt.Errorf("error eliminando %s: %v", entry.Path, err)
+		}
+		purged++
+	}
+
+	log.Printf("Cuarentena vaciada: %d backups eliminados", purged)
+	return purged, nil
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Estados de un trabajo de backup en la cola
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// maxFinishedJobs limita cuántos trabajos terminados se conservan para GetQueueStatus
+const maxFinishedJobs = 50
+
+// Eventos de los trabajos de la cola; llevan el ID de la operación (BackupJob.ID)
+const (
+	backupProgressEvent = "backup:progress" // BackupJob con el progreso actual, como mucho cada 250 ms
+	backupFinishedEvent = "backup:finished" // BackupJob terminado, completado, fallido o cancelado
+)
+
+// BackupJob es un backup encolado, en ejecución o terminado
+type BackupJob struct {
+	ID         string    `json:"id"` // ID de la operación: aparece en sus eventos, en el registro y en la actividad
+	GameID     string    `json:"game_id"`
+	Trigger    string    `json:"trigger"`
+	Status     string    `json:"status"`
+	FilesDone  int       `json:"files_done"`
+	FilesTotal int       `json:"files_total"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	BackupPath string    `json:"backup_path"`
+	Error      string    `json:"error"`
+
+	Duration  time.Duration `json:"duration"`
+	Throttled bool          `json:"throttled"` // Se ejecutó con el límite de E/S de los backups automáticos
+
+	opts BackupOptions
+	done chan struct{}
+}
+
+// QueueStatus es la foto actual de la cola de backups
+type QueueStatus struct {
+	Pending   []BackupJob `json:"pending"`
+	Running   []BackupJob `json:"running"`
+	Completed []BackupJob `json:"completed"`
+}
+
+// backupQueue ejecuta los backups de uno en uno para que no se pisen entre sí. Los pedidos por el usuario
+// (manual, API...) pasan por delante de los automáticos pendientes.
+type backupQueue struct {
+	bm       *BackupManager
+	mu       sync.Mutex
+	pending  []*BackupJob
+	running  *BackupJob
+	finished []*BackupJob
+	wake     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopped  chan struct{}
+}
+
+// newBackupQueue crea la cola y arranca su despachador
+func newBackupQueue(bm *BackupManager) *backupQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &backupQueue{
+		bm:      bm,
+		wake:    make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+	go q.dispatch()
+	return q
+}
+
+// backupQueue devuelve la cola del manager, creándola la primera vez
+func (bm *BackupManager) backupQueue() *backupQueue {
+	bm.queueM.Lock()
+	defer bm.queueM.Unlock()
+	if bm.queue == nil {
+		bm.queue = newBackupQueue(bm)
+	}
+	return bm.queue
+}
+
+// EnqueueBackup encola un backup y devuelve el ID del trabajo.
+// Si el juego ya tiene un backup pendiente, se devuelve ese mismo trabajo; si era automático y ahora
+// lo pide el usuario, pasa a tener su origen y su prioridad.
+func (bm *BackupManager) EnqueueBackup(gameID string, opts BackupOptions) (string, error) {
+	if _, exists := bm.getGame(gameID); !exists {
+		return "", errGameNotFound(gameID)
+	}
+	if opts.Trigger == "" {
+		opts.Trigger = "manual"
+	}
+
+	job, err := bm.backupQueue().enqueue(gameID, opts)
+	if err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// GetOperationStatus devuelve el estado y el último progreso de un trabajo de la cola por su ID de operación.
+// Los trabajos terminados se conservan mientras estén entre los últimos maxFinishedJobs.
+func (bm *BackupManager) GetOperationStatus(operationID string) (*BackupJob, error) {
+	job, ok := bm.backupQueue().find(operationID)
+	if !ok {
+		return nil, fmt.Errorf("operación %s no encontrada", operationID)
+	}
+	return &job, nil
+}
+
+// CancelOperation cancela un trabajo pendiente de la cola por su ID de operación.
+// Un backup en curso no se interrumpe: se dejaría un archivo a medias.
+func (bm *BackupManager) CancelOperation(operationID string) error {
+	return bm.backupQueue().cancelPending(operationID)
+}
+
+// RunBackup encola un backup y espera a que termine
+func (bm *BackupManager) RunBackup(gameID string, opts BackupOptions) (*BackupJob, error) {
+	if _, exists := bm.getGame(gameID); !exists {
+		return nil, errGameNotFound(gameID)
+	}
+	if opts.Trigger == "" {
+		opts.Trigger = "manual"
+	}
+
+	q := bm.backupQueue()
+	job, err := q.enqueue(gameID, opts)
+	if err != nil {
+		return nil, err
+	}
+	<-job.done
+
+	snapshot := q.snapshot(job)
+	if snapshot.Status != JobCompleted {
+		return &snapshot, fmt.Errorf("backup de %s no completado: %s", gameID, snapshot.Error)
+	}
+	return &snapshot, nil
+}
+
+// GetQueueStatus devuelve los trabajos pendientes, en ejecución y terminados
+func (bm *BackupManager) GetQueueStatus() QueueStatus {
+	return bm.backupQueue().status()
+}
+
+// ShutdownQueue cancela los trabajos pendientes y espera a que termine el que está en ejecución
+func (bm *BackupManager) ShutdownQueue(timeout time.Duration) {
+	bm.queueM.Lock()
+	q := bm.queue
+	bm.queueM.Unlock()
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	for _, job := range q.pending {
+		job.Status = JobCancelled
+		job.FinishedAt = time.Now()
+		q.finish(job)
+	}
+	q.pending = nil
+	q.mu.Unlock()
+
+	q.cancel()
+	select {
+	case <-q.stopped:
+	case <-time.After(timeout):
+		log.Printf("La cola de backups no terminó en %v", timeout)
+	}
+}
+
+func (q *backupQueue) enqueue(gameID string, opts BackupOptions) (*BackupJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.ctx.Err() != nil {
+		return nil, fmt.Errorf("la cola de backups está detenida")
+	}
+
+	for i, job := range q.pending {
+		if job.GameID != gameID {
+			continue
+		}
+		if isBackgroundTrigger(job.Trigger) && !isBackgroundTrigger(opts.Trigger) {
+			log.Printf("Operación %s: backup de %s adelantado (%s -> %s)", job.ID, gameID, job.Trigger, opts.Trigger)
+			job.Trigger = opts.Trigger
+			job.opts.Trigger = opts.Trigger
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			q.insertPending(job)
+		}
+		return job, nil
+	}
+
+	if opts.OperationID == "" {
+		opts.OperationID = uuid.NewString()
+	}
+	job := &BackupJob{
+		ID:         opts.OperationID,
+		GameID:     gameID,
+		Trigger:    opts.Trigger,
+		Status:     JobPending,
+		EnqueuedAt: time.Now(),
+		opts:       opts,
+		done:       make(chan struct{}),
+	}
+	q.insertPending(job)
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// insertPending añade un trabajo a los pendientes: los automáticos al final y los demás delante del primer
+// automático; debe llamarse con q.mu bloqueado
+func (q *backupQueue) insertPending(job *BackupJob) {
+	position := len(q.pending)
+	if !isBackgroundTrigger(job.Trigger) {
+		for i, pending := range q.pending {
+			if isBackgroundTrigger(pending.Trigger) {
+				position = i
+				break
+			}
+		}
+	}
+	q.pending = slices.Insert(q.pending, position, job)
+}
+
+// dispatch ejecuta los trabajos pendientes en orden hasta que se detiene la cola
+func (q *backupQueue) dispatch() {
+	defer close(q.stopped)
+
+	for {
+		q.mu.Lock()
+		var job *BackupJob
+		if len(q.pending) > 0 && q.ctx.Err() == nil {
+			job = q.pending[0]
+			q.pending = q.pending[1:]
+			job.Status = JobRunning
+			job.StartedAt = time.Now()
+			q.running = job
+		}
+		q.mu.Unlock()
+
+		if job == nil {
+			select {
+			case <-q.wake:
+				continue
+			case <-q.ctx.Done():
+				return
+			}
+		}
+
+		q.run(job)
+	}
+}
+
+func (q *backupQueue) run(job *BackupJob) {
+	opts := job.opts
+	userProgress := opts.Progress
+	var lastEmit time.Time
+	opts.Progress = func(done, total int) {
+		q.mu.Lock()
+		job.FilesDone = done
+		job.FilesTotal = total
+		snapshot := *job
+		q.mu.Unlock()
+		if time.Since(lastEmit) >= 250*time.Millisecond || done == total {
+			lastEmit = time.Now()
+			q.bm.emit(backupProgressEvent, snapshot)
+		}
+		if userProgress != nil {
+			userProgress(done, total)
+		}
+	}
+	log.Printf("Operación %s: backup de %s (%s) iniciado", job.ID, job.GameID, opts.Trigger)
+
+	if opts.Trigger == "auto" {
+		// Se cuenta antes de crear el backup para que SaveDatabase persista el contador
+		q.bm.recordAutoBackup(job.GameID)
+	}
+	info, err := q.bm.CreateBackupWithOptions(job.GameID, opts)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job.FinishedAt = time.Now()
+	job.Duration = job.FinishedAt.Sub(job.StartedAt)
+	job.Throttled = isBackgroundTrigger(opts.Trigger) && q.bm.config().BackgroundIOThrottleMBps > 0
+	if err != nil {
+		job.Status = JobFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = JobCompleted
+		job.BackupPath = info.Path
+	}
+	log.Printf("Operación %s: terminada (%s) en %v", job.ID, job.Status, job.Duration)
+	q.running = nil
+	q.finish(job)
+}
+
+// finish mueve un trabajo a la lista de terminados y lo emite; debe llamarse con q.mu bloqueado
+func (q *backupQueue) finish(job *BackupJob) {
+	q.finished = append(q.finished, job)
+	if len(q.finished) > maxFinishedJobs {
+		q.finished = q.finished[len(q.finished)-maxFinishedJobs:]
+	}
+	close(job.done)
+	q.bm.emit(backupFinishedEvent, *job)
+}
+
+// find busca un trabajo pendiente, en ejecución o terminado por su ID
+func (q *backupQueue) find(id string) (BackupJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.running != nil && q.running.ID == id {
+		return *q.running, true
+	}
+	for _, jobs := range [][]*BackupJob{q.pending, q.finished} {
+		for _, job := range jobs {
+			if job.ID == id {
+				return *job, true
+			}
+		}
+	}
+	return BackupJob{}, false
+}
+
+// cancelPending quita de la cola un trabajo pendiente y lo marca como cancelado
+func (q *backupQueue) cancelPending(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, job := range q.pending {
+		if job.ID == id {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			job.Status = JobCancelled
+			job.FinishedAt = time.Now()
+			q.finish(job)
+			log.Printf("Operación %s: cancelada", id)
+			return nil
+		}
+	}
+	if q.running != nil && q.running.ID == id {
+		return fmt.Errorf("la operación %s ya está en curso y no se puede cancelar", id)
+	}
+	for _, job := range q.finished {
+		if job.ID == id {
+			return fmt.Errorf("la operación %s ya terminó (%s)", id, job.Status)
+		}
+	}
+	return fmt.Errorf("operación %s no encontrada", id)
+}
+
+func (q *backupQueue) snapshot(job *BackupJob) BackupJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return *job
+}
+
+func (q *backupQueue) status() QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status := QueueStatus{
+		Pending:   []BackupJob{},
+		Running:   []BackupJob{},
+		Completed: []BackupJob{},
+	}
+	for _, job := range q.pending {
+		status.Pending = append(status.Pending, *job)
+	}
+	if q.running != nil {
+		status.Running = append(status.Running, *q.running)
+	}
+	for i := len(q.finished) - 1; i >= 0; i-- {
+		status.Completed = append(status.Completed, *q.finished[i])
+	}
+	return status
+}
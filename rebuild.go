@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// gameSidecarName es la copia de los datos del juego que se guarda en su carpeta de backups en cada backup,
+// para poder reconstruir la base de datos con RebuildDatabaseFromBackups si se pierde game_saves.json
+const gameSidecarName = "game.json"
+
+// GameStatusSavePathsUnknown marca un juego reconstruido desde sus backups sin saber dónde guarda las partidas
+const GameStatusSavePathsUnknown = "save_paths_unknown"
+
+// writeGameSidecar guarda los datos del juego en su carpeta de backups
+func (bm *BackupManager) writeGameSidecar(game *GameInfo) {
+	data, err := json.MarshalIndent(game, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(bm.gameBackupDir(game.ID), gameSidecarName), data, 0644)
+	}
+	if err != nil {
+		log.Printf("Error guardando los datos de %s junto a sus backups: %v", game.Name, err)
+	}
+}
+
+// RebuildDatabaseFromBackups agrega a la biblioteca los juegos de las carpetas de BackupDir que no están en ella
+// y devuelve cuántos agregó. Los datos salen del game.json de cada carpeta o, si no lo tiene, de los guardados
+// dentro del backup más reciente (winesave-meta.json). Sin ninguno de los dos (backups de versiones anteriores)
+// el ID sale de los manifiestos y el nombre de la carpeta, y el juego queda sin rutas de guardado,
+// con Status save_paths_unknown y sin backups automáticos hasta que se le asignen con UpdateGame.
+func (bm *BackupManager) RebuildDatabaseFromBackups() (int, error) {
+	dirs, err := os.ReadDir(bm.config().BackupDir)
+	if err != nil {
+		return 0, fmt.Errorf("error leyendo el directorio de backups: %v", err)
+	}
+
+	added := 0
+	for _, dir := range dirs {
+		if !dir.IsDir() || strings.HasPrefix(dir.Name(), ".") {
+			continue
+		}
+		if _, exists := bm.findGameBySlug(dir.Name()); exists {
+			continue
+		}
+
+		game, err := bm.rebuildGame(dir.Name())
+		if err != nil {
+			log.Printf("No se reconstruyó %s: %v", dir.Name(), err)
+			continue
+		}
+		if game == nil {
+			continue // No es una carpeta de backups
+		}
+		bm.setGame(game)
+		added++
+		log.Printf("Juego reconstruido desde sus backups: %s (%s)", game.Name, game.ID)
+	}
+
+	if added == 0 {
+		return 0, nil
+	}
+	bm.checkPathOverlaps()
+	return added, bm.SaveDatabase()
+}
+
+// rebuildGame reconstruye el juego de una carpeta de backups; nil si la carpeta no tiene backups ni game.json
+func (bm *BackupManager) rebuildGame(slug string) (*GameInfo, error) {
+	backups, err := bm.listBackups(slug) // Sin juego, el ID es el nombre de la carpeta
+	if err != nil {
+		return nil, err
+	}
+
+	game := &GameInfo{}
+	data, err := os.ReadFile(filepath.Join(bm.config().BackupDir, slug, gameSidecarName))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, game); err != nil {
+			return nil, fmt.Errorf("%s ilegible: %v", gameSidecarName, err)
+		}
+	case os.IsNotExist(err) && len(backups) > 0:
+		game = gameFromBackupMeta(backups)
+		if game == nil {
+			game = &GameInfo{
+				ID:        manifestGameID(backups),
+				Name:      slug,
+				Platform:  "custom",
+				SavePaths: []string{},
+				Patterns:  append([]string(nil), SaveFilePatterns...),
+				Schedule:  "off",
+				Status:    GameStatusSavePathsUnknown,
+			}
+		}
+	case os.IsNotExist(err):
+		return nil, nil
+	default:
+		return nil, err
+	}
+
+	// La carpeta manda: es donde están los backups
+	game.Slug = slug
+	if _, exists := bm.getGame(game.ID); exists || game.ID == "" {
+		game.ID = newGameID()
+	}
+	if game.Metadata == nil {
+		game.Metadata = make(map[string]string)
+	}
+	if game.CustomPaths == nil {
+		game.CustomPaths = []string{}
+	}
+	for _, backup := range backups {
+		if backup.Created.After(game.LastBackup) {
+			game.LastBackup = backup.Created
+		}
+	}
+	return game, nil
+}
+
+// gameFromBackupMeta reconstruye el juego con los datos guardados dentro del backup más reciente que los tenga
+func gameFromBackupMeta(backups []BackupInfo) *GameInfo {
+	for _, backup := range backups {
+		meta, err := readBackupMeta(backup.Path)
+		if err != nil {
+			continue
+		}
+		game := &GameInfo{
+			ID:             meta.GameID,
+			Name:           meta.Name,
+			Platform:       meta.Platform,
+			SavePaths:      meta.SavePaths,
+			InstallDir:     meta.InstallDir,
+			Patterns:       meta.Patterns,
+			Metadata:       meta.Metadata,
+			BackupRegistry: meta.BackupRegistry,
+			RegistryKeys:   meta.RegistryKeys,
+		}
+		if len(game.SavePaths) == 0 {
+			game.Schedule = "off"
+			game.Status = GameStatusSavePathsUnknown
+		}
+		return game
+	}
+	return nil
+}
+
+// manifestGameID devuelve el ID de juego guardado en los manifiestos de los backups, si es un UUID
+func manifestGameID(backups []BackupInfo) string {
+	for _, backup := range backups {
+		if manifest, err := loadManifest(backup.Path); err == nil {
+			if _, err := uuid.Parse(manifest.GameID); err == nil {
+				return manifest.GameID
+			}
+		}
+	}
+	return ""
+}
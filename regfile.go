@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// regFile es un archivo de registro de Wine (user.reg, system.reg) en formato texto.
+// Las claves conservan sus líneas tal cual para reescribir el archivo sin reinterpretar los valores.
+type regFile struct {
+	Header []string // Líneas anteriores a la primera clave (versión, ;; comentarios, #arch)
+	Keys   []regKey
+}
+
+// regKey es una clave del registro con su línea de cabecera ([Clave] marca_de_tiempo) y sus valores
+type regKey struct {
+	Name  string   // Ruta sin escapar, p. ej. Software\Vendor\Game
+	Lines []string // Cabecera, #time y valores (las continuaciones con \ quedan como líneas separadas)
+}
+
+// parseRegFile lee un archivo de registro de Wine, en UTF-8 o en UTF-16 con BOM (p. ej. un user.reg guardado
+// con un editor de Windows); Bytes lo escribe siempre en UTF-8, como Wine.
+func parseRegFile(data []byte) (*regFile, error) {
+	text := strings.ReplaceAll(decodeRegText(data), "\r\n", "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "WINE REGISTRY Version") {
+		return nil, fmt.Errorf("no es un archivo de registro de Wine")
+	}
+
+	file := &regFile{}
+	var current *regKey
+	for i, line := range lines {
+		if strings.HasPrefix(line, "[") {
+			end := strings.LastIndex(line, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("línea %d: clave sin cerrar", i+1)
+			}
+			name, err := unescapeRegName(line[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("línea %d: %v", i+1, err)
+			}
+			file.Keys = append(file.Keys, regKey{Name: name, Lines: []string{line}})
+			current = &file.Keys[len(file.Keys)-1]
+			continue
+		}
+
+		if current == nil {
+			file.Header = append(file.Header, line)
+			continue
+		}
+		if line != "" {
+			current.Lines = append(current.Lines, line)
+		}
+	}
+
+	// Wine deja una línea en blanco antes de la primera clave; se vuelve a poner al escribir
+	for len(file.Header) > 0 && file.Header[len(file.Header)-1] == "" {
+		file.Header = file.Header[:len(file.Header)-1]
+	}
+	return file, nil
+}
+
+// decodeRegText pasa a texto UTF-8 el contenido de un archivo de registro, quitando el BOM
+func decodeRegText(data []byte) string {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		order = binary.BigEndian
+	default:
+		return strings.TrimPrefix(string(data), "\uFEFF")
+	}
+
+	data = data[2:]
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// Bytes escribe el archivo con el formato de Wine: una línea en blanco antes de cada clave
+func (f *regFile) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, line := range f.Header {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	for _, key := range f.Keys {
+		buf.WriteByte('\n')
+		for _, line := range key.Lines {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+// subtree devuelve las claves que están en alguna de las rutas indicadas o por debajo de ellas
+func (f *regFile) subtree(roots []string) []regKey {
+	var keys []regKey
+	for _, key := range f.Keys {
+		for _, root := range roots {
+			if regKeyWithin(root, key.Name) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// merge reemplaza las claves que ya existen (sin distinguir mayúsculas, como Windows) y agrega el resto al final
+func (f *regFile) merge(keys []regKey) (replaced, added int) {
+	index := make(map[string]int, len(f.Keys))
+	for i, key := range f.Keys {
+		index[strings.ToLower(key.Name)] = i
+	}
+
+	for _, key := range keys {
+		if i, exists := index[strings.ToLower(key.Name)]; exists {
+			f.Keys[i] = key
+			replaced++
+			continue
+		}
+		index[strings.ToLower(key.Name)] = len(f.Keys)
+		f.Keys = append(f.Keys, key)
+		added++
+	}
+	return replaced, added
+}
+
+// regKeyWithin indica si la clave name es root o cuelga de ella
+func regKeyWithin(root, name string) bool {
+	root = strings.ToLower(strings.Trim(root, `\`))
+	name = strings.ToLower(name)
+	return name == root || strings.HasPrefix(name, root+`\`)
+}
+
+// unescapeRegName deshace el escapado de Wine en los nombres de clave: \\ para la barra y \x{hex} para otros caracteres
+func unescapeRegName(escaped string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(escaped); i++ {
+		c := escaped[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		if i+1 >= len(escaped) {
+			return "", fmt.Errorf("escape incompleto en %q", escaped)
+		}
+
+		i++
+		switch escaped[i] {
+		case 'x':
+			end := i + 1
+			for end < len(escaped) && end-i <= 4 && strings.IndexByte("0123456789abcdefABCDEF", escaped[end]) >= 0 {
+				end++
+			}
+			code, err := strconv.ParseUint(escaped[i+1:end], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("escape inválido en %q", escaped)
+			}
+			b.WriteRune(rune(code))
+			i = end - 1
+		default:
+			b.WriteByte(escaped[i])
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// testUserReg es un user.reg de Wine con valores de varias líneas, binarios, DWORD y nombres escapados
+const testUserReg = `WINE REGISTRY Version 2
+;; All keys relative to \\User\\S-1-5-21-0-0-0-1000
+
+#arch=win64
+
+[Software\\Studio\\Game] 1700000000
+#time=1da1234567890ab
+"Volume"=dword:00000050
+"PlayerName"="Ana \"la rápida\" C:\\Games"
+"Slots"=hex:01,02,03,04,05,06,07,08,09,0a,0b,0c,0d,0e,0f,10,11,12,13,14,15,16,17,\
+  18,19,1a,1b,1c,1d,1e,1f,20,21,22,23,24,25,26,27,28,29,2a,2b,2c,2d,2e,2f,30,\
+  31,32
+"SavePath"=str(2):"%APPDATA%\\Game"
+"Extra"=hex(7):41,00,00,00,42,00,00,00,00,00
+@="default"
+
+[Software\\Studio\\Game\\Profiles\\1] 1700000001
+"Level"=dword:0000000c
+
+[Software\\Studio\\Caf\xe9 Game] 1700000002
+"Name"="Café"
+
+[Software\\StudioX] 1700000003
+"Other"=dword:00000001
+`
+
+func TestParseRegFile(t *testing.T) {
+	file, err := parseRegFile([]byte(testUserReg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantHeader := []string{"WINE REGISTRY Version 2", `;; All keys relative to \\User\\S-1-5-21-0-0-0-1000`, "", "#arch=win64"}
+	if !reflect.DeepEqual(file.Header, wantHeader) {
+		t.Errorf("cabecera %q, se esperaba %q", file.Header, wantHeader)
+	}
+	var names []string
+	for _, key := range file.Keys {
+		names = append(names, key.Name)
+	}
+	wantNames := []string{`Software\Studio\Game`, `Software\Studio\Game\Profiles\1`, `Software\Studio\Café Game`, `Software\StudioX`}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("claves %q, se esperaban %q", names, wantNames)
+	}
+
+	// Cabecera, #time, 2 valores, 3 líneas del binario largo y 3 valores más
+	if lines := file.Keys[0].Lines; len(lines) != 10 || !strings.HasSuffix(lines[4], `\`) || !strings.HasPrefix(lines[5], "  18,") {
+		t.Errorf("líneas de la primera clave: %q", lines)
+	}
+
+	// Escribirlo sin cambios deja el archivo igual
+	if got := string(file.Bytes()); got != testUserReg {
+		t.Errorf("Bytes() no reproduce el archivo:\n%s", got)
+	}
+}
+
+func TestParseRegFileCRLFAndUTF16(t *testing.T) {
+	crlf := strings.ReplaceAll(testUserReg, "\n", "\r\n")
+	encodings := map[string][]byte{
+		"crlf":      []byte(crlf),
+		"utf-8 bom": append([]byte{0xEF, 0xBB, 0xBF}, testUserReg...),
+		"utf-16le":  encodeUTF16(crlf, binary.LittleEndian, []byte{0xFF, 0xFE}),
+		"utf-16be":  encodeUTF16(testUserReg, binary.BigEndian, []byte{0xFE, 0xFF}),
+	}
+	for name, data := range encodings {
+		file, err := parseRegFile(data)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got := string(file.Bytes()); got != testUserReg {
+			t.Errorf("%s: Bytes() no devuelve el archivo en UTF-8 con saltos de línea de Wine:\n%s", name, got)
+		}
+	}
+
+	// Sin BOM UTF-16 no se reconoce: no se adivina la codificación
+	if _, err := parseRegFile(encodeUTF16(testUserReg, binary.LittleEndian, nil)); err == nil {
+		t.Error("UTF-16 sin BOM debería rechazarse")
+	}
+	if _, err := parseRegFile([]byte("Windows Registry Editor Version 5.00\n")); err == nil {
+		t.Error("un archivo que no es de Wine debería rechazarse")
+	}
+	if _, err := parseRegFile([]byte("WINE REGISTRY Version 2\n\n[Software\\\\Game 123\n")); err == nil {
+		t.Error("una clave sin cerrar debería rechazarse")
+	}
+}
+
+func encodeUTF16(text string, order binary.ByteOrder, bom []byte) []byte {
+	buf := bytes.NewBuffer(bom)
+	for _, unit := range utf16.Encode([]rune(text)) {
+		binary.Write(buf, order, unit)
+	}
+	return buf.Bytes()
+}
+
+func TestUnescapeRegName(t *testing.T) {
+	valid := map[string]string{
+		`Software\\Studio\\Game`: `Software\Studio\Game`,
+		`Caf\xe9`:                "Café",
+		`\x41\x0042C`:            "ABC", // Como mucho 4 cifras: Wine las escribe todas si sigue una cifra hex
+		`\x00e9x`:                "éx",
+		`\x3042\x3044`:           "あい",
+		`Quote\"d`:               `Quote"d`,
+		"plain name":             "plain name",
+	}
+	for escaped, want := range valid {
+		if got, err := unescapeRegName(escaped); err != nil || got != want {
+			t.Errorf("unescapeRegName(%q) = %q, %v; se esperaba %q", escaped, got, err, want)
+		}
+	}
+	for _, escaped := range []string{`trailing\`, `\xzz`, `\x`} {
+		if got, err := unescapeRegName(escaped); err == nil {
+			t.Errorf("unescapeRegName(%q) = %q, se esperaba un error", escaped, got)
+		}
+	}
+}
+
+func TestRegFileSubtreeAndMerge(t *testing.T) {
+	file, err := parseRegFile([]byte(testUserReg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sin distinguir mayúsculas y sin tomar Software\StudioX por Software\Studio
+	keys := file.subtree([]string{`software\studio\`})
+	if len(keys) != 3 {
+		t.Fatalf("subtree: %d claves, se esperaban 3", len(keys))
+	}
+
+	target, err := parseRegFile([]byte("WINE REGISTRY Version 2\n\n[Software\\\\STUDIO\\\\game] 1\n\"Volume\"=dword:00000001\n\n[Software\\\\Wine] 1\n\"Version\"=\"win10\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaced, added := target.merge(keys)
+	if replaced != 1 || added != 2 {
+		t.Errorf("merge: %d reemplazadas, %d agregadas; se esperaban 1 y 2", replaced, added)
+	}
+	merged := string(target.Bytes())
+	for _, want := range []string{`"Volume"=dword:00000050`, `"Version"="win10"`, `[Software\\Studio\\Caf\xe9 Game]`, "  31,32\n"} {
+		if !strings.Contains(merged, want) {
+			t.Errorf("el registro mezclado no contiene %q:\n%s", want, merged)
+		}
+	}
+	if strings.Contains(merged, "dword:00000001\n\n[Software\\\\Wine]") {
+		t.Error("la clave reemplazada conserva el valor anterior")
+	}
+}
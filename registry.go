@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// registryEntryName es la entrada del backup con las claves del registro del prefijo.
+// Solo se trata como registro en los juegos con BackupRegistry activado.
+const registryEntryName = "registry.reg"
+
+// SetRegistryBackup activa o desactiva el respaldo de las claves del registro de Wine de un juego.
+// keys son rutas como HKEY_CURRENT_USER\Software\Vendor\Game; vacío = mantener las actuales.
+func (bm *BackupManager) SetRegistryBackup(gameID string, enabled bool, keys []string) (*GameInfo, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	if len(keys) > 0 {
+		cleaned := make([]string, 0, len(keys))
+		for _, key := range keys {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			if _, ok := userRegKey(key); !ok {
+				return nil, fmt.Errorf("solo se pueden respaldar claves de HKEY_CURRENT_USER: %s", key)
+			}
+			cleaned = append(cleaned, key)
+		}
+		game.RegistryKeys = cleaned
+	}
+	if enabled && len(game.RegistryKeys) == 0 {
+		return nil, fmt.Errorf("el juego no tiene claves de registro configuradas")
+	}
+	if enabled && gamePrefix(game) == "" {
+		return nil, fmt.Errorf("%s no está asociado a un prefijo de Wine", game.Name)
+	}
+
+	game.BackupRegistry = enabled
+	return game, bm.SaveDatabase()
+}
+
+// gamePrefix devuelve el prefijo de Wine del juego: el del detector o el que contiene sus rutas de guardado
+func gamePrefix(game *GameInfo) string {
+	if prefix := game.Metadata["wine_prefix"]; prefix != "" {
+		return prefix
+	}
+	for _, savePath := range game.SavePaths {
+		slashed := filepath.ToSlash(ExpandPath(savePath))
+		if index := strings.Index(strings.ToLower(slashed), "/drive_c/"); index > 0 {
+			return filepath.FromSlash(slashed[:index])
+		}
+	}
+	return ""
+}
+
+// userRegKey convierte una clave de HKEY_CURRENT_USER en su ruta dentro de user.reg (relativa a HKCU).
+// Devuelve false para otras ramas del registro, que Wine guarda en system.reg.
+func userRegKey(key string) (string, bool) {
+	key = strings.Trim(strings.ReplaceAll(key, "/", `\`), `\`)
+	hive, rest, _ := strings.Cut(key, `\`)
+	switch strings.ToUpper(hive) {
+	case "HKEY_CURRENT_USER", "HKCU":
+		return rest, rest != ""
+	case "HKEY_LOCAL_MACHINE", "HKLM", "HKEY_USERS", "HKU", "HKEY_CLASSES_ROOT", "HKCR":
+		return "", false
+	}
+	// Sin rama: se asume HKEY_CURRENT_USER (p. ej. Software\Vendor\Game)
+	return key, true
+}
+
+// registrySlice extrae de user.reg las claves configuradas del juego, con la cabecera del archivo original.
+// Devuelve nil si el juego no respalda el registro o no hay ninguna de sus claves.
+func (bm *BackupManager) registrySlice(game *GameInfo) ([]byte, error) {
+	if !game.BackupRegistry || len(game.RegistryKeys) == 0 {
+		return nil, nil
+	}
+	prefix := gamePrefix(game)
+	if prefix == "" {
+		return nil, fmt.Errorf("%s no está asociado a un prefijo de Wine", game.Name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(prefix, "user.reg"))
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el registro del prefijo: %v", err)
+	}
+	registry, err := parseRegFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo user.reg: %v", err)
+	}
+
+	roots := make([]string, 0, len(game.RegistryKeys))
+	for _, key := range game.RegistryKeys {
+		if root, ok := userRegKey(key); ok {
+			roots = append(roots, root)
+		}
+	}
+
+	keys := registry.subtree(roots)
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	slice := &regFile{Header: registry.Header, Keys: keys}
+	return slice.Bytes(), nil
+}
+
+// writeRegistryEntry agrega al backup las claves del registro del juego. Un fallo no impide el backup de los archivos.
+func (bm *BackupManager) writeRegistryEntry(game *GameInfo, write func(data []byte) error) error {
+	data, err := bm.registrySlice(game)
+	if err != nil {
+		log.Printf("No se respaldó el registro de %s: %v", game.Name, err)
+		return nil
+	}
+	if data == nil {
+		return nil
+	}
+	return write(data)
+}
+
+// mergeRegistrySlice mezcla las claves de un backup en el user.reg del prefijo, guardando antes una copia
+// del original junto a él. Wine no debe estar en marcha: wineserver sobrescribe user.reg al cerrarse.
+func mergeRegistrySlice(prefix string, open func() (io.ReadCloser, error)) (int, error) {
+	src, err := open()
+	if err != nil {
+		return 0, err
+	}
+	data, err := io.ReadAll(src)
+	src.Close()
+	if err != nil {
+		return 0, err
+	}
+	slice, err := parseRegFile(data)
+	if err != nil {
+		return 0, fmt.Errorf("error leyendo %s del backup: %v", registryEntryName, err)
+	}
+
+	userReg := filepath.Join(prefix, "user.reg")
+	original, err := os.ReadFile(userReg)
+	if os.IsNotExist(err) {
+		return 0, fmt.Errorf("el prefijo %s no tiene user.reg; inicia el juego una vez antes de restaurar el registro", prefix)
+	}
+	if err != nil {
+		return 0, err
+	}
+	registry, err := parseRegFile(original)
+	if err != nil {
+		return 0, fmt.Errorf("error leyendo %s: %v", userReg, err)
+	}
+
+	backupPath := fmt.Sprintf("%s.winesave-%s.bak", userReg, time.Now().Format(backupTimestampLayout))
+	if err := os.WriteFile(backupPath, original, 0644); err != nil {
+		return 0, fmt.Errorf("error guardando copia de user.reg: %v", err)
+	}
+
+	replaced, added := registry.merge(slice.Keys)
+	merged := registry.Bytes()
+	err = writeRestoredFile(userReg, time.Time{}, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(merged)), nil
+	}, copyCheck{})
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Registro restaurado en %s: %d claves reemplazadas, %d nuevas (copia en %s)", userReg, replaced, added, backupPath)
+	return replaced + added, nil
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Búsqueda de la nueva carpeta de un juego reinstalado en otro sitio
+const (
+	relocationProbeCount  = 5 // Archivos del último backup que se buscan en cada carpeta candidata
+	relocationSearchDepth = 5 // Niveles de carpetas que se recorren bajo cada raíz de búsqueda
+)
+
+// GameUpdate son los campos editables de un juego. El ID no cambia aunque cambien las rutas,
+// así el historial de backups sigue asociado al juego.
+type GameUpdate struct {
+	Name      string   `json:"name"`
+	SavePaths []string `json:"save_paths"`
+	Patterns  []string `json:"patterns"` // Vacío = mantener los actuales
+
+	InstallDir string `json:"install_dir"` // Carpeta de instalación para las rutas con %GAME_DIR%; vacío = mantener la actual
+}
+
+// GameRelocation es un juego cuyas rutas de guardado ya no existen y cuyos archivos aparecieron en otra carpeta
+type GameRelocation struct {
+	GameID       string   `json:"game_id"`
+	Name         string   `json:"name"`
+	OldPaths     []string `json:"old_paths"`
+	NewPath      string   `json:"new_path"`
+	MatchedFiles int      `json:"matched_files"` // Archivos del último backup encontrados en la nueva carpeta
+	TotalFiles   int      `json:"total_files"`
+}
+
+// UpdateGame modifica el nombre, las rutas o los patrones de un juego conservando su ID y sus backups
+func (bm *BackupManager) UpdateGame(gameID string, update GameUpdate) (*GameInfo, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	savePaths := make([]string, 0, len(update.SavePaths))
+	for _, path := range update.SavePaths {
+		if path = strings.TrimSpace(path); path != "" {
+			savePaths = append(savePaths, ExpandPath(path))
+		}
+	}
+	if len(savePaths) == 0 {
+		return nil, fmt.Errorf("el juego necesita al menos una ruta de guardado")
+	}
+	installDir := game.InstallDir
+	if dir := strings.TrimSpace(update.InstallDir); dir != "" {
+		installDir = filepath.Clean(ExpandPath(dir))
+		if info, err := os.Stat(installDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("la carpeta de instalación %s no existe", installDir)
+		}
+	}
+	if !bm.gameExists(&GameInfo{SavePaths: savePaths, InstallDir: installDir}) {
+		return nil, fmt.Errorf("ninguna de las rutas de guardado especificadas existe")
+	}
+
+	if !slices.Equal(game.SavePaths, savePaths) {
+		log.Printf("Rutas de guardado de %s cambiadas: %v -> %v (ID %s conservado)", game.Name, game.SavePaths, savePaths, game.ID)
+	}
+
+	if installDir != game.InstallDir {
+		log.Printf("Carpeta de instalación de %s: %s", game.Name, installDir)
+	}
+
+	if name := strings.TrimSpace(update.Name); name != "" {
+		game.Name = name
+	}
+	game.SavePaths = savePaths
+	game.InstallDir = installDir
+	if game.Status == GameStatusSavePathsUnknown {
+		game.Status = ""
+	}
+	if len(update.Patterns) > 0 {
+		game.Patterns = update.Patterns
+	}
+
+	if err := bm.updateGameInfo(game); err != nil {
+		log.Printf("Error actualizando info del juego %s: %v", gameID, err)
+	}
+	return game, bm.SaveDatabase()
+}
+
+// RelocateGame aplica una reubicación detectada: reemplaza las rutas que ya no existen por la nueva carpeta
+func (bm *BackupManager) RelocateGame(gameID, newPath string) (*GameInfo, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	savePaths := []string{ExpandPath(newPath)}
+	for _, path := range game.SavePaths {
+		if bm.gameExists(&GameInfo{SavePaths: []string{path}, InstallDir: game.InstallDir}) {
+			savePaths = append(savePaths, path)
+		}
+	}
+	return bm.UpdateGame(gameID, GameUpdate{SavePaths: savePaths})
+}
+
+// RescanGame vuelve a leer las rutas de un juego; si ya no existen busca la carpeta a la que se movieron
+func (bm *BackupManager) RescanGame(gameID string) (*GameInfo, *GameRelocation, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, nil, errGameNotFound(gameID)
+	}
+
+	if bm.gameExists(game) {
+		if err := bm.updateGameInfo(game); err != nil {
+			return nil, nil, err
+		}
+		return game, nil, bm.SaveDatabase()
+	}
+	if err := bm.checkSaveMounts(game); err != nil {
+		return game, nil, err
+	}
+
+	// Ejecutar los detectores sin guardar nada: la reubicación solo se ofrece, no se aplica
+	result := &ScanResult{NewGames: []*GameInfo{}, Errors: []string{}, DryRun: true, Relocated: []GameRelocation{}}
+	bm.runDetectors(result, false)
+	for _, relocation := range result.Relocated {
+		if relocation.GameID == gameID {
+			return game, &relocation, nil
+		}
+	}
+	return game, nil, nil
+}
+
+// findRelocatedGame comprueba si una carpeta recién detectada contiene los archivos de un juego
+// conocido cuyas rutas ya no existen. Se compara con el manifiesto de su último backup.
+func (bm *BackupManager) findRelocatedGame(candidate *GameInfo) (*GameRelocation, bool) {
+	if len(candidate.SavePaths) != 1 {
+		return nil, false
+	}
+	newPath := candidate.SavePaths[0]
+
+	var best *GameRelocation
+	for _, game := range bm.GetGameList() {
+		// Una unidad desmontada no es una carpeta movida
+		if bm.gameExists(game) || bm.checkSaveMounts(game) != nil {
+			continue
+		}
+
+		backups, err := bm.listBackups(game.ID)
+		if err != nil || len(backups) == 0 {
+			continue
+		}
+		manifest, err := loadManifest(backups[0].Path)
+		if err != nil || len(manifest.Files) == 0 {
+			continue
+		}
+
+		matched := 0
+		for _, file := range manifest.Files {
+			if _, err := os.Stat(filepath.Join(newPath, filepath.FromSlash(file.Path))); err == nil {
+				matched++
+			}
+		}
+
+		// Al menos la mitad de los archivos del último backup deben estar en la nueva carpeta
+		if matched == 0 || matched*2 < len(manifest.Files) {
+			continue
+		}
+		if best == nil || matched > best.MatchedFiles {
+			best = &GameRelocation{
+				GameID:       game.ID,
+				Name:         game.Name,
+				OldPaths:     game.SavePaths,
+				NewPath:      newPath,
+				MatchedFiles: matched,
+				TotalFiles:   len(manifest.Files),
+			}
+		}
+	}
+	return best, best != nil
+}
+
+// RelocateGamePaths cambia el prefijo oldRoot por newRoot en las rutas de guardado, las rutas personalizadas y la
+// carpeta de instalación de un juego, p. ej. tras reinstalarlo en otra unidad. Las rutas con %GAME_DIR% siguen
+// a la carpeta de instalación. Falla sin cambiar nada si ninguna ruta estaba bajo oldRoot o si las nuevas no existen.
+func (bm *BackupManager) RelocateGamePaths(gameID, oldRoot, newRoot string) error {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return errGameNotFound(gameID)
+	}
+	oldRoot, newRoot = strings.TrimSpace(oldRoot), strings.TrimSpace(newRoot)
+	if oldRoot == "" || newRoot == "" {
+		return fmt.Errorf("hay que indicar la carpeta anterior y la nueva")
+	}
+	oldRoot, newRoot = filepath.Clean(ExpandPath(oldRoot)), filepath.Clean(ExpandPath(newRoot))
+	if oldRoot == newRoot {
+		return fmt.Errorf("la carpeta anterior y la nueva son la misma")
+	}
+	if info, err := os.Stat(newRoot); err != nil || !info.IsDir() {
+		return fmt.Errorf("la carpeta %s no existe", newRoot)
+	}
+
+	savePaths, movedSaves := relocatePaths(game.SavePaths, oldRoot, newRoot)
+	customPaths, movedCustom := relocatePaths(game.CustomPaths, oldRoot, newRoot)
+	installDir, movedInstall := relocatePath(game.InstallDir, oldRoot, newRoot)
+	if !movedInstall {
+		installDir = game.InstallDir
+	}
+	if movedSaves == 0 && movedCustom == 0 && !movedInstall {
+		return fmt.Errorf("ninguna ruta de %s está dentro de %s", game.Name, oldRoot)
+	}
+
+	// Se comprueban las rutas nuevas antes de tocar el juego
+	candidate := *game
+	candidate.SavePaths = savePaths
+	candidate.InstallDir = installDir
+	if !bm.gameExists(&candidate) {
+		return fmt.Errorf("ninguna de las rutas de guardado de %s existe dentro de %s", game.Name, newRoot)
+	}
+
+	log.Printf("Rutas de %s movidas de %s a %s: %d de guardado, %d personalizadas (ID %s conservado)",
+		game.Name, oldRoot, newRoot, movedSaves, movedCustom, game.ID)
+	game.SavePaths = savePaths
+	game.CustomPaths = customPaths
+	game.InstallDir = installDir
+	if game.Status == GameStatusSavePathsUnknown {
+		game.Status = ""
+	}
+	if err := bm.updateGameInfo(game); err != nil {
+		log.Printf("Error actualizando info del juego %s: %v", gameID, err)
+	}
+	return bm.SaveDatabase()
+}
+
+// relocatePaths cambia oldRoot por newRoot en las rutas que están dentro de oldRoot y devuelve cuántas cambiaron
+func relocatePaths(paths []string, oldRoot, newRoot string) ([]string, int) {
+	relocated := make([]string, len(paths))
+	moved := 0
+	for i, path := range paths {
+		relocated[i] = path
+		if newPath, ok := relocatePath(path, oldRoot, newRoot); ok {
+			relocated[i] = newPath
+			moved++
+		}
+	}
+	return relocated, moved
+}
+
+// relocatePath devuelve path con oldRoot cambiado por newRoot, o false si path no está dentro de oldRoot
+func relocatePath(path, oldRoot, newRoot string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(oldRoot, filepath.Clean(ExpandPath(path)))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(newRoot, rel), true
+}
+
+// FindLikelyNewLocation busca en las bibliotecas de Steam y en las ubicaciones comunes de guardado carpetas que
+// contienen los archivos del último backup de un juego (mismo nombre y tamaño). Sirve para elegir el newRoot de
+// RelocateGamePaths o la carpeta de RelocateGame. Las rutas actuales del juego no se devuelven.
+func (bm *BackupManager) FindLikelyNewLocation(gameID string) ([]string, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+	backups, err := bm.listBackups(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("%s no tiene backups con los que comparar", game.Name)
+	}
+	manifest, err := loadManifest(backups[0].Path)
+	if err != nil {
+		return nil, fmt.Errorf("el último backup de %s no tiene manifiesto: %v", game.Name, err)
+	}
+	probes := relocationProbes(manifest)
+	if len(probes) == 0 {
+		return nil, fmt.Errorf("el último backup de %s no tiene archivos", game.Name)
+	}
+
+	skip := map[string]bool{filepath.Clean(bm.config().BackupDir): true}
+	for _, root := range bm.saveRoots(game) {
+		skip[filepath.Clean(root.Path)] = true
+	}
+
+	found := []string{}
+	for _, root := range bm.relocationSearchRoots(game) {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if skip[path] {
+				return fs.SkipDir
+			}
+			if matchesProbes(path, probes) {
+				found = append(found, path)
+				skip[path] = true // Las raíces pueden solaparse
+				return fs.SkipDir
+			}
+			if rel, err := filepath.Rel(root, path); err == nil && rel != "." &&
+				strings.Count(rel, string(filepath.Separator)) >= relocationSearchDepth-1 {
+				return fs.SkipDir
+			}
+			return nil
+		})
+	}
+	log.Printf("Búsqueda de la nueva carpeta de %s: %d candidatas", game.Name, len(found))
+	return found, nil
+}
+
+// relocationProbes elige los archivos más grandes de un manifiesto para reconocer la carpeta de un juego.
+// Las entradas que añade WineSave (registro, metadatos) no están en las carpetas del juego.
+func relocationProbes(manifest *BackupManifest) []ManifestEntry {
+	probes := []ManifestEntry{}
+	for _, entry := range manifest.Files {
+		if entry.Path != registryEntryName && entry.Path != backupMetaEntryName {
+			probes = append(probes, entry)
+		}
+	}
+	sort.Slice(probes, func(i, j int) bool {
+		if probes[i].Size != probes[j].Size {
+			return probes[i].Size > probes[j].Size
+		}
+		return probes[i].Path < probes[j].Path
+	})
+	if len(probes) > relocationProbeCount {
+		probes = probes[:relocationProbeCount]
+	}
+	return probes
+}
+
+// matchesProbes indica si al menos la mitad de los archivos de prueba están en dir con el mismo tamaño
+func matchesProbes(dir string, probes []ManifestEntry) bool {
+	matched := 0
+	for _, probe := range probes {
+		if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(probe.Path))); err == nil &&
+			info.Mode().IsRegular() && info.Size() == probe.Size {
+			matched++
+		}
+	}
+	return matched > 0 && matched*2 >= len(probes)
+}
+
+// relocationSearchRoots devuelve dónde buscar la nueva carpeta de un juego: su carpeta de instalación, las
+// bibliotecas de Steam, las ubicaciones comunes de guardado y, fuera de Windows, los usuarios de los prefijos de Wine
+func (bm *BackupManager) relocationSearchRoots(game *GameInfo) []string {
+	candidates := []string{}
+	if game.InstallDir != "" {
+		candidates = append(candidates, game.InstallDir)
+	}
+	for _, root := range steamRoots() {
+		for _, library := range steamLibraries(root) {
+			candidates = append(candidates, filepath.Join(library, "steamapps", "common"))
+		}
+	}
+	platforms := make([]string, 0, len(CommonSavePaths))
+	for platform := range CommonSavePaths {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	for _, platform := range platforms {
+		for _, path := range CommonSavePaths[platform] {
+			candidates = append(candidates, filepath.FromSlash(ExpandPath(path)))
+		}
+	}
+	if runtime.GOOS != "windows" {
+		for _, prefix := range findWinePrefixes() {
+			candidates = append(candidates, filepath.Join(prefix, "drive_c", "users"))
+		}
+	}
+
+	roots := []string{}
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		candidate = filepath.Clean(candidate)
+		if info, err := os.Stat(candidate); err != nil || !info.IsDir() || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		roots = append(roots, candidate)
+	}
+	return roots
+}
@@ -0,0 +1,654 @@
+package main
+
+import (
+	"archive/zip"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ErrUnsavedChanges indica que restaurar sobrescribiría cambios que no están en ningún backup
+var ErrUnsavedChanges = errors.New("los archivos de guardado tienen cambios sin respaldar")
+
+// maxRestoreRecords limita cuántas restauraciones se guardan en el historial de cada juego
+const maxRestoreRecords = 100
+
+// Modos de restauración (RestoreOptions.Mode)
+const (
+	RestoreMerge      = "merge"       // Escribe los archivos del backup y conserva los demás (por defecto)
+	RestoreOverwrite  = "overwrite"   // Deja las rutas de guardado como en el backup: elimina las partidas que no están en él
+	RestoreMergeNewer = "merge-newer" // Solo escribe los archivos que faltan o son más antiguos que los del backup; no toca los más recientes
+)
+
+// validateRestoreMode comprueba que mode es un modo de restauración; vacío es válido (RestoreMerge)
+func validateRestoreMode(mode string) error {
+	switch mode {
+	case "", RestoreMerge, RestoreOverwrite, RestoreMergeNewer:
+		return nil
+	}
+	return fmt.Errorf("modo de restauración no válido: %s", mode)
+}
+
+// RestoreOptions controla cómo se restaura un backup
+type RestoreOptions struct {
+	Force bool `json:"force"` // Restaurar aunque haya cambios sin respaldar o falte espacio en el destino
+
+	Mode string `json:"mode"` // RestoreMerge, RestoreOverwrite o RestoreMergeNewer; vacío = Config.DefaultRestoreMode
+
+	// TargetPrefix restaura dentro de otro prefijo de Wine/Proton, expandiendo allí las rutas de Windows
+	// del juego (%APPDATA%, %USERPROFILE%...). Vacío = las rutas de guardado del juego.
+	TargetPrefix string `json:"target_prefix"`
+
+	// OverwriteProfile confirma la restauración de un backup de perfil completo, que sobrescribe los datos
+	// de todos los juegos del prefijo. Sin ella, esos backups devuelven ErrWholeProfileRestore.
+	OverwriteProfile bool `json:"overwrite_profile"`
+
+	// SteamAccount restaura en la carpeta de otra cuenta de Steam (ID de userdata); vacío = la del juego.
+	// Requiere ConfirmSteamAccount: sin ella devuelve ErrOtherSteamAccount.
+	SteamAccount        string `json:"steam_account"`
+	ConfirmSteamAccount bool   `json:"confirm_steam_account"`
+
+	// TouchCloudCache marca los archivos restaurados como cambios locales en remotecache.vdf para que
+	// Steam Cloud suba la copia restaurada en vez de descargar la de la nube. Solo con Steam Cloud activo.
+	TouchCloudCache bool `json:"touch_cloud_cache"`
+}
+
+// RestoreRecord es una restauración registrada en el historial del juego
+type RestoreRecord struct {
+	Backup       string    `json:"backup"`
+	RestoredAt   time.Time `json:"restored_at"`
+	FilesWritten int       `json:"files_written"`
+	RegistryKeys int       `json:"registry_keys"` // Claves mezcladas en el user.reg del prefijo
+	Forced       bool      `json:"forced"`
+	Mode         string    `json:"mode,omitempty"`
+	FilesSkipped int       `json:"files_skipped,omitempty"` // merge-newer: archivos actuales más recientes que los del backup
+	FilesRemoved int       `json:"files_removed,omitempty"` // overwrite: partidas eliminadas por no estar en el backup
+	TargetPrefix string    `json:"target_prefix,omitempty"`
+	WholeProfile bool      `json:"whole_profile,omitempty"` // Se sobrescribió el perfil completo del prefijo
+	SteamAccount string    `json:"steam_account,omitempty"` // Cuenta de Steam de destino si no era la del juego
+
+	CloudWarning      string `json:"cloud_warning,omitempty"`       // Indicaciones si Steam Cloud sincroniza el juego
+	CloudCacheTouched int    `json:"cloud_cache_touched,omitempty"` // Entradas de remotecache.vdf marcadas como locales
+
+	// CaseCollisions son entradas que pisaron a otra que solo difería en mayúsculas
+	CaseCollisions []string `json:"case_collisions,omitempty"`
+}
+
+// RestoreHistory son las restauraciones de un juego y el backup al que corresponde el estado actual
+type RestoreHistory struct {
+	CurrentBackup string          `json:"current_backup"` // Vacío si los archivos cambiaron desde la última restauración
+	Restores      []RestoreRecord `json:"restores"`
+}
+
+// RestoreBackup escribe el contenido de un backup en las rutas de guardado del juego
+func (bm *BackupManager) RestoreBackup(gameID, fileName string, opts RestoreOptions) (*RestoreRecord, error) {
+	record, err := bm.restoreBackup(gameID, fileName, opts)
+
+	params := map[string]string{"backup": fileName}
+	summary := fmt.Sprintf("Backup restaurado: %s", fileName)
+	if err != nil {
+		summary = fmt.Sprintf("Error restaurando %s: %v", fileName, err)
+	} else {
+		params["files"] = fmt.Sprint(record.FilesWritten)
+	}
+	bm.recordGameActivity(ActivityRestore, gameID, params, summary, err)
+	return record, err
+}
+
+// restoreBackup hace la restauración de RestoreBackup
+func (bm *BackupManager) restoreBackup(gameID, fileName string, opts RestoreOptions) (*RestoreRecord, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	mode := opts.Mode
+	if mode == "" {
+		mode = bm.config().DefaultRestoreMode
+	}
+	if err := validateRestoreMode(mode); err != nil {
+		return nil, err
+	}
+	if mode == "" {
+		mode = RestoreMerge
+	}
+
+	backupPath, err := bm.resolveBackupFile(gameID, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	targetPrefix := ""
+	if opts.TargetPrefix != "" {
+		targetPrefix = filepath.Clean(ExpandPath(opts.TargetPrefix))
+	}
+	source := game
+	if len(game.SavePaths) == 0 {
+		// Juego reconstruido sin rutas: los archivos vuelven a donde estaban al hacer el backup
+		meta, err := readBackupMeta(backupPath)
+		if err != nil || len(meta.SavePaths) == 0 {
+			return nil, fmt.Errorf("%s no tiene rutas de guardado y el backup no indica de dónde salieron sus archivos", game.Name)
+		}
+		source = &GameInfo{SavePaths: meta.SavePaths, InstallDir: cmp.Or(game.InstallDir, meta.InstallDir), Metadata: game.Metadata}
+		log.Printf("Restaurando %s en las rutas guardadas en el backup: %v", game.Name, meta.SavePaths)
+	}
+	savePaths, err := restoreSavePaths(source, targetPrefix, runtime.GOOS == "windows")
+	if err != nil {
+		return nil, err
+	}
+
+	// Otra cuenta de Steam solo con confirmación: Steam Cloud sincroniza su carpeta con su propia nube
+	steamAccount := ""
+	if opts.SteamAccount != "" && opts.SteamAccount != game.Metadata["steam_account_id"] {
+		if targetPrefix != "" {
+			return nil, fmt.Errorf("no se puede restaurar a la vez en otro prefijo y en otra cuenta de Steam")
+		}
+		if !opts.ConfirmSteamAccount {
+			return nil, fmt.Errorf("%w: %s", ErrOtherSteamAccount, opts.SteamAccount)
+		}
+		if savePaths, err = steamAccountSavePaths(game, opts.SteamAccount); err != nil {
+			return nil, err
+		}
+		steamAccount = opts.SteamAccount
+		log.Printf("Aviso: se restaura %s en la cuenta de Steam %s; Steam Cloud puede sobrescribir los archivos", game.Name, steamAccount)
+	}
+
+	// Un backup de perfil completo vuelve al perfil del prefijo aunque el juego ya no use ese modo
+	wholeProfile, err := bm.isWholeProfileBackup(gameID, filepath.Base(backupPath))
+	if err != nil {
+		return nil, err
+	}
+	if wholeProfile {
+		prefix := targetPrefix
+		if prefix == "" {
+			prefix = gamePrefix(game)
+		}
+		if !opts.OverwriteProfile {
+			if others := bm.prefixGames(game, prefix); len(others) > 0 {
+				return nil, fmt.Errorf("%w: %s (también lo usan: %s)", ErrWholeProfileRestore, prefix, strings.Join(others, ", "))
+			}
+			return nil, fmt.Errorf("%w: %s", ErrWholeProfileRestore, prefix)
+		}
+		profile, err := profileDir(game, prefix)
+		if err != nil {
+			return nil, err
+		}
+		savePaths = []string{profile}
+		log.Printf("Aviso: se restaura el perfil completo %s; se sobrescriben los datos de todos los juegos del prefijo", profile)
+	}
+
+	// Restaurar sobre el punto de montaje vacío escribiría en el disco equivocado
+	if targetPrefix != "" {
+		if mountPoint, unmounted := unmountedMountPoint(targetPrefix); unmounted {
+			return nil, fmt.Errorf("%w: %s (%s)", ErrPathUnmounted, mountPoint, targetPrefix)
+		}
+	} else if err := bm.checkSaveMounts(game); err != nil {
+		return nil, err
+	}
+
+	// Sin force, no pisar progreso que no está en ningún backup. En otro prefijo u otra cuenta no hay
+	// cambios del juego que proteger: sus archivos nunca se respaldaron.
+	if !opts.Force && targetPrefix == "" && steamAccount == "" {
+		diff, err := bm.GetChangesSinceLastBackup(gameID)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo comprobar si hay cambios sin respaldar: %v", err)
+		}
+		if diff.hasChanges() {
+			return nil, fmt.Errorf("%w: %d nuevos, %d modificados, %d eliminados", ErrUnsavedChanges,
+				len(diff.Added), len(diff.Changed), len(diff.Removed))
+		}
+	}
+
+	if targetPrefix != "" {
+		log.Printf("Restaurando backup %s de %s en el prefijo %s", fileName, game.Name, targetPrefix)
+	} else {
+		log.Printf("Restaurando backup %s de %s", fileName, game.Name)
+	}
+
+	// Steam Cloud puede sustituir lo restaurado en las carpetas del juego al volver a sincronizar
+	cloudWarning := ""
+	if targetPrefix == "" && steamAccount == "" && cloudSyncStatus(game) == CloudSyncActive {
+		cloudWarning = steamCloudGuidance
+		log.Printf("Aviso: %s", cloudWarning)
+	}
+	var restored []string
+
+	// En los prefijos de Wine los archivos van a las carpetas que ya existen aunque difieran en mayúsculas,
+	// para no crear duplicados (Documents y documents) que el juego nunca lee
+	matchCase := targetPrefix != "" || gamePrefix(game) != ""
+	if matchCase {
+		for i := range savePaths {
+			savePaths[i] = matchGlobCase(savePaths[i])
+		}
+	}
+	// Sin force, no empezar una restauración que se quedaría a medias por falta de espacio
+	if !opts.Force {
+		spaces, err := restoreSpace(backupPath, savePaths, mode)
+		if err != nil {
+			return nil, err
+		}
+		if err := insufficientSpaceError(spaces); err != nil {
+			return nil, err
+		}
+	}
+
+	// Cada archivo se compara con su hash del manifiesto antes de sustituir al actual
+	hashes := make(map[string]string)
+	if manifest, err := loadManifest(backupPath); err == nil {
+		for _, file := range manifest.Files {
+			hashes[file.Path] = file.SHA256
+		}
+	}
+
+	placed := make(map[string]string)        // Destino en minúsculas -> entrada del backup
+	caseInsensitive := make(map[string]bool) // Por raíz de restauración
+	var collisions []string
+
+	written := 0
+	skipped := 0
+	registryKeys := 0
+	err = bm.backupStore().Open(gameID, filepath.Base(backupPath), func(entry backupFileEntry) error {
+		// Nunca se escribe como un archivo de partida, ni en backups hechos antes de desactivar la copia del registro
+		if entry.Name == registryEntryName {
+			if !game.BackupRegistry {
+				log.Printf("No se restauró el registro de %s: la copia del registro está desactivada", game.Name)
+				return nil
+			}
+			prefix := targetPrefix
+			if prefix == "" {
+				prefix = gamePrefix(game)
+			}
+			if prefix == "" {
+				log.Printf("No se restauró el registro de %s: no está asociado a un prefijo de Wine", game.Name)
+				return nil
+			}
+			merged, err := mergeRegistrySlice(prefix, entry.Open)
+			if err != nil {
+				log.Printf("No se restauró el registro de %s: %v", game.Name, err)
+				return nil
+			}
+			registryKeys = merged
+			return nil
+		}
+
+		root, err := restoreRoot(savePaths, entry.Name)
+		if err != nil {
+			return err
+		}
+
+		original := entry.Name
+		if matchCase {
+			entry.Name = matchEntryCase(root, entry.Name)
+		}
+		if _, checked := caseInsensitive[root]; !checked {
+			caseInsensitive[root] = isCaseInsensitiveDir(root)
+		}
+		if matchCase || caseInsensitive[root] {
+			key := strings.ToLower(filepath.Join(root, filepath.FromSlash(entry.Name)))
+			if other, exists := placed[key]; exists {
+				log.Printf("Aviso: %s y %s del backup solo difieren en mayúsculas; se conserva %s", other, original, original)
+				collisions = append(collisions, original)
+			}
+			placed[key] = original
+		}
+		if mode == RestoreMergeNewer {
+			if current, err := safeJoin(root, entry.Name); err == nil {
+				if info, err := os.Lstat(current); err == nil && !entry.Modified.After(info.ModTime()) {
+					skipped++
+					return nil
+				}
+			}
+		}
+		target, err := extractEntry(root, entry, bm.copyCheck(hashes[original]))
+		if err != nil {
+			if errors.Is(err, ErrUnsafeEntry) {
+				return err
+			}
+			return fmt.Errorf("error restaurando %s: %v", entry.Name, err)
+		}
+		restored = append(restored, target)
+		written++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Un perfil completo contiene datos de otros juegos y programas: nunca se eliminan archivos en él
+	removed := 0
+	if mode == RestoreOverwrite && !wholeProfile {
+		removed = bm.removeUnrestoredSaves(game, savePaths, restored)
+	}
+
+	cacheTouched := 0
+	if opts.TouchCloudCache && cloudWarning != "" {
+		if cacheTouched, err = touchRemoteCache(game, restored); err != nil {
+			log.Printf("No se actualizó remotecache.vdf de %s: %v", game.Name, err)
+		}
+	}
+
+	record := RestoreRecord{
+		Backup:         fileName,
+		RestoredAt:     time.Now(),
+		FilesWritten:   written,
+		RegistryKeys:   registryKeys,
+		CaseCollisions: collisions,
+		Forced:         opts.Force,
+		Mode:           mode,
+		FilesSkipped:   skipped,
+		FilesRemoved:   removed,
+		TargetPrefix:   targetPrefix,
+		WholeProfile:   wholeProfile,
+		SteamAccount:   steamAccount,
+
+		CloudWarning:      cloudWarning,
+		CloudCacheTouched: cacheTouched,
+	}
+	if err := bm.recordRestore(gameID, record); err != nil {
+		log.Printf("Error registrando restauración en el historial: %v", err)
+	}
+
+	// Restaurar en otro prefijo u otra cuenta no cambia los archivos de las rutas del juego
+	if targetPrefix == "" && steamAccount == "" {
+		if err := bm.updateGameInfo(game); err != nil {
+			log.Printf("Error actualizando info del juego %s: %v", gameID, err)
+		}
+	}
+
+	log.Printf("Backup restaurado: %s (%d archivos)", fileName, written)
+	return &record, bm.SaveDatabase()
+}
+
+// removeUnrestoredSaves elimina de las rutas de restauración las partidas del juego (las que respaldaría)
+// que no se escribieron desde el backup, y devuelve cuántas eliminó
+func (bm *BackupManager) removeUnrestoredSaves(game *GameInfo, savePaths, restored []string) int {
+	keep := make(map[string]bool, len(restored))
+	for _, path := range restored {
+		keep[filepath.Clean(path)] = true
+	}
+
+	target := *game
+	target.SavePaths = savePaths
+	target.SavePathFromConfig = nil
+
+	removed := 0
+	bm.walkSaveFiles(&target, func(path, name string, d fs.DirEntry) error {
+		if keep[filepath.Clean(path)] {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			log.Printf("Error eliminando %s: %v", path, err)
+			return nil
+		}
+		removed++
+		return nil
+	})
+	return removed
+}
+
+// GetRestoreHistory devuelve las restauraciones de un juego, de la más reciente a la más antigua
+func (bm *BackupManager) GetRestoreHistory(gameID string) (*RestoreHistory, error) {
+	if _, exists := bm.getGame(gameID); !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	index, err := bm.loadBackupIndex(gameID)
+	if err != nil {
+		return nil, err
+	}
+	return &RestoreHistory{CurrentBackup: index.CurrentState, Restores: index.Restores}, nil
+}
+
+// recordRestore agrega una restauración al historial y, si fue en las rutas del juego, marca el backup como estado actual
+func (bm *BackupManager) recordRestore(gameID string, record RestoreRecord) error {
+	index, err := bm.loadBackupIndex(gameID)
+	if err != nil {
+		return err
+	}
+
+	index.Restores = append([]RestoreRecord{record}, index.Restores...)
+	if len(index.Restores) > maxRestoreRecords {
+		index.Restores = index.Restores[:maxRestoreRecords]
+	}
+	// Si se conservaron archivos más recientes, los actuales ya no corresponden solo al backup
+	if record.TargetPrefix == "" && record.SteamAccount == "" && record.FilesSkipped == 0 {
+		index.CurrentState = record.Backup
+	}
+
+	return bm.saveBackupIndex(gameID, index)
+}
+
+// clearCurrentState quita la marca de estado actual cuando los archivos ya no corresponden al backup restaurado
+func (bm *BackupManager) clearCurrentState(gameID string) error {
+	index, err := bm.loadBackupIndex(gameID)
+	if err != nil {
+		return err
+	}
+	if index.CurrentState == "" {
+		return nil
+	}
+
+	index.CurrentState = ""
+	return bm.saveBackupIndex(gameID, index)
+}
+
+// backupFileEntry es un archivo dentro de un backup, comprimido o en carpeta
+type backupFileEntry struct {
+	Name     string
+	Modified time.Time
+	Mode     fs.FileMode
+	Size     int64 // Tamaño sin comprimir
+	Open     func() (io.ReadCloser, error)
+}
+
+// forEachBackupEntry recorre los archivos de un backup comprimido o en carpeta, sin los datos del juego (winesave-meta.json)
+func forEachBackupEntry(backupPath string, fn func(entry backupFileEntry) error) error {
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return filepath.WalkDir(backupPath, func(current string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(backupPath, current)
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil // Igual que copyDir: los enlaces simbólicos no se siguen
+			}
+			if rel == backupMetaEntryName {
+				return nil
+			}
+			fileInfo, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return fn(backupFileEntry{
+				Name:     filepath.ToSlash(rel),
+				Modified: fileInfo.ModTime(),
+				Mode:     fileInfo.Mode(),
+				Size:     fileInfo.Size(),
+				Open:     func() (io.ReadCloser, error) { return os.Open(current) },
+			})
+		})
+	}
+
+	reader, err := zip.OpenReader(backupPath)
+	if err != nil {
+		return fmt.Errorf("error abriendo backup %s: %v", info.Name(), err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || file.Name == backupMetaEntryName {
+			continue
+		}
+		entry := backupFileEntry{
+			Name:     file.Name,
+			Modified: file.Modified,
+			Mode:     file.Mode(),
+			Size:     int64(file.UncompressedSize64),
+			Open:     file.Open,
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreSavePaths devuelve las rutas de guardado expandidas donde restaurar: dentro de targetPrefix si se indica.
+// En Windows, las rutas que apuntan a un prefijo de Wine (backups hechos en Linux) se llevan a sus carpetas nativas.
+// Sin targetPrefix se usa la carpeta leída de la configuración del juego, si tiene SavePathFromConfig.
+func restoreSavePaths(game *GameInfo, targetPrefix string, nativeWindows bool) ([]string, error) {
+	savePaths := game.SavePaths
+	if targetPrefix == "" {
+		savePaths = effectiveSavePaths(game)
+	}
+
+	paths := make([]string, 0, len(savePaths))
+	for _, savePath := range savePaths {
+		switch {
+		case targetPrefix != "":
+			expanded, err := expandInPrefix(savePath, targetPrefix)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, expanded)
+		case nativeWindows:
+			if template, ok := prefixPathToTemplate(savePath); ok {
+				savePath = template
+			}
+			paths = append(paths, filepath.FromSlash(ExpandPath(savePath)))
+		default:
+			paths = append(paths, ExpandPath(savePath))
+		}
+	}
+	return paths, nil
+}
+
+// restoreRoot calcula el directorio del que cuelga una entrada al restaurarla, invirtiendo saveRoot.entryName
+func restoreRoot(savePaths []string, name string) (string, error) {
+	cleaned, err := cleanEntryName(name)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(cleaned, "/")
+
+	// Las rutas con comodines guardan la parte que coincidió como prefijo de la entrada,
+	// así que la entrada completa cuelga de la base del patrón
+	plain := ""
+	for _, expanded := range savePaths {
+		if !hasGlobMeta(expanded) {
+			// Una ruta de guardado de un único archivo se respalda con su nombre: vuelve a su carpeta
+			if len(parts) == 1 && savedAsFile(expanded, parts[0]) {
+				return filepath.Dir(expanded), nil
+			}
+			if plain == "" && !isRegularFile(expanded) {
+				plain = expanded
+			}
+			continue
+		}
+
+		base := globBase(expanded)
+		rel, err := filepath.Rel(base, expanded)
+		if err != nil {
+			continue
+		}
+		if n := matchGlobPrefix(strings.Split(filepath.ToSlash(rel), "/"), parts); n >= 0 && n < len(parts) {
+			return base, nil
+		}
+	}
+
+	if plain == "" {
+		return "", fmt.Errorf("ninguna ruta de guardado corresponde a %s", name)
+	}
+	return plain, nil
+}
+
+// savedAsFile indica si la entrada name de un backup es la ruta de guardado savePath de un único archivo:
+// savePath es un archivo, o ya no existe y su nombre coincide con el de la entrada
+func savedAsFile(savePath, name string) bool {
+	info, err := os.Stat(savePath)
+	if err != nil {
+		return os.IsNotExist(err) && strings.EqualFold(filepath.Base(savePath), name)
+	}
+	return info.Mode().IsRegular() && strings.EqualFold(filepath.Base(savePath), name)
+}
+
+// matchGlobPrefix devuelve cuántos componentes iniciales de parts coinciden con el patrón, o -1 si no coinciden
+func matchGlobPrefix(pattern, parts []string) int {
+	if len(pattern) == 0 {
+		return 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if n := matchGlobPrefix(pattern[1:], parts[i:]); n >= 0 {
+				return i + n
+			}
+		}
+		return -1
+	}
+
+	if len(parts) == 0 {
+		return -1
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return -1
+	}
+	n := matchGlobPrefix(pattern[1:], parts[1:])
+	if n < 0 {
+		return -1
+	}
+	return n + 1
+}
+
+// writeRestoredFile escribe un archivo a través de un temporal para no dejarlo a medias si algo falla.
+// El temporal solo sustituye a target si pasa la verificación de check.
+func writeRestoredFile(target string, modified time.Time, open func() (io.ReadCloser, error), check copyCheck) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	src, err := open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".winesave-restore-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if _, err := copyVerified(src, tmp.Name(), check); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return err
+	}
+
+	if !modified.IsZero() {
+		os.Chtimes(target, modified, modified)
+	}
+	return nil
+}
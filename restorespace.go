@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// restoreFreeMargin es el espacio que debe quedar libre en cada volumen después de restaurar
+const restoreFreeMargin = 100 << 20 // 100 MiB
+
+// ErrInsufficientSpace indica que algún volumen de destino no tiene espacio para restaurar el backup
+var ErrInsufficientSpace = errors.New("no hay espacio libre suficiente para restaurar el backup")
+
+// RestoreSpace es el espacio necesario y disponible en un volumen de destino de una restauración
+type RestoreSpace struct {
+	Volume     string   `json:"volume"`     // Punto de montaje; si no se identifica, la ruta de restauración
+	Paths      []string `json:"paths"`      // Rutas de restauración en este volumen
+	Required   int64    `json:"required"`   // Bytes nuevos a escribir, descontando los archivos que se sustituyen
+	Available  int64    `json:"available"`  // Espacio libre para el usuario; -1 si no se pudo consultar
+	Sufficient bool     `json:"sufficient"` // Queda al menos restoreFreeMargin libre tras restaurar
+}
+
+// CheckRestoreSpace calcula, por volumen, el espacio que ocuparía restaurar un backup con las opciones indicadas
+// (se usan Mode y TargetPrefix) y el espacio libre. Los tamaños salen de las cabeceras del ZIP o de los archivos de la carpeta.
+func (bm *BackupManager) CheckRestoreSpace(gameID, fileName string, opts RestoreOptions) ([]RestoreSpace, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+	backupPath, err := bm.resolveBackupFile(gameID, fileName)
+	if err != nil {
+		return nil, err
+	}
+	targetPrefix := ""
+	if opts.TargetPrefix != "" {
+		targetPrefix = ExpandPath(opts.TargetPrefix)
+	}
+	savePaths, err := restoreSavePaths(game, targetPrefix, runtime.GOOS == "windows")
+	if err != nil {
+		return nil, err
+	}
+	mode := opts.Mode
+	if mode == "" {
+		mode = bm.config().DefaultRestoreMode
+	}
+	return restoreSpace(backupPath, savePaths, mode)
+}
+
+// restoreSpace suma por volumen el tamaño de las entradas del backup que se escribirían en savePaths
+func restoreSpace(backupPath string, savePaths []string, mode string) ([]RestoreSpace, error) {
+	volumes, _ := listVolumes()
+	byVolume := make(map[string]*RestoreSpace)
+	err := forEachBackupEntry(backupPath, func(entry backupFileEntry) error {
+		if entry.Name == registryEntryName {
+			return nil // Se mezcla en user.reg: apenas ocupa
+		}
+		root, err := restoreRoot(savePaths, entry.Name)
+		if err != nil {
+			return nil // La restauración informará del error
+		}
+
+		required := entry.Size
+		if current, err := safeJoin(root, entry.Name); err == nil {
+			if info, err := os.Lstat(current); err == nil && info.Mode().IsRegular() {
+				if mode == RestoreMergeNewer && !entry.Modified.After(info.ModTime()) {
+					return nil // Se conserva el archivo actual
+				}
+				required -= info.Size()
+			}
+		}
+
+		key := root
+		if volume, ok := volumeForPath(volumes, root); ok {
+			key = volume.MountPoint
+		}
+		space, exists := byVolume[key]
+		if !exists {
+			space = &RestoreSpace{Volume: key, Paths: []string{}, Available: -1}
+			if free, _, err := diskUsage(existingParent(root)); err == nil {
+				space.Available = int64(free)
+			}
+			byVolume[key] = space
+		}
+		if !slices.Contains(space.Paths, root) {
+			space.Paths = append(space.Paths, root)
+		}
+		if required > 0 {
+			space.Required += required
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	spaces := make([]RestoreSpace, 0, len(byVolume))
+	for _, space := range byVolume {
+		space.Sufficient = space.Required == 0 || space.Available < 0 || space.Available-space.Required >= restoreFreeMargin
+		spaces = append(spaces, *space)
+	}
+	sort.Slice(spaces, func(i, j int) bool { return spaces[i].Volume < spaces[j].Volume })
+	return spaces, nil
+}
+
+// insufficientSpaceError devuelve ErrInsufficientSpace con lo necesario y lo disponible en cada volumen sin espacio
+func insufficientSpaceError(spaces []RestoreSpace) error {
+	var details []string
+	for _, space := range spaces {
+		if !space.Sufficient {
+			details = append(details, fmt.Sprintf("%s: se necesitan %d bytes (más %d de margen) y hay %d libres",
+				space.Volume, space.Required, restoreFreeMargin, space.Available))
+		}
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInsufficientSpace, strings.Join(details, "; "))
+}
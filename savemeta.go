@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SaveMetadata es la información de la partida extraída de un archivo de guardado
+type SaveMetadata struct {
+	Label  string            `json:"label"`  // Texto corto para el historial, p. ej. "Dovahkiin – Nivel 42 – Carrera Blanca"
+	Source string            `json:"source"` // Archivo del que se extrajo
+	Fields map[string]string `json:"fields"`
+}
+
+// SaveMetadataExtractor lee la cabecera de un formato de guardado conocido.
+// Los juegos sin un extractor que reconozca sus archivos simplemente no tienen metadatos.
+type SaveMetadataExtractor interface {
+	Name() string
+	Match(fileName string) bool
+	Extract(r io.Reader) (*SaveMetadata, error)
+}
+
+// maxSaveMetadataRead limita lo que un extractor puede leer de un archivo
+const maxSaveMetadataRead = 16 << 20
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []SaveMetadataExtractor
+)
+
+func init() {
+	RegisterSaveMetadataExtractor(skyrimSaveExtractor{})
+	RegisterSaveMetadataExtractor(minecraftLevelExtractor{})
+}
+
+// RegisterSaveMetadataExtractor agrega un extractor al registro. Uno con el mismo nombre reemplaza al anterior.
+func RegisterSaveMetadataExtractor(e SaveMetadataExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+
+	for i, existing := range extractors {
+		if existing.Name() == e.Name() {
+			extractors[i] = e
+			return
+		}
+	}
+	extractors = append(extractors, e)
+}
+
+// extractorFor devuelve el primer extractor que reconoce un archivo
+func extractorFor(fileName string) SaveMetadataExtractor {
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+
+	for _, e := range extractors {
+		if e.Match(fileName) {
+			return e
+		}
+	}
+	return nil
+}
+
+// extractSaveMetadata lee los metadatos del archivo reconocible más reciente de un juego.
+// Es solo informativo: cualquier fallo se registra y devuelve nil.
+func (bm *BackupManager) extractSaveMetadata(game *GameInfo) *SaveMetadata {
+	var newestPath string
+	var newestTime time.Time
+	var extractor SaveMetadataExtractor
+
+	bm.walkSaveFiles(game, func(path, name string, d fs.DirEntry) error {
+		e := extractorFor(d.Name())
+		if e == nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(newestTime) {
+			newestPath, newestTime, extractor = path, info.ModTime(), e
+		}
+		return nil
+	})
+	if extractor == nil {
+		return nil
+	}
+
+	meta, err := runExtractor(extractor, newestPath)
+	if err != nil {
+		log.Printf("No se pudieron leer metadatos de %s (%s): %v", newestPath, extractor.Name(), err)
+		return nil
+	}
+	meta.Source = filepath.Base(newestPath)
+	return meta
+}
+
+// runExtractor ejecuta un extractor sobre un archivo protegiéndose de pánicos en el parser
+func runExtractor(e SaveMetadataExtractor, path string) (meta *SaveMetadata, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			meta, err = nil, fmt.Errorf("pánico en el extractor: %v", r)
+		}
+	}()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	meta, err = e.Extract(bufio.NewReader(io.LimitReader(file, maxSaveMetadataRead)))
+	if err == nil && meta == nil {
+		err = errors.New("sin metadatos")
+	}
+	return meta, err
+}
+
+// ------------------- Skyrim (.ess) -------------------
+
+// skyrimSaveExtractor lee la cabecera de las partidas de Skyrim (LE y SE)
+type skyrimSaveExtractor struct{}
+
+func (skyrimSaveExtractor) Name() string { return "skyrim" }
+
+func (skyrimSaveExtractor) Match(fileName string) bool {
+	return strings.EqualFold(filepath.Ext(fileName), ".ess")
+}
+
+func (skyrimSaveExtractor) Extract(r io.Reader) (*SaveMetadata, error) {
+	magic := make([]byte, len("TESV_SAVEGAME"))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != "TESV_SAVEGAME" {
+		return nil, errors.New("no es una partida de Skyrim")
+	}
+
+	var header struct {
+		Size       uint32
+		Version    uint32
+		SaveNumber uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+
+	name, err := readWString(r)
+	if err != nil {
+		return nil, err
+	}
+	var level uint32
+	if err := binary.Read(r, binary.LittleEndian, &level); err != nil {
+		return nil, err
+	}
+	location, err := readWString(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SaveMetadata{
+		Label: fmt.Sprintf("%s – Nivel %d – %s", name, level, location),
+		Fields: map[string]string{
+			"character": name,
+			"level":     strconv.FormatUint(uint64(level), 10),
+			"location":  location,
+			"save":      strconv.FormatUint(uint64(header.SaveNumber), 10),
+		},
+	}, nil
+}
+
+// readWString lee una cadena con longitud uint16 little-endian
+func readWString(r io.Reader) (string, error) {
+	var n uint16
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// ------------------- Minecraft (level.dat) -------------------
+
+// minecraftLevelExtractor lee el nombre del mundo y el día desde level.dat (NBT comprimido con gzip)
+type minecraftLevelExtractor struct{}
+
+func (minecraftLevelExtractor) Name() string { return "minecraft" }
+
+func (minecraftLevelExtractor) Match(fileName string) bool {
+	return strings.EqualFold(fileName, "level.dat")
+}
+
+func (minecraftLevelExtractor) Extract(r io.Reader) (*SaveMetadata, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	root, err := readNBT(bufio.NewReader(io.LimitReader(gz, maxSaveMetadataRead)))
+	if err != nil {
+		return nil, err
+	}
+	data, ok := root["Data"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("level.dat sin compuesto Data")
+	}
+
+	fields := map[string]string{}
+	worldName, _ := data["LevelName"].(string)
+	if worldName != "" {
+		fields["world"] = worldName
+	}
+
+	// DayTime sigue creciendo entre días; cada día dura 24000 ticks
+	ticks, ok := data["DayTime"].(int64)
+	if !ok {
+		ticks, ok = data["Time"].(int64)
+	}
+	if ok {
+		fields["day"] = strconv.FormatInt(ticks/24000+1, 10)
+	}
+
+	label := worldName
+	if day, ok := fields["day"]; ok {
+		label = fmt.Sprintf("%s – Día %s", worldName, day)
+	}
+	return &SaveMetadata{Label: label, Fields: fields}, nil
+}
+
+// Tipos de etiqueta NBT
+const (
+	nbtEnd byte = iota
+	nbtByte
+	nbtShort
+	nbtInt
+	nbtLong
+	nbtFloat
+	nbtDouble
+	nbtByteArray
+	nbtString
+	nbtList
+	nbtCompound
+	nbtIntArray
+	nbtLongArray
+)
+
+// maxNBTDepth evita recursión sin límite con archivos corruptos
+const maxNBTDepth = 64
+
+// readNBT lee el compuesto raíz de un flujo NBT sin comprimir
+func readNBT(r io.Reader) (map[string]interface{}, error) {
+	var tagType byte
+	if err := binary.Read(r, binary.BigEndian, &tagType); err != nil {
+		return nil, err
+	}
+	if tagType != nbtCompound {
+		return nil, fmt.Errorf("la raíz NBT no es un compuesto (tipo %d)", tagType)
+	}
+	if _, err := readNBTString(r); err != nil {
+		return nil, err
+	}
+
+	value, err := readNBTPayload(r, nbtCompound, 0)
+	if err != nil {
+		return nil, err
+	}
+	return value.(map[string]interface{}), nil
+}
+
+// readNBTPayload lee el valor de una etiqueta del tipo indicado
+func readNBTPayload(r io.Reader, tagType byte, depth int) (interface{}, error) {
+	if depth > maxNBTDepth {
+		return nil, errors.New("NBT demasiado anidado")
+	}
+
+	switch tagType {
+	case nbtByte:
+		var v int8
+		err := binary.Read(r, binary.BigEndian, &v)
+		return v, err
+	case nbtShort:
+		var v int16
+		err := binary.Read(r, binary.BigEndian, &v)
+		return v, err
+	case nbtInt:
+		var v int32
+		err := binary.Read(r, binary.BigEndian, &v)
+		return v, err
+	case nbtLong:
+		var v int64
+		err := binary.Read(r, binary.BigEndian, &v)
+		return v, err
+	case nbtFloat:
+		var v uint32
+		err := binary.Read(r, binary.BigEndian, &v)
+		return math.Float32frombits(v), err
+	case nbtDouble:
+		var v uint64
+		err := binary.Read(r, binary.BigEndian, &v)
+		return math.Float64frombits(v), err
+	case nbtString:
+		return readNBTString(r)
+	case nbtByteArray, nbtIntArray, nbtLongArray:
+		// El contenido de los arrays no interesa: solo se salta
+		var n int32
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return nil, err
+		}
+		width := map[byte]int64{nbtByteArray: 1, nbtIntArray: 4, nbtLongArray: 8}[tagType]
+		if n < 0 || int64(n)*width > maxSaveMetadataRead {
+			return nil, errors.New("longitud de array NBT inválida")
+		}
+		_, err := io.CopyN(io.Discard, r, int64(n)*width)
+		return nil, err
+	case nbtList:
+		var elemType byte
+		var n int32
+		if err := binary.Read(r, binary.BigEndian, &elemType); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return nil, err
+		}
+		if n < 0 || n > maxSaveMetadataRead {
+			return nil, errors.New("longitud de lista NBT inválida")
+		}
+		if elemType == nbtEnd {
+			return []interface{}{}, nil
+		}
+		list := make([]interface{}, 0)
+		for i := int32(0); i < n; i++ {
+			v, err := readNBTPayload(r, elemType, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case nbtCompound:
+		compound := make(map[string]interface{})
+		for {
+			var childType byte
+			if err := binary.Read(r, binary.BigEndian, &childType); err != nil {
+				return nil, err
+			}
+			if childType == nbtEnd {
+				return compound, nil
+			}
+			name, err := readNBTString(r)
+			if err != nil {
+				return nil, err
+			}
+			v, err := readNBTPayload(r, childType, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			compound[name] = v
+		}
+	case nbtEnd:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("tipo de etiqueta NBT desconocido: %d", tagType)
+	}
+}
+
+// readNBTString lee una cadena NBT con longitud uint16 big-endian
+func readNBTString(r io.Reader) (string, error) {
+	var n uint16
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SavePathConfig describe dónde guarda un juego la carpeta de partidas en su propia configuración
+// (p. ej. un .ini del lanzador), para respaldar la carpeta real aunque se haya cambiado desde el juego
+type SavePathConfig struct {
+	File    string `json:"file"`    // Archivo de configuración (admite %APPDATA%, ~...)
+	Section string `json:"section"` // Sección [ini] donde está Key; vacío = cualquiera
+	Key     string `json:"key"`     // Clave con la ruta (key=valor o key: valor)
+	Regex   string `json:"regex"`   // Alternativa a Key: el primer grupo (o la coincidencia completa) es la ruta
+}
+
+// SetSavePathConfig configura (o quita, con nil) la lectura de la carpeta de partidas desde la configuración del juego.
+// La configuración se comprueba leyendo la ruta en ese momento.
+func (bm *BackupManager) SetSavePathConfig(gameID string, config *SavePathConfig) (*GameInfo, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	if config != nil {
+		if config.File == "" || (config.Key == "" && config.Regex == "") {
+			return nil, fmt.Errorf("la configuración necesita un archivo y una clave o expresión regular")
+		}
+		path, err := resolveConfigSavePath(game, config)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Carpeta de partidas de %s leída de %s: %s", game.Name, config.File, path)
+	}
+
+	game.SavePathFromConfig = config
+	if err := bm.updateGameInfo(game); err != nil {
+		log.Printf("Error actualizando info del juego %s: %v", gameID, err)
+	}
+	return game, bm.SaveDatabase()
+}
+
+// effectiveSavePaths devuelve las rutas de guardado a usar: el perfil del prefijo en modo perfil completo,
+// la leída de la configuración del juego si existe, o SavePaths (con %GAME_DIR% resuelto) si no hay otra o no se pudo leer
+func effectiveSavePaths(game *GameInfo) []string {
+	if game.BackupWholeProfile {
+		profile, err := profileDir(game, "")
+		if err == nil {
+			return []string{profile}
+		}
+		log.Printf("No se encontró el perfil del prefijo de %s, se usan sus rutas: %v", game.Name, err)
+	}
+	if game.SavePathFromConfig == nil {
+		return resolveGameDir(game, game.SavePaths)
+	}
+
+	path, err := resolveConfigSavePath(game, game.SavePathFromConfig)
+	if err != nil {
+		log.Printf("No se pudo leer la carpeta de partidas de %s desde su configuración, se usan sus rutas: %v", game.Name, err)
+		return resolveGameDir(game, game.SavePaths)
+	}
+	return []string{path}
+}
+
+// resolveConfigSavePath lee la carpeta de partidas del archivo de configuración del juego
+func resolveConfigSavePath(game *GameInfo, config *SavePathConfig) (string, error) {
+	configFile := matchPathCase(filepath.FromSlash(ExpandPath(config.File)))
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return "", fmt.Errorf("error leyendo %s: %v", configFile, err)
+	}
+
+	var value string
+	if config.Regex != "" {
+		value, err = configValueByRegex(data, config.Regex)
+	} else {
+		value, err = configValueByKey(data, config.Section, config.Key)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", configFile, err)
+	}
+
+	path := strings.Trim(strings.TrimSpace(value), `"'`)
+	if path == "" {
+		return "", fmt.Errorf("%s: la ruta está vacía", configFile)
+	}
+
+	// Dentro de un prefijo, las rutas de Windows del juego (C:\..., %APPDATA%...) se traducen al prefijo
+	if prefix := gamePrefix(game); prefix != "" && isWindowsPath(path) {
+		if path, err = expandInPrefix(path, prefix); err != nil {
+			return "", err
+		}
+	} else {
+		path = filepath.FromSlash(ExpandPath(path))
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(configFile), path)
+	}
+
+	path = matchPathCase(filepath.Clean(path))
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("la carpeta %s indicada en %s no existe", path, configFile)
+	}
+	return path, nil
+}
+
+// configValueByRegex devuelve el primer grupo de la expresión (o la coincidencia completa si no tiene grupos)
+func configValueByRegex(data []byte, expr string) (string, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return "", fmt.Errorf("expresión regular inválida: %v", err)
+	}
+	match := re.FindSubmatch(data)
+	if match == nil {
+		return "", fmt.Errorf("ninguna línea coincide con %s", expr)
+	}
+	if len(match) > 1 {
+		return string(match[1]), nil
+	}
+	return string(match[0]), nil
+}
+
+// configValueByKey busca key=valor (o key: valor) en un archivo tipo ini, opcionalmente dentro de [section]
+func configValueByKey(data []byte, section, key string) (string, error) {
+	current := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if section != "" && !strings.EqualFold(current, section) {
+			continue
+		}
+
+		separator := strings.IndexAny(line, "=:")
+		if separator < 0 {
+			continue
+		}
+		// Separa el primer = o :, así que los dos puntos de una unidad (C:\) en el valor no afectan
+		name := strings.Trim(strings.TrimSpace(line[:separator]), `"`)
+		if strings.EqualFold(name, key) {
+			return line[separator+1:], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if section != "" {
+		return "", fmt.Errorf("no se encontró %s en [%s]", key, section)
+	}
+	return "", fmt.Errorf("no se encontró %s", key)
+}
+
+// isWindowsPath indica si una ruta es de Windows: con letra de unidad o con variables como %APPDATA%
+func isWindowsPath(path string) bool {
+	if len(path) >= 2 && path[1] == ':' {
+		return true
+	}
+	return strings.HasPrefix(path, "%")
+}
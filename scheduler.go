@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// schedulerTick es cada cuánto el programador revisa si algún juego necesita backup
+const schedulerTick = time.Minute
+
+// autoBackupResumedEvent se emite cuando termina una pausa de los backups automáticos
+const autoBackupResumedEvent = "auto-backup:resumed"
+
+// autoBackupCapEvent se emite una vez al día por juego cuando se alcanza el límite de backups automáticos
+const autoBackupCapEvent = "auto-backup:cap-reached"
+
+// AutoBackupCounter cuenta los backups automáticos del día; se guarda en la base de datos para sobrevivir reinicios
+type AutoBackupCounter struct {
+	Day         string `json:"day"` // AAAA-MM-DD en hora local
+	Count       int    `json:"count"`
+	CapNotified bool   `json:"cap_notified"`
+}
+
+// ScheduleEntry es la próxima ejecución prevista de un juego
+type ScheduleEntry struct {
+	GameID         string    `json:"game_id"`
+	Name           string    `json:"name"`
+	Schedule       string    `json:"schedule"`
+	LastAutoBackup time.Time `json:"last_auto_backup"`
+	NextRun        time.Time `json:"next_run"`
+	Enabled        bool      `json:"enabled"`
+}
+
+// parseSchedule interpreta una programación simple: "1h", "every 6h", "hourly", "daily", "weekly", "monthly" u "off"
+func parseSchedule(schedule string) (time.Duration, error) {
+	value := strings.ToLower(strings.TrimSpace(schedule))
+	value = strings.TrimPrefix(value, "every ")
+
+	switch value {
+	case "", "off", "never":
+		return 0, nil
+	case "hourly":
+		return time.Hour, nil
+	case "daily":
+		return 24 * time.Hour, nil
+	case "weekly":
+		return 7 * 24 * time.Hour, nil
+	case "monthly":
+		return 30 * 24 * time.Hour, nil
+	}
+
+	// time.ParseDuration no admite días
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		var n int
+		if _, err := fmt.Sscanf(days, "%d", &n); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < schedulerTick {
+		return 0, fmt.Errorf("programación no válida: %q", schedule)
+	}
+	return interval, nil
+}
+
+// gameSchedule devuelve la programación efectiva de un juego y su intervalo (0 = sin backups automáticos)
+func (bm *BackupManager) gameSchedule(game *GameInfo) (string, time.Duration) {
+	schedule := bm.gameSnapshot(game).Schedule
+	if schedule == "" {
+		schedule = bm.config().DefaultSchedule
+	}
+
+	interval, err := parseSchedule(schedule)
+	if err != nil {
+		log.Printf("Programación ignorada para %s: %v", game.Name, err)
+		return schedule, 0
+	}
+	return schedule, interval
+}
+
+// nextAutoBackup calcula cuándo toca el próximo backup automático de un juego (cero = cuanto antes)
+func nextAutoBackup(game *GameInfo, interval time.Duration) time.Time {
+	last := game.LastAutoBackup
+	if last.IsZero() {
+		last = game.LastBackup
+	}
+	if last.IsZero() {
+		return time.Time{}
+	}
+	return last.Add(interval)
+}
+
+// GetSchedulePreview devuelve la próxima ejecución prevista para cada juego
+func (bm *BackupManager) GetSchedulePreview() []ScheduleEntry {
+	entries := []ScheduleEntry{}
+
+	for _, game := range bm.GetGameList() {
+		game = bm.gameSnapshot(game)
+		schedule, interval := bm.gameSchedule(game)
+		entry := ScheduleEntry{
+			GameID:         game.ID,
+			Name:           game.Name,
+			Schedule:       schedule,
+			LastAutoBackup: game.LastAutoBackup,
+			Enabled:        bm.config().AutoBackup && interval > 0,
+		}
+		if entry.Enabled {
+			entry.NextRun = nextAutoBackup(game, interval)
+			if entry.NextRun.IsZero() {
+				entry.NextRun = time.Now()
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Enabled != entries[j].Enabled {
+			return entries[i].Enabled
+		}
+		return entries[i].NextRun.Before(entries[j].NextRun)
+	})
+
+	return entries
+}
+
+// SetGameSchedule valida y guarda la programación de un juego
+func (bm *BackupManager) SetGameSchedule(gameID, schedule string) error {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return errGameNotFound(gameID)
+	}
+	if _, err := parseSchedule(schedule); err != nil {
+		return err
+	}
+
+	bm.updateGame(game, func(game *GameInfo) { game.Schedule = strings.TrimSpace(schedule) })
+	return bm.SaveDatabase()
+}
+
+// StartScheduler arranca el programador de backups automáticos
+func (bm *BackupManager) StartScheduler() {
+	bm.schedulerM.Lock()
+	defer bm.schedulerM.Unlock()
+	if bm.stopScheduler != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bm.stopScheduler = cancel
+
+	go func() {
+		ticker := time.NewTicker(schedulerTick)
+		defer ticker.Stop()
+
+		for {
+			if !bm.autoBackupPaused(time.Now()) {
+				bm.runIntegrityChecks()
+				bm.runScheduledScrub()
+				bm.runScheduledBackups()
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// StopScheduler detiene el programador de backups automáticos
+func (bm *BackupManager) StopScheduler() {
+	bm.schedulerM.Lock()
+	defer bm.schedulerM.Unlock()
+	if bm.stopScheduler != nil {
+		bm.stopScheduler()
+		bm.stopScheduler = nil
+	}
+}
+
+// PauseAutoBackup suspende los backups automáticos y las comprobaciones programadas durante duration.
+// La pausa se guarda en la configuración para que sobreviva a un reinicio.
+func (bm *BackupManager) PauseAutoBackup(duration time.Duration) (time.Time, error) {
+	if duration <= 0 {
+		return time.Time{}, fmt.Errorf("duración de la pausa no válida: %v", duration)
+	}
+
+	until := time.Now().Add(duration)
+	bm.setConfig(func(config *BackupConfig) { config.AutoBackupPausedUntil = until })
+
+	log.Printf("Backups automáticos en pausa hasta %s", until.Format(time.DateTime))
+	return until, nil
+}
+
+// ResumeAutoBackup termina la pausa de los backups automáticos antes de tiempo
+func (bm *BackupManager) ResumeAutoBackup() {
+	paused := false
+	bm.setConfig(func(config *BackupConfig) {
+		paused = !config.AutoBackupPausedUntil.IsZero()
+		config.AutoBackupPausedUntil = time.Time{}
+	})
+
+	if paused {
+		log.Println("Backups automáticos reanudados")
+		bm.emit(autoBackupResumedEvent, nil)
+	}
+}
+
+// autoBackupPaused indica si los backups automáticos están en pausa; al vencer la pausa la quita y avisa
+func (bm *BackupManager) autoBackupPaused(now time.Time) bool {
+	until := bm.config().AutoBackupPausedUntil
+	if until.IsZero() {
+		return false
+	}
+	if now.Before(until) {
+		return true
+	}
+	bm.ResumeAutoBackup()
+	return false
+}
+
+// runScheduledBackups encola los juegos cuya programación ha vencido y cuyos archivos cambiaron
+func (bm *BackupManager) runScheduledBackups() {
+	if !bm.config().AutoBackup {
+		return
+	}
+
+	now := time.Now()
+	changed := false
+
+	for _, game := range bm.GetGameList() {
+		_, interval := bm.gameSchedule(game)
+		if interval == 0 || now.Before(nextAutoBackup(bm.gameSnapshot(game), interval)) {
+			continue
+		}
+
+		// Se registra la ejecución aunque se omita, para no volver a comprobar el juego en cada tick
+		bm.updateGame(game, func(game *GameInfo) { game.LastAutoBackup = now })
+		changed = true
+
+		if !bm.autoBackupAllowed(game, now) {
+			continue
+		}
+
+		// La fecha de última partida se actualiza con la información del juego
+		if bm.config().AutoBackupPlayedWithinDays > 0 {
+			if err := bm.updateGameInfo(game); err != nil {
+				log.Printf("Error actualizando info del juego %s: %v", game.Name, err)
+			}
+			if !bm.playedRecently(bm.gameSnapshot(game), now) {
+				log.Printf("Backup automático omitido para %s: no se ha jugado en %d días", game.Name, bm.config().AutoBackupPlayedWithinDays)
+				continue
+			}
+		}
+
+		diff, err := bm.GetChangesSinceLastBackup(game.ID)
+		if errors.Is(err, ErrPathUnmounted) {
+			log.Printf("Backup automático omitido para %s: %v", game.Name, err)
+			continue
+		}
+		if err == nil && !diff.hasChanges() {
+			log.Printf("Backup automático omitido para %s: sin cambios", game.Name)
+			continue
+		}
+
+		if _, err := bm.EnqueueBackup(game.ID, BackupOptions{Trigger: "auto"}); err != nil {
+			log.Printf("Error encolando backup automático de %s: %v", game.Name, err)
+		}
+	}
+
+	// Persistir LastAutoBackup para que un reinicio no repita los backups
+	if changed {
+		if err := bm.SaveDatabase(); err != nil {
+			log.Printf("Error guardando base de datos: %v", err)
+		}
+	}
+}
+
+// maxAutoBackupsPerDay devuelve el límite diario efectivo de un juego (0 = sin límite)
+func (bm *BackupManager) maxAutoBackupsPerDay(game *GameInfo) int {
+	if game.MaxAutoBackupsPerDay > 0 {
+		return game.MaxAutoBackupsPerDay
+	}
+	return bm.config().MaxAutoBackupsPerDay
+}
+
+// autoBackupAllowed comprueba el límite diario de backups automáticos y avisa una sola vez al alcanzarlo
+func (bm *BackupManager) autoBackupAllowed(game *GameInfo, now time.Time) bool {
+	limit := bm.maxAutoBackupsPerDay(game)
+	if limit <= 0 {
+		return true
+	}
+
+	allowed, notify := false, false
+	bm.updateGame(game, func(game *GameInfo) {
+		counter := &game.AutoBackupCounter
+		if counter.Day != now.Format("2006-01-02") || counter.Count < limit {
+			allowed = true
+			return
+		}
+		notify = !counter.CapNotified
+		counter.CapNotified = true
+	})
+	if allowed {
+		return true
+	}
+
+	if notify {
+		log.Printf("Límite diario de backups automáticos alcanzado para %s (%d)", game.Name, limit)
+		bm.emit(autoBackupCapEvent, map[string]interface{}{
+			"game_id": game.ID,
+			"name":    game.Name,
+			"limit":   limit,
+		})
+	}
+	return false
+}
+
+// recordAutoBackup suma un backup automático al contador diario del juego (los manuales no cuentan)
+func (bm *BackupManager) recordAutoBackup(gameID string) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return
+	}
+
+	today := time.Now().Format("2006-01-02")
+	bm.updateGame(game, func(game *GameInfo) {
+		if game.AutoBackupCounter.Day != today {
+			game.AutoBackupCounter = AutoBackupCounter{Day: today}
+		}
+		game.AutoBackupCounter.Count++
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetGameSchedule(t *testing.T) {
+	bm, _ := newTestManager(t)
+	if err := bm.SetGameSchedule("g", " 6h "); err != nil {
+		t.Fatal(err)
+	}
+	if got := bm.gameSnapshot(bm.DetectedGames["g"]).Schedule; got != "6h" {
+		t.Errorf("programación %q, se esperaba 6h", got)
+	}
+	if err := bm.SetGameSchedule("g", "cada rato"); err == nil {
+		t.Error("se aceptó una programación no válida")
+	}
+	if err := bm.SetGameSchedule("no-existe", "daily"); err == nil {
+		t.Error("se aceptó un juego que no existe")
+	}
+}
+
+// SetGameSchedule cambia la programación mientras el programador y SaveDatabase la leen; con go test -race
+// este test falla si la escritura no pasa por bm.mu
+func TestSetGameScheduleConcurrentAccess(t *testing.T) {
+	bm, _ := newTestManager(t)
+	bm.Config.AutoBackup = true
+	bm.Config.DefaultSchedule = "off"
+	defer bm.ShutdownQueue(10 * time.Second)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			schedule := "off"
+			if i%2 == 0 {
+				schedule = "weekly"
+			}
+			if err := bm.SetGameSchedule("g", schedule); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		bm.GetSchedulePreview()
+		bm.gameSchedule(bm.DetectedGames["g"])
+		bm.storedGames()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// scrubSummaryEvent se emite al terminar cada revisión completa de los backups
+const scrubSummaryEvent = "scrub:summary"
+
+// ErrScrubRunning indica que ya hay una revisión completa de los backups en curso
+var ErrScrubRunning = errors.New("ya hay una revisión de los backups en curso")
+
+// ScrubReport resume una revisión completa de la carpeta de backups
+type ScrubReport struct {
+	StartedAt     time.Time     `json:"started_at"`
+	Duration      time.Duration `json:"duration"`
+	Checked       int           `json:"checked"`
+	Valid         int           `json:"valid"`         // Intactos sin tocarlos
+	Repaired      []ScrubEntry  `json:"repaired"`      // Dañados que quedaron intactos tras repararlos
+	Unrecoverable []ScrubEntry  `json:"unrecoverable"` // Siguen dañados: se movieron a la cuarentena
+	Errors        []string      `json:"errors"`        // Backups que no se pudieron revisar (p. ej. manifiesto ilegible)
+}
+
+// ScrubEntry es un backup dañado encontrado en una revisión y lo que se hizo con él
+type ScrubEntry struct {
+	GameID   string `json:"game_id"`
+	GameName string `json:"game_name"`
+	Backup   string `json:"backup"`
+
+	ReplacedFrom string          `json:"replaced_from,omitempty"` // Copia completa de un destino adicional que lo sustituyó
+	Files        []RepairedEntry `json:"files,omitempty"`         // Archivos recuperados de otras copias
+	Lost         []string        `json:"lost,omitempty"`          // Archivos sin ninguna copia intacta
+	Quarantined  bool            `json:"quarantined"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// ScrubAllBackups revisa todos los backups de todos los juegos contra sus manifiestos. Los dañados se reparan con
+// la copia del mismo backup en un destino adicional o, archivo por archivo, con copias intactas de otros backups;
+// los que no quedan intactos se ponen en cuarentena. El informe se guarda para GetLastScrubReport.
+func (bm *BackupManager) ScrubAllBackups() (*ScrubReport, error) {
+	bm.scrubM.Lock()
+	if bm.scrubRunning {
+		bm.scrubM.Unlock()
+		return nil, ErrScrubRunning
+	}
+	bm.scrubRunning = true
+	bm.scrubM.Unlock()
+	defer func() {
+		bm.scrubM.Lock()
+		bm.scrubRunning = false
+		bm.scrubM.Unlock()
+	}()
+
+	entries, err := bm.GetAllBackups()
+	if err != nil {
+		return nil, fmt.Errorf("error listando backups: %v", err)
+	}
+
+	report := &ScrubReport{
+		StartedAt:     time.Now(),
+		Repaired:      []ScrubEntry{},
+		Unrecoverable: []ScrubEntry{},
+		Errors:        []string{},
+	}
+	log.Printf("Revisión de backups: %d backups", len(entries))
+	for _, entry := range entries {
+		bm.scrubBackup(report, entry)
+	}
+	report.Duration = time.Since(report.StartedAt)
+
+	if err := bm.saveScrubReport(report); err != nil {
+		log.Printf("Error guardando el informe de la revisión: %v", err)
+	}
+	bm.reportScrub(report)
+	return report, nil
+}
+
+// scrubBackup revisa un backup y lo repara o lo pone en cuarentena, anotando el resultado en report
+func (bm *BackupManager) scrubBackup(report *ScrubReport, entry GlobalBackupEntry) {
+	store := bm.backupStore()
+	result, err := store.Verify(entry.GameID, entry.Backup, 0)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s/%s: %v", entry.GameName, entry.Backup, err))
+		return
+	}
+	report.Checked++
+	backupPath, err := bm.resolveBackupFile(entry.GameID, entry.Backup)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s/%s: %v", entry.GameName, entry.Backup, err))
+		return
+	}
+	if result.Valid {
+		report.Valid++
+		bm.markVerified(entry.GameID, entry.Backup, backupPath)
+		return
+	}
+
+	scrub := ScrubEntry{GameID: entry.GameID, GameName: entry.GameName, Backup: entry.Backup}
+	// Una copia completa e intacta sirve aunque el ZIP dañado ya no se pueda ni abrir
+	if source, err := bm.replaceFromDestination(entry.GameID, entry.Backup, backupPath); err != nil {
+		log.Printf("Error sustituyendo %s por su copia: %v", entry.Backup, err)
+	} else {
+		scrub.ReplacedFrom = source
+	}
+	if scrub.ReplacedFrom == "" {
+		scrub.Files, scrub.Lost, err = bm.repairEntries(entry.GameID, entry.Backup, backupPath, append(result.Corrupt, result.Missing...))
+		if err != nil {
+			scrub.Error = err.Error()
+		}
+	}
+
+	if result, err := store.Verify(entry.GameID, entry.Backup, 0); err == nil && result.Valid {
+		bm.markVerified(entry.GameID, entry.Backup, backupPath)
+		report.Repaired = append(report.Repaired, scrub)
+		return
+	}
+
+	reason := "dañado y sin copias intactas para repararlo"
+	if err := bm.quarantineBackup(entry.GameID, entry.Backup, reason); err != nil {
+		log.Printf("Error poniendo en cuarentena %s: %v", entry.Backup, err)
+	} else {
+		scrub.Quarantined = true
+	}
+	report.Unrecoverable = append(report.Unrecoverable, scrub)
+}
+
+// markVerified guarda en el historial la fecha de la última verificación correcta de un backup
+func (bm *BackupManager) markVerified(gameID, name, backupPath string) {
+	err := bm.upsertBackupRecord(gameID, name, backupPath, func(record *BackupRecord) {
+		record.VerifiedAt = time.Now()
+	})
+	if err != nil {
+		log.Printf("Error guardando historial de %s: %v", gameID, err)
+	}
+}
+
+// replaceFromDestination sustituye un backup por la primera copia de sus destinos adicionales que tenga todos los
+// archivos de su manifiesto intactos. Devuelve la copia usada; vacío si ninguna sirve.
+func (bm *BackupManager) replaceFromDestination(gameID, name, backupPath string) (string, error) {
+	manifest, err := loadManifest(backupPath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, source := range bm.destinationCopies(gameID, name) {
+		if !matchesManifest(source, manifest) {
+			continue
+		}
+
+		info, err := os.Stat(source)
+		if err != nil {
+			continue
+		}
+		partial := backupPath + ".partial"
+		os.RemoveAll(partial)
+		if info.IsDir() {
+			err = copyDir(source, partial)
+			if err == nil {
+				err = checkMigratedDir(source, partial)
+			}
+		} else {
+			_, err = copyFileVerified(source, partial, bm.copyCheck(""))
+		}
+		if err == nil {
+			if err = os.RemoveAll(backupPath); err == nil {
+				err = os.Rename(partial, backupPath)
+			}
+		}
+		if err != nil {
+			os.RemoveAll(partial)
+			return "", err
+		}
+
+		log.Printf("Backup %s sustituido por su copia intacta de %s", name, filepath.Dir(source))
+		return source, nil
+	}
+	return "", nil
+}
+
+// matchesManifest indica si todos los archivos del manifiesto están en el backup con su hash
+func matchesManifest(backupPath string, manifest *BackupManifest) bool {
+	for _, entry := range manifest.Files {
+		if sum, err := hashBackupFile(backupPath, entry.Path); err != nil || sum != entry.SHA256 {
+			return false
+		}
+	}
+	return true
+}
+
+// runScheduledScrub lanza en segundo plano la revisión completa cuando han pasado ScrubSchedule desde la última
+func (bm *BackupManager) runScheduledScrub() {
+	interval, err := parseSchedule(bm.config().ScrubSchedule)
+	if err != nil || interval == 0 {
+		return
+	}
+
+	bm.scrubM.Lock()
+	running := bm.scrubRunning
+	bm.scrubM.Unlock()
+	if running {
+		return
+	}
+	if last, err := bm.GetLastScrubReport(); err == nil && last != nil && time.Since(last.StartedAt) < interval {
+		return
+	}
+
+	go func() {
+		if _, err := bm.ScrubAllBackups(); err != nil && !errors.Is(err, ErrScrubRunning) {
+			log.Printf("Error en la revisión programada de backups: %v", err)
+		}
+	}()
+}
+
+// scrubReportPath devuelve el archivo del informe de la última revisión, junto a la base de datos
+func (bm *BackupManager) scrubReportPath() string {
+	return filepath.Join(filepath.Dir(bm.DatabasePath), "scrub.json")
+}
+
+// GetLastScrubReport devuelve el informe de la última revisión completa (nil si no se ha hecho ninguna)
+func (bm *BackupManager) GetLastScrubReport() (*ScrubReport, error) {
+	data, err := os.ReadFile(bm.scrubReportPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var report ScrubReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %v", bm.scrubReportPath(), err)
+	}
+	return &report, nil
+}
+
+// saveScrubReport guarda el informe de una revisión
+func (bm *BackupManager) saveScrubReport(report *ScrubReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(bm.scrubReportPath(), data, 0644)
+}
+
+// reportScrub registra el resumen de una revisión en el log y en el registro de actividad y lo emite
+func (bm *BackupManager) reportScrub(report *ScrubReport) {
+	log.Printf("Revisión de backups completada: %d revisados, %d correctos, %d reparados, %d irrecuperables, %d errores (%v)",
+		report.Checked, report.Valid, len(report.Repaired), len(report.Unrecoverable), len(report.Errors),
+		report.Duration.Round(time.Second))
+
+	var err error
+	if len(report.Unrecoverable) > 0 {
+		err = fmt.Errorf("%d backups dañados sin reparación movidos a la cuarentena", len(report.Unrecoverable))
+	}
+	bm.recordActivity(ActivityEntry{
+		Type: ActivityIntegrity,
+		Params: map[string]string{
+			"scrub":         "true", // Revisión completa, no la comprobación de integridad periódica
+			"checked":       fmt.Sprint(report.Checked),
+			"repaired":      fmt.Sprint(len(report.Repaired)),
+			"unrecoverable": fmt.Sprint(len(report.Unrecoverable)),
+		},
+		Summary: fmt.Sprintf("Revisión de backups: %d revisados, %d reparados, %d irrecuperables",
+			report.Checked, len(report.Repaired), len(report.Unrecoverable)),
+	}, err)
+
+	bm.emit(scrubSummaryEvent, report)
+}
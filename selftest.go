@@ -0,0 +1,201 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// selfTestFiles son los archivos de ejemplo de la autoprueba: subcarpetas, espacios, un archivo vacío y datos binarios
+var selfTestFiles = map[string]int{
+	"save1.sav":              64 << 10,
+	"profile/settings.ini":   512,
+	"slots/slot 2/data.bin":  256 << 10,
+	"slots/slot 2/empty.dat": 0,
+}
+
+// SelfTestStage es el resultado de una etapa de la autoprueba
+type SelfTestStage struct {
+	Name     string        `json:"name"`
+	Success  bool          `json:"success"`
+	Skipped  bool          `json:"skipped"` // No se ejecutó porque falló una etapa anterior
+	Detail   string        `json:"detail"`
+	Error    string        `json:"error"`
+	Duration time.Duration `json:"duration"`
+}
+
+// SelfTestReport resume una autoprueba completa
+type SelfTestReport struct {
+	Passed   bool            `json:"passed"`
+	Stages   []SelfTestStage `json:"stages"`
+	Duration time.Duration   `json:"duration"`
+}
+
+// SelfTest comprueba todo el proceso de backup con un juego de prueba en un directorio temporal:
+// escribe archivos, hace el backup, lo verifica, lo restaura en otra carpeta y compara el resultado.
+// Usa la configuración actual pero no toca los juegos, la base de datos ni los backups del usuario.
+func (bm *BackupManager) SelfTest() (*SelfTestReport, error) {
+	start := time.Now()
+
+	workDir, err := os.MkdirTemp(bm.tempDir(), "winesave-selftest-")
+	if err != nil {
+		return nil, fmt.Errorf("error creando directorio temporal: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	savesDir := filepath.Join(workDir, "saves")
+	restoreDir := filepath.Join(workDir, "restored")
+
+	// Gestor aislado: misma configuración, pero backups y base de datos dentro del directorio temporal
+	sandbox := &BackupManager{
+		Config:        bm.config(),
+		DetectedGames: make(map[string]*GameInfo),
+		DatabasePath:  filepath.Join(workDir, "game_saves.json"),
+	}
+	sandbox.Config.BackupDir = filepath.Join(workDir, "backups")
+
+	game := &GameInfo{
+		ID:        newGameID(),
+		Slug:      "winesave-selftest",
+		Name:      "WineSave Self-Test",
+		SavePaths: []string{savesDir},
+		Patterns:  []string{"*"},
+		Platform:  "Custom",
+		Metadata:  map[string]string{},
+	}
+	sandbox.setGame(game)
+
+	var backup *BackupInfo
+	stages := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{"backup_dir", func() (string, error) {
+			if err := os.MkdirAll(bm.config().BackupDir, 0755); err != nil {
+				return "", fmt.Errorf("error creando directorio de backup: %v", err)
+			}
+			if !isWritableDir(bm.config().BackupDir) {
+				return "", fmt.Errorf("no se puede escribir en %s", bm.config().BackupDir)
+			}
+			return bm.config().BackupDir, nil
+		}},
+		{"write_samples", func() (string, error) {
+			for name, size := range selfTestFiles {
+				path := filepath.Join(savesDir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					return "", err
+				}
+				data := make([]byte, size)
+				if _, err := rand.Read(data); err != nil {
+					return "", err
+				}
+				if err := os.WriteFile(path, data, 0644); err != nil {
+					return "", err
+				}
+			}
+			return fmt.Sprintf("%d archivos en %s", len(selfTestFiles), savesDir), nil
+		}},
+		{"backup", func() (string, error) {
+			info, err := sandbox.CreateBackupWithOptions(game.ID, BackupOptions{Trigger: "selftest"})
+			if err != nil {
+				return "", err
+			}
+			backup = info
+			return fmt.Sprintf("%s (%d bytes)", info.Name, info.Size), nil
+		}},
+		{"verify", func() (string, error) {
+			result, err := sandbox.VerifyBackup(game.ID, backup.Name)
+			if err != nil {
+				return "", err
+			}
+			if !result.Valid {
+				return "", fmt.Errorf("%d archivos dañados y %d ausentes", len(result.Corrupt), len(result.Missing))
+			}
+			if result.Checked != len(selfTestFiles) {
+				return "", fmt.Errorf("el backup tiene %d archivos y se esperaban %d", result.Checked, len(selfTestFiles))
+			}
+			return fmt.Sprintf("%d archivos verificados", result.Checked), nil
+		}},
+		{"restore", func() (string, error) {
+			game.SavePaths = []string{restoreDir}
+			record, err := sandbox.RestoreBackup(game.ID, backup.Name, RestoreOptions{Force: true})
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d archivos en %s", record.FilesWritten, restoreDir), nil
+		}},
+		{"compare", func() (string, error) {
+			return compareSelfTestDirs(savesDir, restoreDir)
+		}},
+	}
+
+	report := &SelfTestReport{Passed: true, Stages: make([]SelfTestStage, 0, len(stages))}
+	for _, stage := range stages {
+		result := SelfTestStage{Name: stage.name}
+		if !report.Passed {
+			result.Skipped = true
+			report.Stages = append(report.Stages, result)
+			continue
+		}
+
+		stageStart := time.Now()
+		detail, err := stage.run()
+		result.Duration = time.Since(stageStart)
+		result.Detail = detail
+		result.Success = err == nil
+		if err != nil {
+			result.Error = err.Error()
+			report.Passed = false
+			log.Printf("Autoprueba: falló la etapa %s: %v", stage.name, err)
+		}
+		report.Stages = append(report.Stages, result)
+	}
+
+	report.Duration = time.Since(start)
+	if report.Passed {
+		log.Printf("Autoprueba completada sin errores en %s", report.Duration.Round(time.Millisecond))
+	}
+	return report, nil
+}
+
+// compareSelfTestDirs comprueba que la carpeta restaurada tiene exactamente los mismos archivos que la original
+func compareSelfTestDirs(original, restored string) (string, error) {
+	compared := 0
+	err := filepath.WalkDir(original, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(original, path)
+		if err != nil {
+			return err
+		}
+		same, err := sameFileContent(path, filepath.Join(restored, rel))
+		if err != nil {
+			return fmt.Errorf("%s no se restauró: %v", filepath.ToSlash(rel), err)
+		}
+		if !same {
+			return fmt.Errorf("%s se restauró con otro contenido", filepath.ToSlash(rel))
+		}
+		compared++
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	restoredFiles := 0
+	filepath.WalkDir(restored, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			restoredFiles++
+		}
+		return nil
+	})
+	if restoredFiles != compared {
+		return "", fmt.Errorf("se restauraron %d archivos y se esperaban %d", restoredFiles, compared)
+	}
+	return fmt.Sprintf("%d archivos idénticos", compared), nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// LauncherInfo es un lanzador o entorno de juegos encontrado en el equipo
+type LauncherInfo struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// SetupSummary es la configuración propuesta en el primer arranque, para que la interfaz la confirme
+type SetupSummary struct {
+	FirstRun     bool                 `json:"first_run"` // No había config.json al arrancar
+	Platform     string               `json:"platform"`
+	BackupDir    string               `json:"backup_dir"`
+	FreeBytes    int64                `json:"free_bytes"` // Espacio libre en el volumen de BackupDir
+	Launchers    []LauncherInfo       `json:"launchers"`
+	WinePrefixes []string             `json:"wine_prefixes"`
+	Suggestions  []LocationSuggestion `json:"suggestions"` // Otras ubicaciones posibles para los backups
+	Config       BackupConfig         `json:"config"`
+}
+
+// InitializeDefaults elige valores por defecto adecuados al sistema operativo y detecta los lanzadores
+// instalados. Solo cambia la configuración en memoria en el primer arranque; la interfaz la guarda
+// con UpdateConfig cuando el usuario la confirma.
+func (bm *BackupManager) InitializeDefaults() (*SetupSummary, error) {
+	if bm.firstRun {
+		bm.setConfig(func(config *BackupConfig) { config.BackupDir = defaultBackupDir() })
+	}
+
+	summary := &SetupSummary{
+		FirstRun:     bm.firstRun,
+		Platform:     runtime.GOOS,
+		BackupDir:    bm.config().BackupDir,
+		Launchers:    detectLaunchers(),
+		WinePrefixes: findWinePrefixes(),
+		Config:       bm.config(),
+	}
+	if free, _, err := diskUsage(existingParent(bm.config().BackupDir)); err == nil {
+		summary.FreeBytes = int64(free)
+	}
+
+	suggestions, err := bm.SuggestBackupLocations()
+	if err != nil {
+		return nil, err
+	}
+	summary.Suggestions = suggestions
+	return summary, nil
+}
+
+// launcherPaths son las carpetas que delatan cada lanzador, por sistema operativo
+var launcherPaths = map[string]map[string][]string{
+	"linux": {
+		"Steam": {
+			"~/.steam/steam",
+			"~/.local/share/Steam",
+			"~/.var/app/com.valvesoftware.Steam/.local/share/Steam",
+		},
+		"Lutris":  {"~/.local/share/lutris", "~/.var/app/net.lutris.Lutris/data/lutris"},
+		"Heroic":  {"~/.config/heroic", "~/.var/app/com.heroicgameslauncher.hgl/config/heroic"},
+		"Bottles": {"~/.local/share/bottles", "~/.var/app/com.usebottles.bottles/data/bottles"},
+	},
+	"darwin": {
+		"Steam":         {"~/Library/Application Support/Steam"},
+		"Epic Games":    {"~/Library/Application Support/Epic/EpicGamesLauncher"},
+		"CrossOver":     {"~/Library/Application Support/CrossOver"},
+		"GOG Galaxy":    {"/Users/Shared/GOG.com/Galaxy"},
+		"Heroic":        {"~/Library/Application Support/heroic"},
+		"Battle.net":    {"/Users/Shared/Battle.net"},
+		"Prism/MultiMC": {"~/Library/Application Support/PrismLauncher"},
+	},
+	"windows": {
+		"Steam":           {"%PROGRAMFILES(X86)%/Steam", "%PROGRAMFILES%/Steam"},
+		"Epic Games":      {"%PROGRAMDATA%/Epic/EpicGamesLauncher"},
+		"GOG Galaxy":      {"%PROGRAMDATA%/GOG.com/Galaxy"},
+		"EA app":          {"%PROGRAMDATA%/EA Desktop"},
+		"Ubisoft Connect": {"%PROGRAMFILES(X86)%/Ubisoft/Ubisoft Game Launcher"},
+		"Battle.net":      {"%PROGRAMDATA%/Battle.net"},
+	},
+}
+
+// detectLaunchers devuelve los lanzadores cuya carpeta existe, más Epic dentro de los prefijos de Wine
+func detectLaunchers() []LauncherInfo {
+	launchers := []LauncherInfo{}
+
+	paths := launcherPaths[runtime.GOOS]
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, path := range paths[name] {
+			expanded := filepath.FromSlash(ExpandPath(path))
+			if _, err := os.Stat(expanded); err == nil {
+				launchers = append(launchers, LauncherInfo{Name: name, Path: expanded})
+				break
+			}
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		for _, dir := range epicManifestDirs() {
+			if _, err := os.Stat(dir); err == nil {
+				launchers = append(launchers, LauncherInfo{Name: "Epic Games (Wine)", Path: dir})
+			}
+		}
+	}
+	return launchers
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SlugRenameFile es un archivo o carpeta que cambia de nombre al cambiar el slug de un juego
+type SlugRenameFile struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SlugRenamePlan describe lo que cambia al renombrar la carpeta de backups de un juego
+type SlugRenamePlan struct {
+	GameID  string           `json:"game_id"`
+	OldSlug string           `json:"old_slug"`
+	NewSlug string           `json:"new_slug"`
+	OldDir  string           `json:"old_dir"`
+	NewDir  string           `json:"new_dir"`
+	Files   []SlugRenameFile `json:"files"`   // Backups y backups en cuarentena, con sus manifiestos
+	Records int              `json:"records"` // Entradas del historial que cambian de nombre
+	Applied bool             `json:"applied"` // false en la simulación
+}
+
+// RenameGameSlug cambia el slug de un juego, que da nombre a su carpeta de backups y a cada backup.
+// Con dryRun solo devuelve lo que se renombraría. Las copias ya hechas en los destinos adicionales
+// conservan la carpeta anterior.
+func (bm *BackupManager) RenameGameSlug(gameID, newSlug string, dryRun bool) (*SlugRenamePlan, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	newSlug = strings.TrimSpace(newSlug)
+	if newSlug == "" || sanitizePathComponent(newSlug) != newSlug {
+		return nil, fmt.Errorf("el slug %q no es un nombre de carpeta válido (sugerencia: %s)", newSlug, sanitizePathComponent(newSlug))
+	}
+	if newSlug == game.Slug {
+		return nil, fmt.Errorf("el juego ya usa el slug %s", newSlug)
+	}
+	if other, exists := bm.findGameBySlug(newSlug); exists {
+		return nil, fmt.Errorf("el slug %s ya pertenece a %s", newSlug, other.Name)
+	}
+
+	plan, err := bm.planSlugRename(game, newSlug)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return plan, nil
+	}
+
+	status := bm.GetQueueStatus()
+	for _, job := range append(status.Pending, status.Running...) {
+		if job.GameID == gameID {
+			return nil, fmt.Errorf("%s tiene un backup en cola o en curso; inténtalo cuando termine", game.Name)
+		}
+	}
+
+	if err := bm.migrateBackupDir(game, newSlug); err != nil {
+		return nil, err
+	}
+	log.Printf("Slug de %s cambiado: %s -> %s", game.Name, plan.OldSlug, newSlug)
+	plan.Applied = true
+	return plan, bm.SaveDatabase()
+}
+
+// planSlugRename calcula los backups, backups en cuarentena y entradas del historial que cambian con el slug
+func (bm *BackupManager) planSlugRename(game *GameInfo, newSlug string) (*SlugRenamePlan, error) {
+	plan := &SlugRenamePlan{
+		GameID:  game.ID,
+		OldSlug: game.Slug,
+		NewSlug: newSlug,
+		OldDir:  filepath.Join(bm.config().BackupDir, game.Slug),
+		NewDir:  filepath.Join(bm.config().BackupDir, newSlug),
+		Files:   []SlugRenameFile{},
+	}
+	if _, err := os.Stat(plan.OldDir); os.IsNotExist(err) {
+		return plan, nil
+	}
+	if !sameOrMissing(plan.OldDir, plan.NewDir) {
+		return nil, fmt.Errorf("ya existe la carpeta %s", plan.NewDir)
+	}
+
+	backups, err := bm.listBackups(game.ID)
+	if err != nil {
+		return nil, err
+	}
+	quarantined, err := bm.listQuarantinedBackups(game.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, backup := range append(backups, quarantined...) {
+		rel, err := filepath.Rel(plan.OldDir, filepath.Dir(backup.Path))
+		if err != nil {
+			return nil, err
+		}
+		to := filepath.Join(plan.NewDir, rel, renamedBackup(backup.Name, game.Slug, newSlug))
+		plan.Files = append(plan.Files, SlugRenameFile{From: backup.Path, To: to})
+		for _, sidecar := range sidecarPaths(backup.Path) {
+			if _, err := os.Stat(sidecar); err == nil {
+				plan.Files = append(plan.Files, SlugRenameFile{From: sidecar, To: to + strings.TrimPrefix(sidecar, backup.Path)})
+			}
+		}
+	}
+
+	index, err := bm.loadBackupIndex(game.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range index.Records {
+		if strings.HasPrefix(record.Name, game.Slug+"_") {
+			plan.Records++
+		}
+	}
+	return plan, nil
+}
+
+// migrateBackupDir mueve la carpeta de backups de un juego a newSlug con todo su contenido (cuarentena,
+// copia actual, historial), renombra cada backup y su manifiesto y actualiza el historial y el slug del juego.
+// Una vez movida la carpeta el slug queda cambiado aunque falle algún renombrado, que se registra.
+func (bm *BackupManager) migrateBackupDir(game *GameInfo, newSlug string) error {
+	oldSlug := game.Slug
+	oldDir := filepath.Join(bm.config().BackupDir, oldSlug)
+	newDir := filepath.Join(bm.config().BackupDir, newSlug)
+
+	// Un slug con ".." o separadores pudo haber escrito fuera del directorio de backups: no tocarlo
+	if filepath.Dir(oldDir) != filepath.Clean(bm.config().BackupDir) {
+		log.Printf("La carpeta de backups de %q está fuera del directorio de backups; no se migra", oldSlug)
+		game.Slug = newSlug
+		return nil
+	}
+
+	if _, err := os.Stat(oldDir); os.IsNotExist(err) {
+		game.Slug = newSlug
+		return nil
+	}
+	plan, err := bm.planSlugRename(game, newSlug)
+	if err != nil {
+		return err
+	}
+	index, err := bm.loadBackupIndex(game.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return fmt.Errorf("error moviendo %s a %s: %v", oldDir, newDir, err)
+	}
+	// A partir de aquí las rutas del juego apuntan a la carpeta nueva
+	game.Slug = newSlug
+
+	renamed := make(map[string]string, len(plan.Files))
+	for _, file := range plan.Files {
+		rel, _ := filepath.Rel(oldDir, file.From)
+		from := filepath.Join(newDir, rel)
+		if strings.HasSuffix(file.From, manifestSuffix) {
+			if manifest, err := loadManifest(strings.TrimSuffix(from, manifestSuffix)); err == nil {
+				manifest.Backup = strings.TrimSuffix(filepath.Base(file.To), manifestSuffix)
+				if err := writeManifest(strings.TrimSuffix(file.To, manifestSuffix), manifest); err == nil {
+					os.Remove(from)
+					continue
+				}
+			}
+		}
+		if strings.HasSuffix(file.From, contentIndexSuffix) {
+			// El manifiesto ya está renombrado: el índice se regenera con el nombre nuevo en la cabecera
+			backupPath := strings.TrimSuffix(file.To, contentIndexSuffix)
+			if manifest, err := loadManifest(backupPath); err == nil && writeContentIndex(backupPath, manifest) == nil {
+				os.Remove(from)
+				continue
+			}
+		}
+		if err := os.Rename(from, file.To); err != nil {
+			log.Printf("Error renombrando %s a %s: %v", from, file.To, err)
+			continue
+		}
+		renamed[filepath.Base(file.From)] = filepath.Base(file.To)
+	}
+
+	for i := range index.Records {
+		if name, ok := renamed[index.Records[i].Name]; ok {
+			index.Records[i].Name = name
+		}
+	}
+	for i := range index.Restores {
+		if name, ok := renamed[index.Restores[i].Backup]; ok {
+			index.Restores[i].Backup = name
+		}
+	}
+	if name, ok := renamed[index.CurrentState]; ok {
+		index.CurrentState = name
+	}
+	return bm.saveBackupIndex(game.ID, index)
+}
+
+// renamedBackup cambia el slug del principio del nombre de un backup (<slug>_<fecha>[.zip])
+func renamedBackup(name, oldSlug, newSlug string) string {
+	return newSlug + strings.TrimPrefix(name, oldSlug)
+}
+
+// sameOrMissing indica si newDir no existe o es la misma carpeta que oldDir (cambio de mayúsculas
+// en sistemas de archivos que no las distinguen)
+func sameOrMissing(oldDir, newDir string) bool {
+	newInfo, err := os.Stat(newDir)
+	if os.IsNotExist(err) {
+		return true
+	}
+	oldInfo, err := os.Stat(oldDir)
+	return err == nil && newInfo != nil && os.SameFile(oldInfo, newInfo)
+}
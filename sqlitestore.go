@@ -0,0 +1,189 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchemaVersion es la versión de las tablas de game_saves.db (PRAGMA user_version)
+const sqliteSchemaVersion = 1
+
+// sqliteSchema crea las tablas de game_saves.db. Cada juego se guarda entero en data (el mismo JSON que en
+// game_saves.json); slug, nombre y plataforma se repiten en columnas para poder consultarlos con índices.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS games (
+	id       TEXT PRIMARY KEY,
+	slug     TEXT NOT NULL,
+	name     TEXT NOT NULL,
+	platform TEXT NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS games_slug ON games (slug);
+CREATE INDEX IF NOT EXISTS games_platform ON games (platform);
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+`
+
+// sqliteDatabasePath devuelve la ruta de la base de datos SQLite junto a game_saves.json: game_saves.db
+func sqliteDatabasePath(jsonPath string) string {
+	return strings.TrimSuffix(jsonPath, filepath.Ext(jsonPath)) + ".db"
+}
+
+// sqliteStore guarda la biblioteca en una base de datos SQLite. Guardar solo escribe los juegos que cambiaron.
+type sqliteStore struct {
+	db     *sql.DB
+	path   string
+	legacy Store // game_saves.json, del que se importan los juegos mientras no se haya guardado nada
+
+	initialized bool // Ya se guardó alguna vez: una tabla vacía es una biblioteca vacía, no una por importar
+}
+
+// openSQLiteStore abre (o crea) la base de datos SQLite de juegos
+func openSQLiteStore(path string, legacy Store) (*sqliteStore, error) {
+	_, statErr := os.Stat(path)
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo %s: %v", path, err)
+	}
+	// Una sola conexión: las escrituras ya están serializadas y así no hay bloqueos entre conexiones
+	db.SetMaxOpenConns(1)
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error leyendo %s: %v", path, err)
+	}
+	if version > sqliteSchemaVersion {
+		db.Close()
+		return nil, fmt.Errorf("%s es de una versión más reciente de WineSave (esquema %d)", path, version)
+	}
+	if _, err := db.Exec(sqliteSchema + fmt.Sprintf("PRAGMA user_version = %d;", sqliteSchemaVersion)); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creando las tablas de %s: %v", path, err)
+	}
+	if os.IsNotExist(statErr) {
+		log.Printf("Base de datos SQLite creada en %s", path)
+	}
+
+	var initialized int
+	if err := db.QueryRow("SELECT COUNT(*) FROM meta WHERE key = 'initialized'").Scan(&initialized); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error leyendo %s: %v", path, err)
+	}
+	return &sqliteStore{db: db, path: path, legacy: legacy, initialized: initialized > 0}, nil
+}
+
+func (s *sqliteStore) Load() ([]*GameInfo, bool, error) {
+	rows, err := s.db.Query("SELECT id, data FROM games ORDER BY id")
+	if err != nil {
+		return nil, false, fmt.Errorf("error leyendo los juegos de %s: %v", s.path, err)
+	}
+	defer rows.Close()
+
+	var games []*GameInfo
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, false, err
+		}
+		game := &GameInfo{}
+		if err := json.Unmarshal([]byte(data), game); err != nil {
+			// Sin él, el siguiente guardado lo borraría de la tabla
+			return nil, false, fmt.Errorf("juego %s ilegible en %s: %v", id, s.path, err)
+		}
+		game.ID = id
+		games = append(games, game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	// Primera vez con SQLite: se importan los juegos de game_saves.json, que se deja como estaba
+	if !s.initialized && s.legacy != nil {
+		imported, _, err := s.legacy.Load()
+		if err != nil {
+			return nil, false, err
+		}
+		if len(imported) > 0 {
+			log.Printf("Importando %d juegos a %s", len(imported), s.path)
+			return imported, true, nil
+		}
+	}
+	return games, false, nil
+}
+
+// Save escribe en una transacción los juegos nuevos o modificados y borra los que ya no están
+func (s *sqliteStore) Save(games []storedGame) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, data FROM games")
+	if err != nil {
+		return false, err
+	}
+	existing := make(map[string]string)
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			rows.Close()
+			return false, err
+		}
+		existing[id] = data
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, game := range games {
+		data, stored := existing[game.ID]
+		delete(existing, game.ID)
+		if stored && sameJSON([]byte(data), game.Data) {
+			continue
+		}
+		_, err := tx.Exec(`INSERT INTO games (id, slug, name, platform, data) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET slug = excluded.slug, name = excluded.name, platform = excluded.platform, data = excluded.data`,
+			game.ID, game.Slug, game.Name, game.Platform, string(game.Data))
+		if err != nil {
+			return false, fmt.Errorf("error guardando %s: %v", game.Name, err)
+		}
+		changed = true
+	}
+	for id := range existing {
+		if _, err := tx.Exec("DELETE FROM games WHERE id = ?", id); err != nil {
+			return false, fmt.Errorf("error borrando el juego %s: %v", id, err)
+		}
+		changed = true
+	}
+
+	if !changed && s.initialized {
+		return false, nil
+	}
+	if !s.initialized {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO meta (key, value) VALUES ('initialized', '1')"); err != nil {
+			return false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	s.initialized = true
+	return changed, nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
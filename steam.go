@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrOtherSteamAccount indica que se intenta restaurar en la carpeta de otra cuenta de Steam.
+// Steam Cloud sincroniza esa carpeta con la nube de esa cuenta y puede deshacer o propagar la restauración.
+var ErrOtherSteamAccount = errors.New("la carpeta de destino es de otra cuenta de Steam y Steam Cloud puede sobrescribirla")
+
+// steamID64Base convierte el ID de cuenta de userdata (32 bits) en el SteamID64 de loginusers.vdf
+const steamID64Base = 76561197960265728
+
+// steamClientAppIDs son carpetas de userdata del propio cliente de Steam, no de juegos
+var steamClientAppIDs = map[string]bool{
+	"7":      true, // Configuración del cliente
+	"760":    true, // Capturas de pantalla
+	"241100": true, // Configuraciones de mando
+}
+
+// SteamAccount es una cuenta de Steam con carpeta en userdata
+type SteamAccount struct {
+	ID          string `json:"id"` // Nombre de la carpeta en userdata
+	SteamID64   string `json:"steam_id64"`
+	PersonaName string `json:"persona_name"`
+	AccountName string `json:"account_name"`
+	Userdata    string `json:"userdata"` // Carpeta userdata de la instalación de Steam
+}
+
+// label devuelve el nombre con el que se muestra la cuenta
+func (a SteamAccount) label() string {
+	if a.PersonaName != "" {
+		return a.PersonaName
+	}
+	if a.AccountName != "" {
+		return a.AccountName
+	}
+	return a.ID
+}
+
+// GetSteamAccounts devuelve las cuentas de Steam con carpeta en userdata de todas las instalaciones encontradas
+func (bm *BackupManager) GetSteamAccounts() []SteamAccount {
+	accounts := []SteamAccount{}
+	for _, root := range steamRoots() {
+		accounts = append(accounts, steamAccounts(root)...)
+	}
+	return accounts
+}
+
+// steamRoots devuelve las instalaciones de Steam del sistema y de los prefijos de Wine
+func steamRoots() []string {
+	candidates := []string{}
+	for _, path := range launcherPaths[runtime.GOOS]["Steam"] {
+		candidates = append(candidates, filepath.FromSlash(ExpandPath(path)))
+	}
+	if runtime.GOOS != "windows" {
+		for _, prefix := range findWinePrefixes() {
+			candidates = append(candidates,
+				filepath.Join(prefix, "drive_c", "Program Files (x86)", "Steam"),
+				filepath.Join(prefix, "drive_c", "Program Files", "Steam"))
+		}
+	}
+
+	roots := []string{}
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		// ~/.steam/steam suele ser un enlace a ~/.local/share/Steam
+		resolved, err := filepath.EvalSymlinks(candidate)
+		if err != nil || seen[resolved] {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(resolved, "userdata")); err != nil || !info.IsDir() {
+			continue
+		}
+		seen[resolved] = true
+		roots = append(roots, resolved)
+	}
+	return roots
+}
+
+// steamAccounts lee las cuentas de una instalación de Steam: las carpetas numéricas de userdata,
+// con el nombre público sacado de config/loginusers.vdf cuando está
+func steamAccounts(root string) []SteamAccount {
+	userdata := filepath.Join(root, "userdata")
+	entries, err := os.ReadDir(userdata)
+	if err != nil {
+		return nil
+	}
+
+	var logins *vdfNode
+	if data, err := os.ReadFile(filepath.Join(root, "config", "loginusers.vdf")); err == nil {
+		if parsed, err := parseVDF(data); err == nil {
+			logins = parsed.child("users")
+		}
+	}
+
+	accounts := []SteamAccount{}
+	for _, entry := range entries {
+		id, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if !entry.IsDir() || err != nil || id == 0 {
+			continue
+		}
+		account := SteamAccount{
+			ID:        entry.Name(),
+			SteamID64: strconv.FormatUint(id+steamID64Base, 10),
+			Userdata:  userdata,
+		}
+		if logins != nil {
+			login := logins.child(account.SteamID64)
+			account.PersonaName = login.value("PersonaName")
+			account.AccountName = login.value("AccountName")
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
+// steamApp es un juego instalado según su appmanifest
+type steamApp struct {
+	Name       string
+	InstallDir string // Carpeta de instalación completa (steamapps/common/<installdir>)
+}
+
+// steamLibraries devuelve las bibliotecas de una instalación de Steam: la propia instalación y las de libraryfolders.vdf
+func steamLibraries(root string) []string {
+	libraries := []string{root}
+	if data, err := os.ReadFile(filepath.Join(root, "steamapps", "libraryfolders.vdf")); err == nil {
+		if parsed, err := parseVDF(data); err == nil {
+			folders := parsed.child("libraryfolders")
+			for _, key := range folders.Order {
+				if path := folders.Children[key].value("path"); path != "" {
+					libraries = append(libraries, path)
+				}
+			}
+		}
+	}
+	return libraries
+}
+
+// steamApps devuelve los juegos instalados (por appid) según los appmanifest de las bibliotecas de una instalación de Steam
+func steamApps(root string) map[string]steamApp {
+	apps := make(map[string]steamApp)
+	for _, library := range steamLibraries(root) {
+		manifests, _ := filepath.Glob(filepath.Join(library, "steamapps", "appmanifest_*.acf"))
+		for _, manifest := range manifests {
+			data, err := os.ReadFile(manifest)
+			if err != nil {
+				continue
+			}
+			parsed, err := parseVDF(data)
+			if err != nil {
+				continue
+			}
+			state := parsed.child("AppState")
+			appID := state.value("appid")
+			if appID == "" {
+				continue
+			}
+			app := steamApp{Name: state.value("name")}
+			if dir := state.value("installdir"); dir != "" {
+				app.InstallDir = filepath.Join(library, "steamapps", "common", dir)
+			}
+			apps[appID] = app
+		}
+	}
+	return apps
+}
+
+// steamUserdataDetector detecta las partidas de Steam Cloud (userdata/<cuenta>/<appid>/remote),
+// con un juego por cuenta para que cada una tenga sus propios backups
+type steamUserdataDetector struct{}
+
+func (steamUserdataDetector) Name() string { return "steam-userdata" }
+
+func (steamUserdataDetector) Detect(bm *BackupManager) ([]*GameInfo, error) {
+	games := []*GameInfo{}
+	for _, root := range steamRoots() {
+		installed := steamApps(root)
+		prefix := ""
+		if strings.Contains(filepath.ToSlash(root), "/drive_c/") {
+			prefix = gamePrefix(&GameInfo{SavePaths: []string{root}})
+		}
+
+		for _, account := range steamAccounts(root) {
+			apps, err := os.ReadDir(filepath.Join(account.Userdata, account.ID))
+			if err != nil {
+				continue
+			}
+			for _, app := range apps {
+				if !app.IsDir() || steamClientAppIDs[app.Name()] {
+					continue
+				}
+				remote := filepath.Join(account.Userdata, account.ID, app.Name(), "remote")
+				if files, err := os.ReadDir(remote); err != nil || len(files) == 0 {
+					continue
+				}
+
+				name := installed[app.Name()].Name
+				if name == "" {
+					name = "Steam App " + app.Name()
+				}
+				game := &GameInfo{
+					Slug:        sanitizePathComponent(fmt.Sprintf("steam-%s-%s", app.Name(), account.ID)),
+					Name:        fmt.Sprintf("%s (%s)", name, account.label()),
+					Platform:    "steam",
+					SavePaths:   []string{remote},
+					Patterns:    []string{"*"}, // remote solo contiene archivos sincronizados por Steam Cloud
+					CustomPaths: []string{},
+					InstallDir:  installed[app.Name()].InstallDir,
+					Metadata: map[string]string{
+						"steam_app_id":     app.Name(),
+						"steam_account_id": account.ID,
+						"steam_persona":    account.label(),
+						"steam_userdata":   account.Userdata,
+					},
+				}
+				if prefix != "" {
+					game.Metadata["wine_prefix"] = prefix
+				}
+				games = append(games, game)
+			}
+		}
+	}
+
+	sort.Slice(games, func(i, j int) bool { return games[i].Slug < games[j].Slug })
+	return games, nil
+}
+
+// steamAccountSavePaths devuelve la carpeta remote del juego en otra cuenta de Steam de la misma instalación
+func steamAccountSavePaths(game *GameInfo, accountID string) ([]string, error) {
+	userdata, appID := game.Metadata["steam_userdata"], game.Metadata["steam_app_id"]
+	if userdata == "" || appID == "" {
+		return nil, fmt.Errorf("%s no es un juego de Steam Cloud", game.Name)
+	}
+	if _, err := strconv.ParseUint(accountID, 10, 32); err != nil {
+		return nil, fmt.Errorf("ID de cuenta de Steam inválido: %s", accountID)
+	}
+	if info, err := os.Stat(filepath.Join(userdata, accountID)); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("la cuenta de Steam %s no tiene carpeta en %s", accountID, userdata)
+	}
+	return []string{filepath.Join(userdata, accountID, appID, "remote")}, nil
+}
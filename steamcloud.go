@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Estados de Steam Cloud de un juego (GameInfo.CloudSyncStatus); vacío = no es un juego de Steam Cloud
+const (
+	CloudSyncActive   = "active"   // remotecache.vdf sigue archivos del juego: Steam sincroniza sus partidas
+	CloudSyncInactive = "inactive" // Carpeta de Steam Cloud sin archivos sincronizados
+)
+
+// steamCloudGuidance es el aviso que acompaña a las restauraciones de juegos con Steam Cloud activo
+const steamCloudGuidance = "Steam Cloud sincroniza este juego: cierra Steam antes de restaurar o, al iniciar el juego, " +
+	"elige la copia local en el aviso de conflicto de sincronización; si no, Steam puede sustituir los archivos restaurados por los de la nube"
+
+// remoteCachePath devuelve el remotecache.vdf de un juego de Steam Cloud, o vacío si no lo es
+func remoteCachePath(game *GameInfo) string {
+	userdata, account, appID := game.Metadata["steam_userdata"], game.Metadata["steam_account_id"], game.Metadata["steam_app_id"]
+	if userdata == "" || account == "" || appID == "" {
+		return ""
+	}
+	return filepath.Join(userdata, account, appID, "remotecache.vdf")
+}
+
+// cloudSyncStatus detecta si Steam Cloud sincroniza las partidas de un juego: remotecache.vdf existe
+// y sigue al menos un archivo
+func cloudSyncStatus(game *GameInfo) string {
+	path := remoteCachePath(game)
+	if path == "" {
+		return ""
+	}
+
+	files, err := remoteCacheFiles(path, game.Metadata["steam_app_id"])
+	if err != nil || len(files) == 0 {
+		return CloudSyncInactive
+	}
+	return CloudSyncActive
+}
+
+// remoteCacheFiles devuelve los archivos que sigue un remotecache.vdf (los bloques dentro del de la app)
+func remoteCacheFiles(path, appID string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseVDF(data)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %v", path, err)
+	}
+	return parsed.child(appID).Order, nil
+}
+
+// touchRemoteCache marca como modificados localmente los archivos restaurados de un juego de Steam Cloud,
+// para que Steam suba la copia local en lugar de descargar la de la nube: pone su fecha a ahora y su
+// "localtime" en remotecache.vdf a 0. Antes guarda una copia de remotecache.vdf junto a él.
+// Devuelve cuántas entradas de remotecache.vdf se modificaron.
+func touchRemoteCache(game *GameInfo, restored []string) (int, error) {
+	cachePath := remoteCachePath(game)
+	if cachePath == "" {
+		return 0, fmt.Errorf("%s no es un juego de Steam Cloud", game.Name)
+	}
+	remoteDir := filepath.Join(filepath.Dir(cachePath), "remote")
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return 0, fmt.Errorf("error leyendo %s: %v", cachePath, err)
+	}
+
+	now := time.Now()
+	names := make(map[string]bool)
+	for _, path := range restored {
+		rel, err := filepath.Rel(remoteDir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if err := os.Chtimes(path, now, now); err != nil {
+			log.Printf("Error actualizando la fecha de %s: %v", path, err)
+		}
+		names[strings.ToLower(filepath.ToSlash(rel))] = true
+	}
+
+	updated, touched, err := resetRemoteCacheTimes(data, names)
+	if err != nil {
+		return 0, fmt.Errorf("error leyendo %s: %v", cachePath, err)
+	}
+	if touched == 0 {
+		return 0, nil
+	}
+
+	backupPath := fmt.Sprintf("%s.winesave-%s.bak", cachePath, now.Format(backupTimestampLayout))
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return 0, fmt.Errorf("error guardando copia de remotecache.vdf: %v", err)
+	}
+	if err := os.WriteFile(cachePath, updated, 0644); err != nil {
+		return 0, err
+	}
+
+	log.Printf("remotecache.vdf de %s actualizado: %d archivos marcados como locales más recientes (copia en %s)", game.Name, touched, backupPath)
+	return touched, nil
+}
+
+// resetRemoteCacheTimes pone a 0 el "localtime" de los bloques de remotecache.vdf cuyos nombres están en names
+// (en minúsculas y con /). Edita el texto línea a línea para conservar el resto del archivo tal cual.
+func resetRemoteCacheTimes(data []byte, names map[string]bool) ([]byte, int, error) {
+	lines := strings.SplitAfter(string(data), "\n")
+	depth := 0
+	pending := "" // Última clave sin valor: nombre del bloque que abre la siguiente llave
+	current := "" // Archivo del bloque en el que estamos (profundidad 2)
+	touched := 0
+
+	for i, line := range lines {
+		tokens, err := vdfTokens(line)
+		if err != nil {
+			return nil, 0, err
+		}
+		for j := 0; j < len(tokens); j++ {
+			token := tokens[j]
+			switch token.brace {
+			case '{':
+				depth++
+				if depth == 2 {
+					current = strings.ToLower(strings.ReplaceAll(pending, `\`, "/"))
+				}
+				pending = ""
+				continue
+			case '}':
+				if depth == 2 {
+					current = ""
+				}
+				depth--
+				continue
+			}
+
+			if j+1 < len(tokens) && tokens[j+1].brace == 0 {
+				if depth == 2 && names[current] && strings.EqualFold(token.text, "localtime") && tokens[j+1].text != "0" {
+					lines[i] = strings.Replace(lines[i], `"`+tokens[j+1].text+`"`, `"0"`, 1)
+					touched++
+				}
+				j++
+				continue
+			}
+			pending = token.text
+		}
+	}
+	return []byte(strings.Join(lines, "")), touched, nil
+}
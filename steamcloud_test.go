@@ -0,0 +1,186 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// steamCloudAppID y steamCloudAccount son los de los remotecache.vdf de testdata/steamcloud
+const (
+	steamCloudAppID   = "1245620"
+	steamCloudAccount = "12345678"
+)
+
+// newSteamCloudGame registra el juego "cloud" con la carpeta userdata/<cuenta>/<app>/remote de Steam y, si
+// fixture no está vacío, ese remotecache.vdf de testdata/steamcloud. Devuelve el juego y su remotecache.vdf.
+func newSteamCloudGame(t *testing.T, bm *BackupManager, dir, fixture string) (*GameInfo, string) {
+	t.Helper()
+	userdata := filepath.Join(dir, "Steam", "userdata")
+	appDir := filepath.Join(userdata, steamCloudAccount, steamCloudAppID)
+	writeTestFiles(t, filepath.Join(appDir, "remote"), map[string]string{
+		"ER0000.sl2":                  "partida",
+		"ER0000.sl2.bak":              "partida anterior",
+		"profiles/GraphicsConfig.xml": "<config/>",
+	})
+
+	cachePath := filepath.Join(appDir, "remotecache.vdf")
+	if fixture != "" {
+		data, err := os.ReadFile(filepath.Join("testdata", "steamcloud", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(cachePath, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	game := &GameInfo{
+		ID:        "cloud",
+		Name:      "Elden Ring",
+		Slug:      "cloud",
+		Platform:  "steam",
+		SavePaths: []string{filepath.Join(appDir, "remote")},
+		Patterns:  []string{"*"},
+		Metadata: map[string]string{
+			"steam_app_id":     steamCloudAppID,
+			"steam_account_id": steamCloudAccount,
+			"steam_userdata":   userdata,
+		},
+	}
+	bm.setGame(game)
+	return game, cachePath
+}
+
+func TestCloudSyncStatus(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    string
+	}{
+		{"active.vdf", CloudSyncActive},
+		{"empty.vdf", CloudSyncInactive},     // Sin archivos sincronizados
+		{"otherapp.vdf", CloudSyncInactive},  // Los archivos son de otra app
+		{"truncated.vdf", CloudSyncInactive}, // Ilegible: no se da por activo
+		{"", CloudSyncInactive},              // Sin remotecache.vdf
+	}
+	for _, test := range tests {
+		bm, dir := newTestManager(t)
+		game, _ := newSteamCloudGame(t, bm, dir, test.fixture)
+		if got := cloudSyncStatus(game); got != test.want {
+			t.Errorf("%q: estado %q, se esperaba %q", test.fixture, got, test.want)
+		}
+
+		// updateGameInfo lo guarda en el juego para el frontend
+		if err := bm.updateGameInfo(game); err != nil {
+			t.Fatal(err)
+		}
+		if got := bm.gameSnapshot(game).CloudSyncStatus; got != test.want {
+			t.Errorf("%q: CloudSyncStatus %q tras updateGameInfo, se esperaba %q", test.fixture, got, test.want)
+		}
+	}
+
+	// Los juegos que no son de Steam no tienen estado
+	bm, _ := newTestManager(t)
+	if got := cloudSyncStatus(bm.DetectedGames["g"]); got != "" {
+		t.Errorf("juego sin Steam: estado %q", got)
+	}
+}
+
+func TestResetRemoteCacheTimes(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "steamcloud", "active.vdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nombres en minúsculas y con /, como los pasa touchRemoteCache
+	updated, touched, err := resetRemoteCacheTimes(data, map[string]bool{
+		"er0000.sl2":                  true,
+		"profiles/graphicsconfig.xml": true,
+		"missing.sav":                 true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if touched != 2 {
+		t.Errorf("%d entradas modificadas, se esperaban 2", touched)
+	}
+
+	// Solo cambian los localtime de esas entradas; el resto del archivo queda igual, tabuladores incluidos
+	want := strings.Replace(string(data), `"localtime"		"1712345678"`, `"localtime"		"0"`, 1)
+	want = strings.Replace(want, `"localtime"		"1712000000"`, `"localtime"		"0"`, 1)
+	if string(updated) != want {
+		t.Errorf("remotecache.vdf modificado:\n%s\nse esperaba:\n%s", updated, want)
+	}
+	if parsed, err := parseVDF(updated); err != nil || len(parsed.child(steamCloudAppID).Order) != 3 {
+		t.Errorf("el resultado no es un remotecache.vdf válido: %v", err)
+	}
+
+	// Las entradas que ya están a 0 no se cuentan
+	if _, touched, _ := resetRemoteCacheTimes(updated, map[string]bool{"er0000.sl2": true}); touched != 0 {
+		t.Errorf("%d entradas modificadas por segunda vez", touched)
+	}
+}
+
+func TestRestoreSteamCloud(t *testing.T) {
+	bm, dir := newTestManager(t)
+	game, cachePath := newSteamCloudGame(t, bm, dir, "active.vdf")
+	original, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := bm.CreateBackupWithOptions(game.ID, BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sin TouchCloudCache solo se avisa: remotecache.vdf no se toca
+	record, err := bm.RestoreBackup(game.ID, info.Name, RestoreOptions{Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.CloudWarning != steamCloudGuidance || record.CloudCacheTouched != 0 {
+		t.Errorf("aviso %q, %d entradas modificadas", record.CloudWarning, record.CloudCacheTouched)
+	}
+	if data, _ := os.ReadFile(cachePath); string(data) != string(original) {
+		t.Error("remotecache.vdf cambió sin TouchCloudCache")
+	}
+
+	// Con TouchCloudCache se marcan los archivos restaurados y se guarda una copia del original
+	record, err = bm.RestoreBackup(game.ID, info.Name, RestoreOptions{Force: true, TouchCloudCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.CloudCacheTouched != 3 {
+		t.Errorf("%d entradas modificadas, se esperaban 3", record.CloudCacheTouched)
+	}
+	data, _ := os.ReadFile(cachePath)
+	if strings.Contains(string(data), `"localtime"		"17`) {
+		t.Errorf("quedan entradas sin marcar:\n%s", data)
+	}
+	copies, _ := filepath.Glob(cachePath + ".winesave-*.bak")
+	if len(copies) != 1 {
+		t.Fatalf("%d copias de remotecache.vdf, se esperaba 1", len(copies))
+	}
+	if backup, _ := os.ReadFile(copies[0]); string(backup) != string(original) {
+		t.Error("la copia de remotecache.vdf no es el original")
+	}
+
+	// Sin Steam Cloud activo no hay aviso ni se toca nada aunque se pida
+	inactive, inactiveDir := newTestManager(t)
+	game, cachePath = newSteamCloudGame(t, inactive, inactiveDir, "empty.vdf")
+	info, err = inactive.CreateBackupWithOptions(game.ID, BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err = inactive.RestoreBackup(game.ID, info.Name, RestoreOptions{Force: true, TouchCloudCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.CloudWarning != "" || record.CloudCacheTouched != 0 {
+		t.Errorf("sin Steam Cloud: aviso %q, %d entradas modificadas", record.CloudWarning, record.CloudCacheTouched)
+	}
+	if copies, _ := filepath.Glob(cachePath + ".winesave-*.bak"); len(copies) != 0 {
+		t.Errorf("copias de remotecache.vdf sin Steam Cloud: %v", copies)
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Categorías de los datos que guarda WineSave además de las partidas
+const (
+	StorageBackups        = "backups"         // Backups de los juegos: solo se informa, nunca se limpian
+	StorageQuarantine     = "quarantine"      // Backups dañados apartados en la cuarentena de cada juego
+	StoragePartial        = "partial"         // Restos de copias interrumpidas dentro del directorio de backups
+	StorageTemp           = "temp"            // Directorios de trabajo de backups y autopruebas en el directorio temporal
+	StorageLogs           = "logs"            // Registro de actividad
+	StorageDatabaseCopies = "database_copies" // Copias apartadas de bases de datos dañadas
+)
+
+// minArtifactAge protege los temporales y copias a medias de una operación en curso, sea cual sea olderThan
+const minArtifactAge = time.Hour
+
+// StorageCategory es el espacio ocupado por una categoría de datos
+type StorageCategory struct {
+	Name      string   `json:"name"`
+	Size      int64    `json:"size"`
+	Files     int      `json:"files"`
+	Locations []string `json:"locations"`
+	Cleanable bool     `json:"cleanable"` // Se puede limpiar con CleanupStorage
+}
+
+// StorageBreakdown es el espacio que ocupan los datos de WineSave, por categoría
+type StorageBreakdown struct {
+	Categories []StorageCategory `json:"categories"`
+	Total      int64             `json:"total"`
+}
+
+// CleanupReport resume una limpieza con CleanupStorage
+type CleanupReport struct {
+	Removed    int      `json:"removed"`
+	FreedBytes int64    `json:"freed_bytes"`
+	Errors     []string `json:"errors"`
+}
+
+// storageItem es un archivo o carpeta de una categoría
+type storageItem struct {
+	path     string
+	size     int64
+	files    int
+	modified time.Time
+}
+
+// GetStorageBreakdown devuelve cuánto ocupa cada categoría de datos de WineSave y dónde está
+func (bm *BackupManager) GetStorageBreakdown() (*StorageBreakdown, error) {
+	breakdown := &StorageBreakdown{Categories: []StorageCategory{}}
+
+	backups := StorageCategory{Name: StorageBackups, Locations: []string{bm.config().BackupDir}}
+	for _, item := range bm.storageItems(StorageBackups) {
+		backups.Size += item.size
+		backups.Files += item.files
+	}
+	breakdown.Categories = append(breakdown.Categories, backups)
+
+	for _, name := range []string{StorageQuarantine, StoragePartial, StorageTemp, StorageLogs, StorageDatabaseCopies} {
+		category := StorageCategory{Name: name, Locations: []string{}, Cleanable: true}
+		for _, item := range bm.storageItems(name) {
+			category.Size += item.size
+			category.Files += item.files
+			category.Locations = append(category.Locations, item.path)
+		}
+		breakdown.Categories = append(breakdown.Categories, category)
+	}
+
+	// Los backups incluyen la cuarentena y las copias a medias, que están dentro de BackupDir
+	breakdown.Total = backups.Size
+	for _, category := range breakdown.Categories[1:] {
+		if category.Name != StorageQuarantine && category.Name != StoragePartial {
+			breakdown.Total += category.Size
+		}
+	}
+	return breakdown, nil
+}
+
+// CleanupStorage elimina los datos de las categorías indicadas con más de olderThan de antigüedad.
+// Nunca toca los backups: dentro de BackupDir solo borra la cuarentena y las copias a medias reconocidas.
+// El registro de actividad no se borra, se quitan sus entradas antiguas.
+func (bm *BackupManager) CleanupStorage(categories []string, olderThan time.Duration) (*CleanupReport, error) {
+	for _, category := range categories {
+		switch category {
+		case StorageQuarantine, StoragePartial, StorageTemp, StorageLogs, StorageDatabaseCopies:
+		case StorageBackups:
+			return nil, fmt.Errorf("los backups no se limpian desde aquí: usa la rotación o elimínalos uno a uno")
+		default:
+			return nil, fmt.Errorf("categoría de almacenamiento desconocida: %s", category)
+		}
+	}
+
+	report := &CleanupReport{Errors: []string{}}
+	cutoff := time.Now().Add(-olderThan)
+	for _, category := range categories {
+		if category == StorageLogs {
+			removed, err := bm.pruneActivity(cutoff)
+			if err != nil {
+				report.Errors = append(report.Errors, err.Error())
+			}
+			report.Removed += removed
+			continue
+		}
+
+		categoryCutoff := cutoff
+		if category == StoragePartial || category == StorageTemp {
+			if limit := time.Now().Add(-minArtifactAge); categoryCutoff.After(limit) {
+				categoryCutoff = limit
+			}
+		}
+
+		for _, item := range bm.storageItems(category) {
+			if !item.modified.Before(categoryCutoff) {
+				continue
+			}
+			if err := bm.removeStorageItem(category, item); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", item.path, err))
+				continue
+			}
+			report.Removed++
+			report.FreedBytes += item.size
+		}
+	}
+
+	log.Printf("Limpieza de almacenamiento %v: %d elementos eliminados, %d bytes liberados", categories, report.Removed, report.FreedBytes)
+	return report, nil
+}
+
+// storageItems devuelve los archivos o carpetas de una categoría
+func (bm *BackupManager) storageItems(category string) []storageItem {
+	var items []storageItem
+	add := func(path string) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return
+		}
+		item := storageItem{path: path, size: info.Size(), files: 1, modified: info.ModTime()}
+		if info.IsDir() {
+			item.size, item.files = 0, 0
+			filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					if info, err := d.Info(); err == nil {
+						item.size += info.Size()
+						item.files++
+					}
+				}
+				return nil
+			})
+		}
+		items = append(items, item)
+	}
+
+	switch category {
+	case StorageBackups:
+		add(bm.config().BackupDir)
+	case StorageQuarantine:
+		backups, _ := filepath.Glob(filepath.Join(bm.config().BackupDir, "*", quarantineDirName, "*"))
+		for _, path := range backups {
+			if !isSidecar(path) { // Se borra con su backup
+				add(path)
+			}
+		}
+	case StoragePartial:
+		filepath.WalkDir(bm.config().BackupDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if rel, err := filepath.Rel(bm.config().BackupDir, path); err == nil && isPartialArtifact(rel) {
+				add(path)
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+			}
+			return nil
+		})
+	case StorageTemp:
+		dirs, _ := filepath.Glob(filepath.Join(bm.tempDir(), "winesave-*"))
+		for _, dir := range dirs {
+			add(dir)
+		}
+	case StorageLogs:
+		add(bm.activityPath())
+	case StorageDatabaseCopies:
+		copies, _ := filepath.Glob(bm.DatabasePath + databaseCorruptSuffix + "*")
+		for _, path := range copies {
+			add(path)
+		}
+	}
+	return items
+}
+
+// removeStorageItem borra un elemento de una categoría, comprobando antes que no es un backup
+func (bm *BackupManager) removeStorageItem(category string, item storageItem) error {
+	if isWithin(bm.config().BackupDir, item.path) {
+		rel, err := filepath.Rel(bm.config().BackupDir, item.path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		quarantined := len(parts) == 3 && parts[1] == quarantineDirName
+		if !(category == StorageQuarantine && quarantined) && !(category == StoragePartial && isPartialArtifact(rel)) {
+			return fmt.Errorf("no se borra: está en el directorio de backups y no es un temporal reconocido")
+		}
+	}
+
+	if category == StorageQuarantine {
+		// Con su manifiesto y su entrada del historial
+		gameID := filepath.Base(filepath.Dir(filepath.Dir(item.path)))
+		if game, exists := bm.findGameBySlug(gameID); exists {
+			gameID = game.ID
+		}
+		return bm.removeBackup(gameID, BackupInfo{Name: filepath.Base(item.path), Path: item.path})
+	}
+	return os.RemoveAll(item.path)
+}
+
+// isPartialArtifact indica si una ruta relativa a BackupDir es una copia provisional de WineSave. Solo se reconocen
+// donde WineSave las crea, para no confundirlas con partidas dentro de los backups en carpeta.
+func isPartialArtifact(rel string) bool {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	name := parts[len(parts)-1]
+	switch {
+	case strings.HasSuffix(name, partialCopySuffix): // Migración del directorio de backups, a cualquier profundidad
+		return true
+	case len(parts) == 1: // Prueba de escritura en BackupDir
+		return strings.HasPrefix(name, ".winesave-write-test-")
+	case len(parts) == 2: // Junto a los backups de un juego: moveIntoPlace, copia actual y reparaciones
+		return strings.HasSuffix(name, ".partial") || strings.HasSuffix(name, ".repair.tmp") || name == currentMirrorName+".old"
+	}
+	return false
+}
+
+// pruneActivity quita del registro de actividad las entradas anteriores a cutoff y devuelve cuántas quitó
+func (bm *BackupManager) pruneActivity(cutoff time.Time) (int, error) {
+	bm.activityMu.Lock()
+	defer bm.activityMu.Unlock()
+
+	entries, err := bm.loadActivity()
+	if err != nil {
+		return 0, err
+	}
+	kept := entries[:0]
+	for _, entry := range entries {
+		if !entry.Time.Before(cutoff) {
+			kept = append(kept, entry)
+		}
+	}
+	removed := len(entries) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, bm.saveActivity(kept)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Formatos de la base de datos de juegos (Config.DatabaseBackend)
+const (
+	DatabaseJSON   = "json"   // game_saves.json, se reescribe entero en cada cambio
+	DatabaseSQLite = "sqlite" // game_saves.db, solo se escriben los juegos que cambian
+)
+
+// Store guarda la biblioteca de juegos
+type Store interface {
+	// Load devuelve los juegos guardados; rewrite indica que hay que volver a guardarlos
+	// (formato antiguo, datos recuperados de una copia o importados de otro formato)
+	Load() (games []*GameInfo, rewrite bool, err error)
+	// Save guarda los juegos, ordenados por ID, y devuelve si cambió algo
+	Save(games []storedGame) (changed bool, err error)
+	Close() error
+}
+
+// storedGame es un juego ya serializado, con los campos por los que se puede consultar
+type storedGame struct {
+	ID       string
+	Slug     string
+	Name     string
+	Platform string
+	Data     json.RawMessage
+}
+
+// validateDatabaseBackend comprueba el formato de la base de datos de la configuración
+func validateDatabaseBackend(backend string) error {
+	switch backend {
+	case "", DatabaseJSON, DatabaseSQLite:
+		return nil
+	}
+	return fmt.Errorf("formato de base de datos desconocido: %s (json o sqlite)", backend)
+}
+
+// openStore abre la base de datos de juegos en el formato indicado; vacío = JSON
+func (bm *BackupManager) openStore(backend string) (Store, error) {
+	switch backend {
+	case "", DatabaseJSON:
+		return &jsonStore{bm: bm}, nil
+	case DatabaseSQLite:
+		return openSQLiteStore(sqliteDatabasePath(bm.DatabasePath), &jsonStore{bm: bm})
+	}
+	return nil, validateDatabaseBackend(backend)
+}
+
+// database devuelve la base de datos de juegos, abriéndola la primera vez con el formato de la configuración
+func (bm *BackupManager) database() (Store, error) {
+	bm.storeM.Lock()
+	defer bm.storeM.Unlock()
+	if bm.store == nil {
+		store, err := bm.openStore(bm.config().DatabaseBackend)
+		if err != nil {
+			return nil, err
+		}
+		bm.store = store
+	}
+	return bm.store, nil
+}
+
+// SetDatabaseBackend pasa la biblioteca a otro formato de base de datos: guarda en él los juegos actuales y
+// lo usa a partir de entonces. El archivo del formato anterior se conserva tal como estaba.
+func (bm *BackupManager) SetDatabaseBackend(backend string) error {
+	if err := validateDatabaseBackend(backend); err != nil {
+		return err
+	}
+	store, err := bm.openStore(backend)
+	if err != nil {
+		return err
+	}
+
+	bm.dbMu.Lock()
+	defer bm.dbMu.Unlock()
+	if _, err := store.Save(bm.storedGames()); err != nil {
+		store.Close()
+		return fmt.Errorf("error guardando los juegos en la base de datos %s: %v", backend, err)
+	}
+
+	bm.storeM.Lock()
+	previous := bm.store
+	bm.store = store
+	bm.setConfig(func(config *BackupConfig) { config.DatabaseBackend = backend })
+	bm.storeM.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
+	log.Printf("Base de datos de juegos cambiada a %s", backend)
+	return nil
+}
+
+// CloseDatabase cierra la base de datos de juegos; se vuelve a abrir al usarla
+func (bm *BackupManager) CloseDatabase() error {
+	bm.storeM.Lock()
+	defer bm.storeM.Unlock()
+	if bm.store == nil {
+		return nil
+	}
+	err := bm.store.Close()
+	bm.store = nil
+	return err
+}
+
+// jsonStore guarda la biblioteca en game_saves.json (DatabasePath)
+type jsonStore struct {
+	bm *BackupManager
+}
+
+func (s *jsonStore) Load() ([]*GameInfo, bool, error) {
+	if _, err := os.Stat(s.bm.DatabasePath); os.IsNotExist(err) {
+		return nil, false, nil // No hay base de datos, empezar limpio
+	}
+
+	data, err := os.ReadFile(s.bm.DatabasePath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	dbData, err := parseDatabase(data)
+	recovered := err != nil
+	if recovered {
+		// Nunca se empieza vacío sobre los datos existentes: se apartan y se prueba la copia anterior
+		if dbData, err = s.bm.recoverDatabase(err); err != nil {
+			return nil, false, err
+		}
+	}
+
+	games := dbData.Games
+	if dbData.SchemaVersion < 2 {
+		games = make([]*GameInfo, 0, len(dbData.DetectedGames))
+		for id, game := range dbData.DetectedGames {
+			game.ID = id // La versión 1 identificaba los juegos por la clave
+			games = append(games, game)
+		}
+	}
+	// Tras recuperar la copia anterior se vuelve a escribir game_saves.json
+	return games, recovered || dbData.SchemaVersion < databaseSchemaVersion, nil
+}
+
+// Save no reescribe el archivo si los juegos no cambiaron, ni siquiera last_update
+func (s *jsonStore) Save(games []storedGame) (bool, error) {
+	raw := make([]json.RawMessage, len(games))
+	for i, game := range games {
+		raw[i] = game.Data
+	}
+	gamesData, err := json.Marshal(raw)
+	if err != nil {
+		return false, err
+	}
+
+	previous, err := os.ReadFile(s.bm.DatabasePath)
+	if err == nil {
+		var stored struct {
+			SchemaVersion int             `json:"schema_version"`
+			Games         json.RawMessage `json:"games"`
+		}
+		if json.Unmarshal(previous, &stored) != nil {
+			previous = nil // No se guarda como copia anterior un archivo ilegible
+		} else if stored.SchemaVersion == databaseSchemaVersion && sameJSON(stored.Games, gamesData) {
+			return false, nil
+		}
+	}
+
+	data, err := json.MarshalIndent(struct {
+		SchemaVersion int               `json:"schema_version"`
+		Games         []json.RawMessage `json:"games"`
+		LastUpdate    time.Time         `json:"last_update"`
+	}{databaseSchemaVersion, raw, time.Now()}, "", "  ")
+	if err != nil {
+		return false, err
+	}
+
+	// La versión anterior queda como .bak y la nueva se escribe aparte y se renombra,
+	// para que un cierre a mitad de escritura no deje el archivo dañado
+	if previous != nil {
+		if err := os.WriteFile(s.bm.DatabasePath+databaseBackupSuffix, previous, 0644); err != nil {
+			log.Printf("Error guardando copia de la base de datos: %v", err)
+		}
+	}
+	tmpPath := s.bm.DatabasePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmpPath, s.bm.DatabasePath); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *jsonStore) Close() error { return nil }
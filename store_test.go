@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// addStoreTestGames registra, en el orden indicado, juegos con patrones y etiquetas desordenados
+func addStoreTestGames(bm *BackupManager, ids ...string) {
+	for _, id := range ids {
+		bm.setGame(&GameInfo{
+			ID:        id,
+			Name:      "Juego " + id,
+			Slug:      "juego-" + id,
+			SavePaths: []string{"/saves/" + id + "/b", "/saves/" + id + "/a"},
+			Patterns:  []string{"*.sav", "*.dat", "profile*"},
+			Tags:      []string{"RPG", "coop", "rpg"},
+			Metadata:  map[string]string{"z": "1", "a": "2", "steam_app_id": id},
+		})
+	}
+}
+
+// readDatabase lee game_saves.json y la sección de juegos
+func readDatabase(t *testing.T, path string) ([]byte, json.RawMessage) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Games json.RawMessage `json:"games"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	return data, file.Games
+}
+
+func TestSaveDatabaseTwiceIsByteIdentical(t *testing.T) {
+	bm, dir := newTestManager(t)
+	addStoreTestGames(bm, "30", "10", "20")
+	if err := bm.SaveDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	first, games := readDatabase(t, bm.DatabasePath)
+
+	if err := bm.SaveDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := readDatabase(t, bm.DatabasePath); !bytes.Equal(first, second) {
+		t.Errorf("guardar sin cambios cambió game_saves.json:\n%s\n---\n%s", first, second)
+	}
+	if _, err := os.Stat(bm.DatabasePath + databaseBackupSuffix); err == nil {
+		t.Error("guardar sin cambios dejó una copia anterior")
+	}
+
+	// Juegos ordenados por ID, patrones y etiquetas ordenados; las rutas conservan su orden
+	var stored []*GameInfo
+	if err := json.Unmarshal(games, &stored); err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, game := range stored {
+		ids = append(ids, game.ID)
+	}
+	if want := []string{"10", "20", "30", "g"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("juegos en el orden %v, se esperaba %v", ids, want)
+	}
+	if game := stored[0]; !reflect.DeepEqual(game.Patterns, []string{"*.dat", "*.sav", "profile*"}) ||
+		!reflect.DeepEqual(game.Tags, []string{"coop", "rpg"}) ||
+		!reflect.DeepEqual(game.SavePaths, []string{"/saves/10/b", "/saves/10/a"}) {
+		t.Errorf("patrones %v, etiquetas %v, rutas %v", game.Patterns, game.Tags, game.SavePaths)
+	}
+	// Los juegos en memoria no cambian al guardarlos
+	if game, _ := bm.getGame("10"); game.Patterns[0] != "*.sav" {
+		t.Errorf("SaveDatabase ordenó los patrones del juego en memoria: %v", game.Patterns)
+	}
+
+	// Cargar y volver a guardar, con otro gestor, tampoco cambia nada
+	loaded, _ := newTestManager(t)
+	loaded.DatabasePath = filepath.Join(dir, "game_saves.json")
+	if err := loaded.LoadDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.SaveDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	if third, _ := readDatabase(t, bm.DatabasePath); !bytes.Equal(first, third) {
+		t.Errorf("cargar y guardar cambió game_saves.json:\n%s\n---\n%s", first, third)
+	}
+}
+
+// El contenido no depende del orden en que se agregaron los juegos, solo last_update
+func TestSaveDatabaseIndependentOfInsertionOrder(t *testing.T) {
+	var sections []json.RawMessage
+	for _, order := range [][]string{{"10", "20", "30"}, {"30", "20", "10"}, {"20", "10", "30"}} {
+		bm, _ := newTestManager(t)
+		delete(bm.DetectedGames, "g") // Sus rutas están en otra carpeta temporal en cada vuelta
+		addStoreTestGames(bm, order...)
+		if err := bm.SaveDatabase(); err != nil {
+			t.Fatal(err)
+		}
+		_, games := readDatabase(t, bm.DatabasePath)
+		sections = append(sections, games)
+	}
+	for i := 1; i < len(sections); i++ {
+		if !bytes.Equal(sections[0], sections[i]) {
+			t.Errorf("juegos distintos según el orden de alta:\n%s\n---\n%s", sections[0], sections[i])
+		}
+	}
+}
+
+// Una base de datos de la versión 1 (juegos en un objeto por ID) se carga y se reescribe en la versión actual
+func TestLoadDatabaseVersion1(t *testing.T) {
+	bm, _ := newTestManager(t)
+	v1 := `{
+  "detected_games": {
+    "b": {"id": "b", "name": "B", "slug": "b", "save_paths": ["/saves/b"], "patterns": ["*.sav", "*.dat"]},
+    "a": {"name": "A", "slug": "a", "save_paths": ["/saves/a"], "patterns": ["*"]}
+  },
+  "last_update": "2024-01-01T00:00:00Z"
+}`
+	if err := os.WriteFile(bm.DatabasePath, []byte(v1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := bm.LoadDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	if game, exists := bm.getGame("a"); !exists || game.Name != "A" || game.Metadata == nil {
+		t.Fatalf("juego de la versión 1: %+v", game)
+	}
+
+	first, games := readDatabase(t, bm.DatabasePath)
+	var file databaseFile
+	if err := json.Unmarshal(first, &file); err != nil {
+		t.Fatal(err)
+	}
+	if file.SchemaVersion != databaseSchemaVersion || file.DetectedGames != nil || len(file.Games) != 2 ||
+		file.Games[0].ID != "a" || file.Games[1].ID != "b" {
+		t.Errorf("reescrita como versión %d con %d juegos: %s", file.SchemaVersion, len(file.Games), games)
+	}
+	if err := bm.SaveDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := readDatabase(t, bm.DatabasePath); !bytes.Equal(first, second) {
+		t.Error("guardar tras la migración sin cambios cambió game_saves.json")
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// UsageSummary resume los backups de un periodo a partir del registro de actividad local. No sale del equipo.
+type UsageSummary struct {
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Backups   int       `json:"backups"`
+	Failures  int       `json:"failures"`
+	Bytes     int64     `json:"bytes"`                // Tamaño de los backups creados en el periodo
+	HumanSize string    `json:"human_size,omitempty"` // Bytes para mostrar
+	Games     int       `json:"games"`                // Juegos con al menos un backup
+
+	MostBackedUp *GameUsage `json:"most_backed_up,omitempty"`
+
+	LongestStreak int       `json:"longest_streak"` // Días seguidos con algún backup
+	StreakStart   time.Time `json:"streak_start,omitempty"`
+	StreakEnd     time.Time `json:"streak_end,omitempty"`
+
+	// El registro de actividad guarda las últimas maxActivityEntries entradas: si empieza después de From,
+	// el resumen no cubre todo el periodo
+	Truncated bool `json:"truncated"`
+}
+
+// GameUsage son los backups de un juego en un resumen de uso
+type GameUsage struct {
+	GameID   string `json:"game_id"`
+	GameName string `json:"game_name"`
+	Backups  int    `json:"backups"`
+	Bytes    int64  `json:"bytes"`
+
+	HumanSize string `json:"human_size,omitempty"`
+}
+
+// GenerateSummary resume los backups de los últimos period (0 = todo el registro): cuántos, cuánto ocupan,
+// el juego con más backups y la racha más larga de días seguidos con alguno
+func (bm *BackupManager) GenerateSummary(period time.Duration) (*UsageSummary, error) {
+	entries, err := bm.GetActivity(0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	summary := &UsageSummary{To: now}
+	if period > 0 {
+		summary.From = now.Add(-period)
+	}
+	if len(entries) >= maxActivityEntries && entries[len(entries)-1].Time.After(summary.From) {
+		summary.Truncated = true
+	}
+
+	games := make(map[string]*GameUsage)
+	days := make(map[time.Time]bool)
+	sizes := make(map[string]*backupIndex) // Historial de cada juego, para las entradas sin tamaño
+	for _, entry := range entries {
+		if entry.Type != ActivityBackup || entry.Time.Before(summary.From) {
+			continue
+		}
+		if entry.Outcome == ActivityFailure {
+			summary.Failures++
+			continue
+		}
+
+		size, err := strconv.ParseInt(entry.Params["size"], 10, 64)
+		if err != nil {
+			// Entradas anteriores al tamaño en el registro: se busca el backup en el historial si sigue ahí
+			size = 0
+			index, loaded := sizes[entry.GameID]
+			if !loaded {
+				index, _ = bm.loadBackupIndex(entry.GameID)
+				sizes[entry.GameID] = index
+			}
+			if index != nil {
+				if record := index.find(entry.Params["backup"]); record != nil {
+					size = record.Size
+				}
+			}
+		}
+
+		summary.Backups++
+		summary.Bytes += size
+		usage, exists := games[entry.GameID]
+		if !exists {
+			usage = &GameUsage{GameID: entry.GameID, GameName: entry.GameName}
+			games[entry.GameID] = usage
+		}
+		usage.Backups++
+		usage.Bytes += size
+		year, month, day := entry.Time.Date()
+		days[time.Date(year, month, day, 0, 0, 0, 0, time.Local)] = true
+	}
+
+	summary.Games = len(games)
+	for _, usage := range games {
+		best := summary.MostBackedUp
+		if best == nil || usage.Backups > best.Backups || (usage.Backups == best.Backups && usage.GameName < best.GameName) {
+			summary.MostBackedUp = usage
+		}
+	}
+	if game := summary.MostBackedUp; game != nil {
+		if current, exists := bm.getGame(game.GameID); exists {
+			game.GameName = current.Name
+		}
+	}
+
+	summary.LongestStreak, summary.StreakStart, summary.StreakEnd = longestStreak(days)
+	return summary, nil
+}
+
+// longestStreak devuelve la racha más larga de días seguidos y sus extremos (la más reciente si hay empate)
+func longestStreak(days map[time.Time]bool) (int, time.Time, time.Time) {
+	sorted := make([]time.Time, 0, len(days))
+	for day := range days {
+		sorted = append(sorted, day)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	best, bestStart, bestEnd := 0, time.Time{}, time.Time{}
+	length, start := 0, time.Time{}
+	for i, day := range sorted {
+		// AddDate y no 24h: los cambios de hora hacen días de 23 y 25 horas
+		if i > 0 && sorted[i-1].AddDate(0, 0, 1).Equal(day) {
+			length++
+		} else {
+			length, start = 1, day
+		}
+		if length >= best {
+			best, bestStart, bestEnd = length, start, day
+		}
+	}
+	return best, bestStart, bestEnd
+}
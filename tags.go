@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// SetTags sustituye las etiquetas de un juego (p. ej. "completado", "competitivo") para agruparlo en la biblioteca.
+// Se guardan en minúsculas, sin espacios sobrantes, sin repetir y ordenadas.
+func (bm *BackupManager) SetTags(gameID string, tags []string) (*GameInfo, error) {
+	game, exists := bm.getGame(gameID)
+	if !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	game.Tags = normalizeTags(tags)
+	return game, bm.SaveDatabase()
+}
+
+// GetGamesByTag devuelve los juegos con una etiqueta, ordenados por nombre. Sin etiqueta devuelve todos.
+func (bm *BackupManager) GetGamesByTag(tag string) []*GameInfo {
+	games := bm.GetGameList()
+	tag = normalizeTag(tag)
+	if tag == "" {
+		return games
+	}
+
+	filtered := []*GameInfo{}
+	for _, game := range games {
+		if hasTag(game, tag) {
+			filtered = append(filtered, game)
+		}
+	}
+	return filtered
+}
+
+// GetAllTags devuelve las etiquetas usadas en la biblioteca, ordenadas
+func (bm *BackupManager) GetAllTags() []string {
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, game := range bm.GetGameList() {
+		for _, tag := range game.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// normalizeTags limpia una lista de etiquetas; nil si no queda ninguna
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	var cleaned []string
+	for _, tag := range tags {
+		if tag = normalizeTag(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			cleaned = append(cleaned, tag)
+		}
+	}
+	sort.Strings(cleaned)
+	return cleaned
+}
+
+// normalizeTag deja una etiqueta en minúsculas y con los espacios internos simplificados
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// hasTag indica si el juego tiene la etiqueta, ya normalizada
+func hasTag(game *GameInfo, tag string) bool {
+	for _, t := range game.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// ioLimiter limita los bytes copiados por segundo con un cubo de fichas de un segundo de capacidad.
+// Un *ioLimiter nil no limita nada.
+type ioLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Bytes por segundo
+	tokens float64
+	last   time.Time
+}
+
+// newIOLimiter crea un limitador de mbps megabytes por segundo (nil si mbps <= 0)
+func newIOLimiter(mbps int) *ioLimiter {
+	return newRateLimiter(int64(mbps) << 20)
+}
+
+// newRateLimiter crea un limitador de rate bytes por segundo (nil si rate <= 0)
+func newRateLimiter(rate int64) *ioLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &ioLimiter{rate: float64(rate), tokens: float64(rate), last: time.Now()}
+}
+
+// wait espera hasta que se puedan copiar n bytes
+func (l *ioLimiter) wait(n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.rate, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	deficit := -l.tokens
+	l.mu.Unlock()
+
+	if deficit > 0 {
+		time.Sleep(time.Duration(deficit / l.rate * float64(time.Second)))
+	}
+}
+
+// reader envuelve r para que sus lecturas respeten el límite
+func (l *ioLimiter) reader(r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &limitedReader{r: r, limit: l}
+}
+
+// limitedReader es un io.Reader limitado por un ioLimiter
+type limitedReader struct {
+	r     io.Reader
+	limit *ioLimiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	lr.limit.wait(n)
+	return n, err
+}
+
+// isBackgroundTrigger indica si un backup lo lanzó el programador y no el usuario
+func isBackgroundTrigger(trigger string) bool {
+	return trigger == "auto"
+}
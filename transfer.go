@@ -0,0 +1,239 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// supportedImportFormats se muestra en los errores de importación
+const supportedImportFormats = ".zip, carpeta"
+
+// ImportBackup importa un archivo o carpeta existente como backup de un juego
+func (bm *BackupManager) ImportBackup(gameID string, archivePath string, takenAt time.Time) (*BackupInfo, error) {
+	if _, exists := bm.getGame(gameID); !exists {
+		return nil, errGameNotFound(gameID)
+	}
+
+	sourcePath := ExpandPath(archivePath)
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("no se puede leer %s: %v", sourcePath, err)
+	}
+
+	compressed := !info.IsDir()
+	if compressed {
+		if !strings.EqualFold(filepath.Ext(sourcePath), ".zip") {
+			return nil, fmt.Errorf("formato no soportado: %s (formatos soportados: %s)", filepath.Ext(sourcePath), supportedImportFormats)
+		}
+		reader, err := zip.OpenReader(sourcePath)
+		if err != nil {
+			return nil, fmt.Errorf("el archivo no es un ZIP válido: %v", err)
+		}
+		reader.Close()
+
+		// Rechazar archivos hostiles antes de que lleguen al historial y a una restauración
+		if err := validateArchive(sourcePath); err != nil {
+			return nil, fmt.Errorf("el archivo no se puede importar: %w", err)
+		}
+	}
+
+	if takenAt.IsZero() {
+		takenAt = info.ModTime()
+	}
+
+	folder := bm.backupFolder(gameID)
+	backupDir := filepath.Join(bm.config().BackupDir, folder)
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creando directorio de backup: %v", err)
+	}
+
+	// Buscar un nombre libre con el esquema <carpeta>_<timestamp>
+	var destPath string
+	for {
+		name := fmt.Sprintf("%s_%s", folder, takenAt.Format(backupTimestampLayout))
+		if compressed {
+			name += ".zip"
+		}
+		destPath = filepath.Join(backupDir, name)
+		if _, err := os.Stat(destPath); os.IsNotExist(err) {
+			break
+		}
+		takenAt = takenAt.Add(time.Second)
+	}
+
+	if compressed {
+		err = copyFile(sourcePath, destPath)
+	} else {
+		err = copyDir(sourcePath, destPath)
+	}
+	if err != nil {
+		os.RemoveAll(destPath)
+		return nil, fmt.Errorf("error copiando backup importado: %v", err)
+	}
+
+	record, err := bm.registerBackup(gameID, destPath, "import", takenAt)
+	if err != nil {
+		os.RemoveAll(destPath)
+		return nil, err
+	}
+
+	log.Printf("Backup importado para %s: %s", gameID, destPath)
+
+	return &BackupInfo{
+		Name:       record.Name,
+		Path:       destPath,
+		Size:       record.Size,
+		Created:    takenAt,
+		Compressed: compressed,
+	}, nil
+}
+
+// exportProgressEvent es el evento emitido al exportar archivos grandes
+const exportProgressEvent = "export:progress"
+
+// ExportProgress es el contenido del evento de progreso de exportación
+type ExportProgress struct {
+	GameID  string `json:"game_id"`
+	Backup  string `json:"backup"`
+	Written int64  `json:"written"`
+	Total   int64  `json:"total"`
+}
+
+// progressWriter cuenta los bytes escritos y emite eventos de progreso limitados en frecuencia
+type progressWriter struct {
+	bm       *BackupManager
+	progress ExportProgress
+	lastEmit time.Time
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.progress.Written += int64(len(p))
+	if time.Since(w.lastEmit) >= 250*time.Millisecond || w.progress.Written == w.progress.Total {
+		w.lastEmit = time.Now()
+		w.bm.emit(exportProgressEvent, w.progress)
+	}
+	return len(p), nil
+}
+
+// ExportBackup copia un backup (y su manifiesto) a un destino elegido por el usuario, verificando la copia.
+// Los backups en carpeta se comprimen en un ZIP al exportarlos.
+func (bm *BackupManager) ExportBackup(gameID, backupFileName, destPath string) error {
+	sourcePath, err := bm.resolveBackupFile(gameID, backupFileName)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	destPath = ExpandPath(destPath)
+	if destInfo, err := os.Stat(destPath); err == nil && destInfo.IsDir() {
+		destPath = filepath.Join(destPath, backupFileName)
+	}
+	if info.IsDir() && !strings.EqualFold(filepath.Ext(destPath), ".zip") {
+		destPath += ".zip"
+	}
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return fmt.Errorf("error creando directorio destino: %v", err)
+	}
+
+	progress := &progressWriter{
+		bm:       bm,
+		progress: ExportProgress{GameID: gameID, Backup: backupFileName},
+	}
+
+	check := bm.copyCheck("")
+	if info.IsDir() {
+		var written string
+		written, err = zipFolderTo(sourcePath, destPath, progress)
+		if err == nil && check.reread {
+			err = verifyCopiedFile(destPath, written)
+		}
+	} else {
+		progress.progress.Total = info.Size()
+		_, err = copyFileWithProgress(sourcePath, destPath, progress, check)
+	}
+	if err != nil {
+		os.Remove(destPath)
+		return fmt.Errorf("error exportando backup a %s: %v", destPath, err)
+	}
+
+	for _, sidecar := range sidecarPaths(sourcePath) {
+		if _, err := os.Stat(sidecar); err == nil {
+			if err := copyFile(sidecar, destPath+strings.TrimPrefix(sidecar, sourcePath)); err != nil {
+				return fmt.Errorf("error copiando %s: %v", filepath.Base(sidecar), err)
+			}
+		}
+	}
+
+	log.Printf("Backup %s exportado a %s", backupFileName, destPath)
+	return nil
+}
+
+// copyFileWithProgress copia un archivo con copyVerified informando del progreso y devuelve el SHA-256 de lo escrito
+func copyFileWithProgress(src, dst string, progress io.Writer, check copyCheck) (string, error) {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer srcFile.Close()
+	return copyVerified(io.TeeReader(srcFile, progress), dst, check)
+}
+
+// zipFolderTo comprime una carpeta de backup en un ZIP y devuelve el SHA-256 del ZIP generado
+func zipFolderTo(src, dst string, progress *progressWriter) (string, error) {
+	progress.progress.Total = backupSize(src)
+
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	zipWriter := zip.NewWriter(io.MultiWriter(dstFile, hash))
+
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		entry, err := zipWriter.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(io.MultiWriter(entry, progress), file)
+		return err
+	})
+
+	if closeErr := zipWriter.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// vdfNode es un bloque de un archivo KeyValues de Valve (.vdf, .acf). Las claves se guardan en minúsculas
+// porque Steam no distingue mayúsculas en ellas.
+type vdfNode struct {
+	Values   map[string]string
+	Children map[string]*vdfNode
+	Order    []string // Claves de los bloques hijos en el orden del archivo
+}
+
+// newVDFNode crea un bloque vacío
+func newVDFNode() *vdfNode {
+	return &vdfNode{Values: make(map[string]string), Children: make(map[string]*vdfNode)}
+}
+
+// child devuelve el bloque hijo con esa clave, o uno vacío si no existe
+func (n *vdfNode) child(key string) *vdfNode {
+	if c, ok := n.Children[strings.ToLower(key)]; ok {
+		return c
+	}
+	return newVDFNode()
+}
+
+// value devuelve el valor de una clave del bloque
+func (n *vdfNode) value(key string) string {
+	return n.Values[strings.ToLower(key)]
+}
+
+// parseVDF lee un archivo KeyValues de texto: pares "clave" "valor" y bloques "clave" { ... }
+func parseVDF(data []byte) (*vdfNode, error) {
+	tokens, err := vdfTokens(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	root := newVDFNode()
+	stack := []*vdfNode{root}
+	for i := 0; i < len(tokens); i++ {
+		current := stack[len(stack)-1]
+		token := tokens[i]
+
+		if token.brace == '}' {
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("llave de cierre sin abrir")
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if token.brace != 0 {
+			return nil, fmt.Errorf("llave de apertura sin clave")
+		}
+		if i+1 >= len(tokens) {
+			return nil, fmt.Errorf("falta el valor de %s", token.text)
+		}
+
+		key := strings.ToLower(token.text)
+		next := tokens[i+1]
+		i++
+		switch next.brace {
+		case '{':
+			child := newVDFNode()
+			if _, exists := current.Children[key]; !exists {
+				current.Order = append(current.Order, key)
+			}
+			current.Children[key] = child
+			stack = append(stack, child)
+		case '}':
+			return nil, fmt.Errorf("falta el valor de %s", token.text)
+		default:
+			current.Values[key] = next.text
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("falta una llave de cierre")
+	}
+	return root, nil
+}
+
+// vdfToken es una cadena o una llave de un archivo KeyValues
+type vdfToken struct {
+	text  string
+	brace byte
+}
+
+// vdfTokens separa un archivo KeyValues en cadenas (con o sin comillas) y llaves, ignorando comentarios //
+func vdfTokens(s string) ([]vdfToken, error) {
+	var tokens []vdfToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '/' && i+1 < len(s) && s[i+1] == '/':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '{' || c == '}':
+			tokens = append(tokens, vdfToken{brace: c})
+			i++
+		case c == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+					switch s[i] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					default:
+						b.WriteByte(s[i])
+					}
+					continue
+				}
+				b.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("cadena sin cerrar")
+			}
+			i++
+			tokens = append(tokens, vdfToken{text: b.String()})
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\r\n{}\"", rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, vdfToken{text: s[start:i]})
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrCopyMismatch indica que un archivo copiado no coincide con su origen o con el hash de su manifiesto
+var ErrCopyMismatch = errors.New("la copia no coincide con el original")
+
+// copyCheck es la verificación de una copia con copyVerified
+type copyCheck struct {
+	expected string // SHA-256 que debe tener lo leído (p. ej. el del manifiesto); vacío = no se compara
+	reread   bool   // Volver a leer el destino y compararlo con lo leído
+}
+
+// copyCheck devuelve la verificación de las copias según la configuración (SkipCopyReread)
+func (bm *BackupManager) copyCheck(expected string) copyCheck {
+	return copyCheck{expected: expected, reread: !bm.config().SkipCopyReread}
+}
+
+// copyVerified crea dst con el contenido de src calculando su SHA-256 mientras copia, lo comprueba según check
+// y devuelve el SHA-256. Si falla la comprobación, dst queda escrito: el que llama lo descarta.
+func copyVerified(src io.Reader, dst string, check copyCheck) (string, error) {
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(dstFile, io.TeeReader(src, hash))
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if check.expected != "" && sum != check.expected {
+		return "", fmt.Errorf("%w: lo leído no tiene el hash de su manifiesto", ErrCopyMismatch)
+	}
+	if check.reread {
+		if err := verifyCopiedFile(dst, sum); err != nil {
+			return "", err
+		}
+	}
+	return sum, nil
+}
+
+// copyFileVerified copia el archivo src en dst con copyVerified
+func copyFileVerified(src, dst string, check copyCheck) (string, error) {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer srcFile.Close()
+	return copyVerified(srcFile, dst, check)
+}
+
+// verifyCopiedFile relee una copia y la compara con el SHA-256 de lo que se escribió
+func verifyCopiedFile(path, expected string) error {
+	actual, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return ErrCopyMismatch
+	}
+	return nil
+}
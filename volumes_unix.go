@@ -0,0 +1,122 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// localFileSystems son los sistemas de archivos de discos reales; el resto (proc, tmpfs, squashfs...) se ignora
+var localFileSystems = map[string]bool{
+	"ext2": true, "ext3": true, "ext4": true, "btrfs": true, "xfs": true, "f2fs": true, "zfs": true,
+	"bcachefs": true, "jfs": true, "reiserfs": true, "ntfs": true, "ntfs3": true, "fuseblk": true,
+	"vfat": true, "exfat": true, "hfsplus": true, "apfs": true,
+}
+
+// networkFileSystems son los sistemas de archivos de red
+var networkFileSystems = map[string]bool{
+	"nfs": true, "nfs4": true, "cifs": true, "smb3": true, "smbfs": true, "9p": true,
+	"fuse.sshfs": true, "fuse.rclone": true, "afpfs": true, "davfs": true,
+}
+
+// diskUsage devuelve el espacio libre (para el usuario) y total del volumen que contiene path
+func diskUsage(path string) (free, total uint64, err error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), stat.Blocks * uint64(stat.Bsize), nil
+}
+
+// listVolumes devuelve los volúmenes montados que podrían alojar backups
+func listVolumes() ([]volumeInfo, error) {
+	if runtime.GOOS == "darwin" {
+		return listDarwinVolumes()
+	}
+
+	mounts, err := readMounts()
+	if err != nil {
+		return nil, err
+	}
+
+	var volumes []volumeInfo
+	seenDevices := make(map[string]bool)
+	for _, mount := range mounts {
+		network := networkFileSystems[mount.FSType]
+		if !localFileSystems[mount.FSType] && !network {
+			continue
+		}
+		// Particiones de arranque y montajes de sistema
+		if mount.MountPoint == "/boot" || strings.HasPrefix(mount.MountPoint, "/boot/") ||
+			mount.MountPoint == "/efi" || strings.HasPrefix(mount.MountPoint, "/snap/") {
+			continue
+		}
+		// Un mismo dispositivo montado varias veces (bind mounts, subvolúmenes) cuenta una vez
+		if !network && seenDevices[mount.Device] {
+			continue
+		}
+		seenDevices[mount.Device] = true
+
+		volumes = append(volumes, volumeInfo{
+			MountPoint: mount.MountPoint,
+			FileSystem: mount.FSType,
+			Removable:  !network && isRemovableMount(mount),
+			Network:    network,
+			ReadOnly:   slices.Contains(mount.Options, "ro"),
+		})
+	}
+	return volumes, nil
+}
+
+// isRemovableMount indica si un montaje es una unidad extraíble (USB, tarjeta SD...)
+func isRemovableMount(mount mountEntry) bool {
+	if strings.HasPrefix(mount.MountPoint, "/media/") || strings.HasPrefix(mount.MountPoint, "/run/media/") {
+		return true
+	}
+	if !strings.HasPrefix(mount.Device, "/dev/") {
+		return false
+	}
+
+	// /sys/class/block/sdb1 apunta a .../block/sdb/sdb1; el atributo removable está en el disco
+	device, err := filepath.EvalSymlinks(mount.Device)
+	if err != nil {
+		device = mount.Device
+	}
+	sysPath, err := filepath.EvalSymlinks(filepath.Join("/sys/class/block", filepath.Base(device)))
+	if err != nil {
+		return false
+	}
+	for _, dir := range []string{sysPath, filepath.Dir(sysPath)} {
+		if data, err := os.ReadFile(filepath.Join(dir, "removable")); err == nil {
+			return strings.TrimSpace(string(data)) == "1"
+		}
+	}
+	return false
+}
+
+// listDarwinVolumes devuelve el disco de arranque y los volúmenes de /Volumes (que en macOS son externos)
+func listDarwinVolumes() ([]volumeInfo, error) {
+	volumes := []volumeInfo{{MountPoint: "/"}}
+
+	entries, err := os.ReadDir("/Volumes")
+	if err != nil {
+		return volumes, nil
+	}
+	for _, entry := range entries {
+		// El disco de arranque aparece en /Volumes como enlace simbólico a /
+		if !entry.IsDir() || entry.Type()&os.ModeSymlink != 0 {
+			continue
+		}
+		volumes = append(volumes, volumeInfo{
+			MountPoint: filepath.Join("/Volumes", entry.Name()),
+			Removable:  true,
+		})
+	}
+	return volumes, nil
+}
@@ -0,0 +1,50 @@
+//go:build windows
+
+package main
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// diskUsage devuelve el espacio libre (para el usuario) y total del volumen que contiene path
+func diskUsage(path string) (free, total uint64, err error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &free, &total, &totalFree); err != nil {
+		return 0, 0, err
+	}
+	return free, total, nil
+}
+
+// listVolumes devuelve las unidades con letra que podrían alojar backups (sin lectores de CD ni discos RAM)
+func listVolumes() ([]volumeInfo, error) {
+	drives, err := windows.GetLogicalDrives()
+	if err != nil {
+		return nil, err
+	}
+
+	var volumes []volumeInfo
+	for i := 0; i < 26; i++ {
+		if drives&(1<<uint(i)) == 0 {
+			continue
+		}
+		root := string(rune('A'+i)) + `:\`
+		rootPtr, err := windows.UTF16PtrFromString(root)
+		if err != nil {
+			continue
+		}
+
+		switch windows.GetDriveType(rootPtr) {
+		case windows.DRIVE_FIXED:
+			volumes = append(volumes, volumeInfo{MountPoint: root})
+		case windows.DRIVE_REMOVABLE:
+			volumes = append(volumes, volumeInfo{MountPoint: root, Removable: true})
+		case windows.DRIVE_REMOTE:
+			volumes = append(volumes, volumeInfo{MountPoint: root, Network: true})
+		}
+	}
+	return volumes, nil
+}
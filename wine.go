@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// findWinePrefixes devuelve los prefijos de Wine y Proton presentes en el sistema
+func findWinePrefixes() []string {
+	homeDir, _ := os.UserHomeDir()
+
+	candidates := []string{}
+	if prefix := os.Getenv("WINEPREFIX"); prefix != "" {
+		candidates = append(candidates, prefix)
+	}
+	if homeDir != "" {
+		candidates = append(candidates, filepath.Join(homeDir, ".wine"))
+
+		// Prefijos de Proton: uno por juego dentro de compatdata
+		for _, steamRoot := range []string{
+			filepath.Join(homeDir, ".steam", "steam"),
+			filepath.Join(homeDir, ".local", "share", "Steam"),
+		} {
+			matches, _ := filepath.Glob(filepath.Join(steamRoot, "steamapps", "compatdata", "*", "pfx"))
+			candidates = append(candidates, matches...)
+		}
+	}
+
+	prefixes := []string{}
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		// ~/.steam/steam suele ser un enlace a ~/.local/share/Steam
+		resolved, err := filepath.EvalSymlinks(candidate)
+		if err != nil || seen[resolved] || !isWinePrefix(resolved) {
+			continue
+		}
+		seen[resolved] = true
+		prefixes = append(prefixes, resolved)
+	}
+	return prefixes
+}
+
+// isWinePrefix comprueba si un directorio tiene la estructura de un prefijo de Wine
+func isWinePrefix(path string) bool {
+	info, err := os.Stat(filepath.Join(path, "drive_c"))
+	return err == nil && info.IsDir()
+}
+
+// wineSaveDirs devuelve las carpetas de usuario de un prefijo donde los juegos suelen guardar partidas
+func wineSaveDirs(prefix string) []string {
+	users, err := os.ReadDir(filepath.Join(prefix, "drive_c", "users"))
+	if err != nil {
+		return nil
+	}
+
+	dirs := []string{}
+	for _, user := range users {
+		if !user.IsDir() || strings.EqualFold(user.Name(), "Public") {
+			continue
+		}
+
+		userDir := filepath.Join(prefix, "drive_c", "users", user.Name())
+		for _, sub := range []string{
+			"AppData/Roaming",
+			"AppData/Local",
+			"Application Data", // Prefijos antiguos (Windows XP)
+			"Documents/My Games",
+			"My Documents/My Games",
+			"Saved Games",
+		} {
+			dir := filepath.Join(userDir, filepath.FromSlash(sub))
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// prefixFolder relaciona una variable de Windows con su carpeta dentro de drive_c
+type prefixFolder struct {
+	Var    string
+	Dir    string // Relativa al perfil del usuario o a drive_c
+	InUser bool   // Dir cuelga del perfil del usuario
+}
+
+// prefixFolders están ordenadas de más a menos específica, tanto para expandir como para convertir a plantilla
+var prefixFolders = []prefixFolder{
+	{Var: "%APPDATA%", Dir: "AppData/Roaming", InUser: true},
+	{Var: "%APPDATA%", Dir: "Application Data", InUser: true}, // Prefijos antiguos (Windows XP)
+	{Var: "%LOCALAPPDATA%", Dir: "AppData/Local", InUser: true},
+	{Var: "%USERPROFILE%", Dir: "", InUser: true},
+	{Var: "%PROGRAMDATA%", Dir: "ProgramData"},
+	{Var: "%PROGRAMFILES(X86)%", Dir: "Program Files (x86)"},
+	{Var: "%PROGRAMFILES%", Dir: "Program Files"},
+}
+
+// prefixUser devuelve el usuario de Windows de un prefijo: el que ya exista o, en un prefijo nuevo,
+// steamuser para Proton y el usuario actual para Wine
+func prefixUser(prefix string) string {
+	if users, err := os.ReadDir(filepath.Join(prefix, "drive_c", "users")); err == nil {
+		for _, entry := range users {
+			if entry.IsDir() && !strings.EqualFold(entry.Name(), "Public") {
+				return entry.Name()
+			}
+		}
+	}
+
+	if filepath.Base(prefix) == "pfx" {
+		return "steamuser"
+	}
+	if current, err := user.Current(); err == nil {
+		return filepath.Base(current.Username)
+	}
+	return "user"
+}
+
+// expandInPrefix expande una ruta de guardado de Windows (con variables como %APPDATA% o con letra de unidad)
+// dentro de un prefijo de Wine. Las letras se traducen con dosdevices; las que el prefijo no tiene van a drive_c.
+// Las rutas absolutas de otro prefijo se convierten antes a plantilla.
+func expandInPrefix(savePath, prefix string) (string, error) {
+	if template, ok := prefixPathToTemplate(savePath); ok {
+		savePath = template
+	}
+	slashed := strings.ReplaceAll(savePath, `\`, "/")
+
+	driveC := filepath.Join(prefix, "drive_c")
+	if letter, rest, ok := cutDriveLetter(slashed); ok {
+		root, mapped := wineDrive(prefix, letter)
+		if !mapped {
+			root = driveC
+		}
+		return filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(rest, "/"))), nil
+	}
+
+	userDir := filepath.Join(driveC, "users", prefixUser(prefix))
+	for _, folder := range prefixFolders {
+		rest, ok := strings.CutPrefix(slashed, folder.Var)
+		if !ok {
+			continue
+		}
+		base := driveC
+		if folder.InUser {
+			base = userDir
+		}
+		return filepath.Join(base, filepath.FromSlash(folder.Dir), filepath.FromSlash(strings.TrimPrefix(rest, "/"))), nil
+	}
+
+	return "", fmt.Errorf("%s no es una ruta de Windows que se pueda restaurar dentro de un prefijo", savePath)
+}
+
+// cutDriveLetter separa la letra de unidad (en minúscula) de una ruta de Windows como D:/Games/...
+func cutDriveLetter(path string) (letter, rest string, ok bool) {
+	if len(path) < 2 || path[1] != ':' {
+		return "", "", false
+	}
+	c := path[0] | 0x20 // A-Z a minúscula
+	if c < 'a' || c > 'z' {
+		return "", "", false
+	}
+	return string(c), path[2:], true
+}
+
+// wineDrive devuelve la carpeta del sistema a la que apunta una unidad del prefijo: el enlace
+// <prefijo>/dosdevices/<letra>: que crea Wine (c: apunta a ../drive_c). false si no existe.
+func wineDrive(prefix, letter string) (string, bool) {
+	link := filepath.Join(prefix, "dosdevices", letter+":")
+	target, err := os.Readlink(link)
+	if err != nil {
+		return "", false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(link), target)
+	}
+	return filepath.Clean(target), true
+}
+
+// unmappedDrive indica si una ruta usa una unidad distinta de C: que el prefijo no tiene en dosdevices
+// (expandInPrefix la lleva a drive_c, que probablemente no es donde están las partidas)
+func unmappedDrive(path, prefix string) bool {
+	letter, _, ok := cutDriveLetter(path)
+	if !ok || letter == "c" {
+		return false
+	}
+	_, mapped := wineDrive(prefix, letter)
+	return !mapped
+}
+
+// prefixPathToTemplate convierte una ruta dentro de un prefijo de Wine (.../drive_c/users/<usuario>/AppData/Roaming/...)
+// en su plantilla de Windows (%APPDATA%/...). Devuelve false si la ruta no está en un prefijo.
+func prefixPathToTemplate(savePath string) (string, bool) {
+	slashed := filepath.ToSlash(savePath)
+	index := strings.Index(strings.ToLower(slashed), "/drive_c/")
+	if index < 0 {
+		return "", false
+	}
+	rel := slashed[index+len("/drive_c/"):]
+
+	inUser := false
+	if parts := strings.SplitN(rel, "/", 3); len(parts) >= 2 && strings.EqualFold(parts[0], "users") {
+		inUser = true
+		rel = ""
+		if len(parts) == 3 {
+			rel = parts[2]
+		}
+	}
+
+	for _, folder := range prefixFolders {
+		if folder.InUser != inUser {
+			continue
+		}
+		if folder.Dir == "" {
+			return joinTemplate(folder.Var, rel), true
+		}
+		if rest, ok := cutFolderPrefix(rel, folder.Dir); ok {
+			return joinTemplate(folder.Var, rest), true
+		}
+	}
+	return joinTemplate("C:", rel), true
+}
+
+// cutFolderPrefix quita dir del principio de rel sin distinguir mayúsculas (Windows no las distingue)
+func cutFolderPrefix(rel, dir string) (string, bool) {
+	if len(rel) < len(dir) || !strings.EqualFold(rel[:len(dir)], dir) {
+		return "", false
+	}
+	rest := rel[len(dir):]
+	if rest != "" && rest[0] != '/' {
+		return "", false
+	}
+	return strings.TrimPrefix(rest, "/"), true
+}
+
+// joinTemplate une una variable de Windows con el resto de la ruta
+func joinTemplate(variable, rest string) string {
+	if rest == "" {
+		return variable
+	}
+	return variable + "/" + rest
+}
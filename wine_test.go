@@ -0,0 +1,259 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFakePrefix crea un prefijo de Wine mínimo en dir/name con el usuario de Windows user (vacío = sin carpeta
+// de usuario, como un prefijo recién creado) y el enlace dosdevices/c: a drive_c
+func newFakePrefix(t *testing.T, dir, name, user string) string {
+	t.Helper()
+	prefix := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Join(prefix, "drive_c", "windows"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if user != "" {
+		if err := os.MkdirAll(filepath.Join(prefix, "drive_c", "users", user), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(prefix, "dosdevices"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../drive_c", filepath.Join(prefix, "dosdevices", "c:")); err != nil {
+		t.Skipf("no se pueden crear enlaces simbólicos: %v", err)
+	}
+	return prefix
+}
+
+// prefixTemplates son rutas de guardado de Windows con su carpeta dentro de un prefijo (relativa a drive_c,
+// con <user> en lugar del usuario)
+var prefixTemplates = map[string]string{
+	"%APPDATA%/Studio/Game":                 "users/<user>/AppData/Roaming/Studio/Game",
+	"%LOCALAPPDATA%/Game/Saved/SaveGames":   "users/<user>/AppData/Local/Game/Saved/SaveGames",
+	"%USERPROFILE%/Documents/My Games/Game": "users/<user>/Documents/My Games/Game",
+	"%USERPROFILE%/Saved Games/Game":        "users/<user>/Saved Games/Game",
+	"%PROGRAMDATA%/Game":                    "ProgramData/Game",
+	"%PROGRAMFILES(X86)%/Game/save":         "Program Files (x86)/Game/save",
+	"%PROGRAMFILES%/Game/save":              "Program Files/Game/save",
+	"C:/Games/Game/save":                    "Games/Game/save",
+}
+
+// inPrefix devuelve la ruta de prefixTemplates dentro de prefix para user
+func inPrefix(prefix, user, rel string) string {
+	return filepath.Join(prefix, "drive_c", filepath.FromSlash(strings.ReplaceAll(rel, "<user>", user)))
+}
+
+func TestExpandInPrefixAndBack(t *testing.T) {
+	dir := t.TempDir()
+	wine := newFakePrefix(t, dir, "wine", "alice")
+	proton := newFakePrefix(t, filepath.Join(dir, "compatdata", "440"), "pfx", "") // Prefijo nuevo de Proton
+
+	for template, rel := range prefixTemplates {
+		winePath, err := expandInPrefix(template, wine)
+		if err != nil {
+			t.Fatalf("%s: %v", template, err)
+		}
+		if want := inPrefix(wine, "alice", rel); winePath != want {
+			t.Errorf("%s en Wine = %s, se esperaba %s", template, winePath, want)
+		}
+
+		// Del prefijo a la plantilla y de vuelta a la misma ruta
+		back, ok := prefixPathToTemplate(winePath)
+		if !ok || back != template {
+			t.Errorf("prefixPathToTemplate(%s) = %q, %v; se esperaba %q", winePath, back, ok, template)
+		}
+
+		// De un prefijo a otro: la ruta de Wine se expande en Proton con el usuario de Proton
+		protonPath, err := expandInPrefix(winePath, proton)
+		if err != nil {
+			t.Fatalf("%s: %v", winePath, err)
+		}
+		if want := inPrefix(proton, "steamuser", rel); protonPath != want {
+			t.Errorf("%s en Proton = %s, se esperaba %s", winePath, protonPath, want)
+		}
+		if again, err := expandInPrefix(protonPath, wine); err != nil || again != winePath {
+			t.Errorf("%s de vuelta en Wine = %s, %v; se esperaba %s", protonPath, again, err, winePath)
+		}
+	}
+
+	if _, err := expandInPrefix("/home/alice/.local/share/Game", wine); err == nil {
+		t.Error("una ruta de Linux fuera de un prefijo no debería poder expandirse en él")
+	}
+}
+
+func TestRestoreSavePathsNativeWindows(t *testing.T) {
+	appdata := filepath.Join(t.TempDir(), "Roaming")
+	t.Setenv("APPDATA", appdata)
+	game := &GameInfo{SavePaths: []string{"/home/alice/Games/wine/drive_c/users/alice/AppData/Roaming/Studio/Game"}}
+
+	paths, err := restoreSavePaths(game, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(appdata, "Studio", "Game"); len(paths) != 1 || paths[0] != want {
+		t.Errorf("restoreSavePaths = %v, se esperaba %s", paths, want)
+	}
+}
+
+// Un backup hecho en un prefijo de Wine se restaura en un prefijo de Proton recién creado y al revés
+func TestRestoreTargetPrefixRoundTrip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("los prefijos de Wine solo existen fuera de Windows")
+	}
+	bm, dir := newTestManager(t)
+	wine := newFakePrefix(t, dir, "wine", "alice")
+	proton := newFakePrefix(t, filepath.Join(dir, "compatdata", "440"), "pfx", "")
+
+	wineSaves := filepath.Join(wine, "drive_c", "users", "alice", "AppData", "Roaming", "Studio", "Game")
+	writeTestFiles(t, wineSaves, map[string]string{"slot1.sav": "wine-1", "profiles/p.cfg": "wine-p"})
+	game := bm.DetectedGames["g"]
+	game.SavePaths = []string{wineSaves}
+	game.Metadata["wine_prefix"] = wine
+
+	info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bm.RestoreBackup("g", info.Name, RestoreOptions{TargetPrefix: proton, Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	protonSaves := filepath.Join(proton, "drive_c", "users", "steamuser", "AppData", "Roaming", "Studio", "Game")
+	assertFiles(t, protonSaves, map[string]string{"slot1.sav": "wine-1", "profiles/p.cfg": "wine-p"})
+
+	// Y de vuelta: la partida cambia en Proton y se restaura en el prefijo de Wine
+	writeTestFiles(t, protonSaves, map[string]string{"slot1.sav": "proton-1"})
+	game.SavePaths = []string{protonSaves}
+	game.Metadata["wine_prefix"] = proton
+	time.Sleep(time.Second) // Los nombres de los backups tienen resolución de segundos
+	info, err = bm.CreateBackupWithOptions("g", BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bm.RestoreBackup("g", info.Name, RestoreOptions{TargetPrefix: wine, Force: true, Mode: RestoreOverwrite}); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, wineSaves, map[string]string{"slot1.sav": "proton-1", "profiles/p.cfg": "wine-p"})
+}
+
+// assertFiles comprueba el contenido de los archivos de dir (ruta relativa con / -> contenido)
+func assertFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, want := range files {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if string(data) != want {
+			t.Errorf("%s = %q, se esperaba %q", name, data, want)
+		}
+	}
+}
+
+// newDosdevicesPrefix crea un prefijo con d: enlazada a una carpeta de fuera del prefijo (relativa a dosdevices,
+// como la crea winecfg) y f: con ruta absoluta; e: no existe
+func newDosdevicesPrefix(t *testing.T, dir string) (prefix, driveD, driveF string) {
+	t.Helper()
+	prefix = newFakePrefix(t, dir, "wine", "alice")
+	driveD = filepath.Join(dir, "mnt", "games")
+	driveF = filepath.Join(dir, "mnt", "other")
+	for _, drive := range []string{driveD, driveF} {
+		if err := os.MkdirAll(drive, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("../../mnt/games", filepath.Join(prefix, "dosdevices", "d:")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(driveF, filepath.Join(prefix, "dosdevices", "f:")); err != nil {
+		t.Fatal(err)
+	}
+	return prefix, driveD, driveF
+}
+
+func TestWineDrive(t *testing.T) {
+	prefix, driveD, driveF := newDosdevicesPrefix(t, t.TempDir())
+	for letter, want := range map[string]string{"c": filepath.Join(prefix, "drive_c"), "d": driveD, "f": driveF} {
+		if got, ok := wineDrive(prefix, letter); !ok || got != want {
+			t.Errorf("wineDrive(%s) = %s, %v; se esperaba %s", letter, got, ok, want)
+		}
+	}
+	if got, ok := wineDrive(prefix, "e"); ok {
+		t.Errorf("wineDrive(e) = %s, la unidad no existe", got)
+	}
+}
+
+func TestExpandGamePathDosdevices(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("en Windows las letras de unidad son las del sistema")
+	}
+	prefix, driveD, driveF := newDosdevicesPrefix(t, t.TempDir())
+	game := &GameInfo{Metadata: map[string]string{"wine_prefix": prefix}, InstallDir: `D:\Games\Foo`}
+
+	tests := []struct {
+		path, want string
+		unmapped   bool
+	}{
+		{`D:\Games\Foo\Saves`, filepath.Join(driveD, "Games", "Foo", "Saves"), false},
+		{"d:/Games/Foo/Saves", filepath.Join(driveD, "Games", "Foo", "Saves"), false},
+		{`F:\Saves`, filepath.Join(driveF, "Saves"), false},
+		{`C:\Games\Bar`, filepath.Join(prefix, "drive_c", "Games", "Bar"), false},
+		{`%GAME_DIR%\save`, filepath.Join(driveD, "Games", "Foo", "save"), false},
+		{`E:\Games\Baz`, filepath.Join(prefix, "drive_c", "Games", "Baz"), true}, // Sin asignar: a drive_c
+	}
+	for _, test := range tests {
+		if got := ExpandGamePath(game, test.path); got != test.want {
+			t.Errorf("ExpandGamePath(%s) = %s, se esperaba %s", test.path, got, test.want)
+		}
+		resolved := strings.ReplaceAll(test.path, gameDirPlaceholder, game.InstallDir)
+		if got := unmappedDrive(resolved, prefix); got != test.unmapped {
+			t.Errorf("unmappedDrive(%s) = %v, se esperaba %v", resolved, got, test.unmapped)
+		}
+	}
+
+	// Sin prefijo la letra de unidad no se traduce
+	plain := &GameInfo{Metadata: map[string]string{}}
+	if got := ExpandGamePath(plain, `D:\Games\Foo`); got != `D:\Games\Foo` {
+		t.Errorf("ExpandGamePath sin prefijo = %s", got)
+	}
+}
+
+// Las partidas de una unidad asignada en dosdevices se respaldan, se validan y se restauran en esa unidad
+func TestDosdevicesBackupRoundTrip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("en Windows las letras de unidad son las del sistema")
+	}
+	bm, dir := newTestManager(t)
+	prefix, driveD, _ := newDosdevicesPrefix(t, dir)
+	saves := filepath.Join(driveD, "Games", "Foo", "Saves")
+	writeTestFiles(t, saves, map[string]string{"slot1.sav": "d-1", "auto/slot2.sav": "d-2"})
+
+	game := bm.DetectedGames["g"]
+	game.SavePaths = []string{`D:\Games\Foo\Saves`, `E:\Games\Foo\Saves`}
+	game.Metadata["wine_prefix"] = prefix
+
+	valid, invalid, _, unmapped := bm.ValidateGamePaths("g")
+	if len(valid) != 1 || valid[0] != saves {
+		t.Errorf("rutas válidas %v, se esperaba %s", valid, saves)
+	}
+	if len(invalid) != 1 || len(unmapped) != 1 || unmapped[0] != `E:\Games\Foo\Saves` {
+		t.Errorf("rutas no válidas %v, unidades sin asignar %v", invalid, unmapped)
+	}
+
+	info, err := bm.CreateBackupWithOptions("g", BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFiles(t, saves, map[string]string{"slot1.sav": "cambiado"})
+	if err := os.Remove(filepath.Join(saves, "auto", "slot2.sav")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bm.RestoreBackup("g", info.Name, RestoreOptions{Force: true, Mode: RestoreOverwrite}); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, saves, map[string]string{"slot1.sav": "d-1", "auto/slot2.sav": "d-2"})
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxCompressionWorkers limita los hilos de compresión por defecto: más no acelera la escritura en disco
+const maxCompressionWorkers = 8
+
+// parallelZipMaxBuffer es el tamaño a partir del cual un archivo no se comprime en memoria en paralelo,
+// sino al escribirlo en el ZIP, para acotar la memoria a unos pocos búferes de este tamaño por hilo
+const parallelZipMaxBuffer = 32 << 20
+
+// compressionWorkers devuelve cuántos hilos comprimen los backups ZIP según CompressionWorkers
+func (bm *BackupManager) compressionWorkers() int {
+	if bm.config().CompressionWorkers > 0 {
+		return bm.config().CompressionWorkers
+	}
+	return min(runtime.NumCPU(), maxCompressionWorkers)
+}
+
+// zipJob es un archivo de partida pendiente de comprimir; su resultado se entrega por result
+type zipJob struct {
+	path     string
+	name     string
+	modified time.Time
+	size     int64
+	method   uint16
+	stream   bool // Demasiado grande para comprimirlo en memoria: se comprime al escribirlo
+	result   chan zipResult
+}
+
+// zipResult es un archivo ya comprimido con los datos de su cabecera
+type zipResult struct {
+	job        zipJob
+	compressed *bytes.Buffer // Vuelve a zipBuffers una vez escrito
+	crc        uint32
+	size       uint64
+	err        error
+}
+
+// writeZipEntriesParallel comprime los archivos de partida en varios hilos y los escribe en el ZIP en el orden
+// del recorrido. Como mucho hay 2*workers archivos comprimidos en memoria esperando a escribirse.
+func (bm *BackupManager) writeZipEntriesParallel(game *GameInfo, zipWriter *zip.Writer, workers int, limit *ioLimiter, onFile func()) error {
+	jobs := make(chan zipJob)
+	ordered := make(chan chan zipResult, 2*workers)
+	var failed atomic.Bool
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if failed.Load() {
+					job.result <- zipResult{job: job}
+					continue
+				}
+				job.method = bm.zipMethod(job.path)
+				if job.stream {
+					job.result <- zipResult{job: job}
+					continue
+				}
+				job.result <- bm.compressZipEntry(job, limit)
+			}
+		}()
+	}
+
+	walkErr := make(chan error, 1)
+	go func() {
+		err := bm.walkSaveFiles(game, func(path, name string, d fs.DirEntry) error {
+			if failed.Load() {
+				return fs.SkipAll
+			}
+			job := zipJob{path: path, name: name, result: make(chan zipResult, 1)}
+			if info, err := d.Info(); err == nil {
+				job.modified = info.ModTime()
+				job.size = info.Size()
+				job.stream = info.Size() > parallelZipMaxBuffer
+			}
+			ordered <- job.result
+			jobs <- job
+			return nil
+		})
+		close(jobs)
+		close(ordered)
+		walkErr <- err
+	}()
+
+	// Consumir todos los resultados aunque falle uno, para que el recorrido y los hilos terminen
+	var err error
+	for result := range ordered {
+		res := <-result
+		if err == nil {
+			if err = res.err; err == nil {
+				err = bm.writeZipResult(zipWriter, res, limit)
+			}
+		}
+		if res.compressed != nil {
+			zipBuffers.Put(res.compressed)
+		}
+		if err != nil {
+			failed.Store(true)
+			continue
+		}
+		onFile()
+	}
+	wg.Wait()
+
+	if walk := <-walkErr; err == nil {
+		err = walk
+	}
+	return err
+}
+
+// zipDeflateLevel es el nivel que usa zip.Writer.CreateHeader: los backups salen igual con uno o varios hilos
+const zipDeflateLevel = 5
+
+// flateWriters reutiliza los compresores: crear uno reserva más de 1 MiB, más que muchos archivos de partida
+var flateWriters = sync.Pool{
+	New: func() any {
+		writer, _ := flate.NewWriter(nil, zipDeflateLevel)
+		return writer
+	},
+}
+
+// zipBuffers reutiliza los búferes de los archivos comprimidos en memoria entre archivos y backups
+var zipBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// compressZipEntry lee y comprime un archivo en memoria (o solo lo lee si se guarda sin comprimir)
+func (bm *BackupManager) compressZipEntry(job zipJob, limit *ioLimiter) zipResult {
+	result := zipResult{job: job}
+
+	file, err := bm.openSaveFile(job.path)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defer file.Close()
+
+	buf := zipBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	buf.Grow(int(job.size) + 1024) // Los datos que no se comprimen ocupan algo más al pasar por deflate
+	var compressor io.WriteCloser = nopWriteCloser{buf}
+	if job.method == zip.Deflate {
+		writer := flateWriters.Get().(*flate.Writer)
+		writer.Reset(buf)
+		defer flateWriters.Put(writer)
+		compressor = writer
+	}
+	hash := crc32.NewIEEE()
+	size, err := io.Copy(io.MultiWriter(compressor, hash), limit.reader(file))
+	if err == nil {
+		err = compressor.Close()
+	}
+	if err != nil {
+		zipBuffers.Put(buf)
+		result.err = err
+		return result
+	}
+
+	result.compressed = buf
+	result.crc = hash.Sum32()
+	result.size = uint64(size)
+	return result
+}
+
+// nopWriteCloser es un io.WriteCloser cuyo Close no hace nada (archivos guardados sin comprimir)
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// setRawModTime pone en la cabecera la fecha de Modified como lo hace CreateHeader: en los campos MS-DOS y en
+// el campo extra de fecha extendida. CreateRaw escribe la cabecera tal cual y sin esto el archivo saldría con
+// fecha de 1979 al restaurarlo.
+func setRawModTime(header *zip.FileHeader) {
+	if header.Modified.IsZero() {
+		return
+	}
+	modified := header.Modified
+	header.ModifiedDate = uint16(modified.Day() + int(modified.Month())<<5 + (modified.Year()-1980)<<9)
+	header.ModifiedTime = uint16(modified.Second()/2 + modified.Minute()<<5 + modified.Hour()<<11)
+
+	extra := make([]byte, 9)
+	binary.LittleEndian.PutUint16(extra[0:], zipExtTimeExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 5) // Banderas (1 byte) y fecha de modificación (4 bytes)
+	extra[4] = 1                                // Solo la fecha de modificación
+	binary.LittleEndian.PutUint32(extra[5:], uint32(modified.Unix()))
+	header.Extra = append(header.Extra, extra...)
+}
+
+// zipExtTimeExtraID es el identificador del campo extra de fecha extendida (Info-ZIP "UT")
+const zipExtTimeExtraID = 0x5455
+
+// writeZipResult escribe en el ZIP un archivo ya comprimido o, si es grande, lo comprime al escribirlo
+func (bm *BackupManager) writeZipResult(zipWriter *zip.Writer, res zipResult, limit *ioLimiter) error {
+	header := &zip.FileHeader{Name: res.job.name, Method: res.job.method, Modified: res.job.modified}
+	if res.job.stream {
+		entry, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		file, err := bm.openSaveFile(res.job.path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(entry, limit.reader(file))
+		return err
+	}
+
+	header.CRC32 = res.crc
+	header.UncompressedSize64 = res.size
+	header.CompressedSize64 = uint64(res.compressed.Len())
+	setRawModTime(header)
+	entry, err := zipWriter.CreateRaw(header)
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(res.compressed.Bytes())
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// zipTestGame prepara el juego "g" con una fecha conocida en sus archivos de partida
+func zipTestGame(t testing.TB, bm *BackupManager, modified time.Time) *GameInfo {
+	t.Helper()
+	game := bm.DetectedGames["g"]
+	for _, name := range []string{"a.sav", filepath.Join("sub", "b.sav")} {
+		if err := os.Chtimes(filepath.Join(game.SavePaths[0], name), modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return game
+}
+
+func TestWriteZipEntriesKeepModTime(t *testing.T) {
+	modified := time.Date(2024, 3, 15, 18, 42, 10, 0, time.Local)
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			bm, _ := newTestManager(t)
+			game := zipTestGame(t, bm, modified)
+
+			var buf bytes.Buffer
+			zipWriter := zip.NewWriter(&buf)
+			var err error
+			if workers > 1 {
+				err = bm.writeZipEntriesParallel(game, zipWriter, workers, nil, func() {})
+			} else {
+				err = bm.writeZipEntries(game, zipWriter, nil, func() {})
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := zipWriter.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(reader.File) != 2 {
+				t.Fatalf("%d entradas, se esperaban 2", len(reader.File))
+			}
+			for _, file := range reader.File {
+				if !file.Modified.Equal(modified) {
+					t.Errorf("%s: fecha %v, se esperaba %v", file.Name, file.Modified, modified)
+				}
+				if got := file.FileInfo().ModTime(); !got.Equal(modified) {
+					t.Errorf("%s: ModTime %v, se esperaba %v", file.Name, got, modified)
+				}
+			}
+		})
+	}
+}
+
+// benchmarkZipGame crea un juego con files archivos de size bytes, la mitad aleatorios (no se comprimen) y la
+// mitad repetitivos, como una carpeta de partidas con capturas y archivos de texto
+func benchmarkZipGame(b *testing.B, files, size int) (*BackupManager, *GameInfo) {
+	bm, _ := newTestManager(b)
+	game := bm.DetectedGames["g"]
+	saves := game.SavePaths[0]
+	data := make([]byte, size)
+	for i := 0; i < files; i++ {
+		if i%2 == 0 {
+			rand.Read(data)
+		} else {
+			copy(data, bytes.Repeat([]byte(fmt.Sprintf("slot=%d;level=%d;", i, i*7)), size)[:size])
+		}
+		path := filepath.Join(saves, fmt.Sprintf("dir%02d", i%10), fmt.Sprintf("save%03d.dat", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return bm, game
+}
+
+func benchmarkWriteZip(b *testing.B, workers int) {
+	bm, game := benchmarkZipGame(b, 200, 256<<10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		zipWriter := zip.NewWriter(io.Discard)
+		var err error
+		if workers > 1 {
+			err = bm.writeZipEntriesParallel(game, zipWriter, workers, nil, func() {})
+		} else {
+			err = bm.writeZipEntries(game, zipWriter, nil, func() {})
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+		zipWriter.Close()
+	}
+}
+
+// Comparar con: go test -run '^$' -bench BenchmarkWriteZip
+func BenchmarkWriteZipSequential(b *testing.B) { benchmarkWriteZip(b, 1) }
+func BenchmarkWriteZipParallel2(b *testing.B)  { benchmarkWriteZip(b, 2) }
+func BenchmarkWriteZipParallel4(b *testing.B)  { benchmarkWriteZip(b, 4) }
+func BenchmarkWriteZipParallel8(b *testing.B)  { benchmarkWriteZip(b, 8) }